@@ -0,0 +1,243 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"sync"
+
+	"github.com/atotto/clipboard"
+
+	"clipboard-translate/ai"
+	"clipboard-translate/config"
+	"clipboard-translate/constants"
+	"clipboard-translate/notify"
+	log "clipboard-translate/utils/log"
+)
+
+var (
+	lastAction     string     // 上一次执行的文本处理动作
+	lastActionText string     // 上一次处理的文本
+	lastActionMu   sync.Mutex // 保护上一次动作记录
+)
+
+// 文本处理动作的显示名称
+var actionLabels = map[string]string{
+	constants.ACTION_EXPLAIN:   "解释",
+	constants.ACTION_POLISH:    "润色",
+	constants.ACTION_SUMMARIZE: "摘要",
+}
+
+// 热键动作对应的AI任务，即默认使用的内置模板
+var actionTasks = map[string]ai.Task{
+	constants.ACTION_TRANSLATE:     ai.TaskTranslate,
+	constants.ACTION_TRANSLATE_ALT: ai.TaskTranslateTo,
+	constants.ACTION_EXPLAIN:       ai.TaskExplain,
+	constants.ACTION_POLISH:        ai.TaskPolish,
+	constants.ACTION_SUMMARIZE:     ai.TaskSummarize,
+}
+
+// 长文本需要分段处理的动作；解释和摘要需要完整的原文，不分段
+var chunkedActions = map[string]bool{
+	constants.ACTION_TRANSLATE:     true,
+	constants.ACTION_TRANSLATE_ALT: true,
+	constants.ACTION_POLISH:        true,
+}
+
+// 触发翻译
+func triggerTranslation(ctx context.Context) {
+	triggerAction(ctx, constants.ACTION_TRANSLATE)
+}
+
+// 读取剪贴板并执行文本处理动作
+func triggerAction(ctx context.Context, action string) {
+	// 获取当前剪贴板内容
+	content, err := clipboard.ReadAll()
+	if err != nil {
+		log.Error("读取剪贴板失败: %v", err)
+		return
+	}
+
+	if content == "" {
+		log.Warn("剪贴板内容为空")
+		return
+	}
+
+	runAction(ctx, action, content)
+}
+
+// 重复上一次的文本处理动作
+func repeatLastAction(ctx context.Context) {
+	lastActionMu.Lock()
+	action, text := lastAction, lastActionText
+	lastActionMu.Unlock()
+
+	if action == "" {
+		log.Warn("没有可重复的操作")
+		return
+	}
+
+	log.Info("重复上一次操作: %s", action)
+	runAction(ctx, action, text)
+}
+
+// 对文本执行动作，发送通知并记录历史
+func runAction(ctx context.Context, action, content string) {
+	lastActionMu.Lock()
+	lastAction, lastActionText = action, content
+	lastActionMu.Unlock()
+
+	// 热键可以指定使用的AI配置和提示词模板
+	cfg := config.GetConfig()
+	// 预算用尽回退时，提示词按实际使用的AI配置选择
+	client, profile, err := aiClientFor(cfg.ProfileFor(action))
+	var (
+		tmpl   *ai.PromptTemplate
+		data   ai.PromptData
+		prompt string
+	)
+	if err == nil {
+		tmpl, err = promptFor(cfg, action, profile)
+	}
+	if err == nil {
+		data = promptData(cfg, action, content)
+		prompt, err = tmpl.Render(data)
+	}
+	if err != nil {
+		log.Error("%v", err)
+		events.publish(EventTranslationFailed, translationEvent{ID: newTranslationID(), Origin: "clipboard", Action: action, Original: content, Error: err.Error()})
+		if err := notify.Push("处理失败", err.Error()); err != nil {
+			log.Error("发送通知失败: %v", err)
+		}
+		return
+	}
+
+	// 记录操作方向
+	direction := actionLabels[action]
+	switch action {
+	case constants.ACTION_TRANSLATE:
+		direction = "中 → 英"
+		if !isChineseText(content) {
+			direction = "英 → 中"
+		}
+	case constants.ACTION_TRANSLATE_ALT:
+		direction = "→ " + cfg.Translation.AlternateLanguage
+	}
+
+	log.Info("开始处理剪贴板内容... 操作: %s, 模板: %s, 使用: %s", direction, tmpl.Stamp(), client.GetName())
+	event := translationEvent{ID: newTranslationID(), Origin: "clipboard", Action: action, Direction: direction, Original: content}
+	events.publish(EventTranslationStarted, event)
+
+	var output ai.Result
+	if chunkedActions[action] {
+		output, err = completeChunked(ctx, cfg, client, action, tmpl, data, content)
+	} else {
+		output, err = client.Complete(ctx, prompt, content)
+	}
+	result := output.Text
+	failed := err != nil
+	if failed {
+		log.Error("%s失败: %v", direction, err)
+		event.Error = err.Error()
+		result = "处理失败: " + err.Error()
+	}
+
+	title := fmt.Sprintf("翻译结果 (%s - %s)", client.GetName(), direction)
+	if err := notify.Push(title, result); err != nil {
+		log.Error("发送通知失败: %v", err)
+	} else {
+		log.Info("已发送通知，内容: %s", result)
+	}
+
+	// 添加到历史记录，包含操作方向和模板版本
+	event.HistoryID = addHistoryItem(content, result, direction, tmpl.Stamp(), usageOf(client, output.Usage))
+	if failed {
+		events.publish(EventTranslationFailed, event)
+	} else {
+		event.Translated = result
+		events.publish(EventTranslationCompleted, event)
+	}
+}
+
+// 处理可能较长或包含 Markdown 的文本
+//
+// 代码、链接和HTML先替换为占位符，只有正文交给模型；长文本分段处理，每段以上一段原文作为上下文，
+// 并向外壳报告进度。译文中的占位符与原文不一致时重试一次，返回的用量包含重试。
+func completeChunked(ctx context.Context, cfg *config.Config, client ai.AIClient, action string, tmpl *ai.PromptTemplate, data ai.PromptData, content string) (ai.Result, error) {
+	masked := &ai.MaskedText{Text: content}
+	if cfg.Translation.Format == config.FormatAuto {
+		masked = ai.MaskMarkdown(content)
+	}
+	if masked.Masked() && !masked.HasProse() {
+		log.Info("内容全部为代码或链接，无需处理")
+		return ai.Result{Text: content}, nil
+	}
+
+	chunks := ai.SplitText(masked.Text, cfg.Translation.ChunkTokens)
+	total := len(chunks)
+	if total > 1 {
+		log.Info("文本较长，分为 %d 段处理", total)
+	}
+
+	render := func(previous string) (string, error) {
+		d := data
+		d.Context = previous
+		prompt, err := tmpl.Render(d)
+		if err != nil || !masked.Masked() {
+			return prompt, err
+		}
+		return prompt + "\n\n" + ai.MarkdownInstruction, nil
+	}
+	progress := func(done, total int) {
+		if total <= 1 {
+			return
+		}
+		log.Debug("分段处理进度: %d/%d", done, total)
+		sendShellEvent("translation-progress", map[string]any{"action": action, "done": done, "total": total})
+	}
+
+	var usage ai.Usage
+	for attempt := 1; ; attempt++ {
+		progress(0, total)
+		result, err := ai.CompleteChunked(ctx, client, chunks, cfg.Translation.ChunkConcurrency, render, progress)
+		usage = usage.Add(result.Usage)
+		if err != nil {
+			// 失败时也通知外壳结束进度显示
+			progress(total, total)
+			return ai.Result{Usage: usage, Truncated: result.Truncated}, err
+		}
+
+		restored, err := masked.Restore(result.Text)
+		if errors.Is(err, ai.ErrPlaceholderMismatch) && attempt == 1 {
+			log.Warn("%v，重试", err)
+			continue
+		}
+		return ai.Result{Text: restored, Usage: usage}, err
+	}
+}
+
+// 发送给外壳的事件行的前缀，外壳只解析带此前缀的行
+const shellEventPrefix = "@ct-event "
+
+// 保证每个事件整行写出，不与其他事件交错
+var shellEventMu sync.Mutex
+
+// 向 Electron 外壳发送事件：标准输出中以 shellEventPrefix 开头的一行 JSON
+//
+// 日志写入标准错误，因此事件不会与日志混在一起；事件可能包含访问令牌，外壳不应原样记录。
+func sendShellEvent(event string, payload map[string]any) {
+	message := map[string]any{"event": event}
+	for k, v := range payload {
+		message[k] = v
+	}
+	data, err := json.Marshal(message)
+	if err != nil {
+		return
+	}
+
+	shellEventMu.Lock()
+	defer shellEventMu.Unlock()
+	os.Stdout.WriteString(shellEventPrefix + string(data) + "\n")
+}
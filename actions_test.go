@@ -0,0 +1,59 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"os"
+	"strings"
+	"sync"
+	"testing"
+)
+
+func TestSendShellEvent(t *testing.T) {
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	stdout := os.Stdout
+	os.Stdout = w
+	t.Cleanup(func() { os.Stdout = stdout })
+
+	// 并发发送的事件各占一行，不会交错
+	const n = 50
+	var wg sync.WaitGroup
+	for i := range n {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			sendShellEvent("translation-progress", map[string]any{"done": i, "total": n, "text": strings.Repeat("x", 4096)})
+		}()
+	}
+	go func() {
+		wg.Wait()
+		w.Close()
+	}()
+
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(nil, 1<<20)
+	seen := make(map[int]bool)
+	for scanner.Scan() {
+		line, ok := strings.CutPrefix(scanner.Text(), shellEventPrefix)
+		if !ok {
+			t.Fatalf("事件行缺少前缀: %.40q", scanner.Text())
+		}
+		var message struct {
+			Event string `json:"event"`
+			Done  int    `json:"done"`
+		}
+		if err := json.Unmarshal([]byte(line), &message); err != nil {
+			t.Fatalf("事件不是完整的 JSON: %v", err)
+		}
+		if message.Event != "translation-progress" {
+			t.Errorf("event = %q", message.Event)
+		}
+		seen[message.Done] = true
+	}
+	if len(seen) != n {
+		t.Errorf("收到 %d 个事件, want %d", len(seen), n)
+	}
+}
@@ -5,24 +5,65 @@ import (
 	"errors"
 	"fmt"
 	"strings"
+	"time"
 )
 
 // AIClient 定义AI客户端接口
 type AIClient interface {
 	// Translate 翻译文本
-	Translate(ctx context.Context, text string) (string, error)
+	Translate(ctx context.Context, text string) (Result, error)
+	// Complete 使用指定的系统提示词处理文本
+	Complete(ctx context.Context, systemPrompt, text string) (Result, error)
 	// GetName 获取客户端名称
 	GetName() string
+	// GetModel 获取使用的模型
+	GetModel() string
 	// Close 关闭客户端连接
 	Close() error
 }
 
 // AIConfig AI配置
 type AIConfig struct {
-	Provider string `json:"provider"` // gemini, openai, claude, ollama, etc.
-	APIKey   string `json:"api_key"`
-	Model    string `json:"model"`
-	BaseURL  string `json:"base_url,omitempty"` // 可选的自定义API端点
+	Provider     string           `json:"provider"` // gemini, openai, claude, ollama, etc.
+	APIKey       string           `json:"api_key"`
+	Model        string           `json:"model"`
+	BaseURL      string           `json:"base_url,omitempty"`      // 可选的自定义API端点
+	SystemPrompt string           `json:"system_prompt,omitempty"` // 自定义翻译提示词，为空时使用默认提示词
+	Params       GenerationParams `json:"params"`                  // 生成参数
+	Timeout      time.Duration    `json:"timeout,omitempty"`       // 单次请求超时，为零时使用默认值
+}
+
+// GenerationParams 生成参数，零值表示使用提供商默认值
+//
+// 各提供商不支持的参数会被忽略：Claude 和 Gemini 不支持 Seed。
+type GenerationParams struct {
+	Temperature *float64 `json:"temperature,omitempty"` // 采样温度
+	TopP        *float64 `json:"top_p,omitempty"`       // 核采样概率
+	MaxTokens   int      `json:"max_tokens,omitempty"`  // 最大输出token数
+	Seed        *int64   `json:"seed,omitempty"`        // 随机种子
+	Stop        []string `json:"stop,omitempty"`        // 停止序列
+}
+
+// 各提供商请求超时的默认值
+const (
+	defaultTimeout      = 30 * time.Second
+	defaultLocalTimeout = 60 * time.Second // 本地模型响应较慢
+)
+
+// 未设置超时时使用默认值
+func (c AIConfig) timeout(def time.Duration) time.Duration {
+	if c.Timeout > 0 {
+		return c.Timeout
+	}
+	return def
+}
+
+// 翻译使用的系统提示词
+func (c AIConfig) translatePrompt() string {
+	if c.SystemPrompt != "" {
+		return c.SystemPrompt
+	}
+	return getSystemPrompt()
 }
 
 // NewAIClient 创建AI客户端工厂函数
@@ -69,10 +110,31 @@ func getSystemPrompt() string {
 * 简洁明了，直接给出翻译结果。`
 }
 
+// Task 文本处理任务类型，同时也是对应内置提示词模板的名称
+type Task string
+
+const (
+	TaskTranslate   Task = "translate"    // 中英互译
+	TaskTranslateTo Task = "translate_to" // 翻译为指定语言
+	TaskExplain     Task = "explain"      // 解释
+	TaskPolish      Task = "polish"       // 润色/语法修正
+	TaskSummarize   Task = "summarize"    // 摘要
+)
+
 // 通用错误定义
 var (
-	ErrInvalidAPIKey     = errors.New("无效的API密钥")
-	ErrNetworkError      = errors.New("网络连接错误")
-	ErrRateLimitExceeded = errors.New("API调用频率限制")
-	ErrModelNotFound     = errors.New("模型不存在")
+	ErrInvalidAPIKey       = errors.New("无效的API密钥")
+	ErrNetworkError        = errors.New("网络连接错误")
+	ErrRateLimitExceeded   = errors.New("API调用频率限制")
+	ErrModelNotFound       = errors.New("模型不存在")
+	ErrTruncated           = errors.New("输出被截断，已达到最大输出长度")
+	ErrPlaceholderMismatch = errors.New("译文中的占位符与原文不一致")
 )
+
+// 输出因长度限制被截断时返回的错误，maxTokens 为零表示使用了提供商默认值
+func truncatedError(maxTokens int) error {
+	if maxTokens > 0 {
+		return fmt.Errorf("%w (max_tokens=%d)", ErrTruncated, maxTokens)
+	}
+	return ErrTruncated
+}
@@ -0,0 +1,287 @@
+package ai
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"unicode"
+	"unicode/utf8"
+
+	"golang.org/x/sync/errgroup"
+)
+
+// Chunk 长文本的一个分段，Lead + Text + Trail 依次拼接即为原文的对应部分
+type Chunk struct {
+	Lead  string // 分段前的空白，只有第一段可能非空
+	Text  string // 需要处理的内容
+	Trail string // 分段后的空白（段落或句子之间的分隔）
+}
+
+// EstimateTokens 粗略估算文本的token数：中日韩字符每字约一个token，其余约四个字符一个token
+func EstimateTokens(text string) int {
+	var wide, other int
+	for _, r := range text {
+		if r >= 0x2E80 {
+			wide++
+		} else {
+			other++
+		}
+	}
+	return wide + (other+3)/4
+}
+
+// 文本单元：内容及其后的空白
+type unit struct {
+	body string
+	sep  string
+}
+
+// SplitText 按段落和句子边界将文本切分为不超过 budget 个token的分段
+//
+// 单个句子超过预算时按字符硬切分；budget 不大于 0 时不切分。
+func SplitText(text string, budget int) []Chunk {
+	trimmed := strings.TrimLeftFunc(text, unicode.IsSpace)
+	lead := text[:len(text)-len(trimmed)]
+	if trimmed == "" {
+		return []Chunk{{Lead: lead}}
+	}
+	if budget <= 0 || EstimateTokens(trimmed) <= budget {
+		body := strings.TrimRightFunc(trimmed, unicode.IsSpace)
+		return []Chunk{{Lead: lead, Text: body, Trail: trimmed[len(body):]}}
+	}
+
+	// 段落内容过长时再按句子和字符切分
+	var units []unit
+	for _, paragraph := range splitUnits(trimmed, paragraphEnd) {
+		if EstimateTokens(paragraph.body) <= budget {
+			units = append(units, paragraph)
+			continue
+		}
+		sentences := splitUnits(paragraph.body, sentenceEnd)
+		sentences[len(sentences)-1].sep += paragraph.sep
+		for _, sentence := range sentences {
+			if EstimateTokens(sentence.body) <= budget {
+				units = append(units, sentence)
+				continue
+			}
+			units = append(units, hardSplit(sentence, budget)...)
+		}
+	}
+
+	// 贪心合并相邻单元
+	var chunks []Chunk
+	var sb strings.Builder
+	tokens := 0
+	for i, u := range units {
+		cost := EstimateTokens(u.body)
+		if sb.Len() > 0 && tokens+cost > budget {
+			chunks = append(chunks, Chunk{Text: sb.String(), Trail: units[i-1].sep})
+			sb.Reset()
+			tokens = 0
+		}
+		if sb.Len() > 0 {
+			sb.WriteString(units[i-1].sep)
+		}
+		sb.WriteString(u.body)
+		tokens += cost
+	}
+	chunks = append(chunks, Chunk{Text: sb.String(), Trail: units[len(units)-1].sep})
+	chunks[0].Lead = lead
+	return chunks
+}
+
+// 按边界函数切分文本，end 返回从 i 开始的分隔符长度，0 表示不是边界
+func splitUnits(text string, end func(text string, i int) int) []unit {
+	var units []unit
+	start := 0
+	for i := 0; i < len(text); {
+		n := end(text, i)
+		if n == 0 {
+			_, size := utf8.DecodeRuneInString(text[i:])
+			i += size
+			continue
+		}
+
+		// 分隔符中的标点属于前一单元，空白作为分隔
+		sepStart := i
+		for sepStart < i+n {
+			r, size := utf8.DecodeRuneInString(text[sepStart:])
+			if unicode.IsSpace(r) {
+				break
+			}
+			sepStart += size
+		}
+		if body := text[start:sepStart]; body != "" {
+			units = append(units, unit{body: body, sep: text[sepStart : i+n]})
+		} else if len(units) > 0 {
+			units[len(units)-1].sep += text[sepStart : i+n]
+		}
+		i += n
+		start = i
+	}
+
+	if start < len(text) {
+		body := strings.TrimRightFunc(text[start:], unicode.IsSpace)
+		units = append(units, unit{body: body, sep: text[start+len(body):]})
+	} else if len(units) == 0 {
+		units = append(units, unit{})
+	}
+	return units
+}
+
+// 段落边界：包含空行的连续空白
+func paragraphEnd(text string, i int) int {
+	if text[i] != '\n' && text[i] != '\r' {
+		return 0
+	}
+	j := i
+	newlines := 0
+	for j < len(text) {
+		switch text[j] {
+		case '\n':
+			newlines++
+		case ' ', '\t', '\r':
+		default:
+			if newlines < 2 {
+				return 0
+			}
+			return j - i
+		}
+		j++
+	}
+	if newlines < 2 {
+		return 0
+	}
+	return j - i
+}
+
+// 句子边界：中文句末标点，或西文句末标点后跟空白
+func sentenceEnd(text string, i int) int {
+	r, size := utf8.DecodeRuneInString(text[i:])
+	switch r {
+	case '。', '！', '？', '；', '…':
+		j := i + size
+		for j < len(text) {
+			r, size := utf8.DecodeRuneInString(text[j:])
+			if !unicode.IsSpace(r) {
+				break
+			}
+			j += size
+		}
+		return j - i
+	case '.', '!', '?', ';':
+		j := i + size
+		start := j
+		for j < len(text) {
+			r, size := utf8.DecodeRuneInString(text[j:])
+			if !unicode.IsSpace(r) {
+				break
+			}
+			j += size
+		}
+		if j == start && j < len(text) {
+			// 如 3.14、e.g 中的标点不是句子边界
+			return 0
+		}
+		return j - i
+	case '\n':
+		// 单个换行（如列表项）也可以作为切分点
+		return size
+	}
+	return 0
+}
+
+// 将过长的句子按估算的token数硬切分
+func hardSplit(u unit, budget int) []unit {
+	var units []unit
+	limit := budget * 4 // 以四分之一token为单位计算
+	body := u.body
+	for body != "" {
+		end, cost := len(body), 0
+		for i, r := range body {
+			c := 1
+			if r >= 0x2E80 {
+				c = 4
+			}
+			if cost+c > limit && i > 0 {
+				end = i
+				break
+			}
+			cost += c
+		}
+		units = append(units, unit{body: body[:end]})
+		body = body[end:]
+	}
+	units[len(units)-1].sep = u.sep
+	return units
+}
+
+// ChunkPrompt 为分段生成系统提示词，previous 为上一段的原文（第一段为空），用作上下文
+type ChunkPrompt func(previous string) (string, error)
+
+// CompleteChunked 以有限并发处理各分段，按原顺序拼接结果
+//
+// progress 在每段完成后调用，可能来自不同的 goroutine；任一分段失败时取消其余请求并返回错误，
+// 返回的用量包含失败前各段已消耗的用量。
+func CompleteChunked(ctx context.Context, client AIClient, chunks []Chunk, concurrency int, prompt ChunkPrompt, progress func(done, total int)) (Result, error) {
+	if concurrency <= 0 {
+		concurrency = 1
+	}
+
+	results := make([]string, len(chunks))
+	var done atomic.Int32
+	var (
+		mu     sync.Mutex
+		result Result
+	)
+
+	g, gctx := errgroup.WithContext(ctx)
+	g.SetLimit(concurrency)
+	for i, chunk := range chunks {
+		if chunk.Text == "" {
+			continue
+		}
+		previous := ""
+		if i > 0 {
+			previous = chunks[i-1].Text
+		}
+
+		g.Go(func() error {
+			system, err := prompt(previous)
+			if err != nil {
+				return err
+			}
+			out, err := client.Complete(gctx, system, chunk.Text)
+			mu.Lock()
+			result.Usage = result.Usage.Add(out.Usage)
+			result.Truncated = result.Truncated || out.Truncated
+			mu.Unlock()
+			if err != nil {
+				if len(chunks) == 1 {
+					return err
+				}
+				return fmt.Errorf("第 %d/%d 段: %w", i+1, len(chunks), err)
+			}
+			results[i] = strings.TrimSpace(out.Text)
+
+			if progress != nil {
+				progress(int(done.Add(1)), len(chunks))
+			}
+			return nil
+		})
+	}
+	if err := g.Wait(); err != nil {
+		return result, err
+	}
+
+	var sb strings.Builder
+	for i, chunk := range chunks {
+		sb.WriteString(chunk.Lead)
+		sb.WriteString(results[i])
+		sb.WriteString(chunk.Trail)
+	}
+	result.Text = sb.String()
+	return result, nil
+}
@@ -0,0 +1,243 @@
+package ai
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// 测试用的AI客户端，complete 为空时原样返回文本，每次调用消耗 1/1 token
+type fakeClient struct {
+	complete func(system, text string) (Result, error)
+
+	mu    sync.Mutex
+	calls []string // 每次调用的文本
+}
+
+func (c *fakeClient) Translate(ctx context.Context, text string) (Result, error) {
+	return c.Complete(ctx, "", text)
+}
+
+func (c *fakeClient) Complete(ctx context.Context, system, text string) (Result, error) {
+	if err := ctx.Err(); err != nil {
+		return Result{}, err
+	}
+	c.mu.Lock()
+	c.calls = append(c.calls, text)
+	c.mu.Unlock()
+	if c.complete != nil {
+		return c.complete(system, text)
+	}
+	return Result{Text: text, Usage: Usage{InputTokens: 1, OutputTokens: 1}}, nil
+}
+
+func (c *fakeClient) GetName() string  { return "fake" }
+func (c *fakeClient) GetModel() string { return "fake-model" }
+func (c *fakeClient) Close() error     { return nil }
+
+func TestEstimateTokens(t *testing.T) {
+	tests := []struct {
+		text string
+		want int
+	}{
+		{"", 0},
+		{"abcd", 1},
+		{"abcde", 2},
+		{"你好", 2},
+		{"你好abcd", 3},
+		{"こんにちは", 5},
+	}
+	for _, tt := range tests {
+		if got := EstimateTokens(tt.text); got != tt.want {
+			t.Errorf("EstimateTokens(%q) = %d, want %d", tt.text, got, tt.want)
+		}
+	}
+}
+
+func TestSplitText(t *testing.T) {
+	tests := []struct {
+		name   string
+		text   string
+		budget int
+		want   []string // 各分段的 Text
+	}{
+		{
+			name:   "空文本",
+			text:   "",
+			budget: 10,
+			want:   []string{""},
+		},
+		{
+			name:   "只有空白",
+			text:   " \n\t",
+			budget: 10,
+			want:   []string{""},
+		},
+		{
+			name:   "不超过预算不切分",
+			text:   "  Hello world.  \n",
+			budget: 10,
+			want:   []string{"Hello world."},
+		},
+		{
+			name:   "预算为 0 不切分",
+			text:   strings.Repeat("word ", 100),
+			budget: 0,
+			want:   []string{strings.TrimSpace(strings.Repeat("word ", 100))},
+		},
+		{
+			name:   "按段落切分",
+			text:   "Para one.\n\nPara two.",
+			budget: 3,
+			want:   []string{"Para one.", "Para two."},
+		},
+		{
+			name:   "合并相邻的短段落",
+			text:   "One.\n\nTwo.\n\nThree and more.",
+			budget: 4,
+			want:   []string{"One.\n\nTwo.", "Three and more."},
+		},
+		{
+			name:   "段落过长时按句子切分",
+			text:   "First sentence. Second sentence. Third one!",
+			budget: 5,
+			want:   []string{"First sentence.", "Second sentence.", "Third one!"},
+		},
+		{
+			name:   "中文句子",
+			text:   "第一句话。第二句话！第三句话？",
+			budget: 6,
+			want:   []string{"第一句话。", "第二句话！", "第三句话？"},
+		},
+		{
+			name:   "小数点不是句子边界",
+			text:   "Pi is 3.14159 roughly. Yes it is.",
+			budget: 6,
+			want:   []string{"Pi is 3.14159 roughly.", "Yes it is."},
+		},
+		{
+			name:   "过长的句子按字符硬切分",
+			text:   strings.Repeat("a", 20),
+			budget: 2,
+			want:   []string{"aaaaaaaa", "aaaaaaaa", "aaaa"},
+		},
+		{
+			name:   "过长的中文句子按字符硬切分",
+			text:   "一二三四五六七",
+			budget: 3,
+			want:   []string{"一二三", "四五六", "七"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			chunks := SplitText(tt.text, tt.budget)
+
+			var texts []string
+			var sb strings.Builder
+			for _, chunk := range chunks {
+				texts = append(texts, chunk.Text)
+				sb.WriteString(chunk.Lead + chunk.Text + chunk.Trail)
+				if tt.budget > 0 && EstimateTokens(chunk.Text) > tt.budget {
+					t.Errorf("chunk %q = %d tokens, budget %d", chunk.Text, EstimateTokens(chunk.Text), tt.budget)
+				}
+			}
+			if !reflect.DeepEqual(texts, tt.want) {
+				t.Errorf("SplitText() = %q, want %q", texts, tt.want)
+			}
+			if sb.String() != tt.text {
+				t.Errorf("chunks joined = %q, want original %q", sb.String(), tt.text)
+			}
+		})
+	}
+}
+
+func TestCompleteChunked(t *testing.T) {
+	chunks := []Chunk{
+		{Lead: "\n", Text: "one", Trail: "\n\n"},
+		{Text: "two", Trail: "\n\n"},
+		{Text: "three", Trail: "\n"},
+	}
+
+	t.Run("按原顺序拼接并累计用量", func(t *testing.T) {
+		var mu sync.Mutex
+		previous := make(map[string]string)
+		client := &fakeClient{complete: func(system, text string) (Result, error) {
+			mu.Lock()
+			previous[text] = system
+			mu.Unlock()
+			return Result{Text: " " + strings.ToUpper(text) + "\n", Usage: Usage{InputTokens: 2, OutputTokens: 1}}, nil
+		}}
+		var progress []int
+		prompt := func(prev string) (string, error) { return prev, nil }
+
+		result, err := CompleteChunked(context.Background(), client, chunks, 3, prompt, func(done, total int) {
+			mu.Lock()
+			progress = append(progress, done)
+			mu.Unlock()
+			if total != len(chunks) {
+				t.Errorf("progress total = %d, want %d", total, len(chunks))
+			}
+		})
+		if err != nil {
+			t.Fatalf("CompleteChunked() error = %v", err)
+		}
+		if want := "\nONE\n\nTWO\n\nTHREE\n"; result.Text != want {
+			t.Errorf("Text = %q, want %q", result.Text, want)
+		}
+		if want := (Usage{InputTokens: 6, OutputTokens: 3}); result.Usage != want {
+			t.Errorf("Usage = %+v, want %+v", result.Usage, want)
+		}
+		if want := map[string]string{"one": "", "two": "one", "three": "two"}; !reflect.DeepEqual(previous, want) {
+			t.Errorf("previous chunks = %v, want %v", previous, want)
+		}
+		if len(progress) != len(chunks) {
+			t.Errorf("progress calls = %v, want %d", progress, len(chunks))
+		}
+	})
+
+	t.Run("跳过空分段", func(t *testing.T) {
+		client := &fakeClient{}
+		result, err := CompleteChunked(context.Background(), client, []Chunk{{Lead: "  "}}, 1, func(string) (string, error) { return "", nil }, nil)
+		if err != nil {
+			t.Fatalf("CompleteChunked() error = %v", err)
+		}
+		if result.Text != "  " || len(client.calls) != 0 {
+			t.Errorf("Text = %q, calls = %v, want original text and no calls", result.Text, client.calls)
+		}
+	})
+
+	t.Run("失败时返回已消耗的用量", func(t *testing.T) {
+		errFailed := errors.New("failed")
+		client := &fakeClient{complete: func(system, text string) (Result, error) {
+			if text == "two" {
+				return Result{Usage: Usage{InputTokens: 1, OutputTokens: 1}, Truncated: true}, errFailed
+			}
+			return Result{Text: text, Usage: Usage{InputTokens: 1, OutputTokens: 1}}, nil
+		}}
+
+		result, err := CompleteChunked(context.Background(), client, chunks, 1, func(string) (string, error) { return "", nil }, nil)
+		if !errors.Is(err, errFailed) || !strings.Contains(err.Error(), "第 2/3 段") {
+			t.Errorf("CompleteChunked() error = %v, want chunk 2 failure", err)
+		}
+		// 第三段在取消后才开始，不消耗用量
+		if want := (Usage{InputTokens: 2, OutputTokens: 2}); result.Usage != want {
+			t.Errorf("Usage = %+v, want %+v", result.Usage, want)
+		}
+		if !result.Truncated {
+			t.Error("Truncated = false, want true")
+		}
+	})
+
+	t.Run("单个分段的错误不加序号", func(t *testing.T) {
+		errFailed := errors.New("failed")
+		client := &fakeClient{complete: func(string, string) (Result, error) { return Result{}, errFailed }}
+		_, err := CompleteChunked(context.Background(), client, chunks[:1], 1, func(string) (string, error) { return "", nil }, nil)
+		if err != errFailed {
+			t.Errorf("CompleteChunked() error = %v, want %v", err, errFailed)
+		}
+	})
+}
@@ -7,7 +7,6 @@ import (
 	"fmt"
 	"io"
 	"net/http"
-	"time"
 )
 
 // ClaudeClient Claude客户端
@@ -15,17 +14,25 @@ type ClaudeClient struct {
 	apiKey  string
 	model   string
 	baseURL string
+	prompt  string
+	params  GenerationParams
 	client  *http.Client
 }
 
 // ClaudeRequest Claude API请求结构
 type ClaudeRequest struct {
-	Model     string          `json:"model"`
-	MaxTokens int             `json:"max_tokens"`
-	Messages  []ClaudeMessage `json:"messages"`
-	System    string          `json:"system,omitempty"`
+	Model         string          `json:"model"`
+	MaxTokens     int             `json:"max_tokens"`
+	Messages      []ClaudeMessage `json:"messages"`
+	System        string          `json:"system,omitempty"`
+	Temperature   *float64        `json:"temperature,omitempty"`
+	TopP          *float64        `json:"top_p,omitempty"`
+	StopSequences []string        `json:"stop_sequences,omitempty"`
 }
 
+// Claude API 要求必须指定最大输出token数
+const claudeDefaultMaxTokens = 4096
+
 // ClaudeMessage Claude消息结构
 type ClaudeMessage struct {
 	Role    string `json:"role"`
@@ -34,8 +41,16 @@ type ClaudeMessage struct {
 
 // ClaudeResponse Claude API响应结构
 type ClaudeResponse struct {
-	Content []ClaudeContent `json:"content"`
-	Error   *APIError       `json:"error,omitempty"`
+	Content    []ClaudeContent `json:"content"`
+	StopReason string          `json:"stop_reason"` // max_tokens 表示达到最大输出长度
+	Usage      *ClaudeUsage    `json:"usage,omitempty"`
+	Error      *APIError       `json:"error,omitempty"`
+}
+
+// ClaudeUsage token用量
+type ClaudeUsage struct {
+	InputTokens  int `json:"input_tokens"`
+	OutputTokens int `json:"output_tokens"`
 }
 
 // ClaudeContent Claude内容结构
@@ -63,34 +78,49 @@ func NewClaudeClient(config AIConfig) (*ClaudeClient, error) {
 		apiKey:  config.APIKey,
 		model:   config.Model,
 		baseURL: baseURL,
+		prompt:  config.translatePrompt(),
+		params:  config.Params,
 		client: &http.Client{
-			Timeout: 30 * time.Second,
+			Timeout: config.timeout(defaultTimeout),
 		},
 	}, nil
 }
 
 // Translate 实现翻译功能
-func (c *ClaudeClient) Translate(ctx context.Context, text string) (string, error) {
+func (c *ClaudeClient) Translate(ctx context.Context, text string) (Result, error) {
+	return c.Complete(ctx, c.prompt, text)
+}
+
+// Complete 使用指定的系统提示词处理文本
+func (c *ClaudeClient) Complete(ctx context.Context, systemPrompt, text string) (Result, error) {
+	maxTokens := c.params.MaxTokens
+	if maxTokens <= 0 {
+		maxTokens = claudeDefaultMaxTokens
+	}
+
 	request := ClaudeRequest{
 		Model:     c.model,
-		MaxTokens: 1000,
-		System:    getSystemPrompt(),
+		MaxTokens: maxTokens,
+		System:    systemPrompt,
 		Messages: []ClaudeMessage{
 			{
 				Role:    "user",
 				Content: text,
 			},
 		},
+		Temperature:   c.params.Temperature,
+		TopP:          c.params.TopP,
+		StopSequences: c.params.Stop,
 	}
 
 	jsonData, err := json.Marshal(request)
 	if err != nil {
-		return "", fmt.Errorf("序列化请求失败: %w", err)
+		return Result{}, fmt.Errorf("序列化请求失败: %w", err)
 	}
 
 	req, err := http.NewRequestWithContext(ctx, "POST", c.baseURL+"/messages", bytes.NewBuffer(jsonData))
 	if err != nil {
-		return "", fmt.Errorf("创建请求失败: %w", err)
+		return Result{}, fmt.Errorf("创建请求失败: %w", err)
 	}
 
 	req.Header.Set("Content-Type", "application/json")
@@ -99,29 +129,39 @@ func (c *ClaudeClient) Translate(ctx context.Context, text string) (string, erro
 
 	resp, err := c.client.Do(req)
 	if err != nil {
-		return "", fmt.Errorf("发送请求失败: %w", err)
+		return Result{}, fmt.Errorf("发送请求失败: %w", err)
 	}
 	defer resp.Body.Close()
 
 	body, err := io.ReadAll(resp.Body)
 	if err != nil {
-		return "", fmt.Errorf("读取响应失败: %w", err)
+		return Result{}, fmt.Errorf("读取响应失败: %w", err)
 	}
 
 	var claudeResp ClaudeResponse
 	if err := json.Unmarshal(body, &claudeResp); err != nil {
-		return "", fmt.Errorf("解析响应失败: %w", err)
+		return Result{}, fmt.Errorf("解析响应失败: %w", err)
 	}
 
 	if claudeResp.Error != nil {
-		return "", fmt.Errorf("claude api错误: %s", claudeResp.Error.Message)
+		return Result{}, fmt.Errorf("claude api错误: %s", claudeResp.Error.Message)
+	}
+
+	var result Result
+	if claudeResp.Usage != nil {
+		result.Usage = Usage{InputTokens: claudeResp.Usage.InputTokens, OutputTokens: claudeResp.Usage.OutputTokens}
 	}
 
 	if len(claudeResp.Content) == 0 {
-		return "", fmt.Errorf("没有返回翻译结果")
+		return result, fmt.Errorf("没有返回翻译结果")
 	}
 
-	return claudeResp.Content[0].Text, nil
+	result.Text = claudeResp.Content[0].Text
+	if claudeResp.StopReason == "max_tokens" {
+		result.Truncated = true
+		return result, truncatedError(maxTokens)
+	}
+	return result, nil
 }
 
 // GetName 获取客户端名称
@@ -129,6 +169,11 @@ func (c *ClaudeClient) GetName() string {
 	return "Claude"
 }
 
+// GetModel 获取使用的模型
+func (c *ClaudeClient) GetModel() string {
+	return c.model
+}
+
 // Close 关闭客户端
 func (c *ClaudeClient) Close() error {
 	return nil
@@ -0,0 +1,171 @@
+package ai
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// 各 HTTP 提供商的测试桩：path 为请求路径，params 从请求体中取出生成参数，reply 构造响应体
+type providerStub struct {
+	provider string
+	path     string
+	params   func(body map[string]any) map[string]any
+	reply    func(text string, truncated bool) any
+}
+
+var providerStubs = []providerStub{
+	{
+		provider: "openai",
+		path:     "/chat/completions",
+		params:   func(body map[string]any) map[string]any { return body },
+		reply: func(text string, truncated bool) any {
+			reason := "stop"
+			if truncated {
+				reason = "length"
+			}
+			return map[string]any{
+				"choices": []any{map[string]any{"message": map[string]any{"role": "assistant", "content": text}, "finish_reason": reason}},
+				"usage":   map[string]any{"prompt_tokens": 3, "completion_tokens": 2},
+			}
+		},
+	},
+	{
+		provider: "claude",
+		path:     "/messages",
+		params:   func(body map[string]any) map[string]any { return body },
+		reply: func(text string, truncated bool) any {
+			reason := "end_turn"
+			if truncated {
+				reason = "max_tokens"
+			}
+			return map[string]any{
+				"content":     []any{map[string]any{"type": "text", "text": text}},
+				"stop_reason": reason,
+				"usage":       map[string]any{"input_tokens": 3, "output_tokens": 2},
+			}
+		},
+	},
+	{
+		provider: "ollama",
+		path:     "/api/generate",
+		params: func(body map[string]any) map[string]any {
+			options, _ := body["options"].(map[string]any)
+			return options
+		},
+		reply: func(text string, truncated bool) any {
+			reason := "stop"
+			if truncated {
+				reason = "length"
+			}
+			return map[string]any{"response": text, "done": true, "done_reason": reason, "prompt_eval_count": 3, "eval_count": 2}
+		},
+	},
+}
+
+func stubFor(t *testing.T, provider string) providerStub {
+	t.Helper()
+	for _, stub := range providerStubs {
+		if stub.provider == provider {
+			return stub
+		}
+	}
+	t.Fatalf("没有 %s 的测试桩", provider)
+	return providerStub{}
+}
+
+// 启动测试桩服务器，返回指向它的客户端以及收到的最后一个请求体
+func newStubClient(t *testing.T, stub providerStub, params GenerationParams, truncated bool) (AIClient, *map[string]any) {
+	t.Helper()
+	var body map[string]any
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != stub.path {
+			http.Error(w, "unexpected path "+r.URL.Path, http.StatusNotFound)
+			return
+		}
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		json.NewEncoder(w).Encode(stub.reply("译文", truncated))
+	}))
+	t.Cleanup(srv.Close)
+
+	client, err := NewAIClient(AIConfig{Provider: stub.provider, APIKey: "test-key", Model: "test-model", BaseURL: srv.URL, Params: params})
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { client.Close() })
+	return client, &body
+}
+
+func TestGenerationParams(t *testing.T) {
+	temperature, topP := 0.2, 0.9
+	seed := int64(42)
+	params := GenerationParams{Temperature: &temperature, TopP: &topP, MaxTokens: 256, Seed: &seed, Stop: []string{"\n\n", "END"}}
+
+	// JSON 解码后数字均为 float64
+	tests := []struct {
+		provider string
+		params   GenerationParams
+		want     map[string]any
+	}{
+		{"openai", params, map[string]any{"temperature": 0.2, "top_p": 0.9, "max_tokens": 256.0, "seed": 42.0, "stop": []any{"\n\n", "END"}}},
+		{"openai", GenerationParams{}, map[string]any{}},
+		// Claude 不支持 seed，且必须指定 max_tokens
+		{"claude", params, map[string]any{"temperature": 0.2, "top_p": 0.9, "max_tokens": 256.0, "stop_sequences": []any{"\n\n", "END"}}},
+		{"claude", GenerationParams{}, map[string]any{"max_tokens": float64(claudeDefaultMaxTokens)}},
+		{"ollama", params, map[string]any{"temperature": 0.2, "top_p": 0.9, "num_predict": 256.0, "seed": 42.0, "stop": []any{"\n\n", "END"}}},
+		{"ollama", GenerationParams{}, map[string]any{}},
+	}
+
+	keys := []string{"temperature", "top_p", "max_tokens", "num_predict", "seed", "stop", "stop_sequences"}
+	for _, tt := range tests {
+		stub := stubFor(t, tt.provider)
+		client, body := newStubClient(t, stub, tt.params, false)
+		if _, err := client.Complete(context.Background(), "system", "text"); err != nil {
+			t.Fatalf("%s: Complete() error = %v", tt.provider, err)
+		}
+
+		sent := stub.params(*body)
+		got := make(map[string]any)
+		for _, key := range keys {
+			if value, ok := sent[key]; ok {
+				got[key] = value
+			}
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s %+v: 请求中的生成参数为 %v, want %v", tt.provider, tt.params, got, tt.want)
+		}
+	}
+}
+
+func TestTruncation(t *testing.T) {
+	for _, stub := range providerStubs {
+		t.Run(stub.provider, func(t *testing.T) {
+			client, _ := newStubClient(t, stub, GenerationParams{}, false)
+			result, err := client.Complete(context.Background(), "system", "text")
+			if err != nil || result.Truncated || result.Text != "译文" {
+				t.Errorf("正常结束: Complete() = %+v, %v", result, err)
+			}
+
+			client, _ = newStubClient(t, stub, GenerationParams{MaxTokens: 16}, true)
+			result, err = client.Complete(context.Background(), "system", "text")
+			if !errors.Is(err, ErrTruncated) {
+				t.Fatalf("截断: Complete() error = %v, want ErrTruncated", err)
+			}
+			if !strings.Contains(err.Error(), "max_tokens=16") {
+				t.Errorf("截断错误 %q 未包含 max_tokens 上限", err)
+			}
+			// 截断时仍返回已生成的部分译文和用量
+			if !result.Truncated || result.Text != "译文" || result.Usage != (Usage{InputTokens: 3, OutputTokens: 2}) {
+				t.Errorf("截断: Complete() = %+v", result)
+			}
+		})
+	}
+}
@@ -11,8 +11,11 @@ import (
 
 // GeminiClient Gemini AI客户端
 type GeminiClient struct {
-	client *gemini.Client
-	model  string
+	client  *gemini.Client
+	model   string
+	prompt  string
+	params  GenerationParams
+	timeout time.Duration
 }
 
 // NewGeminiClient 创建Gemini客户端
@@ -34,47 +37,81 @@ func NewGeminiClient(config AIConfig) (*GeminiClient, error) {
 	}
 
 	return &GeminiClient{
-		client: client,
-		model:  config.Model,
+		client:  client,
+		model:   config.Model,
+		prompt:  config.translatePrompt(),
+		params:  config.Params,
+		timeout: config.timeout(defaultTimeout),
 	}, nil
 }
 
 // Translate 实现翻译功能
-func (g *GeminiClient) Translate(ctx context.Context, text string) (string, error) {
+func (g *GeminiClient) Translate(ctx context.Context, text string) (Result, error) {
+	return g.Complete(ctx, g.prompt, text)
+}
+
+// Complete 使用指定的系统提示词处理文本
+func (g *GeminiClient) Complete(ctx context.Context, systemPrompt, text string) (Result, error) {
 	model := g.client.GenerativeModel(g.model)
 
 	systemInstruction := &gemini.Content{
 		Parts: []gemini.Part{
-			gemini.Text(getSystemPrompt()),
+			gemini.Text(systemPrompt),
 		},
 		Role: "system",
 	}
 
 	model.SystemInstruction = systemInstruction
+	if g.params.Temperature != nil {
+		model.SetTemperature(float32(*g.params.Temperature))
+	}
+	if g.params.TopP != nil {
+		model.SetTopP(float32(*g.params.TopP))
+	}
+	if g.params.MaxTokens > 0 {
+		model.SetMaxOutputTokens(int32(g.params.MaxTokens))
+	}
+	model.StopSequences = g.params.Stop
 
 	// 最大重试次数
 	maxRetries := 3
 	var lastErr error
+	var result Result // 用量累加每次尝试
 
 	for attempt := range maxRetries {
 		if attempt > 0 {
 			time.Sleep(time.Duration(attempt) * time.Second)
 		}
 
-		resp, err := model.GenerateContent(ctx, gemini.Text(text))
+		attemptCtx, cancel := context.WithTimeout(ctx, g.timeout)
+		resp, err := model.GenerateContent(attemptCtx, gemini.Text(text))
+		cancel()
 		if err != nil {
 			lastErr = err
 			continue
 		}
+		if resp.UsageMetadata != nil {
+			result.Usage = result.Usage.Add(Usage{
+				InputTokens:  int(resp.UsageMetadata.PromptTokenCount),
+				OutputTokens: int(resp.UsageMetadata.CandidatesTokenCount),
+			})
+		}
 
-		if len(resp.Candidates) > 0 && len(resp.Candidates[0].Content.Parts) > 0 {
-			if responseText, ok := resp.Candidates[0].Content.Parts[0].(gemini.Text); ok {
-				return string(responseText), nil
+		if len(resp.Candidates) > 0 && resp.Candidates[0].Content != nil && len(resp.Candidates[0].Content.Parts) > 0 {
+			candidate := resp.Candidates[0]
+			if responseText, ok := candidate.Content.Parts[0].(gemini.Text); ok {
+				// 截断不是临时错误，不再重试
+				result.Text = string(responseText)
+				if candidate.FinishReason == gemini.FinishReasonMaxTokens {
+					result.Truncated = true
+					return result, truncatedError(g.params.MaxTokens)
+				}
+				return result, nil
 			}
 		}
 	}
 
-	return "", fmt.Errorf("翻译失败，已重试%d次: %v", maxRetries, lastErr)
+	return result, fmt.Errorf("翻译失败，已重试%d次: %v", maxRetries, lastErr)
 }
 
 // GetName 获取客户端名称
@@ -82,6 +119,11 @@ func (g *GeminiClient) GetName() string {
 	return "Gemini"
 }
 
+// GetModel 获取使用的模型
+func (g *GeminiClient) GetModel() string {
+	return g.model
+}
+
 // Close 关闭客户端
 func (g *GeminiClient) Close() error {
 	if g.client != nil {
@@ -0,0 +1,236 @@
+package ai
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// 占位符形如 ⟦0⟧，模型偶尔会在括号内加空格，还原时一并识别
+var placeholderPattern = regexp.MustCompile(`⟦\s*(\d+)\s*⟧`)
+
+// 行内需要保护的内容：HTML注释和标签、尖括号自动链接、链接目标、裸URL
+//
+// 裸URL遇到全角标点即结束，中文正文中URL后常紧跟全角标点而没有空格。
+var inlinePattern = regexp.MustCompile(`(?s)<!--.*?-->` +
+	`|<(?:https?|ftp|mailto):[^\s<>]+>` +
+	`|</?[A-Za-z][A-Za-z0-9-]*(?:\s[^<>]*)?/?>` +
+	`|\]\([^()\s]*(?:\([^()\s]*\)[^()\s]*)*(?:\s+"[^"]*")?\)` +
+	`|https?://[^\s<>()\[\]，。；：！？、（）]*[^\s<>()\[\].,;:!?'"，。；：！？、（）]`)
+
+// 引用式链接定义，如 [1]: https://example.com "title"
+var linkDefinitionPattern = regexp.MustCompile(`^ {0,3}\[[^\]]+\]:\s*\S+.*$`)
+
+// MarkdownInstruction 追加到系统提示词中，要求模型保留占位符和 Markdown 标记
+const MarkdownInstruction = `文本中形如 ⟦0⟧ 的标记是代码、链接或HTML标签的占位符：必须原样保留每一个占位符，不要翻译、修改、删除或重复它们，并保持其在句子中的对应位置。同时保留 Markdown 标记（如 #、*、-、>、|、[]）和原有的换行。`
+
+// MaskedText 已将代码、链接和HTML替换为占位符的文本
+type MaskedText struct {
+	Text         string   // 替换后的文本，只剩需要翻译的正文和 Markdown 标记
+	placeholders []string // 占位符序号 -> 原始内容
+}
+
+// MaskMarkdown 解析 Markdown 文本，将围栏代码块、行内代码、URL、链接目标和HTML标签替换为占位符
+//
+// 缩进代码块与列表的续行难以区分，不做处理。原文本身包含占位符括号时不做替换。
+func MaskMarkdown(text string) *MaskedText {
+	m := &MaskedText{}
+	if strings.Contains(text, "⟦") {
+		m.Text = text
+		return m
+	}
+
+	var sb, prose strings.Builder
+	flush := func() {
+		sb.WriteString(m.maskInline(prose.String()))
+		prose.Reset()
+	}
+
+	lines := strings.SplitAfter(text, "\n")
+	for i := 0; i < len(lines); i++ {
+		line := lines[i]
+		fence := fenceMarker(line)
+		if fence == "" {
+			if linkDefinitionPattern.MatchString(strings.TrimRight(line, "\r\n")) {
+				flush()
+				body := strings.TrimRight(line, "\r\n")
+				sb.WriteString(m.placeholder(body) + line[len(body):])
+				continue
+			}
+			prose.WriteString(line)
+			continue
+		}
+
+		// 围栏代码块：到相同字符且不短于起始围栏的结束行为止，未闭合时到文本末尾
+		flush()
+		block := line
+		for i+1 < len(lines) {
+			i++
+			block += lines[i]
+			if closing := fenceMarker(lines[i]); closing != "" && closing[0] == fence[0] && len(closing) >= len(fence) &&
+				strings.TrimSpace(strings.TrimLeft(strings.TrimSpace(lines[i]), closing[:1])) == "" {
+				break
+			}
+		}
+		body := strings.TrimRight(block, "\r\n")
+		sb.WriteString(m.placeholder(body) + block[len(body):])
+	}
+	flush()
+
+	m.Text = sb.String()
+	return m
+}
+
+// MaskPattern 将文本中匹配 pattern 的部分替换为占位符，如本地化字符串中的 {count}、%s
+//
+// 原文本身包含占位符括号时不做替换。
+func MaskPattern(text string, pattern *regexp.Regexp) *MaskedText {
+	m := &MaskedText{Text: text}
+	if strings.Contains(text, "⟦") {
+		return m
+	}
+	m.Text = pattern.ReplaceAllStringFunc(text, m.placeholder)
+	return m
+}
+
+// 返回围栏代码块的起始标记（``` 或 ~~~ 及更长），不是围栏时返回空
+func fenceMarker(line string) string {
+	trimmed := strings.TrimLeft(line, " ")
+	if len(line)-len(trimmed) > 3 || len(trimmed) < 3 {
+		return ""
+	}
+	c := trimmed[0]
+	if c != '`' && c != '~' {
+		return ""
+	}
+	n := 0
+	for n < len(trimmed) && trimmed[n] == c {
+		n++
+	}
+	if n < 3 {
+		return ""
+	}
+	// 反引号围栏的信息字符串中不能再出现反引号
+	if c == '`' && strings.Contains(trimmed[n:], "`") {
+		return ""
+	}
+	return trimmed[:n]
+}
+
+// 替换正文中的行内代码及其他行内元素
+func (m *MaskedText) maskInline(text string) string {
+	var sb strings.Builder
+	for {
+		start, end := inlineCodeSpan(text)
+		if start < 0 {
+			sb.WriteString(m.maskPattern(text))
+			return sb.String()
+		}
+		sb.WriteString(m.maskPattern(text[:start]))
+		sb.WriteString(m.placeholder(text[start:end]))
+		text = text[end:]
+	}
+}
+
+// 查找第一个行内代码：由相同长度的反引号串包围，返回其起止位置，未找到时返回 -1
+func inlineCodeSpan(text string) (int, int) {
+	for i := 0; i < len(text); {
+		if text[i] != '`' {
+			i++
+			continue
+		}
+		n := 0
+		for i+n < len(text) && text[i+n] == '`' {
+			n++
+		}
+		for j := i + n; j < len(text); {
+			if text[j] != '`' {
+				j++
+				continue
+			}
+			k := 0
+			for j+k < len(text) && text[j+k] == '`' {
+				k++
+			}
+			if k == n {
+				return i, j + k
+			}
+			j += k
+		}
+		// 没有对应的结束反引号，按普通字符处理
+		i += n
+	}
+	return -1, -1
+}
+
+// 替换HTML标签、链接和URL
+func (m *MaskedText) maskPattern(text string) string {
+	return inlinePattern.ReplaceAllStringFunc(text, func(match string) string {
+		// 链接只保护目标部分，链接文字仍需翻译
+		if strings.HasPrefix(match, "](") {
+			return "]" + m.placeholder(match[1:])
+		}
+		return m.placeholder(match)
+	})
+}
+
+// 记录原始内容并返回对应的占位符
+func (m *MaskedText) placeholder(original string) string {
+	m.placeholders = append(m.placeholders, original)
+	return fmt.Sprintf("⟦%d⟧", len(m.placeholders)-1)
+}
+
+// Masked 返回是否替换了任何内容
+func (m *MaskedText) Masked() bool {
+	return len(m.placeholders) > 0
+}
+
+// HasProse 返回去掉占位符后是否还有需要翻译的文字
+func (m *MaskedText) HasProse() bool {
+	return strings.TrimSpace(placeholderPattern.ReplaceAllString(m.Text, "")) != ""
+}
+
+// Restore 将译文中的占位符还原为原始内容，并校验每个占位符恰好出现一次
+func (m *MaskedText) Restore(translated string) (string, error) {
+	if !m.Masked() {
+		return translated, nil
+	}
+
+	counts := make([]int, len(m.placeholders))
+	var unknown []string
+	restored := placeholderPattern.ReplaceAllStringFunc(translated, func(match string) string {
+		index, err := strconv.Atoi(placeholderPattern.FindStringSubmatch(match)[1])
+		if err != nil || index >= len(m.placeholders) {
+			unknown = append(unknown, match)
+			return match
+		}
+		counts[index]++
+		return m.placeholders[index]
+	})
+
+	var missing, repeated []string
+	for i, count := range counts {
+		switch {
+		case count == 0:
+			missing = append(missing, fmt.Sprintf("⟦%d⟧", i))
+		case count > 1:
+			repeated = append(repeated, fmt.Sprintf("⟦%d⟧", i))
+		}
+	}
+
+	var problems []string
+	if len(missing) > 0 {
+		problems = append(problems, "缺少 "+strings.Join(missing, " "))
+	}
+	if len(repeated) > 0 {
+		problems = append(problems, "重复 "+strings.Join(repeated, " "))
+	}
+	if len(unknown) > 0 {
+		problems = append(problems, "多出 "+strings.Join(unknown, " "))
+	}
+	if len(problems) > 0 {
+		return "", fmt.Errorf("%w: %s", ErrPlaceholderMismatch, strings.Join(problems, "，"))
+	}
+	return restored, nil
+}
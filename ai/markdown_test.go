@@ -0,0 +1,197 @@
+package ai
+
+import (
+	"errors"
+	"regexp"
+	"strings"
+	"testing"
+)
+
+func TestMaskMarkdown(t *testing.T) {
+	tests := []struct {
+		name     string
+		text     string
+		want     string
+		wantKeep []string // 必须被替换为占位符的内容
+	}{
+		{
+			name: "纯文本不替换",
+			text: "Hello *world*\n\n- item",
+			want: "Hello *world*\n\n- item",
+		},
+		{
+			name:     "行内代码",
+			text:     "Run `go test` and ``a ` b`` now",
+			want:     "Run ⟦0⟧ and ⟦1⟧ now",
+			wantKeep: []string{"`go test`", "``a ` b``"},
+		},
+		{
+			name: "未闭合的反引号按普通字符处理",
+			text: "a ` b",
+			want: "a ` b",
+		},
+		{
+			name:     "围栏代码块",
+			text:     "Intro\n```go\nfmt.Println(\"hi\")\n```\nOutro",
+			want:     "Intro\n⟦0⟧\nOutro",
+			wantKeep: []string{"```go\nfmt.Println(\"hi\")\n```"},
+		},
+		{
+			name:     "波浪线围栏需要相同字符结束",
+			text:     "~~~~\ncode\n```\n~~~~\ntext",
+			want:     "⟦0⟧\ntext",
+			wantKeep: []string{"~~~~\ncode\n```\n~~~~"},
+		},
+		{
+			name:     "未闭合的围栏到文本末尾",
+			text:     "text\n```\ncode\n",
+			want:     "text\n⟦0⟧\n",
+			wantKeep: []string{"```\ncode"},
+		},
+		{
+			name:     "链接只保护目标",
+			text:     "See [the docs](https://example.com/a_(b) \"Title\").",
+			want:     "See [the docs]⟦0⟧.",
+			wantKeep: []string{"(https://example.com/a_(b) \"Title\")"},
+		},
+		{
+			name:     "裸URL不包含句末标点",
+			text:     "访问 https://example.com/path。或 http://a.b/c, ok",
+			want:     "访问 ⟦0⟧。或 ⟦1⟧, ok",
+			wantKeep: []string{"https://example.com/path", "http://a.b/c"},
+		},
+		{
+			name:     "HTML标签和注释",
+			text:     "<b>bold</b> <!-- note --> <https://x.io>",
+			want:     "⟦0⟧bold⟦1⟧ ⟦2⟧ ⟦3⟧",
+			wantKeep: []string{"<b>", "</b>", "<!-- note -->", "<https://x.io>"},
+		},
+		{
+			name:     "引用式链接定义",
+			text:     "Text [a][1].\n\n[1]: https://example.com \"t\"\n",
+			want:     "Text [a][1].\n\n⟦0⟧\n",
+			wantKeep: []string{"[1]: https://example.com \"t\""},
+		},
+		{
+			name: "原文包含占位符括号时不替换",
+			text: "⟦0⟧ `code`",
+			want: "⟦0⟧ `code`",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := MaskMarkdown(tt.text)
+			if m.Text != tt.want {
+				t.Errorf("Text = %q, want %q", m.Text, tt.want)
+			}
+			if m.Masked() != (len(tt.wantKeep) > 0) {
+				t.Errorf("Masked() = %v, want %v", m.Masked(), len(tt.wantKeep) > 0)
+			}
+			for _, keep := range tt.wantKeep {
+				if strings.Contains(m.Text, keep) {
+					t.Errorf("Text still contains %q", keep)
+				}
+			}
+
+			restored, err := m.Restore(m.Text)
+			if err != nil {
+				t.Fatalf("Restore() error = %v", err)
+			}
+			if restored != tt.text {
+				t.Errorf("Restore() = %q, want original %q", restored, tt.text)
+			}
+		})
+	}
+}
+
+func TestMaskPattern(t *testing.T) {
+	m := MaskPattern("Deleted {count} files in %s", regexp.MustCompile(`\{\w+\}|%s`))
+	if want := "Deleted ⟦0⟧ files in ⟦1⟧"; m.Text != want {
+		t.Errorf("Text = %q, want %q", m.Text, want)
+	}
+	restored, err := m.Restore("在 ⟦1⟧ 中删除了 ⟦0⟧ 个文件")
+	if err != nil {
+		t.Fatalf("Restore() error = %v", err)
+	}
+	if want := "在 %s 中删除了 {count} 个文件"; restored != want {
+		t.Errorf("Restore() = %q, want %q", restored, want)
+	}
+}
+
+func TestHasProse(t *testing.T) {
+	tests := []struct {
+		text string
+		want bool
+	}{
+		{"```\ncode\n```", false},
+		{"`a` https://example.com\n", false},
+		{"`a` is code", true},
+		{"plain", true},
+	}
+	for _, tt := range tests {
+		if got := MaskMarkdown(tt.text).HasProse(); got != tt.want {
+			t.Errorf("HasProse(%q) = %v, want %v", tt.text, got, tt.want)
+		}
+	}
+}
+
+func TestRestore(t *testing.T) {
+	m := MaskMarkdown("Use `a` and `b`.")
+
+	tests := []struct {
+		name       string
+		translated string
+		want       string
+		wantErr    string
+	}{
+		{
+			name:       "调整顺序",
+			translated: "先用 ⟦1⟧ 再用 ⟦0⟧。",
+			want:       "先用 `b` 再用 `a`。",
+		},
+		{
+			name:       "括号内的空格",
+			translated: "使用 ⟦ 0 ⟧ 和 ⟦1 ⟧。",
+			want:       "使用 `a` 和 `b`。",
+		},
+		{
+			name:       "缺少占位符",
+			translated: "使用 ⟦0⟧。",
+			wantErr:    "缺少 ⟦1⟧",
+		},
+		{
+			name:       "重复的占位符",
+			translated: "⟦0⟧ ⟦0⟧ ⟦1⟧",
+			wantErr:    "重复 ⟦0⟧",
+		},
+		{
+			name:       "多出的占位符",
+			translated: "⟦0⟧ ⟦1⟧ ⟦2⟧",
+			wantErr:    "多出 ⟦2⟧",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := m.Restore(tt.translated)
+			if tt.wantErr != "" {
+				if !errors.Is(err, ErrPlaceholderMismatch) || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Errorf("Restore() error = %v, want %v containing %q", err, ErrPlaceholderMismatch, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Restore() error = %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("Restore() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+
+	// 没有占位符时原样返回
+	if got, err := MaskMarkdown("plain").Restore("⟦0⟧ 文本"); err != nil || got != "⟦0⟧ 文本" {
+		t.Errorf("Restore() = %q, %v, want unchanged", got, err)
+	}
+}
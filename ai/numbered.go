@@ -0,0 +1,129 @@
+package ai
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// NumberedInstruction 追加到系统提示词中，说明编号列表的输入输出格式
+const NumberedInstruction = `输入是按顺序编号的条目，每行格式为 [编号] 文本。
+* 逐条处理，输出同样的格式：每条一行，以原编号开头，不要合并、拆分、省略或增加条目。
+* 结合前后条目理解语境，但每条只输出其自身的结果。`
+
+// 匹配模型输出的编号行
+var numberedLinePattern = regexp.MustCompile(`^\s*\[(\d+)\]\s?(.*)$`)
+
+// 每次请求默认最多包含的条目数，过多时模型容易漏译
+const defaultBatchItems = 40
+
+// BatchOptions 编号批量处理的参数
+type BatchOptions struct {
+	Tokens      int // 每次请求的估算token数上限，不大于 0 时只按条目数分批
+	Items       int // 每次请求的最大条目数，默认 40
+	Concurrency int // 同时进行的请求数
+}
+
+// CompleteNumbered 将多条单行文本编号后分批处理，返回与输入一一对应的结果
+//
+// 空文本不发送，结果为空。每批以上一批的原文作为上下文；输出缺少某些条目时只对这些条目再请求一次，
+// 仍缺少时返回错误。文本中的换行会被替换为空格，需要保留换行时应先替换为占位符。
+// 返回的用量包含所有批次和重试，失败时也包含已消耗的用量。
+func CompleteNumbered(ctx context.Context, client AIClient, texts []string, opts BatchOptions, prompt ChunkPrompt, progress func(done, total int)) ([]string, Usage, error) {
+	var usage Usage
+	results := make([]string, len(texts))
+	done := make([]bool, len(texts))
+	pending := make([]int, 0, len(texts))
+	for i, text := range texts {
+		if strings.TrimSpace(text) != "" {
+			pending = append(pending, i)
+		}
+	}
+
+	for attempt := 1; attempt <= 2 && len(pending) > 0; attempt++ {
+		output, err := CompleteChunked(ctx, client, numberedChunks(texts, pending, opts), opts.Concurrency, prompt, progress)
+		usage = usage.Add(output.Usage)
+		if err != nil {
+			return nil, usage, err
+		}
+
+		for index, text := range parseNumbered(output.Text) {
+			if index >= 0 && index < len(texts) && text != "" && strings.TrimSpace(texts[index]) != "" {
+				results[index] = text
+				done[index] = true
+			}
+		}
+
+		var missing []int
+		for _, index := range pending {
+			if !done[index] {
+				missing = append(missing, index)
+			}
+		}
+		pending = missing
+	}
+
+	if len(pending) > 0 {
+		numbers := make([]string, 0, len(pending))
+		for _, index := range pending {
+			numbers = append(numbers, strconv.Itoa(index+1))
+		}
+		return nil, usage, fmt.Errorf("输出缺少第 %s 条", strings.Join(numbers, "、"))
+	}
+	return results, usage, nil
+}
+
+// 将待处理的条目按条数和token数分批，编号从 1 开始与下标对应
+func numberedChunks(texts []string, indexes []int, opts BatchOptions) []Chunk {
+	maxItems := opts.Items
+	if maxItems <= 0 {
+		maxItems = defaultBatchItems
+	}
+
+	var chunks []Chunk
+	var sb strings.Builder
+	count, tokens := 0, 0
+	for _, index := range indexes {
+		line := fmt.Sprintf("[%d] %s", index+1, strings.Join(strings.Fields(texts[index]), " "))
+		cost := EstimateTokens(line)
+		if count > 0 && (count >= maxItems || (opts.Tokens > 0 && tokens+cost > opts.Tokens)) {
+			chunks = append(chunks, Chunk{Text: sb.String(), Trail: "\n"})
+			sb.Reset()
+			count, tokens = 0, 0
+		}
+		if count > 0 {
+			sb.WriteString("\n")
+		}
+		sb.WriteString(line)
+		count++
+		tokens += cost
+	}
+	if count > 0 {
+		chunks = append(chunks, Chunk{Text: sb.String(), Trail: "\n"})
+	}
+	return chunks
+}
+
+// 解析模型输出，返回下标到结果的映射；不以编号开头的行并入上一条
+func parseNumbered(output string) map[int]string {
+	results := make(map[int]string)
+	current := -1
+	for _, line := range strings.Split(output, "\n") {
+		if m := numberedLinePattern.FindStringSubmatch(line); m != nil {
+			n, err := strconv.Atoi(m[1])
+			if err != nil {
+				current = -1
+				continue
+			}
+			current = n - 1
+			results[current] = strings.TrimSpace(m[2])
+			continue
+		}
+		if current >= 0 && strings.TrimSpace(line) != "" {
+			results[current] = strings.TrimSpace(results[current] + " " + strings.TrimSpace(line))
+		}
+	}
+	return results
+}
@@ -0,0 +1,200 @@
+package ai
+
+import (
+	"context"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+)
+
+func TestNumberedChunks(t *testing.T) {
+	texts := []string{"one", "", "two  words", "three\nlines here", "four"}
+
+	tests := []struct {
+		name    string
+		indexes []int
+		opts    BatchOptions
+		want    []string
+	}{
+		{
+			name:    "一批，换行和多余空白合并为空格",
+			indexes: []int{0, 2, 3, 4},
+			want:    []string{"[1] one\n[3] two words\n[4] three lines here\n[5] four"},
+		},
+		{
+			name:    "按条目数分批",
+			indexes: []int{0, 2, 3, 4},
+			opts:    BatchOptions{Items: 3},
+			want:    []string{"[1] one\n[3] two words\n[4] three lines here", "[5] four"},
+		},
+		{
+			name:    "按token数分批",
+			indexes: []int{0, 2, 4},
+			opts:    BatchOptions{Tokens: 6},
+			want:    []string{"[1] one\n[3] two words", "[5] four"},
+		},
+		{
+			name:    "单条超过token上限时单独成批",
+			indexes: []int{3, 4},
+			opts:    BatchOptions{Tokens: 1},
+			want:    []string{"[4] three lines here", "[5] four"},
+		},
+		{
+			name:    "只处理指定的条目",
+			indexes: []int{4},
+			want:    []string{"[5] four"},
+		},
+		{
+			name: "没有条目",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got []string
+			for _, chunk := range numberedChunks(texts, tt.indexes, tt.opts) {
+				got = append(got, chunk.Text)
+				if chunk.Trail != "\n" {
+					t.Errorf("Trail = %q, want newline", chunk.Trail)
+				}
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("numberedChunks() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseNumbered(t *testing.T) {
+	tests := []struct {
+		name   string
+		output string
+		want   map[int]string
+	}{
+		{
+			name:   "编号行",
+			output: "[1] 一\n[2]二\n  [10]  十  ",
+			want:   map[int]string{0: "一", 1: "二", 9: "十"},
+		},
+		{
+			name:   "续行并入上一条",
+			output: "[1] 第一行\n第二行\n\n[2] 二",
+			want:   map[int]string{0: "第一行 第二行", 1: "二"},
+		},
+		{
+			name:   "编号前的内容忽略",
+			output: "好的，以下是译文：\n[1] 一",
+			want:   map[int]string{0: "一"},
+		},
+		{
+			name:   "空输出",
+			output: "",
+			want:   map[int]string{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := parseNumbered(tt.output); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("parseNumbered() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+// 返回逐行加上“译:”前缀的客户端，drop 返回是否省略某条（编号从 1 开始）及当前是第几次出现
+func numberedClient(drop func(n string, seen int) bool) *fakeClient {
+	var mu sync.Mutex
+	seen := make(map[string]int)
+	return &fakeClient{complete: func(system, text string) (Result, error) {
+		var lines []string
+		for _, line := range strings.Split(text, "\n") {
+			m := numberedLinePattern.FindStringSubmatch(line)
+			mu.Lock()
+			seen[m[1]]++
+			skip := drop != nil && drop(m[1], seen[m[1]])
+			mu.Unlock()
+			if !skip {
+				lines = append(lines, "["+m[1]+"] 译:"+m[2])
+			}
+		}
+		return Result{Text: strings.Join(lines, "\n"), Usage: Usage{InputTokens: 10, OutputTokens: 5}}, nil
+	}}
+}
+
+func TestCompleteNumbered(t *testing.T) {
+	texts := []string{"a", " ", "b", "c"}
+	prompt := func(string) (string, error) { return "", nil }
+
+	tests := []struct {
+		name      string
+		drop      func(n string, seen int) bool
+		opts      BatchOptions
+		want      []string
+		wantUsage Usage
+		wantCalls int
+		wantErr   string
+	}{
+		{
+			name:      "一批完成，空文本不发送",
+			want:      []string{"译:a", "", "译:b", "译:c"},
+			wantUsage: Usage{InputTokens: 10, OutputTokens: 5},
+			wantCalls: 1,
+		},
+		{
+			name:      "分批",
+			opts:      BatchOptions{Items: 2, Concurrency: 2},
+			want:      []string{"译:a", "", "译:b", "译:c"},
+			wantUsage: Usage{InputTokens: 20, OutputTokens: 10},
+			wantCalls: 2,
+		},
+		{
+			name:      "漏译的条目重试一次",
+			drop:      func(n string, seen int) bool { return n == "3" && seen == 1 },
+			want:      []string{"译:a", "", "译:b", "译:c"},
+			wantUsage: Usage{InputTokens: 20, OutputTokens: 10},
+			wantCalls: 2,
+		},
+		{
+			name:      "重试后仍缺少时返回错误",
+			drop:      func(n string, seen int) bool { return n == "3" || n == "4" },
+			wantUsage: Usage{InputTokens: 20, OutputTokens: 10},
+			wantCalls: 2,
+			wantErr:   "输出缺少第 3、4 条",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client := numberedClient(tt.drop)
+			got, usage, err := CompleteNumbered(context.Background(), client, texts, tt.opts, prompt, nil)
+			if tt.wantErr != "" {
+				if err == nil || err.Error() != tt.wantErr {
+					t.Errorf("CompleteNumbered() error = %v, want %q", err, tt.wantErr)
+				}
+			} else if err != nil {
+				t.Fatalf("CompleteNumbered() error = %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("CompleteNumbered() = %q, want %q", got, tt.want)
+			}
+			if usage != tt.wantUsage {
+				t.Errorf("usage = %+v, want %+v", usage, tt.wantUsage)
+			}
+			if len(client.calls) != tt.wantCalls {
+				t.Errorf("calls = %q, want %d calls", client.calls, tt.wantCalls)
+			}
+		})
+	}
+
+	t.Run("重试只发送缺少的条目", func(t *testing.T) {
+		client := numberedClient(func(n string, seen int) bool { return n == "3" && seen == 1 })
+		if _, _, err := CompleteNumbered(context.Background(), client, texts, BatchOptions{}, prompt, nil); err != nil {
+			t.Fatal(err)
+		}
+		if want := []string{"[1] a\n[3] b\n[4] c", "[3] b"}; !reflect.DeepEqual(client.calls, want) {
+			t.Errorf("calls = %q, want %q", client.calls, want)
+		}
+	})
+}
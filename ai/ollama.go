@@ -7,27 +7,42 @@ import (
 	"fmt"
 	"io"
 	"net/http"
-	"time"
 )
 
 // OllamaClient Ollama客户端
 type OllamaClient struct {
 	model   string
 	baseURL string
+	prompt  string
+	params  GenerationParams
 	client  *http.Client
 }
 
 // OllamaRequest Ollama API请求结构
 type OllamaRequest struct {
-	Model  string `json:"model"`
-	Prompt string `json:"prompt"`
-	Stream bool   `json:"stream"`
+	Model   string         `json:"model"`
+	Prompt  string         `json:"prompt"`
+	Stream  bool           `json:"stream"`
+	Options *OllamaOptions `json:"options,omitempty"`
+}
+
+// OllamaOptions Ollama 模型参数
+type OllamaOptions struct {
+	Temperature *float64 `json:"temperature,omitempty"`
+	TopP        *float64 `json:"top_p,omitempty"`
+	NumPredict  int      `json:"num_predict,omitempty"` // 最大输出token数
+	Seed        *int64   `json:"seed,omitempty"`
+	Stop        []string `json:"stop,omitempty"`
 }
 
 // OllamaResponse Ollama API响应结构
 type OllamaResponse struct {
-	Response string `json:"response"`
-	Done     bool   `json:"done"`
+	Response   string `json:"response"`
+	Done       bool   `json:"done"`
+	DoneReason string `json:"done_reason"`       // length 表示达到最大输出长度
+	PromptEval int    `json:"prompt_eval_count"` // 输入token数
+	Eval       int    `json:"eval_count"`        // 输出token数
+	Error      string `json:"error,omitempty"`
 }
 
 // NewOllamaClient 创建Ollama客户端
@@ -44,51 +59,76 @@ func NewOllamaClient(config AIConfig) (*OllamaClient, error) {
 	return &OllamaClient{
 		model:   config.Model,
 		baseURL: baseURL,
+		prompt:  config.translatePrompt(),
+		params:  config.Params,
 		client: &http.Client{
-			Timeout: 60 * time.Second,
+			Timeout: config.timeout(defaultLocalTimeout),
 		},
 	}, nil
 }
 
 // Translate 实现翻译功能
-func (o *OllamaClient) Translate(ctx context.Context, text string) (string, error) {
-	prompt := fmt.Sprintf("%s\n\n%s", getSystemPrompt(), text)
+func (o *OllamaClient) Translate(ctx context.Context, text string) (Result, error) {
+	return o.Complete(ctx, o.prompt, text)
+}
+
+// Complete 使用指定的系统提示词处理文本
+func (o *OllamaClient) Complete(ctx context.Context, systemPrompt, text string) (Result, error) {
+	prompt := fmt.Sprintf("%s\n\n%s", systemPrompt, text)
 
 	request := OllamaRequest{
 		Model:  o.model,
 		Prompt: prompt,
 		Stream: false,
+		Options: &OllamaOptions{
+			Temperature: o.params.Temperature,
+			TopP:        o.params.TopP,
+			NumPredict:  o.params.MaxTokens,
+			Seed:        o.params.Seed,
+			Stop:        o.params.Stop,
+		},
 	}
 
 	jsonData, err := json.Marshal(request)
 	if err != nil {
-		return "", fmt.Errorf("序列化请求失败: %w", err)
+		return Result{}, fmt.Errorf("序列化请求失败: %w", err)
 	}
 
 	req, err := http.NewRequestWithContext(ctx, "POST", o.baseURL+"/api/generate", bytes.NewBuffer(jsonData))
 	if err != nil {
-		return "", fmt.Errorf("创建请求失败: %w", err)
+		return Result{}, fmt.Errorf("创建请求失败: %w", err)
 	}
 
 	req.Header.Set("Content-Type", "application/json")
 
 	resp, err := o.client.Do(req)
 	if err != nil {
-		return "", fmt.Errorf("发送请求失败: %w", err)
+		return Result{}, fmt.Errorf("发送请求失败: %w", err)
 	}
 	defer resp.Body.Close()
 
 	body, err := io.ReadAll(resp.Body)
 	if err != nil {
-		return "", fmt.Errorf("读取响应失败: %w", err)
+		return Result{}, fmt.Errorf("读取响应失败: %w", err)
 	}
 
 	var ollamaResp OllamaResponse
 	if err := json.Unmarshal(body, &ollamaResp); err != nil {
-		return "", fmt.Errorf("解析响应失败: %w", err)
+		return Result{}, fmt.Errorf("解析响应失败: %w", err)
 	}
 
-	return ollamaResp.Response, nil
+	if ollamaResp.Error != "" {
+		return Result{}, fmt.Errorf("ollama api错误: %s", ollamaResp.Error)
+	}
+	result := Result{
+		Text:  ollamaResp.Response,
+		Usage: Usage{InputTokens: ollamaResp.PromptEval, OutputTokens: ollamaResp.Eval},
+	}
+	if ollamaResp.DoneReason == "length" {
+		result.Truncated = true
+		return result, truncatedError(o.params.MaxTokens)
+	}
+	return result, nil
 }
 
 // GetName 获取客户端名称
@@ -96,6 +136,11 @@ func (o *OllamaClient) GetName() string {
 	return "Ollama"
 }
 
+// GetModel 获取使用的模型
+func (o *OllamaClient) GetModel() string {
+	return o.model
+}
+
 // Close 关闭客户端
 func (o *OllamaClient) Close() error {
 	return nil
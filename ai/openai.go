@@ -7,7 +7,6 @@ import (
 	"fmt"
 	"io"
 	"net/http"
-	"time"
 )
 
 // OpenAIClient OpenAI客户端
@@ -15,13 +14,20 @@ type OpenAIClient struct {
 	apiKey  string
 	model   string
 	baseURL string
+	prompt  string
+	params  GenerationParams
 	client  *http.Client
 }
 
 // OpenAIRequest OpenAI API请求结构
 type OpenAIRequest struct {
-	Model    string    `json:"model"`
-	Messages []Message `json:"messages"`
+	Model       string    `json:"model"`
+	Messages    []Message `json:"messages"`
+	Temperature *float64  `json:"temperature,omitempty"`
+	TopP        *float64  `json:"top_p,omitempty"`
+	MaxTokens   int       `json:"max_tokens,omitempty"`
+	Seed        *int64    `json:"seed,omitempty"`
+	Stop        []string  `json:"stop,omitempty"`
 }
 
 // Message 消息结构
@@ -32,13 +38,21 @@ type Message struct {
 
 // OpenAIResponse OpenAI API响应结构
 type OpenAIResponse struct {
-	Choices []Choice  `json:"choices"`
-	Error   *APIError `json:"error,omitempty"`
+	Choices []Choice     `json:"choices"`
+	Usage   *OpenAIUsage `json:"usage,omitempty"`
+	Error   *APIError    `json:"error,omitempty"`
+}
+
+// OpenAIUsage token用量
+type OpenAIUsage struct {
+	PromptTokens     int `json:"prompt_tokens"`
+	CompletionTokens int `json:"completion_tokens"`
 }
 
 // Choice 选择结构
 type Choice struct {
-	Message Message `json:"message"`
+	Message      Message `json:"message"`
+	FinishReason string  `json:"finish_reason"` // length 表示达到最大输出长度
 }
 
 // APIError API错误结构
@@ -66,36 +80,48 @@ func NewOpenAIClient(config AIConfig) (*OpenAIClient, error) {
 		apiKey:  config.APIKey,
 		model:   config.Model,
 		baseURL: baseURL,
+		prompt:  config.translatePrompt(),
+		params:  config.Params,
 		client: &http.Client{
-			Timeout: 30 * time.Second,
+			Timeout: config.timeout(defaultTimeout),
 		},
 	}, nil
 }
 
 // Translate 实现翻译功能
-func (o *OpenAIClient) Translate(ctx context.Context, text string) (string, error) {
+func (o *OpenAIClient) Translate(ctx context.Context, text string) (Result, error) {
+	return o.Complete(ctx, o.prompt, text)
+}
+
+// Complete 使用指定的系统提示词处理文本
+func (o *OpenAIClient) Complete(ctx context.Context, systemPrompt, text string) (Result, error) {
 	request := OpenAIRequest{
 		Model: o.model,
 		Messages: []Message{
 			{
 				Role:    "system",
-				Content: getSystemPrompt(),
+				Content: systemPrompt,
 			},
 			{
 				Role:    "user",
 				Content: text,
 			},
 		},
+		Temperature: o.params.Temperature,
+		TopP:        o.params.TopP,
+		MaxTokens:   o.params.MaxTokens,
+		Seed:        o.params.Seed,
+		Stop:        o.params.Stop,
 	}
 
 	jsonData, err := json.Marshal(request)
 	if err != nil {
-		return "", fmt.Errorf("序列化请求失败: %w", err)
+		return Result{}, fmt.Errorf("序列化请求失败: %w", err)
 	}
 
 	req, err := http.NewRequestWithContext(ctx, "POST", o.baseURL+"/chat/completions", bytes.NewBuffer(jsonData))
 	if err != nil {
-		return "", fmt.Errorf("创建请求失败: %w", err)
+		return Result{}, fmt.Errorf("创建请求失败: %w", err)
 	}
 
 	req.Header.Set("Content-Type", "application/json")
@@ -103,29 +129,40 @@ func (o *OpenAIClient) Translate(ctx context.Context, text string) (string, erro
 
 	resp, err := o.client.Do(req)
 	if err != nil {
-		return "", fmt.Errorf("发送请求失败: %w", err)
+		return Result{}, fmt.Errorf("发送请求失败: %w", err)
 	}
 	defer resp.Body.Close()
 
 	body, err := io.ReadAll(resp.Body)
 	if err != nil {
-		return "", fmt.Errorf("读取响应失败: %w", err)
+		return Result{}, fmt.Errorf("读取响应失败: %w", err)
 	}
 
 	var openAIResp OpenAIResponse
 	if err := json.Unmarshal(body, &openAIResp); err != nil {
-		return "", fmt.Errorf("解析响应失败: %w", err)
+		return Result{}, fmt.Errorf("解析响应失败: %w", err)
 	}
 
 	if openAIResp.Error != nil {
-		return "", fmt.Errorf("OpenAI API错误: %s", openAIResp.Error.Message)
+		return Result{}, fmt.Errorf("OpenAI API错误: %s", openAIResp.Error.Message)
+	}
+
+	var result Result
+	if openAIResp.Usage != nil {
+		result.Usage = Usage{InputTokens: openAIResp.Usage.PromptTokens, OutputTokens: openAIResp.Usage.CompletionTokens}
 	}
 
 	if len(openAIResp.Choices) == 0 {
-		return "", fmt.Errorf("没有返回翻译结果")
+		return result, fmt.Errorf("没有返回翻译结果")
 	}
 
-	return openAIResp.Choices[0].Message.Content, nil
+	choice := openAIResp.Choices[0]
+	result.Text = choice.Message.Content
+	if choice.FinishReason == "length" {
+		result.Truncated = true
+		return result, truncatedError(o.params.MaxTokens)
+	}
+	return result, nil
 }
 
 // GetName 获取客户端名称
@@ -133,6 +170,11 @@ func (o *OpenAIClient) GetName() string {
 	return "OpenAI"
 }
 
+// GetModel 获取使用的模型
+func (o *OpenAIClient) GetModel() string {
+	return o.model
+}
+
 // Close 关闭客户端
 func (o *OpenAIClient) Close() error {
 	// HTTP客户端不需要显式关闭
@@ -0,0 +1,157 @@
+package ai
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"sort"
+	"strings"
+	"text/template"
+)
+
+// PromptData 提示词模板可使用的变量
+type PromptData struct {
+	SourceLanguage string            `json:"source_language"` // 原文语言，自动检测时可能为空
+	TargetLanguage string            `json:"target_language"` // 目标语言
+	Tone           string            `json:"tone"`            // 语气，如 "正式"、"口语"
+	Domain         string            `json:"domain"`          // 领域，如 "法律"、"医学"
+	Glossary       map[string]string `json:"glossary"`        // 术语表：原文 -> 译文
+	Context        string            `json:"context"`         // 上下文，仅供参考不需要处理
+}
+
+// PromptTemplate 已解析的提示词模板
+type PromptTemplate struct {
+	Name    string `json:"name"`
+	Source  string `json:"source"`
+	Version string `json:"version"` // 模板内容的摘要，内容修改后随之变化
+	Builtin bool   `json:"builtin"` // 是否为内置模板
+
+	tmpl *template.Template
+}
+
+// ParsePromptTemplate 解析 text/template 格式的提示词模板
+func ParsePromptTemplate(name, source string) (*PromptTemplate, error) {
+	tmpl, err := template.New(name).Option("missingkey=zero").Parse(source)
+	if err != nil {
+		return nil, fmt.Errorf("解析提示词模板 %s 失败: %w", name, err)
+	}
+
+	sum := sha256.Sum256([]byte(source))
+	return &PromptTemplate{
+		Name:    name,
+		Source:  source,
+		Version: hex.EncodeToString(sum[:4]),
+		tmpl:    tmpl,
+	}, nil
+}
+
+// Render 使用变量渲染模板
+func (t *PromptTemplate) Render(data PromptData) (string, error) {
+	var sb strings.Builder
+	if err := t.tmpl.Execute(&sb, data); err != nil {
+		return "", fmt.Errorf("渲染提示词模板 %s 失败: %w", t.Name, err)
+	}
+	return strings.TrimSpace(sb.String()), nil
+}
+
+// Stamp 返回记录在历史中的模板版本标记，如 "translate@1a2b3c4d"
+func (t *PromptTemplate) Stamp() string {
+	return t.Name + "@" + t.Version
+}
+
+// 内置模板共用的可选部分：语气、领域、术语表和上下文
+const promptExtras = `
+{{- if or .Tone .Domain .Glossary}}
+
+附加要求：
+{{- if .Tone}}
+* 语气：{{.Tone}}。
+{{- end}}
+{{- if .Domain}}
+* 领域：{{.Domain}}，使用该领域的通用术语。
+{{- end}}
+{{- if .Glossary}}
+* 必须遵循以下术语表：
+{{- range $source, $target := .Glossary}}
+  * {{$source}} → {{$target}}
+{{- end}}
+{{- end}}
+{{- end}}
+{{- if .Context}}
+
+上下文（仅供理解，不要输出）：
+{{.Context}}
+{{- end}}`
+
+// 内置模板，名称与任务类型一致
+var builtinTemplates = map[Task]string{
+	TaskTranslate: getSystemPrompt() + promptExtras,
+	TaskTranslateTo: `你是一位专业的翻译专家。
+* 将用户输入的文本准确、流畅地翻译为 {{.TargetLanguage}}。
+* 保留原文的含义、语气和格式。
+* 只输出翻译后的文本，不包含任何额外的解释或说明。` + promptExtras,
+	TaskExplain: `你是一位知识渊博的讲解者。
+* 用简体中文解释用户输入的内容：词语给出含义和用法示例，句子说明其意思和语法要点，代码说明其作用。
+* 解释应简洁清晰，不超过300字。` + promptExtras,
+	TaskPolish: `你是一位专业的文字编辑。
+* 修正用户输入文本中的拼写、语法和标点错误，并使表达更加自然流畅。
+* 保持原文的语言、含义和语气不变。
+* 只输出修改后的文本，不包含任何额外的解释或说明。` + promptExtras,
+	TaskSummarize: `你是一位擅长归纳的编辑。
+* 用与原文相同的语言概括用户输入文本的要点。
+* 摘要应简洁，长文本使用条目列出关键信息。
+* 只输出摘要内容，不包含任何额外的说明。` + promptExtras,
+}
+
+// PromptTemplates 提示词模板集合，包含内置模板和用户模板
+type PromptTemplates struct {
+	templates map[string]*PromptTemplate
+}
+
+// NewPromptTemplates 创建模板集合，用户模板与内置模板同名时覆盖内置模板
+func NewPromptTemplates(custom map[string]string) (*PromptTemplates, error) {
+	set := &PromptTemplates{templates: make(map[string]*PromptTemplate)}
+
+	for task, source := range builtinTemplates {
+		tmpl, err := ParsePromptTemplate(string(task), source)
+		if err != nil {
+			// 内置模板在开发时即应保证正确
+			panic(err)
+		}
+		tmpl.Builtin = true
+		set.templates[tmpl.Name] = tmpl
+	}
+
+	for name, source := range custom {
+		tmpl, err := ParsePromptTemplate(name, source)
+		if err != nil {
+			return nil, err
+		}
+		set.templates[name] = tmpl
+	}
+	return set, nil
+}
+
+// Get 按名称获取模板
+func (s *PromptTemplates) Get(name string) (*PromptTemplate, bool) {
+	tmpl, ok := s.templates[name]
+	return tmpl, ok
+}
+
+// List 返回按名称排序的所有模板
+func (s *PromptTemplates) List() []*PromptTemplate {
+	list := make([]*PromptTemplate, 0, len(s.templates))
+	for _, tmpl := range s.templates {
+		list = append(list, tmpl)
+	}
+	sort.Slice(list, func(i, j int) bool {
+		return list[i].Name < list[j].Name
+	})
+	return list
+}
+
+// IsBuiltinTemplate 判断是否为内置模板名称
+func IsBuiltinTemplate(name string) bool {
+	_, ok := builtinTemplates[Task(name)]
+	return ok
+}
@@ -0,0 +1,140 @@
+package ai
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestPromptTemplateRender(t *testing.T) {
+	tests := []struct {
+		name    string
+		source  string
+		data    PromptData
+		want    string
+		wantErr bool
+	}{
+		{
+			name:   "变量",
+			source: "  翻译为 {{.TargetLanguage}}{{if .Tone}}，语气{{.Tone}}{{end}}\n",
+			data:   PromptData{TargetLanguage: "英语", Tone: "正式"},
+			want:   "翻译为 英语，语气正式",
+		},
+		{
+			name:   "未设置的变量为空",
+			source: "[{{.SourceLanguage}}]",
+			want:   "[]",
+		},
+		{
+			name:   "术语表",
+			source: "{{range $k, $v := .Glossary}}{{$k}}={{$v}};{{end}}",
+			data:   PromptData{Glossary: map[string]string{"b": "乙", "a": "甲"}},
+			want:   "a=甲;b=乙;",
+		},
+		{
+			name:    "不存在的字段",
+			source:  "{{.Target}}",
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tmpl, err := ParsePromptTemplate("test", tt.source)
+			if err != nil {
+				t.Fatalf("ParsePromptTemplate() error = %v", err)
+			}
+			got, err := tmpl.Render(tt.data)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Render() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("Render() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParsePromptTemplate(t *testing.T) {
+	if _, err := ParsePromptTemplate("bad", "{{.TargetLanguage"); err == nil {
+		t.Error("ParsePromptTemplate() error = nil, want syntax error")
+	}
+
+	a, _ := ParsePromptTemplate("custom", "A")
+	b, _ := ParsePromptTemplate("custom", "B")
+	if a.Version == b.Version {
+		t.Errorf("Version = %q for different sources", a.Version)
+	}
+	if want := "custom@" + a.Version; a.Stamp() != want {
+		t.Errorf("Stamp() = %q, want %q", a.Stamp(), want)
+	}
+}
+
+func TestBuiltinTemplates(t *testing.T) {
+	templates, err := NewPromptTemplates(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	data := PromptData{
+		TargetLanguage: "日语",
+		Tone:           "口语",
+		Domain:         "医学",
+		Glossary:       map[string]string{"CPU": "处理器"},
+		Context:        "上一段",
+	}
+	for task := range builtinTemplates {
+		tmpl, ok := templates.Get(string(task))
+		if !ok || !tmpl.Builtin {
+			t.Fatalf("builtin template %q missing", task)
+		}
+		prompt, err := tmpl.Render(data)
+		if err != nil {
+			t.Fatalf("%s: Render() error = %v", task, err)
+		}
+		for _, want := range []string{"语气：口语", "领域：医学", "CPU → 处理器", "上一段"} {
+			if !strings.Contains(prompt, want) {
+				t.Errorf("%s: prompt missing %q", task, want)
+			}
+		}
+
+		// 没有附加变量时不输出附加要求
+		if prompt, _ := tmpl.Render(PromptData{}); strings.Contains(prompt, "附加要求") || strings.Contains(prompt, "上下文") {
+			t.Errorf("%s: empty data rendered extras: %q", task, prompt)
+		}
+	}
+}
+
+func TestNewPromptTemplates(t *testing.T) {
+	templates, err := NewPromptTemplates(map[string]string{
+		string(TaskTranslate): "自定义 {{.TargetLanguage}}",
+		"formal":              "正式",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	translate, _ := templates.Get(string(TaskTranslate))
+	if translate.Builtin || translate.Source != "自定义 {{.TargetLanguage}}" {
+		t.Errorf("custom template did not override builtin: %+v", translate)
+	}
+	if _, ok := templates.Get("formal"); !ok {
+		t.Error("custom template formal missing")
+	}
+	if IsBuiltinTemplate("formal") || !IsBuiltinTemplate(string(TaskTranslate)) {
+		t.Error("IsBuiltinTemplate() should only report builtin names")
+	}
+
+	list := templates.List()
+	if len(list) != len(builtinTemplates)+1 {
+		t.Errorf("List() returned %d templates, want %d", len(list), len(builtinTemplates)+1)
+	}
+	for i := 1; i < len(list); i++ {
+		if list[i-1].Name >= list[i].Name {
+			t.Errorf("List() not sorted: %q before %q", list[i-1].Name, list[i].Name)
+		}
+	}
+
+	if _, err := NewPromptTemplates(map[string]string{"bad": "{{if}}"}); err == nil {
+		t.Error("NewPromptTemplates() error = nil, want syntax error")
+	}
+}
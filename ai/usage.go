@@ -0,0 +1,26 @@
+package ai
+
+// Usage token用量，提供商没有返回用量时为零
+type Usage struct {
+	InputTokens  int `json:"input_tokens"`
+	OutputTokens int `json:"output_tokens"`
+}
+
+// Total 返回输入与输出token数之和
+func (u Usage) Total() int {
+	return u.InputTokens + u.OutputTokens
+}
+
+// Add 返回两次用量之和
+func (u Usage) Add(other Usage) Usage {
+	return Usage{InputTokens: u.InputTokens + other.InputTokens, OutputTokens: u.OutputTokens + other.OutputTokens}
+}
+
+// Result 一次模型调用（或分段、分批处理）的结果
+//
+// 调用失败时 Text 可能为空，Usage 仍包含已消耗的用量。
+type Result struct {
+	Text      string
+	Usage     Usage
+	Truncated bool // 输出因长度限制被截断，此时同时返回 ErrTruncated
+}
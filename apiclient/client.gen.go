@@ -0,0 +1,785 @@
+// Code generated by clipboard-translate/openapi/gen. DO NOT EDIT.
+
+package apiclient
+
+import (
+	"context"
+	"io"
+	"net/url"
+	"strconv"
+	"time"
+)
+
+// APIConfig API相关配置
+type APIConfig struct {
+	ActiveProfile string `json:"active_profile,omitempty"` // 当前激活的AI配置名称
+}
+
+// ActiveProfileRequest 切换AI配置的参数
+type ActiveProfileRequest struct {
+	Name string `json:"name"` // 配置名称
+}
+
+// BudgetConfig 消费预算
+type BudgetConfig struct {
+	FallbackProfile string        `json:"fallback_profile,omitempty"` // 预算用尽时改用的AI配置，必须使用 Ollama
+	Limits          []BudgetLimit `json:"limits,omitempty"`
+}
+
+// BudgetLimit 单项预算，provider 和 profile 都为空时限制所有模型调用
+type BudgetLimit struct {
+	Provider string  `json:"provider,omitempty"` // 限制的AI提供商
+	Profile  string  `json:"profile,omitempty"`  // 限制的AI配置，按其提供商和模型统计用量
+	Period   string  `json:"period"`
+	Tokens   int     `json:"tokens,omitempty"`  // 输入和输出token合计的上限，0 表示不限制
+	Cost     float64 `json:"cost,omitempty"`    // 费用上限，货币单位为 pricing.currency，0 表示不限制
+	WarnAt   float64 `json:"warn_at,omitempty"` // 用量达到上限的该比例时提醒，默认 0.8
+	Action   string  `json:"action,omitempty"`  // 用尽时的处理方式，默认 refuse
+}
+
+// Config 应用配置
+type Config struct {
+	Version     int                      `json:"version,omitempty"` // 配置文件结构版本，用于迁移
+	Hotkeys     map[string]HotkeyConfig  `json:"hotkeys,omitempty"` // 各动作的热键
+	API         APIConfig                `json:"api"`
+	Profiles    map[string]ProfileConfig `json:"profiles,omitempty"`  // 命名的AI服务配置
+	Templates   map[string]string        `json:"templates,omitempty"` // 用户提示词模板，与内置模板同名时覆盖
+	Translation TranslationConfig        `json:"translation"`
+	UI          UIConfig                 `json:"ui"`
+	System      SystemConfig             `json:"system"`
+	Database    DatabaseConfig           `json:"database"`
+	Pricing     PricingConfig            `json:"pricing"`
+	Budget      BudgetConfig             `json:"budget"`
+}
+
+// ConfigChangedEvent 配置变更事件的数据
+type ConfigChangedEvent struct {
+	Sections []string `json:"sections"` // 发生变化的配置部分
+}
+
+// DatabaseConfig 数据库配置
+type DatabaseConfig struct {
+	Type       string `json:"type,omitempty"`
+	Connection string `json:"connection,omitempty"`
+}
+
+// ErrorResponse 错误响应
+type ErrorResponse struct {
+	Error        string            `json:"error"`                   // 错误说明
+	Errors       []FieldError      `json:"errors,omitempty"`        // 每个无效字段的错误
+	Details      map[string]string `json:"details,omitempty"`       // 应用失败的设置及原因
+	HotkeyErrors map[string]string `json:"hotkey_errors,omitempty"` // 注册失败的热键及原因
+	Status       string            `json:"status,omitempty"`        // 任务已结束时的任务状态
+}
+
+// FieldError 字段错误
+type FieldError struct {
+	Field   string `json:"field"`   // 字段路径，如 "api.provider"
+	Message string `json:"message"` // 错误说明
+}
+
+// HistoryItem 翻译历史记录
+type HistoryItem struct {
+	ID            string    `json:"id"`
+	Original      string    `json:"original"`
+	Translated    string    `json:"translated"`
+	Direction     string    `json:"direction"` // 翻译方向，如 "中 → 英"
+	Timestamp     time.Time `json:"timestamp"`
+	PromptVersion string    `json:"prompt_version"` // 使用的提示词模板版本，如 "translate@1a2b3c4d"
+	Provider      string    `json:"provider,omitempty"`
+	Model         string    `json:"model,omitempty"`
+	InputTokens   int       `json:"input_tokens,omitempty"`
+	OutputTokens  int       `json:"output_tokens,omitempty"`
+	Cost          float64   `json:"cost,omitempty"` // 按当时的价格表计算，模型不在价格表中时为零
+}
+
+// HotkeyConfig 热键配置
+type HotkeyConfig struct {
+	Modifiers []string `json:"modifiers,omitempty"`
+	Key       string   `json:"key,omitempty"`      // 按键名称，为空表示未启用
+	Profile   string   `json:"profile,omitempty"`  // 该动作使用的AI配置，为空时使用当前激活的配置
+	Template  string   `json:"template,omitempty"` // 该动作使用的提示词模板，为空时使用动作对应的内置模板
+}
+
+// Job 批量翻译任务及其进度
+type Job struct {
+	ID          string    `json:"id"`
+	Status      string    `json:"status"`
+	Source      string    `json:"source,omitempty"`
+	Target      string    `json:"target,omitempty"`
+	Profile     string    `json:"profile"`
+	Template    string    `json:"template,omitempty"`
+	SaveHistory bool      `json:"save_history"`
+	CreatedAt   time.Time `json:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at"`
+	Items       []JobItem `json:"items"`
+	Total       int       `json:"total"`
+	Done        int       `json:"done"`
+	Failed      int       `json:"failed"`
+}
+
+// JobItem 批量翻译任务中的一条文本
+type JobItem struct {
+	Index       int    `json:"index"`
+	Text        string `json:"text"`
+	Status      string `json:"status"`
+	Translation string `json:"translation,omitempty"`
+	Error       string `json:"error,omitempty"`
+}
+
+// JobRequest 创建批量翻译任务的参数，除 texts 外与 TextRequest 相同
+type JobRequest struct {
+	Texts       []string `json:"texts"`
+	Source      string   `json:"source,omitempty"`
+	Target      string   `json:"target,omitempty"`
+	Profile     string   `json:"profile,omitempty"`
+	Template    string   `json:"template,omitempty"`
+	SaveHistory bool     `json:"save_history,omitempty"`
+}
+
+// KeyInfo 热键按键
+type KeyInfo struct {
+	Name string `json:"name"`
+	Code int    `json:"code"` // 虚拟键码
+}
+
+// LocaleChange 一个条目的变更
+type LocaleChange struct {
+	Key            string `json:"key"`
+	Status         string `json:"status"`
+	Source         string `json:"source,omitempty"`
+	PreviousSource string `json:"previous_source,omitempty"` // 上次翻译时的原文
+	PreviousTarget string `json:"previous_target,omitempty"` // 原有译文
+	Target         string `json:"target,omitempty"`          // 新译文
+	Error          string `json:"error,omitempty"`           // 翻译失败的原因，失败时保留原有译文
+}
+
+// LocaleForm 本地化文件翻译的表单
+type LocaleForm struct {
+	Source         *File  `json:"source"`                    // 原文文件
+	Target         *File  `json:"target,omitempty"`          // 已有的译文文件
+	TargetLanguage string `json:"target_language,omitempty"` // 目标语言，默认为配置中的目标语言
+	Profile        string `json:"profile,omitempty"`         // 使用的AI配置
+	DryRun         bool   `json:"dry_run,omitempty"`         // 只生成变更报告，不调用AI
+}
+
+// LocaleReport 变更报告
+type LocaleReport struct {
+	Format    string         `json:"format"`
+	Language  string         `json:"language"`
+	Changes   []LocaleChange `json:"changes"`
+	Unchanged int            `json:"unchanged"` // 未修改的条目数
+}
+
+// LocaleResult 本地化文件的翻译结果
+type LocaleResult struct {
+	Filename string       `json:"filename"` // 译文文件名
+	Content  string       `json:"content"`  // 译文文件内容
+	Report   LocaleReport `json:"report"`
+}
+
+// LoginRequest 登录参数
+type LoginRequest struct {
+	Token string `json:"token"` // 访问令牌
+}
+
+// LoginResult 登录结果
+type LoginResult struct {
+	Scope string `json:"scope"` // 令牌的权限范围
+}
+
+// ModelPrice 每百万token的价格
+type ModelPrice struct {
+	Input  float64 `json:"input,omitempty"`
+	Output float64 `json:"output,omitempty"`
+}
+
+// ModelUsage 某个模型在一个周期内的用量
+type ModelUsage struct {
+	Provider     string  `json:"provider"`
+	Model        string  `json:"model"`
+	Calls        int     `json:"calls"`
+	InputTokens  int     `json:"input_tokens"`
+	OutputTokens int     `json:"output_tokens"`
+	Cost         float64 `json:"cost"`
+}
+
+// PeriodUsage 一个周期（一天或一个月）的用量，模型按费用从高到低排列
+type PeriodUsage struct {
+	Period       string       `json:"period"` // 2006-01-02 或 2006-01
+	Calls        int          `json:"calls"`
+	InputTokens  int          `json:"input_tokens"`
+	OutputTokens int          `json:"output_tokens"`
+	Cost         float64      `json:"cost"`
+	Models       []ModelUsage `json:"models"`
+}
+
+// PricingConfig 模型价格，用于统计翻译费用
+type PricingConfig struct {
+	Currency string                `json:"currency,omitempty"` // 价格的货币单位，仅用于显示
+	Models   map[string]ModelPrice `json:"models,omitempty"`   // 模型名称 -> 每百万token的价格，未列出的模型不计费用
+}
+
+// ProfileConfig AI服务配置
+type ProfileConfig struct {
+	Provider    string   `json:"provider,omitempty"`    // AI提供商: gemini, openai, claude, ollama
+	APIKeyRef   string   `json:"api_key_ref,omitempty"` // 密钥存储中的密钥名称，为空时使用提供商名称
+	Model       string   `json:"model,omitempty"`       // 使用的模型
+	BaseURL     string   `json:"base_url,omitempty"`    // 自定义API端点
+	UseEnvKey   bool     `json:"use_env_key,omitempty"` // 是否使用环境变量
+	Prompt      string   `json:"prompt,omitempty"`      // 自定义翻译提示词，为空时使用默认提示词
+	Temperature *float64 `json:"temperature,omitempty"` // 采样温度，为空时使用提供商默认值
+	TopP        *float64 `json:"top_p,omitempty"`       // 核采样概率，为空时使用提供商默认值
+	MaxTokens   int      `json:"max_tokens,omitempty"`  // 最大输出token数，0 使用提供商默认值
+	Timeout     int      `json:"timeout,omitempty"`     // 请求超时秒数，0 使用默认值
+	Seed        *int64   `json:"seed,omitempty"`        // 随机种子，仅 OpenAI 和 Ollama 支持
+	Stop        []string `json:"stop,omitempty"`        // 停止序列
+}
+
+// Profiles AI配置列表
+type Profiles struct {
+	Active   string                   `json:"active"` // 当前激活的配置名称
+	Profiles map[string]ProfileConfig `json:"profiles"`
+}
+
+// PromptData 提示词模板可以使用的变量
+type PromptData struct {
+	SourceLanguage string            `json:"source_language,omitempty"` // 原文语言，自动检测时可能为空
+	TargetLanguage string            `json:"target_language,omitempty"` // 目标语言
+	Tone           string            `json:"tone,omitempty"`            // 语气
+	Domain         string            `json:"domain,omitempty"`          // 领域
+	Glossary       map[string]string `json:"glossary,omitempty"`        // 术语表：原文 -> 译文
+	Context        string            `json:"context,omitempty"`         // 上下文，仅供参考不需要处理
+}
+
+// PromptTemplate 提示词模板
+type PromptTemplate struct {
+	Name    string `json:"name"`
+	Source  string `json:"source"`  // text/template 格式的模板源码
+	Version string `json:"version"` // 模板内容的摘要，内容修改后随之变化
+	Builtin bool   `json:"builtin"` // 是否为内置模板
+}
+
+// SaveResult 保存配置的结果，全部生效时为空
+type SaveResult struct {
+	HotkeyErrors map[string]string `json:"hotkey_errors,omitempty"` // 注册失败的热键及原因
+}
+
+// Secret 已保存的密钥
+type Secret struct {
+	Name   string `json:"name"`
+	Masked string `json:"masked"` // 遮盖后的值
+}
+
+// SecretValue 要保存的密钥
+type SecretValue struct {
+	Value string `json:"value"`
+}
+
+// SubtitleForm 字幕翻译的表单
+type SubtitleForm struct {
+	File           *File  `json:"file"`                      // SRT 或 WebVTT 字幕文件
+	TargetLanguage string `json:"target_language,omitempty"` // 目标语言，默认为配置中的目标语言
+	Profile        string `json:"profile,omitempty"`         // 使用的AI配置
+	MaxLineLength  int    `json:"max_line_length,omitempty"` // 每行的最大显示宽度，负数表示不折行
+}
+
+// SystemConfig 系统相关配置
+type SystemConfig struct {
+	AutoStart       bool   `json:"auto_start,omitempty"`
+	MaxHistoryItems int    `json:"max_history_items,omitempty"`
+	LogLevel        string `json:"log_level,omitempty"` // 日志级别: debug, info, warning, error
+}
+
+// TemplatePreview 提示词预览
+type TemplatePreview struct {
+	Name    string     `json:"name"`
+	Version string     `json:"version"` // 模板版本，格式为 名称@哈希
+	Prompt  string     `json:"prompt"`  // 渲染后的提示词
+	Data    PromptData `json:"data"`
+}
+
+// TemplatePreviewRequest 预览提示词的参数，提供 source 时渲染该源码，否则渲染名为 name 的模板
+type TemplatePreviewRequest struct {
+	Name   string      `json:"name,omitempty"`   // 模板名称
+	Source *string     `json:"source,omitempty"` // 模板源码
+	Data   *PromptData `json:"data,omitempty"`   // 模板变量，为空时取自翻译配置
+}
+
+// TextRequest 文本翻译参数，留空的字段取自配置
+type TextRequest struct {
+	Text        string `json:"text"`
+	Source      string `json:"source,omitempty"`       // 原文语言，为空时自动检测
+	Target      string `json:"target,omitempty"`       // 目标语言，为空时在中英文之间互译
+	Profile     string `json:"profile,omitempty"`      // 使用的AI配置，默认为当前激活的配置
+	Template    string `json:"template,omitempty"`     // 提示词模板名称，默认为翻译模板
+	SaveHistory bool   `json:"save_history,omitempty"` // 是否保存到历史记录
+}
+
+// TextResult 文本翻译结果
+type TextResult struct {
+	Translation      string `json:"translation"`
+	DetectedLanguage string `json:"detected_language"`
+	Source           string `json:"source"`
+	Target           string `json:"target"`
+	Profile          string `json:"profile"`
+	Provider         string `json:"provider"`
+	Model            string `json:"model"`
+	Template         string `json:"template"` // 模板版本，格式为 名称@哈希
+	LatencyMs        int64  `json:"latency_ms"`
+	Usage            Usage  `json:"usage"`
+}
+
+// TranslationConfig 翻译相关配置
+type TranslationConfig struct {
+	TargetLanguage     string            `json:"target_language,omitempty"`
+	AlternateLanguage  string            `json:"alternate_language,omitempty"` // 备用翻译语言，供 translateAlt 热键使用
+	AutoTranslate      bool              `json:"auto_translate,omitempty"`
+	ShowNotification   bool              `json:"show_notification,omitempty"`
+	Tone               string            `json:"tone,omitempty"`                 // 语气
+	Domain             string            `json:"domain,omitempty"`               // 领域
+	Glossary           map[string]string `json:"glossary,omitempty"`             // 术语表：原文 -> 译文
+	Format             string            `json:"format,omitempty"`               // 文本格式：auto 识别 Markdown，plain 按纯文本处理
+	ChunkTokens        int               `json:"chunk_tokens,omitempty"`         // 每段的估算token数上限，负数表示不分段
+	ChunkConcurrency   int               `json:"chunk_concurrency,omitempty"`    // 同时翻译的分段数
+	SubtitleLineLength int               `json:"subtitle_line_length,omitempty"` // 字幕每行的最大显示宽度，负数表示不折行
+	JobWorkers         int               `json:"job_workers,omitempty"`          // 批量任务同时翻译的文本数
+	RateLimits         map[string]int    `json:"rate_limits,omitempty"`          // 各提供商每分钟的请求数上限
+}
+
+// TranslationEvent 翻译事件的数据，同一次翻译的开始和结束事件使用相同的 ID
+type TranslationEvent struct {
+	ID         string `json:"id"`
+	Origin     string `json:"origin"` // 触发方式
+	Action     string `json:"action,omitempty"`
+	Direction  string `json:"direction,omitempty"`
+	Original   string `json:"original,omitempty"`
+	Translated string `json:"translated,omitempty"`
+	Error      string `json:"error,omitempty"`
+	HistoryID  string `json:"history_id,omitempty"` // 保存到历史记录时的记录 ID
+}
+
+// UIConfig UI相关配置
+type UIConfig struct {
+	Port         int      `json:"port,omitempty"`
+	Theme        string   `json:"theme,omitempty"`
+	BindAddress  string   `json:"bind_address,omitempty"`  // 监听地址，默认只接受本机连接；0.0.0.0 监听所有网卡
+	AllowedHosts []string `json:"allowed_hosts,omitempty"` // 除本机外允许的 Host 和 Origin 主机名
+}
+
+// Usage token用量，提供商没有返回用量时为零
+type Usage struct {
+	InputTokens  int     `json:"input_tokens"`
+	OutputTokens int     `json:"output_tokens"`
+	TotalTokens  int     `json:"total_tokens"`
+	Cost         float64 `json:"cost,omitempty"` // 按价格表计算，模型不在价格表中时为零
+}
+
+// UsageSummary 用量汇总，只列出有用量的周期
+type UsageSummary struct {
+	Currency string        `json:"currency"`
+	Period   string        `json:"period"`
+	From     string        `json:"from"`
+	To       string        `json:"to"`
+	Total    UsageTotals   `json:"total"`
+	Periods  []PeriodUsage `json:"periods"`
+}
+
+// UsageTotals 用量合计
+type UsageTotals struct {
+	Calls        int     `json:"calls"` // 模型调用次数
+	InputTokens  int     `json:"input_tokens"`
+	OutputTokens int     `json:"output_tokens"`
+	Cost         float64 `json:"cost"`
+}
+
+// ClearHistory 清空历史记录
+//
+// POST /api/clear
+func (c *Client) ClearHistory(ctx context.Context) error {
+	resp, err := c.do(ctx, "POST", "/api/clear", nil)
+	if err != nil {
+		return err
+	}
+	return discard(resp)
+}
+
+// GetConfig 获取当前配置
+//
+// GET /api/config
+func (c *Client) GetConfig(ctx context.Context) (*Config, error) {
+	resp, err := c.do(ctx, "GET", "/api/config", nil)
+	if err != nil {
+		return nil, err
+	}
+	var out Config
+	if err := decodeJSON(resp, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// SaveConfig 保存完整配置，各子系统随之应用变更
+//
+// POST /api/config
+func (c *Client) SaveConfig(ctx context.Context, body *Config) (*SaveResult, error) {
+	resp, err := c.do(ctx, "POST", "/api/config", jsonBody(body))
+	if err != nil {
+		return nil, err
+	}
+	var out SaveResult
+	if err := decodeJSON(resp, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// SubscribeEvents 以 Server-Sent Events 推送翻译、历史记录和配置变更事件
+//
+// GET /api/events
+func (c *Client) SubscribeEvents(ctx context.Context) (io.ReadCloser, error) {
+	resp, err := c.do(ctx, "GET", "/api/events", nil)
+	if err != nil {
+		return nil, err
+	}
+	return resp.Body, nil
+}
+
+// Health 健康检查
+//
+// GET /api/health
+func (c *Client) Health(ctx context.Context) (string, error) {
+	resp, err := c.do(ctx, "GET", "/api/health", nil)
+	if err != nil {
+		return "", err
+	}
+	data, err := readAll(resp)
+	return string(data), err
+}
+
+// ListHistory 获取翻译历史记录，最新的在前
+//
+// GET /api/history
+func (c *Client) ListHistory(ctx context.Context) ([]HistoryItem, error) {
+	resp, err := c.do(ctx, "GET", "/api/history", nil)
+	if err != nil {
+		return nil, err
+	}
+	var out []HistoryItem
+	if err := decodeJSON(resp, &out); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// ListHotkeyKeys 获取支持的热键按键列表
+//
+// GET /api/hotkeys/keys
+func (c *Client) ListHotkeyKeys(ctx context.Context) ([]KeyInfo, error) {
+	resp, err := c.do(ctx, "GET", "/api/hotkeys/keys", nil)
+	if err != nil {
+		return nil, err
+	}
+	var out []KeyInfo
+	if err := decodeJSON(resp, &out); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// TranslateLocaleFile 翻译本地化文件，只翻译缺失或原文有修改的条目
+//
+// POST /api/i18n/translate
+func (c *Client) TranslateLocaleFile(ctx context.Context, form *LocaleForm) (*LocaleResult, error) {
+	fields := make(map[string]string)
+	files := make(map[string]*File)
+	if form.Source != nil {
+		files["source"] = form.Source
+	}
+	if form.Target != nil {
+		files["target"] = form.Target
+	}
+	if form.TargetLanguage != "" {
+		fields["target_language"] = form.TargetLanguage
+	}
+	if form.Profile != "" {
+		fields["profile"] = form.Profile
+	}
+	if form.DryRun {
+		fields["dry_run"] = "true"
+	}
+	resp, err := c.do(ctx, "POST", "/api/i18n/translate", multipartBody(fields, files))
+	if err != nil {
+		return nil, err
+	}
+	var out LocaleResult
+	if err := decodeJSON(resp, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// CreateJob 创建批量翻译任务，任务在后台执行
+//
+// POST /api/jobs
+func (c *Client) CreateJob(ctx context.Context, body *JobRequest) (*Job, error) {
+	resp, err := c.do(ctx, "POST", "/api/jobs", jsonBody(body))
+	if err != nil {
+		return nil, err
+	}
+	var out Job
+	if err := decodeJSON(resp, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// GetJob 查询批量翻译任务的进度和结果
+//
+// GET /api/jobs/{id}
+func (c *Client) GetJob(ctx context.Context, id string) (*Job, error) {
+	resp, err := c.do(ctx, "GET", "/api/jobs/"+url.PathEscape(id), nil)
+	if err != nil {
+		return nil, err
+	}
+	var out Job
+	if err := decodeJSON(resp, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// CancelJob 取消批量翻译任务，已完成的条目保留
+//
+// DELETE /api/jobs/{id}
+func (c *Client) CancelJob(ctx context.Context, id string) (*Job, error) {
+	resp, err := c.do(ctx, "DELETE", "/api/jobs/"+url.PathEscape(id), nil)
+	if err != nil {
+		return nil, err
+	}
+	var out Job
+	if err := decodeJSON(resp, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// Login 校验访问令牌并写入登录 Cookie，供浏览器使用
+//
+// POST /api/login
+func (c *Client) Login(ctx context.Context, body *LoginRequest) (*LoginResult, error) {
+	resp, err := c.do(ctx, "POST", "/api/login", jsonBody(body))
+	if err != nil {
+		return nil, err
+	}
+	var out LoginResult
+	if err := decodeJSON(resp, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// GetOpenAPI 获取本文档
+//
+// GET /api/openapi.json
+func (c *Client) GetOpenAPI(ctx context.Context) (map[string]any, error) {
+	resp, err := c.do(ctx, "GET", "/api/openapi.json", nil)
+	if err != nil {
+		return nil, err
+	}
+	var out map[string]any
+	if err := decodeJSON(resp, &out); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// ListProfiles 列出AI配置及当前激活的配置
+//
+// GET /api/profiles
+func (c *Client) ListProfiles(ctx context.Context) (*Profiles, error) {
+	resp, err := c.do(ctx, "GET", "/api/profiles", nil)
+	if err != nil {
+		return nil, err
+	}
+	var out Profiles
+	if err := decodeJSON(resp, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// SetActiveProfile 切换激活的AI配置
+//
+// PUT /api/profiles/active
+func (c *Client) SetActiveProfile(ctx context.Context, body *ActiveProfileRequest) (*SaveResult, error) {
+	resp, err := c.do(ctx, "PUT", "/api/profiles/active", jsonBody(body))
+	if err != nil {
+		return nil, err
+	}
+	var out SaveResult
+	if err := decodeJSON(resp, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// RefreshClipboard 翻译当前剪贴板内容，结果通过事件推送
+//
+// POST /api/refresh
+func (c *Client) RefreshClipboard(ctx context.Context) error {
+	resp, err := c.do(ctx, "POST", "/api/refresh", nil)
+	if err != nil {
+		return err
+	}
+	return discard(resp)
+}
+
+// ListSecrets 列出已保存的密钥，只返回遮盖后的值，不包括内部保留的密钥
+//
+// GET /api/secrets
+func (c *Client) ListSecrets(ctx context.Context) ([]Secret, error) {
+	resp, err := c.do(ctx, "GET", "/api/secrets", nil)
+	if err != nil {
+		return nil, err
+	}
+	var out []Secret
+	if err := decodeJSON(resp, &out); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// SetSecret 保存密钥，使用该密钥的AI客户端随之重建
+//
+// PUT /api/secrets/{name}
+func (c *Client) SetSecret(ctx context.Context, name string, body *SecretValue) (*Secret, error) {
+	resp, err := c.do(ctx, "PUT", "/api/secrets/"+url.PathEscape(name), jsonBody(body))
+	if err != nil {
+		return nil, err
+	}
+	var out Secret
+	if err := decodeJSON(resp, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// DeleteSecret 删除密钥，使用该密钥的AI客户端随之丢弃
+//
+// DELETE /api/secrets/{name}
+func (c *Client) DeleteSecret(ctx context.Context, name string) error {
+	resp, err := c.do(ctx, "DELETE", "/api/secrets/"+url.PathEscape(name), nil)
+	if err != nil {
+		return err
+	}
+	return discard(resp)
+}
+
+// TranslateSubtitle 翻译 SRT 或 WebVTT 字幕文件，返回翻译后的文件
+//
+// POST /api/subtitles/translate
+func (c *Client) TranslateSubtitle(ctx context.Context, form *SubtitleForm) ([]byte, error) {
+	fields := make(map[string]string)
+	files := make(map[string]*File)
+	if form.File != nil {
+		files["file"] = form.File
+	}
+	if form.TargetLanguage != "" {
+		fields["target_language"] = form.TargetLanguage
+	}
+	if form.Profile != "" {
+		fields["profile"] = form.Profile
+	}
+	if form.MaxLineLength != 0 {
+		fields["max_line_length"] = strconv.Itoa(form.MaxLineLength)
+	}
+	resp, err := c.do(ctx, "POST", "/api/subtitles/translate", multipartBody(fields, files))
+	if err != nil {
+		return nil, err
+	}
+	return readAll(resp)
+}
+
+// ListTemplates 列出提示词模板，包括内置模板
+//
+// GET /api/templates
+func (c *Client) ListTemplates(ctx context.Context) ([]PromptTemplate, error) {
+	resp, err := c.do(ctx, "GET", "/api/templates", nil)
+	if err != nil {
+		return nil, err
+	}
+	var out []PromptTemplate
+	if err := decodeJSON(resp, &out); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// PreviewTemplate 渲染已保存的模板或请求中提供的模板源码
+//
+// POST /api/templates/preview
+func (c *Client) PreviewTemplate(ctx context.Context, body *TemplatePreviewRequest) (*TemplatePreview, error) {
+	resp, err := c.do(ctx, "POST", "/api/templates/preview", jsonBody(body))
+	if err != nil {
+		return nil, err
+	}
+	var out TemplatePreview
+	if err := decodeJSON(resp, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// TranslateText 同步翻译一段文本
+//
+// POST /api/translate
+func (c *Client) TranslateText(ctx context.Context, body *TextRequest) (*TextResult, error) {
+	resp, err := c.do(ctx, "POST", "/api/translate", jsonBody(body))
+	if err != nil {
+		return nil, err
+	}
+	var out TextResult
+	if err := decodeJSON(resp, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// GetUsageParams 查询参数，零值的参数不发送
+type GetUsageParams struct {
+	Period string // 统计周期，默认 day
+	From   string // 开始日期（包含）
+	To     string // 结束日期（包含），默认今天
+}
+
+// GetUsage 按天或按月汇总token用量和费用
+//
+// GET /api/usage
+func (c *Client) GetUsage(ctx context.Context, params *GetUsageParams) (*UsageSummary, error) {
+	query := url.Values{}
+	if params != nil {
+		if params.Period != "" {
+			query.Set("period", params.Period)
+		}
+		if params.From != "" {
+			query.Set("from", params.From)
+		}
+		if params.To != "" {
+			query.Set("to", params.To)
+		}
+	}
+	path := "/api/usage"
+	if len(query) > 0 {
+		path += "?" + query.Encode()
+	}
+	resp, err := c.do(ctx, "GET", path, nil)
+	if err != nil {
+		return nil, err
+	}
+	var out UsageSummary
+	if err := decodeJSON(resp, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
@@ -0,0 +1,175 @@
+// Package apiclient 剪贴板翻译服务HTTP API的Go客户端，供集成测试和其他工具使用
+//
+// 类型和方法由 openapi/openapi.json 生成（client.gen.go），修改接口后运行 go generate ./apiclient。
+//
+//	client := apiclient.New("http://localhost:8080", nil).WithToken(token)
+//	result, err := client.TranslateText(ctx, &apiclient.TextRequest{Text: "hello", Target: "zh-CN"})
+package apiclient
+
+//go:generate go run ../openapi/gen -o client.gen.go
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"strings"
+)
+
+// Client 调用服务API的客户端
+type Client struct {
+	baseURL string
+	http    *http.Client
+	token   string
+}
+
+// New 创建客户端，baseURL 如 http://localhost:8080；httpClient 为 nil 时使用 http.DefaultClient
+func New(baseURL string, httpClient *http.Client) *Client {
+	if httpClient == nil {
+		httpClient = http.DefaultClient
+	}
+	return &Client{baseURL: strings.TrimRight(baseURL, "/"), http: httpClient}
+}
+
+// WithToken 返回使用指定访问令牌的客户端，令牌可用 clipboard-translate token 查看
+func (c *Client) WithToken(token string) *Client {
+	clone := *c
+	clone.token = token
+	return &clone
+}
+
+// File 上传的文件
+type File struct {
+	Name string
+	Data []byte
+}
+
+// APIError 服务返回的错误响应
+type APIError struct {
+	StatusCode int
+	Message    string       `json:"error"`
+	Errors     []FieldError `json:"errors"` // 每个无效字段的错误
+}
+
+func (e *APIError) Error() string {
+	msg := e.Message
+	if msg == "" {
+		msg = http.StatusText(e.StatusCode)
+	}
+	if len(e.Errors) > 0 {
+		parts := make([]string, len(e.Errors))
+		for i, fe := range e.Errors {
+			parts[i] = fmt.Sprintf("%s: %s", fe.Field, fe.Message)
+		}
+		msg += ": " + strings.Join(parts, "; ")
+	}
+	return fmt.Sprintf("%d %s", e.StatusCode, msg)
+}
+
+// 生成请求体，返回内容和 Content-Type
+type requestBody func() (io.Reader, string, error)
+
+func jsonBody(v any) requestBody {
+	return func() (io.Reader, string, error) {
+		data, err := json.Marshal(v)
+		if err != nil {
+			return nil, "", err
+		}
+		return bytes.NewReader(data), "application/json", nil
+	}
+}
+
+func multipartBody(fields map[string]string, files map[string]*File) requestBody {
+	return func() (io.Reader, string, error) {
+		var buf bytes.Buffer
+		w := multipart.NewWriter(&buf)
+		for name, value := range fields {
+			if err := w.WriteField(name, value); err != nil {
+				return nil, "", err
+			}
+		}
+		for name, file := range files {
+			part, err := w.CreateFormFile(name, file.Name)
+			if err != nil {
+				return nil, "", err
+			}
+			if _, err := part.Write(file.Data); err != nil {
+				return nil, "", err
+			}
+		}
+		if err := w.Close(); err != nil {
+			return nil, "", err
+		}
+		return &buf, w.FormDataContentType(), nil
+	}
+}
+
+// 发送请求，非 2xx 响应转换为 *APIError
+func (c *Client) do(ctx context.Context, method, path string, body requestBody) (*http.Response, error) {
+	var (
+		reader      io.Reader
+		contentType string
+	)
+	if body != nil {
+		var err error
+		if reader, contentType, err = body(); err != nil {
+			return nil, fmt.Errorf("生成请求体失败: %w", err)
+		}
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, c.baseURL+path, reader)
+	if err != nil {
+		return nil, err
+	}
+	if contentType != "" {
+		req.Header.Set("Content-Type", contentType)
+	}
+	if c.token != "" {
+		req.Header.Set("Authorization", "Bearer "+c.token)
+	}
+
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		defer resp.Body.Close()
+		apiErr := &APIError{StatusCode: resp.StatusCode}
+		data, _ := io.ReadAll(resp.Body)
+		if json.Unmarshal(data, apiErr) != nil {
+			apiErr.Message = strings.TrimSpace(string(data))
+		}
+		return nil, apiErr
+	}
+	return resp, nil
+}
+
+// 解析 JSON 响应，空响应保持零值
+func decodeJSON(resp *http.Response, out any) error {
+	defer resp.Body.Close()
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+	if len(bytes.TrimSpace(data)) == 0 {
+		return nil
+	}
+	if err := json.Unmarshal(data, out); err != nil {
+		return fmt.Errorf("解析响应失败: %w", err)
+	}
+	return nil
+}
+
+func readAll(resp *http.Response) ([]byte, error) {
+	defer resp.Body.Close()
+	return io.ReadAll(resp.Body)
+}
+
+func discard(resp *http.Response) error {
+	defer resp.Body.Close()
+	_, err := io.Copy(io.Discard, resp.Body)
+	return err
+}
@@ -0,0 +1,78 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+
+	"clipboard-translate/openapi"
+	log "clipboard-translate/utils/log"
+)
+
+// 按 OpenAPI 文档校验请求，不符合文档的请求返回 400 并列出每个无效字段
+func validateRequest(spec *openapi.Document) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		err := spec.ValidateRequest(c.Request)
+		if err == nil {
+			c.Next()
+			return
+		}
+
+		var verr *openapi.ValidationError
+		if errors.As(err, &verr) {
+			c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": "请求校验失败", "errors": verr.Errors})
+			return
+		}
+		c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": "读取请求失败"})
+	}
+}
+
+// 比对已注册的 API 路由与 OpenAPI 文档，两边不一致时输出警告
+func checkAPIDocs(r *gin.Engine, spec *openapi.Document) {
+	for _, mismatch := range apiDocMismatches(r.Routes(), spec) {
+		log.Warn("%s", mismatch)
+	}
+}
+
+// 返回已注册的 API 路由与 OpenAPI 文档不一致之处：先列出文档中没有的路由，再列出没有路由的文档接口
+func apiDocMismatches(routes gin.RoutesInfo, spec *openapi.Document) []string {
+	var mismatches []string
+	registered := make(map[string]bool)
+	for _, route := range routes {
+		if !strings.HasPrefix(route.Path, "/api/") {
+			continue
+		}
+		// gin 的 :name 参数对应文档中的 {name}
+		parts := strings.Split(route.Path, "/")
+		for i, part := range parts {
+			if strings.HasPrefix(part, ":") {
+				parts[i] = "{" + part[1:] + "}"
+			}
+		}
+		path := strings.Join(parts, "/")
+		registered[route.Method+" "+path] = true
+
+		if _, _, ok := spec.Find(route.Method, path); !ok {
+			mismatches = append(mismatches, fmt.Sprintf("接口未在 OpenAPI 文档中描述: %s %s", route.Method, path))
+		}
+	}
+
+	for _, path := range spec.SortedPaths() {
+		ops := spec.Paths[path].Operations()
+		methods := make([]string, 0, len(ops))
+		for method := range ops {
+			methods = append(methods, method)
+		}
+		sort.Strings(methods)
+		for _, method := range methods {
+			if !registered[method+" "+path] {
+				mismatches = append(mismatches, fmt.Sprintf("OpenAPI 文档中的接口没有对应的路由: %s %s", method, path))
+			}
+		}
+	}
+	return mismatches
+}
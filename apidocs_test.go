@@ -0,0 +1,46 @@
+package main
+
+import (
+	"net/http"
+	"reflect"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+
+	"clipboard-translate/openapi"
+)
+
+func TestAPIDocsMatchRoutes(t *testing.T) {
+	r := setupAPI(t, nil)
+	spec, err := openapi.Load()
+	if err != nil {
+		t.Fatal(err)
+	}
+	// 新增或修改接口时必须同步修改 openapi.json
+	for _, mismatch := range apiDocMismatches(r.Routes(), spec) {
+		t.Error(mismatch)
+	}
+}
+
+func TestAPIDocMismatches(t *testing.T) {
+	spec, err := openapi.Parse([]byte(`{"paths": {
+		"/api/items": {"get": {}, "post": {}},
+		"/api/items/{id}": {"delete": {}}
+	}}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	routes := gin.RoutesInfo{
+		{Method: http.MethodGet, Path: "/api/items"},
+		{Method: http.MethodDelete, Path: "/api/items/:id"},
+		{Method: http.MethodPut, Path: "/api/items/:id"},
+		{Method: http.MethodGet, Path: "/metrics"},
+	}
+	want := []string{
+		"接口未在 OpenAPI 文档中描述: PUT /api/items/{id}",
+		"OpenAPI 文档中的接口没有对应的路由: POST /api/items",
+	}
+	if got := apiDocMismatches(routes, spec); !reflect.DeepEqual(got, want) {
+		t.Errorf("apiDocMismatches() = %q, want %q", got, want)
+	}
+}
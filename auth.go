@@ -0,0 +1,200 @@
+package main
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+
+	"clipboard-translate/config"
+	"clipboard-translate/openapi"
+	"clipboard-translate/secrets"
+)
+
+// 访问令牌在密钥存储中的名称，位于保留的命名空间，首次启动时生成
+const (
+	adminTokenName = secrets.ReservedPrefix + "api-token"      // 可以调用所有接口
+	readTokenName  = secrets.ReservedPrefix + "api-token-read" // 只能调用 GET 接口
+)
+
+// 浏览器登录后保存令牌的 Cookie
+const tokenCookie = "ct_token"
+
+// 令牌的权限范围
+const (
+	scopeRead  = "read"
+	scopeAdmin = "admin"
+)
+
+// 本机地址始终允许作为 Host 和 Origin
+var loopbackHosts = []string{"localhost", "127.0.0.1", "::1"}
+
+// 访问令牌
+type apiTokens struct {
+	Admin string
+	Read  string
+}
+
+// 启动时读取的访问令牌
+var tokens apiTokens
+
+// 读取访问令牌，不存在时生成并保存到密钥存储
+func loadAPITokens() (apiTokens, error) {
+	store := secrets.Default()
+	if store == nil {
+		return apiTokens{}, secrets.ErrNoStore
+	}
+
+	var t apiTokens
+	for name, value := range map[string]*string{adminTokenName: &t.Admin, readTokenName: &t.Read} {
+		token, err := store.Get(name)
+		if errors.Is(err, secrets.ErrNotFound) {
+			if token, err = newToken(); err == nil {
+				err = store.Set(name, token)
+			}
+		}
+		if err != nil {
+			return apiTokens{}, fmt.Errorf("读取访问令牌 %s 失败: %w", name, err)
+		}
+		*value = token
+	}
+	return t, nil
+}
+
+func newToken() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
+
+// 返回令牌的权限范围，无效的令牌返回空
+func (t apiTokens) scope(token string) string {
+	switch {
+	case token == "":
+		return ""
+	case subtle.ConstantTimeCompare([]byte(token), []byte(t.Admin)) == 1:
+		return scopeAdmin
+	case subtle.ConstantTimeCompare([]byte(token), []byte(t.Read)) == 1:
+		return scopeRead
+	}
+	return ""
+}
+
+// 从 Authorization 请求头或登录 Cookie 读取令牌
+func requestToken(c *gin.Context) string {
+	if auth := c.GetHeader("Authorization"); auth != "" {
+		if token, ok := strings.CutPrefix(auth, "Bearer "); ok {
+			return strings.TrimSpace(token)
+		}
+		return ""
+	}
+	token, _ := c.Cookie(tokenCookie)
+	return token
+}
+
+// 校验 /api/ 接口和 /metrics 的访问令牌，文档中标记为公开的接口（security 为空）不需要令牌；只读令牌只能调用 GET 接口
+func requireToken(spec *openapi.Document) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if !strings.HasPrefix(c.Request.URL.Path, "/api/") && c.Request.URL.Path != "/metrics" {
+			c.Next()
+			return
+		}
+		if op, _, ok := spec.Find(c.Request.Method, c.Request.URL.Path); ok && op.Public() {
+			c.Next()
+			return
+		}
+
+		switch tokens.scope(requestToken(c)) {
+		case scopeAdmin:
+			c.Next()
+		case scopeRead:
+			if c.Request.Method != http.MethodGet && c.Request.Method != http.MethodHead {
+				c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "只读令牌不能执行此操作"})
+				return
+			}
+			c.Next()
+		default:
+			c.Header("WWW-Authenticate", `Bearer realm="clipboard-translate"`)
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "缺少或无效的访问令牌"})
+		}
+	}
+}
+
+// 检查 Host 和 Origin 请求头，防止 DNS 重绑定和跨站请求
+//
+// 恶意网页即使把自己的域名解析到 127.0.0.1，浏览器发出的请求中 Host 仍是该域名，会被拒绝。
+func checkOrigin() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		allowed := allowedHosts(config.GetConfig().UI)
+
+		if !allowed[hostname(c.Request.Host)] {
+			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "不允许的 Host"})
+			return
+		}
+		if origin := c.GetHeader("Origin"); origin != "" {
+			u, err := url.Parse(origin)
+			if err != nil || !allowed[strings.ToLower(u.Hostname())] {
+				c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "不允许的来源"})
+				return
+			}
+		}
+		c.Next()
+	}
+}
+
+// 允许的主机名：本机地址、指定的监听地址和 ui.allowed_hosts
+func allowedHosts(ui config.UIConfig) map[string]bool {
+	allowed := make(map[string]bool)
+	for _, host := range loopbackHosts {
+		allowed[host] = true
+	}
+	if ip := net.ParseIP(ui.BindAddress); ip != nil && !ip.IsUnspecified() {
+		allowed[ip.String()] = true
+	}
+	for _, host := range ui.AllowedHosts {
+		allowed[strings.ToLower(strings.Trim(host, "[]"))] = true
+	}
+	return allowed
+}
+
+// 去掉 Host 中的端口和 IPv6 地址的方括号
+func hostname(host string) string {
+	if h, _, err := net.SplitHostPort(host); err == nil {
+		host = h
+	}
+	return strings.ToLower(strings.Trim(host, "[]"))
+}
+
+// 浏览器登录：校验令牌后写入 Cookie，之后同源的请求自动携带令牌
+func login(c *gin.Context) {
+	var req struct {
+		Token string `json:"token"`
+	}
+	if err := c.BindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "无效的请求数据"})
+		return
+	}
+	scope := tokens.scope(req.Token)
+	if scope == "" {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "无效的访问令牌"})
+		return
+	}
+
+	http.SetCookie(c.Writer, &http.Cookie{
+		Name:     tokenCookie,
+		Value:    req.Token,
+		Path:     "/",
+		HttpOnly: true,
+		SameSite: http.SameSiteStrictMode,
+	})
+	c.JSON(http.StatusOK, gin.H{"scope": scope})
+}
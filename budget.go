@@ -0,0 +1,231 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"clipboard-translate/ai"
+	"clipboard-translate/config"
+	"clipboard-translate/database"
+	"clipboard-translate/notify"
+	log "clipboard-translate/utils/log"
+)
+
+// 预算用尽且不回退到本地模型时返回的错误
+var errBudgetExceeded = errors.New("已超出消费预算")
+
+// 模型调用失败时的HTTP状态码：预算用尽返回 429，其他错误返回 502
+func modelErrorStatus(err error) int {
+	if errors.Is(err, errBudgetExceeded) {
+		return http.StatusTooManyRequests
+	}
+	return http.StatusBadGateway
+}
+
+// 一项预算在当前周期的用量
+type budgetStatus struct {
+	Limit  config.BudgetLimit
+	Period string // 当前周期：2006-01-02 或 2006-01
+	Tokens int
+	Cost   float64
+}
+
+// 用量占上限的比例，同时设置token和费用上限时取较大者
+func (s budgetStatus) ratio() float64 {
+	var r float64
+	if s.Limit.Tokens > 0 {
+		r = max(r, float64(s.Tokens)/float64(s.Limit.Tokens))
+	}
+	if s.Limit.Cost > 0 {
+		r = max(r, s.Cost/s.Limit.Cost)
+	}
+	return r
+}
+
+// 用量说明，如「OpenAI 本月预算已用 82%（token 8200/10000）」
+func (s budgetStatus) String() string {
+	period := "今日"
+	if s.Limit.Period == config.BudgetMonthly {
+		period = "本月"
+	}
+	var parts []string
+	if s.Limit.Tokens > 0 {
+		parts = append(parts, fmt.Sprintf("token %d/%d", s.Tokens, s.Limit.Tokens))
+	}
+	if s.Limit.Cost > 0 {
+		parts = append(parts, fmt.Sprintf("费用 %.2f/%.2f %s", s.Cost, s.Limit.Cost, config.GetConfig().Pricing.Currency))
+	}
+	return fmt.Sprintf("%s %s预算已用 %.0f%%（%s）", s.Limit.Scope(), period, s.ratio()*100, strings.Join(parts, "，"))
+}
+
+// 检查AI配置适用的预算：达到提醒比例时通知一次，用尽时改用回退配置或拒绝调用
+//
+// 返回实际使用的客户端及其AI配置名称。用量按已完成的模型调用统计，并发的请求可能略微超出上限。
+// 回退的本地模型不受预算限制。
+func enforceBudget(name string, client ai.AIClient) (ai.AIClient, string, error) {
+	cfg := config.GetConfig()
+	limits := budgetLimitsFor(cfg, name)
+	if len(limits) == 0 {
+		return client, name, nil
+	}
+
+	statuses, err := budgetStatuses(limits, client, time.Now())
+	if err != nil {
+		// 读取用量失败时不影响翻译
+		log.Error("检查消费预算失败: %v", err)
+		return client, name, nil
+	}
+
+	var exhausted *budgetStatus
+	for i := range statuses {
+		status := &statuses[i]
+		ratio := status.ratio()
+		switch {
+		case ratio >= 1:
+			alertBudget(status, true)
+			// 有任一预算要求拒绝时拒绝调用
+			if exhausted == nil || status.Limit.Action == config.BudgetRefuse {
+				exhausted = status
+			}
+		case ratio >= status.Limit.WarnAt:
+			alertBudget(status, false)
+		}
+	}
+	if exhausted == nil {
+		return client, name, nil
+	}
+
+	if exhausted.Limit.Action != config.BudgetFallback {
+		return nil, "", fmt.Errorf("%w: %s", errBudgetExceeded, exhausted)
+	}
+	fallback := cfg.Budget.FallbackProfile
+	if name == fallback {
+		return client, name, nil
+	}
+	fallbackClient, err := cachedAIClient(fallback)
+	if err != nil {
+		return nil, "", fmt.Errorf("%w，回退的AI配置不可用: %v", errBudgetExceeded, err)
+	}
+	log.Debug("%s，改用 %s (%s)", exhausted, fallback, fallbackClient.GetModel())
+	return fallbackClient, fallback, nil
+}
+
+// AI配置适用的预算：指定该配置、该配置的提供商，或不限范围的预算
+func budgetLimitsFor(cfg *config.Config, name string) []config.BudgetLimit {
+	provider := strings.ToLower(cfg.Profiles[name].Provider)
+	var limits []config.BudgetLimit
+	for _, limit := range cfg.Budget.Limits {
+		switch {
+		case limit.Profile != "":
+			if limit.Profile != name {
+				continue
+			}
+		case limit.Provider != "":
+			if limit.Provider != provider {
+				continue
+			}
+		}
+		limits = append(limits, limit)
+	}
+	return limits
+}
+
+// 从用量统计中累加各项预算在当前周期的用量
+//
+// AI配置的预算按客户端的提供商和模型统计，使用相同模型的其他配置也计入。
+func budgetStatuses(limits []config.BudgetLimit, client ai.AIClient, now time.Time) ([]budgetStatus, error) {
+	today := now.Format(usageDayLayout)
+	month := today[:len("2006-01")]
+	monthStart := month + "-01"
+
+	// 数据库未打开时没有用量，预算不生效
+	usages, err := usagesBetween(monthStart, today)
+	if err != nil {
+		return nil, err
+	}
+
+	statuses := make([]budgetStatus, len(limits))
+	for i, limit := range limits {
+		status := budgetStatus{Limit: limit, Period: month}
+		if limit.Period == config.BudgetDaily {
+			status.Period = today
+		}
+		for _, u := range usages {
+			if !strings.HasPrefix(u.Day, status.Period) || !budgetCovers(limit, client, u) {
+				continue
+			}
+			status.Tokens += u.InputTokens + u.OutputTokens
+			status.Cost += u.Cost
+		}
+		statuses[i] = status
+	}
+	return statuses, nil
+}
+
+// 判断一条用量统计是否计入预算
+func budgetCovers(limit config.BudgetLimit, client ai.AIClient, u *database.Usage) bool {
+	switch {
+	case limit.Profile != "":
+		return strings.EqualFold(u.Provider, client.GetName()) && usageModel(u.Model) == usageModel(client.GetModel())
+	case limit.Provider != "":
+		return strings.EqualFold(u.Provider, limit.Provider)
+	default:
+		return true
+	}
+}
+
+var (
+	budgetAlerts   = make(map[string]bool) // 已提醒过的预算，每个周期每种提醒只发送一次
+	budgetAlertsMu sync.Mutex
+)
+
+// 通知预算即将用尽或已用尽，同一周期内不重复通知
+func alertBudget(status *budgetStatus, exhausted bool) {
+	key := fmt.Sprintf("%+v|%s|%t", status.Limit, status.Period, exhausted)
+	budgetAlertsMu.Lock()
+	alerted := budgetAlerts[key]
+	budgetAlerts[key] = true
+	budgetAlertsMu.Unlock()
+	if alerted {
+		return
+	}
+
+	title, eventType := "预算提醒", EventBudgetWarning
+	message := status.String()
+	if exhausted {
+		title, eventType = "预算已用尽", EventBudgetExceeded
+		if status.Limit.Action == config.BudgetFallback {
+			message += "，已改用 " + config.GetConfig().Budget.FallbackProfile
+		} else {
+			message += "，已停止调用模型"
+		}
+	}
+	log.Warn("%s", message)
+	events.publish(eventType, budgetEvent{
+		Scope:   status.Limit.Scope(),
+		Period:  status.Period,
+		Tokens:  status.Tokens,
+		Cost:    status.Cost,
+		Ratio:   status.ratio(),
+		Action:  status.Limit.Action,
+		Message: message,
+	})
+	if err := notify.Push(title, message); err != nil {
+		log.Error("发送通知失败: %v", err)
+	}
+}
+
+// 预算事件的数据
+type budgetEvent struct {
+	Scope   string  `json:"scope"`  // 预算范围：提供商、AI配置或全部模型
+	Period  string  `json:"period"` // 2006-01-02 或 2006-01
+	Tokens  int     `json:"tokens"`
+	Cost    float64 `json:"cost"`
+	Ratio   float64 `json:"ratio"` // 用量占上限的比例
+	Action  string  `json:"action"`
+	Message string  `json:"message"`
+}
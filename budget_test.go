@@ -0,0 +1,199 @@
+package main
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"clipboard-translate/config"
+	"clipboard-translate/database"
+	"clipboard-translate/secrets"
+)
+
+// 加载带预算的配置并打开临时数据库，写入给定的用量
+func setupBudget(t *testing.T, budget map[string]any, usages ...*database.Usage) {
+	t.Helper()
+	srv := fakeOpenAI(t)
+	extra := map[string]any{"budget": budget}
+	extra["profiles"] = map[string]any{
+		"fake":  map[string]any{"provider": "openai", "model": "m", "base_url": srv.URL + "/v1", "use_env_key": true},
+		"other": map[string]any{"provider": "openai", "model": "n", "base_url": srv.URL + "/v1", "use_env_key": true},
+		"local": map[string]any{"provider": "ollama", "model": "llama3", "base_url": srv.URL},
+	}
+	if err := config.SetConfigFile(setupCommandDir(t, srv.URL, extra)); err != nil {
+		t.Fatal(err)
+	}
+	vault, err := secrets.NewVaultStore("secrets.vault", "secrets.key")
+	if err != nil {
+		t.Fatal(err)
+	}
+	secrets.SetDefault(vault)
+	if err := config.LoadConfig(); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(closeAIClients)
+
+	if db, err = openDatabase(config.GetConfig()); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		if db != nil {
+			db.Close()
+			db = nil
+		}
+	})
+	for _, u := range usages {
+		if err := db.AddUsage(u); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestBudgetStatusRatio(t *testing.T) {
+	tests := []struct {
+		name   string
+		limit  config.BudgetLimit
+		tokens int
+		cost   float64
+		want   float64
+	}{
+		{"只限制token", config.BudgetLimit{Tokens: 100}, 50, 9, 0.5},
+		{"只限制费用", config.BudgetLimit{Cost: 2}, 1000, 1, 0.5},
+		{"取较大者", config.BudgetLimit{Tokens: 100, Cost: 2}, 30, 1.5, 0.75},
+		{"超出上限", config.BudgetLimit{Tokens: 100}, 150, 0, 1.5},
+		{"没有用量", config.BudgetLimit{Tokens: 100, Cost: 1}, 0, 0, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := budgetStatus{Limit: tt.limit, Tokens: tt.tokens, Cost: tt.cost}
+			if got := s.ratio(); got != tt.want {
+				t.Errorf("ratio() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestBudgetLimitsFor(t *testing.T) {
+	cfg := &config.Config{
+		Profiles: map[string]config.ProfileConfig{
+			"fake":  {Provider: "OpenAI"},
+			"local": {Provider: "ollama"},
+		},
+		Budget: config.BudgetConfig{Limits: []config.BudgetLimit{
+			{Provider: "openai", Period: config.BudgetDaily, Tokens: 1},
+			{Profile: "fake", Period: config.BudgetMonthly, Tokens: 2},
+			{Profile: "other", Period: config.BudgetMonthly, Tokens: 3},
+			{Period: config.BudgetMonthly, Tokens: 4},
+		}},
+	}
+	tests := []struct {
+		profile string
+		want    []int // 适用预算的 token 上限
+	}{
+		{"fake", []int{1, 2, 4}},
+		{"local", []int{4}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.profile, func(t *testing.T) {
+			limits := budgetLimitsFor(cfg, tt.profile)
+			if len(limits) != len(tt.want) {
+				t.Fatalf("得到 %d 项预算 %+v, want %v", len(limits), limits, tt.want)
+			}
+			for i, limit := range limits {
+				if limit.Tokens != tt.want[i] {
+					t.Errorf("第 %d 项上限为 %d, want %d", i, limit.Tokens, tt.want[i])
+				}
+			}
+		})
+	}
+}
+
+func TestBudgetStatuses(t *testing.T) {
+	setupBudget(t, nil,
+		&database.Usage{Day: "2026-03-15", Provider: "OpenAI", Model: "m", Calls: 1, InputTokens: 10, OutputTokens: 5, Cost: 1},
+		&database.Usage{Day: "2026-03-15", Provider: "OpenAI", Model: "n", Calls: 1, InputTokens: 100, OutputTokens: 0, Cost: 2},
+		&database.Usage{Day: "2026-03-15", Provider: "Ollama", Model: "llama3", Calls: 1, InputTokens: 1000, OutputTokens: 0},
+		&database.Usage{Day: "2026-03-01", Provider: "OpenAI", Model: "m", Calls: 1, InputTokens: 20, OutputTokens: 20, Cost: 4},
+		&database.Usage{Day: "2026-02-28", Provider: "OpenAI", Model: "m", Calls: 1, InputTokens: 500, OutputTokens: 500, Cost: 50},
+	)
+	client, err := cachedAIClient("fake")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name   string
+		limit  config.BudgetLimit
+		tokens int
+		cost   float64
+	}{
+		{"提供商当天", config.BudgetLimit{Provider: "openai", Period: config.BudgetDaily, Tokens: 1}, 115, 3},
+		{"提供商当月", config.BudgetLimit{Provider: "openai", Period: config.BudgetMonthly, Tokens: 1}, 155, 7},
+		{"配置只统计其模型", config.BudgetLimit{Profile: "fake", Period: config.BudgetMonthly, Tokens: 1}, 55, 5},
+		{"全部模型", config.BudgetLimit{Period: config.BudgetDaily, Tokens: 1}, 1115, 3},
+	}
+	now := time.Date(2026, 3, 15, 12, 0, 0, 0, time.Local)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			statuses, err := budgetStatuses([]config.BudgetLimit{tt.limit}, client, now)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := statuses[0]; got.Tokens != tt.tokens || got.Cost != tt.cost {
+				t.Errorf("用量为 token %d 费用 %v, want token %d 费用 %v", got.Tokens, got.Cost, tt.tokens, tt.cost)
+			}
+		})
+	}
+}
+
+func TestEnforceBudget(t *testing.T) {
+	today := time.Now().Format(usageDayLayout)
+	spent := &database.Usage{Day: today, Provider: "OpenAI", Model: "m", Calls: 1, InputTokens: 80, OutputTokens: 20, Cost: 1}
+
+	tests := []struct {
+		name        string
+		action      string
+		tokens      int
+		wantErr     bool
+		wantModel   string
+		wantProfile string
+	}{
+		{"未超出", config.BudgetRefuse, 1000, false, "m", "fake"},
+		{"超出后拒绝", config.BudgetRefuse, 100, true, "", ""},
+		{"超出后回退", config.BudgetFallback, 100, false, "llama3", "local"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			setupBudget(t, map[string]any{
+				"fallback_profile": "local",
+				"limits":           []any{map[string]any{"provider": "openai", "period": "day", "tokens": tt.tokens, "action": tt.action}},
+			}, spent)
+
+			client, profile, err := aiClientFor("fake")
+			if tt.wantErr {
+				if !errors.Is(err, errBudgetExceeded) {
+					t.Fatalf("err = %v, want errBudgetExceeded", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if client.GetModel() != tt.wantModel || profile != tt.wantProfile {
+				t.Errorf("使用 %s 的模型 %s, want %s 的模型 %s", profile, client.GetModel(), tt.wantProfile, tt.wantModel)
+			}
+		})
+	}
+}
+
+func TestEnforceBudgetWithoutDatabase(t *testing.T) {
+	setupBudget(t, map[string]any{
+		"limits": []any{map[string]any{"period": "day", "tokens": 1}},
+	})
+	db.Close()
+	db = nil
+
+	if _, _, err := aiClientFor("fake"); err != nil {
+		t.Fatalf("数据库未打开时不应限制调用: %v", err)
+	}
+}
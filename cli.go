@@ -0,0 +1,341 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"text/tabwriter"
+
+	"clipboard-translate/config"
+	"clipboard-translate/i18n"
+	"clipboard-translate/secrets"
+	log "clipboard-translate/utils/log"
+)
+
+// 启动时的工作目录，main 随后会切换到可执行文件所在目录
+var launchDir string
+
+// 将子命令参数中的相对路径解析为启动目录下的路径
+func cliPath(path string) string {
+	if path == "" || filepath.IsAbs(path) || launchDir == "" {
+		return path
+	}
+	return filepath.Join(launchDir, path)
+}
+
+// 解析命令行参数，返回子命令及其参数
+//
+// 配置覆盖的优先级为：默认值 < 配置文件 < CT_ 环境变量 < 命令行参数。
+func parseCommandLine(args []string) ([]string, error) {
+	if err := config.LoadEnv(); err != nil {
+		return nil, err
+	}
+
+	fs := flag.NewFlagSet("clipboard-translate", flag.ContinueOnError)
+	config.RegisterFlags(fs, args)
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "用法: clipboard-translate [参数]\n       clipboard-translate config print [--effective] [参数]\n       clipboard-translate subtitle translate [--to 语言] [-o 输出文件] [参数] 字幕文件\n       clipboard-translate i18n translate --to 语言 --target 译文文件 [--dry-run] [参数] 原文文件\n       clipboard-translate token [--read-only | --url]\n\n")
+		fs.PrintDefaults()
+	}
+	if err := fs.Parse(args); err != nil {
+		return nil, err
+	}
+	return fs.Args(), nil
+}
+
+// 执行子命令，返回进程退出码
+func runCommand(args []string) int {
+	switch {
+	case len(args) >= 2 && args[0] == "config" && args[1] == "print":
+		return runConfigPrint(args[2:])
+	case len(args) >= 2 && args[0] == "subtitle" && args[1] == "translate":
+		return runSubtitleTranslate(args[2:])
+	case len(args) >= 2 && args[0] == "i18n" && args[1] == "translate":
+		return runI18nTranslate(args[2:])
+	case len(args) >= 1 && args[0] == "token":
+		return runToken(args[1:])
+	}
+	fmt.Fprintf(os.Stderr, "未知命令: %v\n", args)
+	return 2
+}
+
+// 子命令的公共初始化：只输出错误日志，打开密钥存储并加载配置
+func loadCommandConfig() error {
+	// 只输出错误日志，避免干扰命令输出
+	log.SetLogConfig(log.LogConfig{Level: log.ERROR})
+
+	// 配置迁移时可能需要写入密钥
+	vault, err := secrets.NewVaultStore("secrets.vault", "secrets.key")
+	if err != nil {
+		return fmt.Errorf("打开密钥存储失败: %w", err)
+	}
+	secrets.SetDefault(vault)
+
+	if err := config.LoadConfig(); err != nil {
+		return fmt.Errorf("加载配置文件失败: %w", err)
+	}
+	return nil
+}
+
+// config print [--effective]：输出配置，或每个配置项的有效值及来源
+func runConfigPrint(args []string) int {
+	fs := flag.NewFlagSet("config print", flag.ContinueOnError)
+	effective := fs.Bool("effective", false, "输出每个配置项的有效值及来源")
+	config.RegisterFlags(fs, args)
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+
+	if err := loadCommandConfig(); err != nil {
+		fmt.Fprintf(os.Stderr, "%v\n", err)
+		return 1
+	}
+
+	if !*effective {
+		data, err := json.MarshalIndent(config.GetConfig(), "", "  ")
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "%v\n", err)
+			return 1
+		}
+		fmt.Println(string(data))
+		return 0
+	}
+
+	fmt.Printf("# %s\n", config.ConfigFile())
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "PATH\tVALUE\tSOURCE")
+	for _, field := range config.Effective() {
+		fmt.Fprintf(w, "%s\t%s\t%s\n", field.Path, field.Value, field.Source)
+	}
+	w.Flush()
+	return 0
+}
+
+// token [--read-only | --url]：输出访问令牌，首次运行时生成
+func runToken(args []string) int {
+	fs := flag.NewFlagSet("token", flag.ContinueOnError)
+	readOnly := fs.Bool("read-only", false, "输出只读令牌")
+	loginURL := fs.Bool("url", false, "输出浏览器登录链接")
+	config.RegisterFlags(fs, args)
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+
+	if err := loadCommandConfig(); err != nil {
+		fmt.Fprintf(os.Stderr, "%v\n", err)
+		return 1
+	}
+	t, err := loadAPITokens()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "%v\n", err)
+		return 1
+	}
+
+	switch {
+	case *loginURL:
+		// 令牌放在 URL 片段中，不会发送到服务端或写入访问日志；服务运行中时使用其实际端口
+		port := config.GetConfig().UI.Port
+		if info, _, err := readInstanceInfo(lockFilePath()); err == nil && info.Port > 0 {
+			port = info.Port
+		}
+		fmt.Printf("http://localhost:%d/#token=%s\n", port, t.Admin)
+	case *readOnly:
+		fmt.Println(t.Read)
+	default:
+		fmt.Println(t.Admin)
+	}
+	return 0
+}
+
+// subtitle translate [--to 语言] [--profile 配置] [--max-line 宽度] [-o 输出文件] 字幕文件
+func runSubtitleTranslate(args []string) int {
+	fs := flag.NewFlagSet("subtitle translate", flag.ContinueOnError)
+	var req subtitleRequest
+	fs.StringVar(&req.TargetLanguage, "to", "", "目标语言 (默认 translation.target_language)")
+	fs.StringVar(&req.Profile, "profile", "", "使用的AI配置 (默认为当前激活的配置)")
+	fs.IntVar(&req.MaxLineLength, "max-line", 0, "每行最大显示宽度 (默认 translation.subtitle_line_length)")
+	output := fs.String("o", "", "输出文件 (默认在原文件名后加目标语言，如 movie.ja-JP.srt)")
+	config.RegisterFlags(fs, args)
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+	if fs.NArg() != 1 {
+		fmt.Fprintln(os.Stderr, "用法: clipboard-translate subtitle translate [参数] 字幕文件")
+		return 2
+	}
+	input := cliPath(fs.Arg(0))
+
+	if err := loadCommandConfig(); err != nil {
+		fmt.Fprintf(os.Stderr, "%v\n", err)
+		return 1
+	}
+	if err := loadPromptTemplates(config.GetConfig().Templates); err != nil {
+		fmt.Fprintf(os.Stderr, "加载提示词模板失败: %v\n", err)
+		return 1
+	}
+	defer closeAIClients()
+
+	// 模型调用的用量计入用量统计和消费预算
+	var err error
+	if db, err = openDatabase(config.GetConfig()); err != nil {
+		fmt.Fprintf(os.Stderr, "%v\n", err)
+		return 1
+	}
+	defer db.Close()
+
+	data, err := os.ReadFile(input)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "读取字幕文件失败: %v\n", err)
+		return 1
+	}
+
+	progress := func(done, total int) {
+		fmt.Fprintf(os.Stderr, "\r翻译中: %d/%d", done, total)
+		if done == total {
+			fmt.Fprintln(os.Stderr)
+		}
+	}
+	f, err := translateSubtitle(context.Background(), data, req, progress)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "%v\n", err)
+		return 1
+	}
+
+	if *output == "" {
+		language := req.TargetLanguage
+		if language == "" {
+			language = config.GetConfig().Translation.TargetLanguage
+		}
+		*output = translatedFileName(input, language)
+	}
+	if err := os.WriteFile(cliPath(*output), f.Bytes(), 0644); err != nil {
+		fmt.Fprintf(os.Stderr, "写入字幕文件失败: %v\n", err)
+		return 1
+	}
+	fmt.Println(*output)
+	return 0
+}
+
+// i18n translate --to 语言 --target 译文文件 [--dry-run] [--json] 原文文件
+func runI18nTranslate(args []string) int {
+	fs := flag.NewFlagSet("i18n translate", flag.ContinueOnError)
+	var req localeRequest
+	fs.StringVar(&req.TargetLanguage, "to", "", "目标语言 (默认 translation.target_language)")
+	fs.StringVar(&req.Profile, "profile", "", "使用的AI配置 (默认为当前激活的配置)")
+	fs.BoolVar(&req.DryRun, "dry-run", false, "只输出差异报告，不翻译")
+	targetPath := fs.String("target", "", "译文文件，已存在时只翻译缺失或原文有修改的条目")
+	output := fs.String("o", "", "输出文件 (默认覆盖 --target)")
+	asJSON := fs.Bool("json", false, "以 JSON 格式输出差异报告")
+	config.RegisterFlags(fs, args)
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+	if fs.NArg() != 1 || *targetPath == "" {
+		fmt.Fprintln(os.Stderr, "用法: clipboard-translate i18n translate --to 语言 --target 译文文件 [参数] 原文文件")
+		return 2
+	}
+
+	if err := loadCommandConfig(); err != nil {
+		fmt.Fprintf(os.Stderr, "%v\n", err)
+		return 1
+	}
+	if err := loadPromptTemplates(config.GetConfig().Templates); err != nil {
+		fmt.Fprintf(os.Stderr, "加载提示词模板失败: %v\n", err)
+		return 1
+	}
+	defer closeAIClients()
+
+	var err error
+	if db, err = openDatabase(config.GetConfig()); err != nil {
+		fmt.Fprintf(os.Stderr, "%v\n", err)
+		return 1
+	}
+	defer db.Close()
+
+	sourcePath, targetFile := cliPath(fs.Arg(0)), cliPath(*targetPath)
+	source, err := os.ReadFile(sourcePath)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "读取原文文件失败: %v\n", err)
+		return 1
+	}
+	target, err := os.ReadFile(targetFile)
+	if err != nil && !os.IsNotExist(err) {
+		fmt.Fprintf(os.Stderr, "读取译文文件失败: %v\n", err)
+		return 1
+	}
+
+	format, src, tgt, err := parseLocaleFiles(sourcePath, source, targetFile, target)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "%v\n", err)
+		return 1
+	}
+	// 以绝对路径识别译文文件
+	name, err := filepath.Abs(targetFile)
+	if err != nil {
+		name = targetFile
+	}
+
+	progress := func(done, total int) {
+		fmt.Fprintf(os.Stderr, "\r翻译中: %d/%d", done, total)
+		if done == total {
+			fmt.Fprintln(os.Stderr)
+		}
+	}
+	result, err := translateLocaleFile(context.Background(), format, src, tgt, name, req, progress)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "%v\n", err)
+		return 1
+	}
+
+	report := result.Report
+	if *asJSON {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetEscapeHTML(false)
+		enc.SetIndent("", "  ")
+		enc.Encode(report)
+	} else {
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "STATUS\tKEY\tSOURCE\tTRANSLATION")
+		for _, change := range report.Changes {
+			status, translation := string(change.Status), change.Target
+			if change.Error != "" {
+				status, translation = status+" (失败)", change.Error
+			} else if change.Status == i18n.StatusObsolete {
+				translation = change.PreviousTarget
+			}
+			fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", status, change.Key, abbreviate(change.Source, 40), abbreviate(translation, 40))
+		}
+		w.Flush()
+	}
+	fmt.Fprintf(os.Stderr, "新增 %d，修改 %d，删除 %d，未变 %d，失败 %d\n",
+		report.Count(i18n.StatusMissing), report.Count(i18n.StatusChanged), report.Count(i18n.StatusObsolete), report.Unchanged, report.Failed())
+
+	if req.DryRun {
+		return 0
+	}
+	if *output == "" {
+		*output = targetFile
+	}
+	if err := os.WriteFile(cliPath(*output), result.Content, 0644); err != nil {
+		fmt.Fprintf(os.Stderr, "写入译文文件失败: %v\n", err)
+		return 1
+	}
+	if report.Failed() > 0 {
+		return 1
+	}
+	return 0
+}
+
+// 截断过长的文本用于表格显示，换行替换为空格
+func abbreviate(text string, max int) string {
+	text = strings.Join(strings.Fields(text), " ")
+	runes := []rune(text)
+	if len(runes) <= max {
+		return text
+	}
+	return string(runes[:max-1]) + "…"
+}
@@ -0,0 +1,175 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+
+	"clipboard-translate/ai"
+	"clipboard-translate/config"
+	"clipboard-translate/database"
+)
+
+// 模拟 OpenAI 接口：编号行翻译为「译:原文」，其他行原样加前缀，每次返回固定的用量
+func fakeOpenAI(t *testing.T) *httptest.Server {
+	t.Helper()
+	numbered := regexp.MustCompile(`^\[(\d+)\] (.*)$`)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req struct {
+			Messages []struct{ Role, Content string } `json:"messages"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		var out []string
+		for _, line := range strings.Split(req.Messages[len(req.Messages)-1].Content, "\n") {
+			if m := numbered.FindStringSubmatch(line); m != nil {
+				out = append(out, fmt.Sprintf("[%s] 译:%s", m[1], m[2]))
+			} else {
+				out = append(out, "译:"+line)
+			}
+		}
+		json.NewEncoder(w).Encode(map[string]any{
+			"usage":   map[string]any{"prompt_tokens": 10, "completion_tokens": 5},
+			"choices": []any{map[string]any{"message": map[string]any{"role": "assistant", "content": strings.Join(out, "\n")}, "finish_reason": "stop"}},
+		})
+	}))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+// 在临时目录中准备配置文件并切换工作目录，返回配置文件路径；extra 中的配置分区覆盖默认内容
+func setupCommandDir(t *testing.T, baseURL string, extra map[string]any) string {
+	t.Helper()
+	dir := t.TempDir()
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chdir(dir); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { os.Chdir(wd) })
+	t.Setenv("AI_API_KEY", "test-key")
+
+	cfg := map[string]any{
+		"version":  config.CurrentVersion,
+		"api":      map[string]any{"active_profile": "fake"},
+		"profiles": map[string]any{"fake": map[string]any{"provider": "openai", "model": "m", "base_url": baseURL + "/v1", "use_env_key": true}},
+		"pricing":  map[string]any{"currency": "USD", "models": map[string]any{"m": map[string]any{"input": 1, "output": 2}}},
+	}
+	for section, value := range extra {
+		cfg[section] = value
+	}
+	data, err := json.Marshal(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(dir, "config.json")
+	if err := os.WriteFile(path, data, 0600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestRunSubtitleTranslateRecordsUsage(t *testing.T) {
+	srv := fakeOpenAI(t)
+	configPath := setupCommandDir(t, srv.URL, nil)
+	t.Cleanup(func() { db = nil })
+
+	srt := "1\n00:00:01,000 --> 00:00:02,000\nHello\n\n2\n00:00:03,000 --> 00:00:04,000\nWorld\n"
+	if err := os.WriteFile("a.srt", []byte(srt), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	if code := runSubtitleTranslate([]string{"--config", configPath, "--to", "ja-JP", "-o", "out.srt", "a.srt"}); code != 0 {
+		t.Fatalf("runSubtitleTranslate 返回 %d", code)
+	}
+	out, err := os.ReadFile("out.srt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(out), "译:Hello") || !strings.Contains(string(out), "译:World") {
+		t.Fatalf("译文不完整:\n%s", out)
+	}
+
+	store, err := database.New(database.DBConfig{Type: "sqlite", Connection: "clipboard-translate.db"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer store.Close()
+	if err := store.Initialize(); err != nil {
+		t.Fatal(err)
+	}
+	today := time.Now().Format(usageDayLayout)
+	usages, err := store.GetUsage(today, today)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(usages) != 1 || usages[0].Model != "m" || usages[0].InputTokens == 0 || usages[0].Cost == 0 {
+		t.Fatalf("用量统计不正确: %+v", usages)
+	}
+}
+
+func TestUsageWithoutDatabase(t *testing.T) {
+	db = nil
+	recordUsage("OpenAI", "m", ai.Usage{InputTokens: 10, OutputTokens: 5})
+
+	summary, err := summarizeUsage(periodDay, time.Now(), time.Now())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if summary.Total.Calls != 0 || len(summary.Periods) != 0 {
+		t.Fatalf("数据库未打开时应返回空的汇总: %+v", summary)
+	}
+}
+
+func TestGeminiUsagePricing(t *testing.T) {
+	srv := fakeOpenAI(t)
+	pricing := map[string]any{"models": map[string]any{"gemini-2.0-flash": map[string]any{"input": 1, "output": 2}}}
+	if err := config.SetConfigFile(setupCommandDir(t, srv.URL, map[string]any{"pricing": pricing})); err != nil {
+		t.Fatal(err)
+	}
+	if err := config.LoadConfig(); err != nil {
+		t.Fatal(err)
+	}
+	var err error
+	if db, err = openDatabase(config.GetConfig()); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		db.Close()
+		db = nil
+	})
+
+	client, err := ai.NewGeminiClient(ai.AIConfig{APIKey: "test-key", Model: "gemini-2.0-flash"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer client.Close()
+	u := ai.Usage{InputTokens: 1000, OutputTokens: 500}
+
+	// 客户端的模型带有 models/ 前缀，价格按配置中的模型名称查找
+	got := usageOf(client, u)
+	if got.Model != "gemini-2.0-flash" || got.Cost != 0.002 {
+		t.Errorf("usageOf() = %+v, want model gemini-2.0-flash, cost 0.002", got)
+	}
+
+	recordUsage(client.GetName(), client.GetModel(), u)
+	today := time.Now().Format(usageDayLayout)
+	usages, err := db.GetUsage(today, today)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(usages) != 1 || usages[0].Model != "gemini-2.0-flash" || usages[0].Cost != 0.002 {
+		t.Fatalf("用量统计不正确: %+v", usages)
+	}
+}
@@ -1,61 +1,143 @@
 package config
 
 import (
-	"clipboard-translate/constants"
+	"crypto/sha256"
 	"encoding/json"
+	"fmt"
 	"os"
 	"strings"
 	"sync"
+
+	"clipboard-translate/constants"
+	log "clipboard-translate/utils/log"
 )
 
 var (
 	configInstance *Config
 	configMutex    sync.RWMutex
 	configFile     = "config.json"
+	configHash     [sha256.Size]byte // 最近一次读取或写入的文件摘要
 )
 
 // Config 应用配置
 type Config struct {
-	Hotkeys     map[string]HotkeyConfig `json:"hotkeys"`
-	API         APIConfig               `json:"api"`
-	Translation TranslationConfig       `json:"translation"`
-	UI          UIConfig                `json:"ui"`
-	System      SystemConfig            `json:"system"`
-	Database    DatabaseConfig          `json:"database"`
+	Version     int                      `json:"version"` // 配置文件结构版本，用于迁移
+	Hotkeys     map[string]HotkeyConfig  `json:"hotkeys"`
+	API         APIConfig                `json:"api"`
+	Profiles    map[string]ProfileConfig `json:"profiles"`  // 命名的AI服务配置
+	Templates   map[string]string        `json:"templates"` // 用户提示词模板，与内置模板同名时覆盖
+	Translation TranslationConfig        `json:"translation"`
+	UI          UIConfig                 `json:"ui"`
+	System      SystemConfig             `json:"system"`
+	Database    DatabaseConfig           `json:"database"`
+	Pricing     PricingConfig            `json:"pricing"`
+	Budget      BudgetConfig             `json:"budget"`
+
+	pendingKeys map[string]string // DecodeConfig 迁移出的明文密钥，保存成功后写入密钥存储
 }
 
 // HotkeyConfig 热键配置
 type HotkeyConfig struct {
 	Modifiers []string `json:"modifiers"`
 	Key       string   `json:"key"`
+	Profile   string   `json:"profile,omitempty"`  // 该动作使用的AI配置，为空时使用当前激活的配置
+	Template  string   `json:"template,omitempty"` // 该动作使用的提示词模板，为空时使用动作对应的内置模板
 }
 
 // APIConfig API相关配置
 type APIConfig struct {
-	Provider  string `json:"provider"`    // AI提供商: gemini, openai, claude, ollama
-	APIKey    string `json:"api_key"`     // AI厂商的访问密钥
-	Model     string `json:"model"`       // 使用的模型
-	BaseURL   string `json:"base_url"`    // 自定义API端点
-	UseEnvKey bool   `json:"use_env_key"` // 是否使用环境变量
+	ActiveProfile string `json:"active_profile"` // 当前激活的AI配置名称
+}
+
+// DefaultProfile 默认AI配置的名称
+const DefaultProfile = "default"
+
+// ProfileConfig 命名的AI服务配置，可在运行时切换
+type ProfileConfig struct {
+	Provider    string   `json:"provider"`              // AI提供商: gemini, openai, claude, ollama
+	APIKeyRef   string   `json:"api_key_ref"`           // 密钥存储中的密钥名称，为空时使用提供商名称
+	Model       string   `json:"model"`                 // 使用的模型
+	BaseURL     string   `json:"base_url"`              // 自定义API端点
+	UseEnvKey   bool     `json:"use_env_key"`           // 是否使用环境变量
+	Prompt      string   `json:"prompt,omitempty"`      // 自定义翻译提示词，为空时使用默认提示词
+	Temperature *float64 `json:"temperature,omitempty"` // 采样温度，为空时使用提供商默认值
+	TopP        *float64 `json:"top_p,omitempty"`       // 核采样概率，为空时使用提供商默认值
+	MaxTokens   int      `json:"max_tokens,omitempty"`  // 最大输出token数，0 使用提供商默认值
+	Timeout     int      `json:"timeout,omitempty"`     // 请求超时秒数，0 使用默认值
+	Seed        *int64   `json:"seed,omitempty"`        // 随机种子，仅 OpenAI 和 Ollama 支持
+	Stop        []string `json:"stop,omitempty"`        // 停止序列
+}
+
+// KeyName 返回API密钥在密钥存储中的名称，每个提供商默认使用各自的密钥
+func (p ProfileConfig) KeyName() string {
+	if p.APIKeyRef != "" {
+		return p.APIKeyRef
+	}
+	return strings.ToLower(p.Provider)
+}
+
+// ActiveProfile 返回当前激活的AI配置
+func (c *Config) ActiveProfile() ProfileConfig {
+	return c.Profiles[c.API.ActiveProfile]
 }
 
+// ProfileFor 返回热键动作使用的AI配置名称
+func (c *Config) ProfileFor(action string) string {
+	if profile := c.Hotkeys[action].Profile; profile != "" {
+		return profile
+	}
+	return c.API.ActiveProfile
+}
+
+// 翻译文本格式
+const (
+	FormatAuto  = "auto"
+	FormatPlain = "plain"
+)
+
 // TranslationConfig 翻译相关配置
 type TranslationConfig struct {
-	TargetLanguage   string `json:"target_language"`
-	AutoTranslate    bool   `json:"auto_translate"`
-	ShowNotification bool   `json:"show_notification"`
+	TargetLanguage    string `json:"target_language"`
+	AlternateLanguage string `json:"alternate_language"` // 备用翻译语言，供 translateAlt 热键使用
+	AutoTranslate     bool   `json:"auto_translate"`
+	ShowNotification  bool   `json:"show_notification"`
+
+	// 提示词模板变量的默认值
+	Tone     string            `json:"tone,omitempty"`     // 语气
+	Domain   string            `json:"domain,omitempty"`   // 领域
+	Glossary map[string]string `json:"glossary,omitempty"` // 术语表：原文 -> 译文
+
+	// 文本格式：auto 识别 Markdown 并保护代码、链接和HTML，plain 按纯文本处理
+	Format string `json:"format"`
+
+	// 长文本分段翻译
+	ChunkTokens      int `json:"chunk_tokens"`      // 每段的估算token数上限，负数表示不分段
+	ChunkConcurrency int `json:"chunk_concurrency"` // 同时翻译的分段数
+
+	// 字幕每行的最大显示宽度，中日韩字符计为 2；负数表示不折行
+	SubtitleLineLength int `json:"subtitle_line_length"`
+
+	// 批量翻译任务
+	JobWorkers int            `json:"job_workers"`           // 同时翻译的文本数
+	RateLimits map[string]int `json:"rate_limits,omitempty"` // 各提供商每分钟的请求数上限，未列出或为 0 表示不限制
 }
 
 // UIConfig UI相关配置
 type UIConfig struct {
 	Port  int    `json:"port"`
 	Theme string `json:"theme"`
+
+	// 监听地址，默认只接受本机连接；0.0.0.0 监听所有网卡
+	BindAddress string `json:"bind_address"`
+	// 除本机外允许的 Host 和 Origin 主机名，通过局域网地址或域名访问时需要添加
+	AllowedHosts []string `json:"allowed_hosts,omitempty"`
 }
 
 // SystemConfig 系统相关配置
 type SystemConfig struct {
-	AutoStart       bool `json:"auto_start"`
-	MaxHistoryItems int  `json:"max_history_items"`
+	AutoStart       bool   `json:"auto_start"`
+	MaxHistoryItems int    `json:"max_history_items"`
+	LogLevel        string `json:"log_level"` // 日志级别: debug, info, warning, error
 }
 
 // DatabaseConfig 数据库配置
@@ -64,66 +146,149 @@ type DatabaseConfig struct {
 	Connection string `json:"connection"`
 }
 
-// LoadConfig 加载配置文件
-func LoadConfig() error {
-	configMutex.Lock()
-	defer configMutex.Unlock()
+// PricingConfig 模型价格，用于统计翻译费用
+type PricingConfig struct {
+	Currency string                `json:"currency"`         // 价格的货币单位，仅用于显示
+	Models   map[string]ModelPrice `json:"models,omitempty"` // 模型名称 -> 价格，未列出的模型不计费用
+}
 
-	// 检查配置文件是否存在
-	if _, err := os.Stat(configFile); os.IsNotExist(err) {
-		// 创建默认配置
-		configInstance = &Config{
-			Hotkeys: map[string]HotkeyConfig{
-				"showHide": {
-					Modifiers: []string{"control", "shift"},
-					Key:       "",
-				},
-				"translate": {
-					Modifiers: []string{"control", "alt"},
-					Key:       "t",
-				},
-			},
-			API: APIConfig{
-				APIKey: "",
-				UseEnvKey: true,
-			},
-			Translation: TranslationConfig{
-				TargetLanguage:   "zh-CN",
-				AutoTranslate:    false,
-				ShowNotification: true,
-			},
-			UI: UIConfig{
-				Port:  8080,
-				Theme: "light",
-			},
-			System: SystemConfig{
-				AutoStart:       true,
-				MaxHistoryItems: 100,
-			},
-			Database: DatabaseConfig{
-				Type:       "sqlite",
-				Connection: "clipboard-translate.db",
-			},
-		}
+// ModelPrice 每百万token的价格
+type ModelPrice struct {
+	Input  float64 `json:"input"`
+	Output float64 `json:"output"`
+}
 
-		// 保存默认配置
-		return SaveConfig(configInstance)
+// Cost 按价格表计算token用量的费用，模型不在价格表中时返回 false
+func (p PricingConfig) Cost(model string, inputTokens, outputTokens int) (float64, bool) {
+	price, ok := p.Models[model]
+	if !ok {
+		return 0, false
 	}
+	return (float64(inputTokens)*price.Input + float64(outputTokens)*price.Output) / 1e6, true
+}
 
-	// 读取配置文件
-	data, err := os.ReadFile(configFile)
-	if err != nil {
-		return err
+// 预算周期
+const (
+	BudgetDaily   = "day"
+	BudgetMonthly = "month"
+)
+
+// 预算用尽时的处理方式
+const (
+	BudgetRefuse   = "refuse"   // 拒绝调用模型
+	BudgetFallback = "fallback" // 改用 budget.fallback_profile 指定的本地模型
+)
+
+// 默认在用量达到上限的 80% 时提醒
+const DefaultBudgetWarnAt = 0.8
+
+// BudgetConfig 消费预算
+type BudgetConfig struct {
+	FallbackProfile string        `json:"fallback_profile"` // 预算用尽时改用的AI配置，必须使用 Ollama
+	Limits          []BudgetLimit `json:"limits"`
+}
+
+// BudgetLimit 单项预算，provider 和 profile 都为空时限制所有模型调用
+type BudgetLimit struct {
+	Provider string  `json:"provider,omitempty"` // 限制的AI提供商
+	Profile  string  `json:"profile,omitempty"`  // 限制的AI配置，按其提供商和模型统计用量
+	Period   string  `json:"period"`             // day 或 month
+	Tokens   int     `json:"tokens,omitempty"`   // 输入和输出token合计的上限，0 表示不限制
+	Cost     float64 `json:"cost,omitempty"`     // 费用上限，货币单位为 pricing.currency，0 表示不限制
+	WarnAt   float64 `json:"warn_at,omitempty"`  // 用量达到上限的该比例时提醒，默认 0.8
+	Action   string  `json:"action,omitempty"`   // 用尽时的处理方式：refuse（默认）或 fallback
+}
+
+// Scope 预算限制的范围，用于日志和通知
+func (l BudgetLimit) Scope() string {
+	switch {
+	case l.Profile != "":
+		return "AI配置 " + l.Profile
+	case l.Provider != "":
+		return l.Provider
+	default:
+		return "全部模型"
 	}
+}
 
-	// 解析JSON
-	var config Config
-	err = json.Unmarshal(data, &config)
-	if err != nil {
-		return err
+// 默认热键配置，按键为空表示未启用
+func defaultHotkeys() map[string]HotkeyConfig {
+	return map[string]HotkeyConfig{
+		constants.ACTION_TRANSLATE: {
+			Modifiers: []string{"control", "alt"},
+			Key:       "t",
+		},
+		constants.ACTION_TRANSLATE_ALT: {
+			Modifiers: []string{"control", "alt"},
+			Key:       "",
+		},
+		constants.ACTION_EXPLAIN: {
+			Modifiers: []string{"control", "alt"},
+			Key:       "",
+		},
+		constants.ACTION_POLISH: {
+			Modifiers: []string{"control", "alt"},
+			Key:       "",
+		},
+		constants.ACTION_SUMMARIZE: {
+			Modifiers: []string{"control", "alt"},
+			Key:       "",
+		},
+		constants.ACTION_SHOW_HIDE: {
+			Modifiers: []string{"control", "shift"},
+			Key:       "",
+		},
+		constants.ACTION_REPEAT_LAST: {
+			Modifiers: []string{"control", "alt"},
+			Key:       "",
+		},
+	}
+}
+
+// 默认配置
+func defaultConfig() *Config {
+	return &Config{
+		Version: CurrentVersion,
+		Hotkeys: defaultHotkeys(),
+		API: APIConfig{
+			ActiveProfile: DefaultProfile,
+		},
+		Profiles: map[string]ProfileConfig{
+			DefaultProfile: {UseEnvKey: true},
+		},
+		Translation: TranslationConfig{
+			TargetLanguage:     "zh-CN",
+			AlternateLanguage:  "ja-JP",
+			AutoTranslate:      false,
+			ShowNotification:   true,
+			Format:             FormatAuto,
+			ChunkTokens:        1500,
+			ChunkConcurrency:   3,
+			SubtitleLineLength: 42,
+			JobWorkers:         4,
+		},
+		UI: UIConfig{
+			Port:        8080,
+			Theme:       "light",
+			BindAddress: "127.0.0.1",
+		},
+		System: SystemConfig{
+			AutoStart:       true,
+			MaxHistoryItems: 100,
+			LogLevel:        "info",
+		},
+		Database: DatabaseConfig{
+			Type:       "sqlite",
+			Connection: "clipboard-translate.db",
+		},
+		Pricing: PricingConfig{
+			Currency: "USD",
+		},
 	}
+}
 
-	// 设置默认值
+// 为缺失的配置项设置默认值
+func applyDefaults(config *Config) {
 	// UI配置
 	if config.UI.Port == 0 {
 		config.UI.Port = 8080
@@ -131,38 +296,64 @@ func LoadConfig() error {
 	if config.UI.Theme == "" {
 		config.UI.Theme = "light"
 	}
+	if config.UI.BindAddress == "" {
+		config.UI.BindAddress = "127.0.0.1"
+	}
 
 	// 热键配置
 	if config.Hotkeys == nil {
 		config.Hotkeys = make(map[string]HotkeyConfig)
 	}
-	if _, ok := config.Hotkeys["translate"]; !ok {
-		config.Hotkeys["translate"] = HotkeyConfig{
-			Modifiers: []string{"control", "alt"},
-			Key:       "t",
+	for action, hotkey := range defaultHotkeys() {
+		if _, ok := config.Hotkeys[action]; !ok {
+			config.Hotkeys[action] = hotkey
 		}
 	}
-	if _, ok := config.Hotkeys["showHide"]; !ok {
-		config.Hotkeys["showHide"] = HotkeyConfig{
-			Modifiers: []string{"control", "shift"},
-			Key:       "",
+
+	// AI配置：至少保留一个配置，未指定时激活默认配置
+	if len(config.Profiles) == 0 {
+		config.Profiles = map[string]ProfileConfig{
+			DefaultProfile: {UseEnvKey: true},
 		}
 	}
-
-	// API配置
-	if config.API.APIKey == "" && !config.API.UseEnvKey {
-		config.API.UseEnvKey = true
+	if config.API.ActiveProfile == "" {
+		if _, ok := config.Profiles[DefaultProfile]; ok {
+			config.API.ActiveProfile = DefaultProfile
+		} else {
+			config.API.ActiveProfile = sortedKeys(config.Profiles)[0]
+		}
 	}
 
 	// 翻译配置
 	if config.Translation.TargetLanguage == "" {
 		config.Translation.TargetLanguage = "zh-CN"
 	}
+	if config.Translation.AlternateLanguage == "" {
+		config.Translation.AlternateLanguage = "ja-JP"
+	}
+	if config.Translation.Format == "" {
+		config.Translation.Format = FormatAuto
+	}
+	if config.Translation.ChunkTokens == 0 {
+		config.Translation.ChunkTokens = 1500
+	}
+	if config.Translation.ChunkConcurrency == 0 {
+		config.Translation.ChunkConcurrency = 3
+	}
+	if config.Translation.SubtitleLineLength == 0 {
+		config.Translation.SubtitleLineLength = 42
+	}
+	if config.Translation.JobWorkers == 0 {
+		config.Translation.JobWorkers = 4
+	}
 
 	// 系统配置
 	if config.System.MaxHistoryItems == 0 {
 		config.System.MaxHistoryItems = 100
 	}
+	if config.System.LogLevel == "" {
+		config.System.LogLevel = "info"
+	}
 
 	// 数据库配置
 	if config.Database.Type == "" {
@@ -172,221 +363,207 @@ func LoadConfig() error {
 		config.Database.Connection = "clipboard-translate.db"
 	}
 
-	configInstance = &config
-	return nil
+	// 价格配置
+	if config.Pricing.Currency == "" {
+		config.Pricing.Currency = "USD"
+	}
+
+	// 预算配置
+	for i := range config.Budget.Limits {
+		limit := &config.Budget.Limits[i]
+		if limit.WarnAt == 0 {
+			limit.WarnAt = DefaultBudgetWarnAt
+		}
+		if limit.Action == "" {
+			limit.Action = BudgetRefuse
+		}
+	}
 }
 
-// GetConfig 获取配置
-func GetConfig() *Config {
-	configMutex.RLock()
-	defer configMutex.RUnlock()
+// 读取并解析配置文件，返回配置、文件内容的摘要及解析信息
+func readConfigFile() (*Config, [sha256.Size]byte, decodeInfo, error) {
+	data, err := os.ReadFile(configFile)
+	if err != nil {
+		return nil, [sha256.Size]byte{}, decodeInfo{}, err
+	}
+	sum := sha256.Sum256(data)
 
-	if configInstance == nil {
-		// 如果配置未加载，尝试加载
-		configMutex.RUnlock()
-		err := LoadConfig()
-		if err != nil {
-			// 加载失败，返回默认配置
-			return &Config{
-				Hotkeys: map[string]HotkeyConfig{
-					"showHide": {
-						Modifiers: []string{"control", "shift"},
-						Key:       "",
-					},
-					"translate": {
-						Modifiers: []string{"control", "alt"},
-						Key:       "t",
-					},
-				},
-				API: APIConfig{
-					APIKey: "",
-					UseEnvKey: true,
-				},
-				Translation: TranslationConfig{
-					TargetLanguage:   "zh-CN",
-					AutoTranslate:    false,
-					ShowNotification: true,
-				},
-				UI: UIConfig{
-					Port:  8080,
-					Theme: "light",
-				},
-				System: SystemConfig{
-					AutoStart:       true,
-					MaxHistoryItems: 100,
-				},
-				Database: DatabaseConfig{
-					Type:       "sqlite",
-					Connection: "clipboard-translate.db",
-				},
-			}
-		}
-		configMutex.RLock()
+	// 解析JSON并迁移旧版本
+	config, info, err := decodeConfig(data)
+	if err != nil {
+		return nil, sum, info, err
+	}
+	if err := storeKeys(info.keys); err != nil {
+		return nil, sum, info, err
+	}
+	if len(info.unknown) > 0 {
+		log.Warn("配置文件包含未知字段，已忽略: %s", strings.Join(info.unknown, ", "))
 	}
 
-	return configInstance
+	// 设置默认值
+	applyDefaults(config)
+	return config, sum, info, nil
 }
 
-// SaveConfig 保存配置
-func SaveConfig(config *Config) error {
-	configMutex.Lock()
-	defer configMutex.Unlock()
-
+// 将配置写入文件，backup 为 true 时先轮换备份，调用方需持有写锁
+func writeConfigFile(config *Config, backup bool) error {
 	// 序列化为JSON
 	data, err := json.MarshalIndent(config, "", "  ")
 	if err != nil {
 		return err
 	}
 
-	// 写入文件
-	err = os.WriteFile(configFile, data, 0644)
-	if err != nil {
+	// 内容未变化时不写入，避免无意义的备份轮换
+	sum := sha256.Sum256(data)
+	if sum == configHash {
+		if _, err := os.Stat(configFile); err == nil {
+			return nil
+		}
+	}
+
+	// 备份旧文件后原子替换
+	if backup {
+		if err := rotateBackups(configFile); err != nil {
+			return fmt.Errorf("备份配置文件失败: %w", err)
+		}
+	}
+	if err := writeFileAtomic(configFile, data, 0644); err != nil {
 		return err
 	}
 
-	configInstance = config
+	// 记录摘要，避免文件监视把自己的写入当作外部修改
+	configHash = sum
 	return nil
 }
 
-// 将虚拟键码字符串转换为键码
-func VirtualKeyFromString(key string) uint16 {
-	key = strings.ToUpper(key)
-	switch key {
-	// 字母键
-	case "A":
-		return constants.VK_A
-	case "B":
-		return constants.VK_B
-	case "C":
-		return constants.VK_C
-	case "D":
-		return constants.VK_D
-	case "E":
-		return constants.VK_E
-	case "F":
-		return constants.VK_F
-	case "G":
-		return constants.VK_G
-	case "H":
-		return constants.VK_H
-	case "I":
-		return constants.VK_I
-	case "J":
-		return constants.VK_J
-	case "K":
-		return constants.VK_K
-	case "L":
-		return constants.VK_L
-	case "M":
-		return constants.VK_M
-	case "N":
-		return constants.VK_N
-	case "O":
-		return constants.VK_O
-	case "P":
-		return constants.VK_P
-	case "Q":
-		return constants.VK_Q
-	case "R":
-		return constants.VK_R
-	case "S":
-		return constants.VK_S
-	case "T":
-		return constants.VK_T
-	case "U":
-		return constants.VK_U
-	case "V":
-		return constants.VK_V
-	case "W":
-		return constants.VK_W
-	case "X":
-		return constants.VK_X
-	case "Y":
-		return constants.VK_Y
-	case "Z":
-		return constants.VK_Z
-
-	// 数字键
-	case "0":
-		return constants.VK_0
-	case "1":
-		return constants.VK_1
-	case "2":
-		return constants.VK_2
-	case "3":
-		return constants.VK_3
-	case "4":
-		return constants.VK_4
-	case "5":
-		return constants.VK_5
-	case "6":
-		return constants.VK_6
-	case "7":
-		return constants.VK_7
-	case "8":
-		return constants.VK_8
-	case "9":
-		return constants.VK_9
-
-	// 功能键
-	case "INSERT":
-		return constants.VK_INSERT
-	case "DELETE":
-		return constants.VK_DELETE
+// LoadConfig 加载配置文件
+func LoadConfig() error {
+	configMutex.Lock()
+	defer configMutex.Unlock()
+
+	// 检查配置文件是否存在
+	if _, err := os.Stat(configFile); os.IsNotExist(err) {
+		// 创建并保存默认配置
+		config := defaultConfig()
+		if err := writeConfigFile(config, false); err != nil {
+			return err
+		}
+		fileConfig = config
+		filePaths = make(map[string]bool)
+		configInstance = withOverrides(config)
+		return nil
+	}
 
-	default:
-		// 如果是单个字符，尝试直接转换
-		if len(key) == 1 {
-			return uint16(key[0])
+	config, sum, info, err := readConfigFile()
+	if err != nil {
+		return err
+	}
+	configHash = sum
+
+	// 旧版本配置升级后写回文件
+	if info.fromVersion < CurrentVersion {
+		log.Info("配置文件已从版本 %d 升级到版本 %d", info.fromVersion, CurrentVersion)
+		// 版本 2 之前的文件可能包含明文密钥，不保留备份
+		if err := writeConfigFile(config, info.fromVersion >= 2); err != nil {
+			log.Error("写回升级后的配置文件失败: %v", err)
 		}
-		return 0
 	}
+
+	// 命令行参数和环境变量优先于配置文件
+	fileConfig = config
+	filePaths = info.present
+	config = withOverrides(config)
+
+	// 启动时配置无效只记录警告，由各子系统在初始化时报告具体错误
+	if err := config.Validate(); err != nil {
+		log.Warn("%v", err)
+	}
+
+	configInstance = config
+	return nil
 }
 
-// 将修饰符字符串数组转换为修饰符标志
-func ModifierFromString(modifiers []string) uint16 {
-	var result uint16
-	for _, mod := range modifiers {
-		mod = strings.ToLower(mod)
-		switch mod {
-		case "alt":
-			result |= constants.MOD_ALT
-		case "control", "ctrl":
-			result |= constants.MOD_CONTROL
-		case "shift":
-			result |= constants.MOD_SHIFT
-		case "win":
-			result |= constants.MOD_WIN
-		}
+// GetConfig 获取配置
+//
+// 返回的配置在替换前不会被修改，调用方不应修改其内容。
+func GetConfig() *Config {
+	configMutex.RLock()
+	config := configInstance
+	configMutex.RUnlock()
+
+	if config != nil {
+		return config
 	}
-	return result
+
+	// 如果配置未加载，尝试加载
+	if err := LoadConfig(); err != nil {
+		// 加载失败，返回默认配置
+		return defaultConfig()
+	}
+
+	configMutex.RLock()
+	defer configMutex.RUnlock()
+	return configInstance
 }
 
-// Equals 判断两个 HotkeyConfig 实例是否相等
-func (h HotkeyConfig) Equals(other HotkeyConfig) bool {
-	// 比较键名
-	if h.Key != other.Key {
-		return false
+// SetActiveProfile 切换当前激活的AI配置并保存
+//
+// 配置不存在、无效或 api.active_profile 被命令行参数或环境变量覆盖时返回 *ValidationError；
+// 其余与 SaveConfig 相同。
+func SetActiveProfile(name string) error {
+	// 被覆盖时保存到文件也不会生效
+	if source, ok := overriddenBy("api.active_profile"); ok {
+		verr := &ValidationError{}
+		verr.add("api.active_profile", "已由%s指定，无法切换", source)
+		return verr
 	}
 
-	// 比较修饰符数量
-	if len(h.Modifiers) != len(other.Modifiers) {
-		return false
+	config := GetConfig().Clone()
+	profile, ok := config.Profiles[name]
+	if !ok {
+		verr := &ValidationError{}
+		verr.add("api.active_profile", "AI配置 %q 不存在", name)
+		return verr
 	}
 
-	// 创建修饰符集合以便进行无序比较
-	selfMods := make(map[string]struct{}, len(h.Modifiers))
-	for _, mod := range h.Modifiers {
-		selfMods[strings.ToLower(mod)] = struct{}{}
+	verr := &ValidationError{}
+	profile.validate("profiles."+name, nil, verr)
+	if len(verr.Errors) > 0 {
+		return verr
 	}
 
-	// 检查对方的所有修饰符是否都存在
-	for _, mod := range other.Modifiers {
-		if _, exists := selfMods[strings.ToLower(mod)]; !exists {
-			return false
-		}
+	config.API.ActiveProfile = name
+	return SaveConfig(config)
+}
+
+// SaveConfig 保存配置并通知订阅者
+//
+// 配置写入成功后才会替换当前配置；订阅者应用变更失败时返回 *ApplyError，此时配置已保存。
+// 由命令行参数或环境变量覆盖的字段保留文件中的原值，有效配置仍以覆盖值为准。
+// DecodeConfig 迁移出的明文密钥在配置写入成功后才写入密钥存储。
+func SaveConfig(config *Config) error {
+	keys := config.pendingKeys
+	config.Version = CurrentVersion
+	applyDefaults(config)
+
+	configMutex.Lock()
+	old := configInstance
+	file := withoutOverrides(config, fileConfig)
+	if err := writeConfigFile(file, true); err != nil {
+		configMutex.Unlock()
+		return err
 	}
+	fileConfig = file
+	for _, path := range configPaths(file) {
+		filePaths[path.String()] = true
+	}
+	config = withOverrides(file)
+	configInstance = config
+	configMutex.Unlock()
 
-	// 所有检查都通过，认为相等
-	return true
+	keyErr := storeKeys(keys)
+	if err := publish(old, config); err != nil {
+		return err
+	}
+	return keyErr
 }
@@ -0,0 +1,291 @@
+package config
+
+import (
+	"clipboard-translate/constants"
+	"fmt"
+	"strings"
+)
+
+// KeyInfo 按键的规范名称与虚拟键码
+type KeyInfo struct {
+	Name string `json:"name"`
+	Code uint16 `json:"code"`
+}
+
+// 修饰键规范名称，按显示顺序排列
+var modifierNames = []struct {
+	name string
+	flag uint16
+}{
+	{"Ctrl", constants.MOD_CONTROL},
+	{"Alt", constants.MOD_ALT},
+	{"Shift", constants.MOD_SHIFT},
+	{"Win", constants.MOD_WIN},
+}
+
+// 修饰键别名（小写）到修饰符标志的映射
+var modifierAliases = map[string]uint16{
+	"control": constants.MOD_CONTROL,
+	"ctrl":    constants.MOD_CONTROL,
+	"alt":     constants.MOD_ALT,
+	"option":  constants.MOD_ALT,
+	"shift":   constants.MOD_SHIFT,
+	"win":     constants.MOD_WIN,
+	"super":   constants.MOD_WIN,
+	"meta":    constants.MOD_WIN,
+	"cmd":     constants.MOD_WIN,
+}
+
+// 修饰符标志在 config.json 中的写法
+var modifierConfigNames = map[uint16]string{
+	constants.MOD_CONTROL: "control",
+	constants.MOD_ALT:     "alt",
+	constants.MOD_SHIFT:   "shift",
+	constants.MOD_WIN:     "win",
+}
+
+// 非字母数字按键的规范名称，按界面显示顺序排列
+var namedKeys = []KeyInfo{
+	{"Space", constants.VK_SPACE},
+	{"Enter", constants.VK_RETURN},
+	{"Tab", constants.VK_TAB},
+	{"Backspace", constants.VK_BACK},
+	{"Escape", constants.VK_ESCAPE},
+	{"Insert", constants.VK_INSERT},
+	{"Delete", constants.VK_DELETE},
+	{"Home", constants.VK_HOME},
+	{"End", constants.VK_END},
+	{"PageUp", constants.VK_PRIOR},
+	{"PageDown", constants.VK_NEXT},
+	{"Left", constants.VK_LEFT},
+	{"Up", constants.VK_UP},
+	{"Right", constants.VK_RIGHT},
+	{"Down", constants.VK_DOWN},
+	{"Pause", constants.VK_PAUSE},
+	{"CapsLock", constants.VK_CAPITAL},
+	{"PrintScreen", constants.VK_SNAPSHOT},
+	{"ScrollLock", constants.VK_SCROLL},
+	{"NumLock", constants.VK_NUMLOCK},
+	{"NumMultiply", constants.VK_MULTIPLY},
+	{"NumAdd", constants.VK_ADD},
+	{"NumSeparator", constants.VK_SEPARATOR},
+	{"NumSubtract", constants.VK_SUBTRACT},
+	{"NumDecimal", constants.VK_DECIMAL},
+	{"NumDivide", constants.VK_DIVIDE},
+	{";", constants.VK_OEM_1},
+	{"=", constants.VK_OEM_PLUS},
+	{",", constants.VK_OEM_COMMA},
+	{"-", constants.VK_OEM_MINUS},
+	{".", constants.VK_OEM_PERIOD},
+	{"/", constants.VK_OEM_2},
+	{"`", constants.VK_OEM_3},
+	{"[", constants.VK_OEM_4},
+	{"\\", constants.VK_OEM_5},
+	{"]", constants.VK_OEM_6},
+	{"'", constants.VK_OEM_7},
+	{"VolumeMute", constants.VK_VOLUME_MUTE},
+	{"VolumeDown", constants.VK_VOLUME_DOWN},
+	{"VolumeUp", constants.VK_VOLUME_UP},
+	{"MediaNext", constants.VK_MEDIA_NEXT_TRACK},
+	{"MediaPrev", constants.VK_MEDIA_PREV_TRACK},
+	{"MediaStop", constants.VK_MEDIA_STOP},
+	{"MediaPlayPause", constants.VK_MEDIA_PLAY_PAUSE},
+}
+
+// 按键别名（大写），用于兼容常见写法
+var keyAliases = map[string]uint16{
+	"ESC":          constants.VK_ESCAPE,
+	"RETURN":       constants.VK_RETURN,
+	"INS":          constants.VK_INSERT,
+	"DEL":          constants.VK_DELETE,
+	"PGUP":         constants.VK_PRIOR,
+	"PGDN":         constants.VK_NEXT,
+	"PRIOR":        constants.VK_PRIOR,
+	"NEXT":         constants.VK_NEXT,
+	"PRTSC":        constants.VK_SNAPSHOT,
+	"ARROWLEFT":    constants.VK_LEFT,
+	"ARROWUP":      constants.VK_UP,
+	"ARROWRIGHT":   constants.VK_RIGHT,
+	"ARROWDOWN":    constants.VK_DOWN,
+	"SEMICOLON":    constants.VK_OEM_1,
+	"PLUS":         constants.VK_OEM_PLUS,
+	"+":            constants.VK_OEM_PLUS,
+	"EQUAL":        constants.VK_OEM_PLUS,
+	"COMMA":        constants.VK_OEM_COMMA,
+	"MINUS":        constants.VK_OEM_MINUS,
+	"PERIOD":       constants.VK_OEM_PERIOD,
+	"SLASH":        constants.VK_OEM_2,
+	"BACKQUOTE":    constants.VK_OEM_3,
+	"BRACKETLEFT":  constants.VK_OEM_4,
+	"BACKSLASH":    constants.VK_OEM_5,
+	"BRACKETRIGHT": constants.VK_OEM_6,
+	"QUOTE":        constants.VK_OEM_7,
+	"MUTE":         constants.VK_VOLUME_MUTE,
+	"PLAYPAUSE":    constants.VK_MEDIA_PLAY_PAUSE,
+}
+
+var (
+	keyTable    = buildKeyTable()         // 所有支持的按键，按界面显示顺序排列
+	keysByName  = make(map[string]uint16) // 大写名称到虚拟键码
+	namesByCode = make(map[uint16]string) // 虚拟键码到规范名称
+)
+
+func init() {
+	for _, k := range keyTable {
+		keysByName[strings.ToUpper(k.Name)] = k.Code
+		namesByCode[k.Code] = k.Name
+	}
+	for alias, code := range keyAliases {
+		keysByName[alias] = code
+	}
+}
+
+// 生成按键表：字母、数字、F1-F24、小键盘数字及其他命名按键
+func buildKeyTable() []KeyInfo {
+	var keys []KeyInfo
+	for c := 'A'; c <= 'Z'; c++ {
+		keys = append(keys, KeyInfo{string(c), uint16(constants.VK_A + (c - 'A'))})
+	}
+	for c := '0'; c <= '9'; c++ {
+		keys = append(keys, KeyInfo{string(c), uint16(constants.VK_0 + (c - '0'))})
+	}
+	for i := 1; i <= 24; i++ {
+		keys = append(keys, KeyInfo{fmt.Sprintf("F%d", i), uint16(constants.VK_F1 + i - 1)})
+	}
+	for i := 0; i <= 9; i++ {
+		keys = append(keys, KeyInfo{fmt.Sprintf("Num%d", i), uint16(constants.VK_NUMPAD0 + i)})
+	}
+	return append(keys, namedKeys...)
+}
+
+// SupportedKeys 返回所有支持的按键
+func SupportedKeys() []KeyInfo {
+	keys := make([]KeyInfo, len(keyTable))
+	copy(keys, keyTable)
+	return keys
+}
+
+// VirtualKeyFromString 将按键名称转换为虚拟键码，未知按键返回0
+func VirtualKeyFromString(key string) uint16 {
+	return keysByName[strings.ToUpper(strings.TrimSpace(key))]
+}
+
+// KeyName 返回虚拟键码的规范名称
+func KeyName(code uint16) (string, bool) {
+	name, ok := namesByCode[code]
+	return name, ok
+}
+
+// ModifierFromString 将修饰符字符串数组转换为修饰符标志，未知修饰符被忽略
+func ModifierFromString(modifiers []string) uint16 {
+	var result uint16
+	for _, mod := range modifiers {
+		result |= modifierAliases[strings.ToLower(strings.TrimSpace(mod))]
+	}
+	return result
+}
+
+// ParseHotkey 解析 "Ctrl+Alt+F7" 形式的热键字符串
+func ParseHotkey(s string) (HotkeyConfig, error) {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return HotkeyConfig{}, fmt.Errorf("热键为空")
+	}
+
+	// "Ctrl++" 表示以加号为按键
+	var parts []string
+	if strings.HasSuffix(s, "++") {
+		parts = append(strings.Split(strings.TrimSuffix(s, "++"), "+"), "+")
+	} else {
+		parts = strings.Split(s, "+")
+	}
+
+	keyPart := strings.TrimSpace(parts[len(parts)-1])
+	code := VirtualKeyFromString(keyPart)
+	if code == 0 {
+		return HotkeyConfig{}, fmt.Errorf("未知的按键: %q", keyPart)
+	}
+	keyName, _ := KeyName(code)
+
+	var flags uint16
+	for _, part := range parts[:len(parts)-1] {
+		flag, ok := modifierAliases[strings.ToLower(strings.TrimSpace(part))]
+		if !ok {
+			return HotkeyConfig{}, fmt.Errorf("未知的修饰键: %q", part)
+		}
+		flags |= flag
+	}
+
+	hotkey := HotkeyConfig{Modifiers: []string{}, Key: keyName}
+	for _, m := range modifierNames {
+		if flags&m.flag != 0 {
+			hotkey.Modifiers = append(hotkey.Modifiers, modifierConfigNames[m.flag])
+		}
+	}
+
+	if err := hotkey.Validate(); err != nil {
+		return HotkeyConfig{}, err
+	}
+	return hotkey, nil
+}
+
+// String 返回 "Ctrl+Alt+F7" 形式的热键字符串，可由 ParseHotkey 解析
+func (h HotkeyConfig) String() string {
+	if !h.Enabled() {
+		return ""
+	}
+
+	flags := ModifierFromString(h.Modifiers)
+	var parts []string
+	for _, m := range modifierNames {
+		if flags&m.flag != 0 {
+			parts = append(parts, m.name)
+		}
+	}
+
+	keyName, ok := KeyName(VirtualKeyFromString(h.Key))
+	if !ok {
+		keyName = h.Key
+	}
+	return strings.Join(append(parts, keyName), "+")
+}
+
+// Enabled 判断热键是否已设置按键
+func (h HotkeyConfig) Enabled() bool {
+	return h.Key != ""
+}
+
+// Validate 检查热键的按键和修饰键，未设置按键的热键视为有效
+func (h HotkeyConfig) Validate() error {
+	if !h.Enabled() {
+		return nil
+	}
+
+	if VirtualKeyFromString(h.Key) == 0 {
+		return fmt.Errorf("未知的按键: %q", h.Key)
+	}
+
+	for _, mod := range h.Modifiers {
+		if _, ok := modifierAliases[strings.ToLower(strings.TrimSpace(mod))]; !ok {
+			return fmt.Errorf("未知的修饰键: %q", mod)
+		}
+	}
+
+	if ModifierFromString(h.Modifiers) == 0 {
+		return fmt.Errorf("热键 %s 缺少修饰键", h.Key)
+	}
+
+	return nil
+}
+
+// Equals 判断两个 HotkeyConfig 实例是否相等
+func (h HotkeyConfig) Equals(other HotkeyConfig) bool {
+	// 比较按键，无法识别的按键按名称比较
+	code, otherCode := VirtualKeyFromString(h.Key), VirtualKeyFromString(other.Key)
+	if code != otherCode || (code == 0 && !strings.EqualFold(h.Key, other.Key)) {
+		return false
+	}
+
+	// 比较修饰符标志，忽略顺序和写法差异（如 "ctrl" 与 "control"）
+	return ModifierFromString(h.Modifiers) == ModifierFromString(other.Modifiers)
+}
@@ -0,0 +1,95 @@
+package config
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseHotkey(t *testing.T) {
+	tests := []struct {
+		input         string
+		wantModifiers []string
+		wantKey       string
+		wantString    string // 空表示解析失败
+	}{
+		{"Ctrl+Alt+T", []string{"control", "alt"}, "T", "Ctrl+Alt+T"},
+		{"alt+ctrl+t", []string{"control", "alt"}, "T", "Ctrl+Alt+T"},
+		{" Control + Shift + F7 ", []string{"control", "shift"}, "F7", "Ctrl+Shift+F7"},
+		{"Cmd+Option+Space", []string{"alt", "win"}, "Space", "Alt+Win+Space"},
+		{"Ctrl+Esc", []string{"control"}, "Escape", "Ctrl+Escape"},
+		{"Ctrl+PgDn", []string{"control"}, "PageDown", "Ctrl+PageDown"},
+		{"Ctrl+ArrowLeft", []string{"control"}, "Left", "Ctrl+Left"},
+		{"Ctrl+Num5", []string{"control"}, "Num5", "Ctrl+Num5"},
+		{"Ctrl+F24", []string{"control"}, "F24", "Ctrl+F24"},
+		{"Ctrl++", []string{"control"}, "=", "Ctrl+="},
+		{"Ctrl+Alt+/", []string{"control", "alt"}, "/", "Ctrl+Alt+/"},
+		{"Shift+MediaPlayPause", []string{"shift"}, "MediaPlayPause", "Shift+MediaPlayPause"},
+		{"", nil, "", ""},
+		{"T", nil, "", ""},           // 缺少修饰键
+		{"Ctrl+F25", nil, "", ""},    // 未知按键
+		{"Hyper+T", nil, "", ""},     // 未知修饰键
+		{"Ctrl+Alt", nil, "", ""},    // 修饰键不能作为按键
+		{"Ctrl+Alt+", nil, "", ""},   // 缺少按键
+		{"Ctrl+Num10", nil, "", ""},  // 未知按键
+		{"Ctrl+Alt+T+", nil, "", ""}, // 缺少按键
+	}
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			got, err := ParseHotkey(tt.input)
+			if tt.wantString == "" {
+				if err == nil {
+					t.Fatalf("ParseHotkey(%q) = %+v, want 错误", tt.input, got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ParseHotkey(%q): %v", tt.input, err)
+			}
+			if !reflect.DeepEqual(got.Modifiers, tt.wantModifiers) || got.Key != tt.wantKey {
+				t.Errorf("ParseHotkey(%q) = %v %q, want %v %q", tt.input, got.Modifiers, got.Key, tt.wantModifiers, tt.wantKey)
+			}
+			if s := got.String(); s != tt.wantString {
+				t.Errorf("String() = %q, want %q", s, tt.wantString)
+			}
+
+			// String 的结果可以再次解析为相同的热键
+			again, err := ParseHotkey(got.String())
+			if err != nil || !again.Equals(got) {
+				t.Errorf("ParseHotkey(%q) = %+v, %v, 与 %+v 不一致", got.String(), again, err, got)
+			}
+		})
+	}
+}
+
+func TestSupportedKeysRoundTrip(t *testing.T) {
+	for _, key := range SupportedKeys() {
+		hotkey := HotkeyConfig{Modifiers: []string{"control"}, Key: key.Name}
+		got, err := ParseHotkey(hotkey.String())
+		if err != nil {
+			t.Errorf("ParseHotkey(%q): %v", hotkey.String(), err)
+			continue
+		}
+		if VirtualKeyFromString(got.Key) != key.Code {
+			t.Errorf("%s 解析后的虚拟键码为 %#x, want %#x", key.Name, VirtualKeyFromString(got.Key), key.Code)
+		}
+	}
+}
+
+func TestHotkeyEquals(t *testing.T) {
+	tests := []struct {
+		a, b HotkeyConfig
+		want bool
+	}{
+		{HotkeyConfig{Modifiers: []string{"ctrl", "alt"}, Key: "t"}, HotkeyConfig{Modifiers: []string{"Alt", "control"}, Key: "T"}, true},
+		{HotkeyConfig{Modifiers: []string{"ctrl"}, Key: "Esc"}, HotkeyConfig{Modifiers: []string{"ctrl"}, Key: "Escape"}, true},
+		{HotkeyConfig{Modifiers: []string{"ctrl"}, Key: "t"}, HotkeyConfig{Modifiers: []string{"ctrl", "shift"}, Key: "t"}, false},
+		{HotkeyConfig{Modifiers: []string{"ctrl"}, Key: "t"}, HotkeyConfig{Modifiers: []string{"ctrl"}, Key: "y"}, false},
+		{HotkeyConfig{Modifiers: []string{"ctrl"}}, HotkeyConfig{Modifiers: []string{"alt"}}, false},
+		{HotkeyConfig{Modifiers: []string{"ctrl"}, Key: "bogus"}, HotkeyConfig{Modifiers: []string{"ctrl"}, Key: "BOGUS"}, true},
+	}
+	for _, tt := range tests {
+		if got := tt.a.Equals(tt.b); got != tt.want {
+			t.Errorf("%+v.Equals(%+v) = %v, want %v", tt.a, tt.b, got, tt.want)
+		}
+	}
+}
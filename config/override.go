@@ -0,0 +1,808 @@
+package config
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+
+	"clipboard-translate/constants"
+	log "clipboard-translate/utils/log"
+)
+
+// 配置值来源，优先级从低到高
+const (
+	SourceDefault = "default"
+	SourceFile    = "file"
+	SourceEnv     = "env"
+	SourceFlag    = "flag"
+)
+
+// 环境变量前缀，例如 CT_UI_PORT 对应 ui.port
+const envPrefix = "CT_"
+
+// 命令行或环境变量设置的配置覆盖值
+type override struct {
+	path   fieldPath
+	value  string
+	source string
+}
+
+var (
+	overrides  = make(map[string]override) // 字段路径 -> 覆盖值，启动时设置后只读
+	filePaths  = make(map[string]bool)     // 配置文件中出现的字段路径
+	fileConfig *Config                     // 未应用覆盖的文件配置，保存时用于还原被覆盖的字段
+)
+
+var (
+	configType       = reflect.TypeOf(Config{})
+	hotkeyConfigType = reflect.TypeOf(HotkeyConfig{})
+)
+
+// 旧版 api 分区中已移到 profiles 的字段，覆盖时作用于当前激活的AI配置，如 CT_API_PROVIDER
+var legacyAPIFields = []string{"provider", "api_key_ref", "model", "base_url", "use_env_key"}
+
+// 字段路径，按层级分段
+//
+// AI配置名称、模型名称等映射键可能包含点号，因此不用拼接后的字符串定位字段。
+type fieldPath []string
+
+func (p fieldPath) String() string {
+	return strings.Join(p, ".")
+}
+
+// 是否为旧版 api 分区的字段
+func (p fieldPath) legacyAPI() bool {
+	return len(p) == 2 && p[0] == "api" && contains(legacyAPIFields, p[1])
+}
+
+// 旧版 api 字段对应到指定AI配置的字段，其他路径原样返回
+func (p fieldPath) resolve(activeProfile string) fieldPath {
+	if !p.legacyAPI() {
+		return p
+	}
+	return fieldPath{"profiles", activeProfile, p[1]}
+}
+
+// SetConfigFile 设置配置文件路径，需在 LoadConfig 之前调用
+func SetConfigFile(path string) error {
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return err
+	}
+	configFile = abs
+	return nil
+}
+
+// ConfigFile 返回配置文件的绝对路径
+func ConfigFile() string {
+	if abs, err := filepath.Abs(configFile); err == nil {
+		return abs
+	}
+	return configFile
+}
+
+// FieldPaths 返回固定的配置字段路径，例如 ui.port、hotkeys.translate、api.provider
+//
+// 映射和列表中的条目（如 profiles.<名称>.model、budget.limits.<序号>.cost）不在其中，由 parseFlagPath 解析。
+func FieldPaths() []string {
+	var paths []string
+	collectPaths(configType, "", &paths)
+	for _, field := range legacyAPIFields {
+		paths = append(paths, "api."+field)
+	}
+	return paths
+}
+
+// 按JSON标签遍历结构体，收集叶子字段路径
+func collectPaths(t reflect.Type, prefix string, paths *[]string) {
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		name := jsonName(field)
+		if name == "" || (t == configType && name == "version") {
+			continue
+		}
+		path := joinPath(prefix, name)
+
+		switch {
+		case field.Type.Kind() == reflect.Map && field.Type.Elem() == hotkeyConfigType:
+			for _, action := range constants.HotkeyActions {
+				*paths = append(*paths, joinPath(path, action))
+			}
+		case field.Type.Kind() == reflect.Struct:
+			collectPaths(field.Type, path, paths)
+		case isLeafKind(field.Type):
+			*paths = append(*paths, path)
+		}
+	}
+}
+
+// 解析以点号分隔的字段路径，如 profiles.default.model、pricing.models.gpt-4.1.input
+func parseFlagPath(name string) (fieldPath, bool) {
+	return parsePath(name, ".", false)
+}
+
+// 按配置结构解析字段路径：sep 为分隔符，env 表示环境变量名（不区分大小写，映射键转为小写）
+func parsePath(name, sep string, env bool) (fieldPath, bool) {
+	if path, ok := resolvePath(configType, name, sep, env); ok {
+		return path, true
+	}
+	for _, field := range legacyAPIFields {
+		if rest, ok := cutName(name, "api", sep); ok && strings.EqualFold(rest, field) {
+			return fieldPath{"api", field}, true
+		}
+	}
+	return nil, false
+}
+
+// 在类型 t 中解析剩余的路径 rest
+func resolvePath(t reflect.Type, rest, sep string, env bool) (fieldPath, bool) {
+	switch {
+	case isLeafKind(t):
+		return fieldPath{}, rest == ""
+
+	case t.Kind() == reflect.Struct:
+		for i := 0; i < t.NumField(); i++ {
+			name := jsonName(t.Field(i))
+			if name == "" || (t == configType && name == "version") {
+				continue
+			}
+			tail, ok := cutName(rest, name, sep)
+			if !ok {
+				continue
+			}
+			if sub, ok := resolvePath(t.Field(i).Type, tail, sep, env); ok {
+				return append(fieldPath{name}, sub...), true
+			}
+		}
+
+	case t.Kind() == reflect.Map:
+		if rest == "" {
+			return nil, false
+		}
+		elem := t.Elem()
+		// 热键可以整体设置为组合键
+		if elem == hotkeyConfigType {
+			if action, ok := hotkeyAction(rest); ok {
+				return fieldPath{action}, true
+			}
+		} else if isLeafKind(elem) {
+			return fieldPath{mapKey(rest, env)}, true
+		}
+		// 键之后是条目的字段，从最短的字段路径开始尝试，键本身可以包含分隔符
+		for i := len(rest) - len(sep); i > 0; i-- {
+			if rest[i:i+len(sep)] != sep {
+				continue
+			}
+			key := rest[:i]
+			if elem == hotkeyConfigType {
+				action, ok := hotkeyAction(key)
+				if !ok {
+					continue
+				}
+				key = action
+			} else {
+				key = mapKey(key, env)
+			}
+			if sub, ok := resolvePath(elem, rest[i+len(sep):], sep, env); ok {
+				return append(fieldPath{key}, sub...), true
+			}
+		}
+
+	case t.Kind() == reflect.Slice && t.Elem().Kind() == reflect.Struct:
+		index, tail, _ := strings.Cut(rest, sep)
+		if n, err := strconv.Atoi(index); err != nil || n < 0 {
+			return nil, false
+		}
+		if sub, ok := resolvePath(t.Elem(), tail, sep, env); ok {
+			return append(fieldPath{index}, sub...), true
+		}
+	}
+	return nil, false
+}
+
+// 去掉开头的字段名和分隔符，字段名不区分大小写
+func cutName(rest, name, sep string) (string, bool) {
+	if len(rest) < len(name) || !strings.EqualFold(rest[:len(name)], name) {
+		return "", false
+	}
+	if len(rest) == len(name) {
+		return "", true
+	}
+	if !strings.HasPrefix(rest[len(name):], sep) {
+		return "", false
+	}
+	return rest[len(name)+len(sep):], true
+}
+
+// 环境变量名不区分大小写，其中的映射键统一转为小写
+func mapKey(key string, env bool) string {
+	if env {
+		return strings.ToLower(key)
+	}
+	return key
+}
+
+// 不区分大小写地匹配热键动作名称
+func hotkeyAction(name string) (string, bool) {
+	for _, action := range constants.HotkeyActions {
+		if strings.EqualFold(action, name) {
+			return action, true
+		}
+	}
+	return "", false
+}
+
+// 能以单个字符串表示的字段类型
+func isLeafKind(t reflect.Type) bool {
+	switch t.Kind() {
+	case reflect.String, reflect.Bool, reflect.Int, reflect.Int64, reflect.Float64:
+		return true
+	case reflect.Slice:
+		return t.Elem().Kind() == reflect.String
+	case reflect.Pointer:
+		return t.Elem().Kind() != reflect.Pointer && isLeafKind(t.Elem())
+	}
+	return false
+}
+
+// 字段的JSON名称，忽略的字段返回空
+func jsonName(field reflect.StructField) string {
+	name := strings.Split(field.Tag.Get("json"), ",")[0]
+	if name == "-" {
+		return ""
+	}
+	return name
+}
+
+// 按JSON名称查找结构体字段
+func fieldByName(v reflect.Value, name string) reflect.Value {
+	for i := 0; i < v.NumField(); i++ {
+		if jsonName(v.Type().Field(i)) == name {
+			return v.Field(i)
+		}
+	}
+	return reflect.Value{}
+}
+
+// 查找映射中的键：优先精确匹配，其次不区分大小写匹配已有的键（环境变量中的键为小写）
+func findKey(m reflect.Value, key string) reflect.Value {
+	k := reflect.ValueOf(key)
+	if m.IsNil() || m.MapIndex(k).IsValid() {
+		return k
+	}
+	for _, existing := range m.MapKeys() {
+		if strings.EqualFold(existing.String(), key) {
+			return existing
+		}
+	}
+	return k
+}
+
+// EnvName 返回字段路径对应的环境变量名
+func EnvName(path string) string {
+	return envPrefix + strings.ToUpper(strings.ReplaceAll(path, ".", "_"))
+}
+
+// 读取字段的字符串表示，映射中不存在的条目返回空字符串
+func getField(config *Config, path fieldPath) (string, error) {
+	v := reflect.ValueOf(config).Elem()
+	for i, part := range path {
+		switch v.Kind() {
+		case reflect.Struct:
+			v = fieldByName(v, part)
+		case reflect.Map:
+			v = v.MapIndex(findKey(v, part))
+			if !v.IsValid() {
+				return "", nil
+			}
+			if v.Type() == hotkeyConfigType && i == len(path)-1 {
+				return v.Interface().(HotkeyConfig).String(), nil
+			}
+		case reflect.Slice:
+			n, err := strconv.Atoi(part)
+			if err != nil || n < 0 {
+				return "", fmt.Errorf("未知配置项: %s", path)
+			}
+			if n >= v.Len() {
+				return "", nil
+			}
+			v = v.Index(n)
+		default:
+			v = reflect.Value{}
+		}
+		if !v.IsValid() {
+			return "", fmt.Errorf("未知配置项: %s", path)
+		}
+	}
+	if !isLeafKind(v.Type()) {
+		return "", fmt.Errorf("未知配置项: %s", path)
+	}
+	return formatLeaf(v), nil
+}
+
+// 叶子字段的字符串表示
+func formatLeaf(v reflect.Value) string {
+	switch v.Kind() {
+	case reflect.Pointer:
+		if v.IsNil() {
+			return ""
+		}
+		return formatLeaf(v.Elem())
+	case reflect.Slice:
+		return strings.Join(v.Interface().([]string), ",")
+	default:
+		return fmt.Sprint(v.Interface())
+	}
+}
+
+// 按字符串设置字段值，映射和列表中不存在的条目会被创建
+func setField(config *Config, path fieldPath, value string) error {
+	return setValue(reflect.ValueOf(config).Elem(), path, path, value)
+}
+
+func setValue(v reflect.Value, rest, path fieldPath, value string) error {
+	if len(rest) == 0 {
+		if !isLeafKind(v.Type()) {
+			return fmt.Errorf("未知配置项: %s", path)
+		}
+		return setLeaf(v, path, value)
+	}
+
+	switch v.Kind() {
+	case reflect.Struct:
+		field := fieldByName(v, rest[0])
+		if !field.IsValid() {
+			break
+		}
+		return setValue(field, rest[1:], path, value)
+
+	case reflect.Map:
+		// 映射的条目不可寻址，修改副本后写回
+		key := findKey(v, rest[0])
+		elem := reflect.New(v.Type().Elem()).Elem()
+		if !v.IsNil() {
+			if existing := v.MapIndex(key); existing.IsValid() {
+				elem.Set(existing)
+			}
+		}
+		if elem.Type() == hotkeyConfigType && len(rest) == 1 {
+			hotkey, err := parseHotkeyValue(path, value)
+			if err != nil {
+				return err
+			}
+			// 保留该动作的AI配置和模板
+			current := elem.Interface().(HotkeyConfig)
+			hotkey.Profile, hotkey.Template = current.Profile, current.Template
+			elem.Set(reflect.ValueOf(hotkey))
+		} else if err := setValue(elem, rest[1:], path, value); err != nil {
+			return err
+		}
+		if v.IsNil() {
+			v.Set(reflect.MakeMap(v.Type()))
+		}
+		v.SetMapIndex(key, elem)
+		return nil
+
+	case reflect.Slice:
+		n, err := strconv.Atoi(rest[0])
+		if err != nil || n < 0 {
+			break
+		}
+		// 跳过的序号补为空条目，由配置校验报告
+		for v.Len() <= n {
+			v.Set(reflect.Append(v, reflect.Zero(v.Type().Elem())))
+		}
+		return setValue(v.Index(n), rest[1:], path, value)
+	}
+	return fmt.Errorf("未知配置项: %s", path)
+}
+
+// 热键使用 Ctrl+Alt+T 形式，空字符串表示禁用
+func parseHotkeyValue(path fieldPath, value string) (HotkeyConfig, error) {
+	if strings.TrimSpace(value) == "" {
+		return HotkeyConfig{}, nil
+	}
+	hotkey, err := ParseHotkey(value)
+	if err != nil {
+		return HotkeyConfig{}, fmt.Errorf("%s: %w", path, err)
+	}
+	return hotkey, nil
+}
+
+func setLeaf(v reflect.Value, path fieldPath, value string) error {
+	switch v.Kind() {
+	case reflect.Pointer:
+		// 空字符串表示使用默认值
+		if value == "" {
+			v.Set(reflect.Zero(v.Type()))
+			return nil
+		}
+		elem := reflect.New(v.Type().Elem())
+		if err := setLeaf(elem.Elem(), path, value); err != nil {
+			return err
+		}
+		v.Set(elem)
+	case reflect.String:
+		v.SetString(value)
+	case reflect.Bool:
+		b, err := strconv.ParseBool(value)
+		if err != nil {
+			return fmt.Errorf("%s: 无效的布尔值 %q", path, value)
+		}
+		v.SetBool(b)
+	case reflect.Int, reflect.Int64:
+		n, err := strconv.ParseInt(value, 10, 64)
+		if err != nil {
+			return fmt.Errorf("%s: 无效的整数 %q", path, value)
+		}
+		v.SetInt(n)
+	case reflect.Float64:
+		f, err := strconv.ParseFloat(value, 64)
+		if err != nil {
+			return fmt.Errorf("%s: 无效的数字 %q", path, value)
+		}
+		v.SetFloat(f)
+	case reflect.Slice:
+		var items []string
+		for _, item := range strings.Split(value, ",") {
+			if item = strings.TrimSpace(item); item != "" {
+				items = append(items, item)
+			}
+		}
+		v.Set(reflect.ValueOf(items))
+	}
+	return nil
+}
+
+// 收集配置中所有叶子字段的路径，包括映射和列表中的条目；热键作为整体
+func valuePaths(v reflect.Value, prefix fieldPath, paths *[]fieldPath) {
+	path := func(part string) fieldPath {
+		return append(append(fieldPath{}, prefix...), part)
+	}
+	switch {
+	case isLeafKind(v.Type()):
+		*paths = append(*paths, prefix)
+	case v.Kind() == reflect.Struct:
+		for i := 0; i < v.NumField(); i++ {
+			name := jsonName(v.Type().Field(i))
+			if name == "" || (v.Type() == configType && name == "version") {
+				continue
+			}
+			valuePaths(v.Field(i), path(name), paths)
+		}
+	case v.Kind() == reflect.Map:
+		keys := v.MapKeys()
+		sort.Slice(keys, func(i, j int) bool { return keys[i].String() < keys[j].String() })
+		for _, key := range keys {
+			if v.Type().Elem() == hotkeyConfigType {
+				*paths = append(*paths, path(key.String()))
+				continue
+			}
+			valuePaths(v.MapIndex(key), path(key.String()), paths)
+		}
+	case v.Kind() == reflect.Slice:
+		for i := 0; i < v.Len(); i++ {
+			valuePaths(v.Index(i), path(strconv.Itoa(i)), paths)
+		}
+	}
+}
+
+// 配置中所有字段的路径
+func configPaths(config *Config) []fieldPath {
+	var paths []fieldPath
+	valuePaths(reflect.ValueOf(config).Elem(), fieldPath{}, &paths)
+	return paths
+}
+
+// 收集配置文件中出现的已知字段路径
+func presentFields(value any, t reflect.Type, prefix fieldPath, present map[string]bool) {
+	path := func(part string) fieldPath {
+		return append(append(fieldPath{}, prefix...), part)
+	}
+	switch {
+	case t.Kind() == reflect.Map:
+		obj, ok := value.(map[string]any)
+		if !ok {
+			return
+		}
+		for key, child := range obj {
+			if t.Elem() == hotkeyConfigType || isLeafKind(t.Elem()) {
+				present[path(key).String()] = true
+			} else {
+				presentFields(child, t.Elem(), path(key), present)
+			}
+		}
+	case t.Kind() == reflect.Slice && t.Elem().Kind() == reflect.Struct:
+		list, ok := value.([]any)
+		if !ok {
+			return
+		}
+		for i, child := range list {
+			presentFields(child, t.Elem(), path(strconv.Itoa(i)), present)
+		}
+	case t.Kind() == reflect.Struct:
+		obj, ok := value.(map[string]any)
+		if !ok {
+			return
+		}
+		for i := 0; i < t.NumField(); i++ {
+			field := t.Field(i)
+			name := jsonName(field)
+			if name == "" {
+				continue
+			}
+			for key, child := range obj {
+				// encoding/json 匹配字段名时不区分大小写
+				if !strings.EqualFold(key, name) {
+					continue
+				}
+				if isLeafKind(field.Type) {
+					present[path(name).String()] = true
+				} else {
+					presentFields(child, field.Type, path(name), present)
+				}
+			}
+		}
+	}
+}
+
+// flagValue 将命令行参数记录为覆盖值
+type flagValue struct {
+	path fieldPath
+}
+
+func (f flagValue) String() string {
+	return ""
+}
+
+func (f flagValue) Set(value string) error {
+	// 提前解析一次，尽早报告格式错误
+	if err := setField(defaultConfig(), f.path.resolve(DefaultProfile), value); err != nil {
+		return err
+	}
+	overrides[f.path.String()] = override{path: f.path, value: value, source: SourceFlag}
+	return nil
+}
+
+// RegisterFlags 为每个配置项注册命令行参数（如 --ui.port），以及 --config 配置文件路径和 --port
+//
+// 映射和列表中的条目（如 --profiles.default.model）无法预先列出，按 args 中出现的参数注册。
+func RegisterFlags(fs *flag.FlagSet, args []string) {
+	fs.Func("config", "配置文件路径 (默认 config.json)", SetConfigFile)
+	fs.Var(flagValue{path: fieldPath{"ui", "port"}}, "port", "同 --ui.port，0 表示自动选择空闲端口")
+	for _, name := range FieldPaths() {
+		path, _ := parseFlagPath(name)
+		usage := fmt.Sprintf("覆盖配置项 %s (环境变量 %s)", name, EnvName(name))
+		if path.legacyAPI() {
+			usage = fmt.Sprintf("覆盖当前激活的AI配置的 %s (环境变量 %s)", path[1], EnvName(name))
+		}
+		fs.Var(flagValue{path: path}, name, usage)
+	}
+
+	for _, arg := range args {
+		if arg == "--" {
+			break
+		}
+		if !strings.HasPrefix(arg, "-") {
+			continue
+		}
+		name, _, _ := strings.Cut(strings.TrimLeft(arg, "-"), "=")
+		if fs.Lookup(name) != nil {
+			continue
+		}
+		if path, ok := parseFlagPath(name); ok {
+			fs.Var(flagValue{path: path}, name, "覆盖配置项 "+name)
+		}
+	}
+}
+
+// LoadEnv 读取 CT_ 前缀的环境变量作为覆盖值，命令行参数优先
+//
+// 应在解析命令行参数之前调用；CT_CONFIG 指定配置文件路径。无法识别的 CT_ 环境变量记录警告。
+func LoadEnv() error {
+	if path := os.Getenv(envPrefix + "CONFIG"); path != "" {
+		if err := SetConfigFile(path); err != nil {
+			return err
+		}
+	}
+
+	environ := os.Environ()
+	sort.Strings(environ)
+	for _, kv := range environ {
+		name, value, _ := strings.Cut(kv, "=")
+		if !strings.HasPrefix(name, envPrefix) || name == envPrefix+"CONFIG" {
+			continue
+		}
+		path, ok := parsePath(strings.TrimPrefix(name, envPrefix), "_", true)
+		if !ok {
+			log.Warn("忽略未知的环境变量 %s", name)
+			continue
+		}
+		if err := setField(defaultConfig(), path.resolve(DefaultProfile), value); err != nil {
+			return fmt.Errorf("环境变量 %s: %w", name, err)
+		}
+		if _, set := overrides[path.String()]; !set {
+			overrides[path.String()] = override{path: path, value: value, source: SourceEnv}
+		}
+	}
+	return nil
+}
+
+// 字段被覆盖时返回覆盖来源的说明，如“环境变量 CT_API_ACTIVE_PROFILE”
+func overriddenBy(path string) (string, bool) {
+	o, ok := overrides[path]
+	if !ok {
+		return "", false
+	}
+	if o.source == SourceFlag {
+		return "命令行参数 --" + path, true
+	}
+	return "环境变量 " + EnvName(path), true
+}
+
+// 是否通过 --port 0 或 CT_UI_PORT=0 要求自动选择空闲端口
+func autoPort() bool {
+	o, ok := overrides["ui.port"]
+	if !ok {
+		return false
+	}
+	port, err := strconv.Atoi(o.value)
+	return err == nil && port == 0
+}
+
+// Clone 返回配置的深拷贝，修改拷贝不会影响当前配置
+func (c *Config) Clone() *Config {
+	data, err := json.Marshal(c)
+	if err != nil {
+		panic(err)
+	}
+	var clone Config
+	if err := json.Unmarshal(data, &clone); err != nil {
+		panic(err)
+	}
+	return &clone
+}
+
+// 按应用顺序排列的覆盖值：环境变量先于命令行参数，旧版 api 字段在确定激活的AI配置之后应用
+func sortedOverrides() []override {
+	list := make([]override, 0, len(overrides))
+	for _, o := range overrides {
+		list = append(list, o)
+	}
+	rank := func(o override) int {
+		r := 0
+		if o.source == SourceFlag {
+			r += 2
+		}
+		if o.path.legacyAPI() {
+			r += 4
+		}
+		return r
+	}
+	sort.Slice(list, func(i, j int) bool {
+		if ri, rj := rank(list[i]), rank(list[j]); ri != rj {
+			return ri < rj
+		}
+		return list[i].path.String() < list[j].path.String()
+	})
+	return list
+}
+
+// 在文件配置之上应用覆盖值，返回新的有效配置
+func withOverrides(config *Config) *Config {
+	if len(overrides) == 0 {
+		return config
+	}
+	effective := config.Clone()
+	for _, o := range sortedOverrides() {
+		// 覆盖值在注册时已校验过格式
+		_ = setField(effective, o.path.resolve(effective.API.ActiveProfile), o.value)
+	}
+	return effective
+}
+
+// 保存前还原被覆盖的字段，避免把命令行或环境变量的值写入配置文件
+func withoutOverrides(config, file *Config) *Config {
+	if len(overrides) == 0 || file == nil {
+		return config
+	}
+	stripped := config.Clone()
+	active := config.API.ActiveProfile
+	for _, o := range overrides {
+		restoreValue(reflect.ValueOf(stripped).Elem(), reflect.ValueOf(file).Elem(), o.path.resolve(active))
+	}
+	return stripped
+}
+
+// 把 dst 中路径对应的值还原为 src 中的值，src 中不存在的映射条目从 dst 中删除
+func restoreValue(dst, src reflect.Value, rest fieldPath) {
+	if !src.IsValid() {
+		src = reflect.Zero(dst.Type())
+	}
+	if len(rest) == 0 {
+		dst.Set(src)
+		return
+	}
+
+	switch dst.Kind() {
+	case reflect.Struct:
+		if field := fieldByName(dst, rest[0]); field.IsValid() {
+			restoreValue(field, fieldByName(src, rest[0]), rest[1:])
+		}
+	case reflect.Map:
+		if dst.IsNil() {
+			return
+		}
+		key := findKey(dst, rest[0])
+		current := dst.MapIndex(key)
+		var original reflect.Value
+		if !src.IsNil() {
+			original = src.MapIndex(findKey(src, rest[0]))
+		}
+		switch {
+		case len(rest) == 1 && !original.IsValid():
+			// 条目由覆盖值创建
+			dst.SetMapIndex(key, reflect.Value{})
+		case len(rest) == 1:
+			// 热键直接复制原条目，保留未启用热键的修饰键
+			dst.SetMapIndex(key, original)
+		case current.IsValid():
+			elem := reflect.New(dst.Type().Elem()).Elem()
+			elem.Set(current)
+			restoreValue(elem, original, rest[1:])
+			dst.SetMapIndex(key, elem)
+		}
+	case reflect.Slice:
+		n, err := strconv.Atoi(rest[0])
+		if err != nil || n < 0 || n >= dst.Len() {
+			return
+		}
+		var original reflect.Value
+		if n < src.Len() {
+			original = src.Index(n)
+		}
+		restoreValue(dst.Index(n), original, rest[1:])
+	}
+}
+
+// EffectiveField 有效配置项及其来源
+type EffectiveField struct {
+	Path   string `json:"path"`
+	Value  string `json:"value"`
+	Source string `json:"source"` // default、file、env 或 flag
+}
+
+// Effective 返回当前有效配置的每个字段及其来源，按路径排序
+func Effective() []EffectiveField {
+	config := GetConfig()
+
+	configMutex.RLock()
+	defer configMutex.RUnlock()
+
+	// 旧版 api 字段的来源记在其对应的AI配置字段上；环境变量中的映射键为小写，不区分大小写匹配
+	sources := make(map[string]string)
+	for _, o := range sortedOverrides() {
+		sources[strings.ToLower(o.path.resolve(config.API.ActiveProfile).String())] = o.source
+	}
+
+	paths := configPaths(config)
+	fields := make([]EffectiveField, 0, len(paths))
+	for _, path := range paths {
+		value, _ := getField(config, path)
+		source := SourceDefault
+		if s, ok := sources[strings.ToLower(path.String())]; ok {
+			source = s
+		} else if filePaths[path.String()] {
+			source = SourceFile
+		}
+		fields = append(fields, EffectiveField{Path: path.String(), Value: value, Source: source})
+	}
+	sort.Slice(fields, func(i, j int) bool { return fields[i].Path < fields[j].Path })
+	return fields
+}
@@ -0,0 +1,291 @@
+package config
+
+import (
+	"errors"
+	"flag"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// 在测试期间替换覆盖值
+func setOverrides(t *testing.T, list ...override) {
+	t.Helper()
+	saved := overrides
+	overrides = make(map[string]override)
+	for _, o := range list {
+		overrides[o.path.String()] = o
+	}
+	t.Cleanup(func() { overrides = saved })
+}
+
+func TestParsePath(t *testing.T) {
+	tests := []struct {
+		name string
+		sep  string
+		env  bool
+		want fieldPath // nil 表示无法识别
+	}{
+		{"ui.port", ".", false, fieldPath{"ui", "port"}},
+		{"hotkeys.translateAlt", ".", false, fieldPath{"hotkeys", "translateAlt"}},
+		{"hotkeys.translate.profile", ".", false, fieldPath{"hotkeys", "translate", "profile"}},
+		{"profiles.default.model", ".", false, fieldPath{"profiles", "default", "model"}},
+		{"profiles.my.profile.top_p", ".", false, fieldPath{"profiles", "my.profile", "top_p"}},
+		{"templates.legal", ".", false, fieldPath{"templates", "legal"}},
+		{"pricing.models.gpt-4.1.input", ".", false, fieldPath{"pricing", "models", "gpt-4.1", "input"}},
+		{"translation.rate_limits.openai", ".", false, fieldPath{"translation", "rate_limits", "openai"}},
+		{"translation.glossary.cloud", ".", false, fieldPath{"translation", "glossary", "cloud"}},
+		{"budget.limits.0.cost", ".", false, fieldPath{"budget", "limits", "0", "cost"}},
+		{"api.provider", ".", false, fieldPath{"api", "provider"}},
+		{"UI_PORT", "_", true, fieldPath{"ui", "port"}},
+		{"HOTKEYS_TRANSLATEALT", "_", true, fieldPath{"hotkeys", "translateAlt"}},
+		{"PROFILES_MY_PROFILE_BASE_URL", "_", true, fieldPath{"profiles", "my_profile", "base_url"}},
+		{"PROFILES_DEFAULT_API_KEY_REF", "_", true, fieldPath{"profiles", "default", "api_key_ref"}},
+		{"TRANSLATION_RATE_LIMITS_OPENAI", "_", true, fieldPath{"translation", "rate_limits", "openai"}},
+		{"BUDGET_LIMITS_1_WARN_AT", "_", true, fieldPath{"budget", "limits", "1", "warn_at"}},
+		{"API_PROVIDER", "_", true, fieldPath{"api", "provider"}},
+		{"API_USE_ENV_KEY", "_", true, fieldPath{"api", "use_env_key"}},
+		{"version", ".", false, nil},
+		{"ui", ".", false, nil},
+		{"ui.unknown", ".", false, nil},
+		{"profiles.default", ".", false, nil},
+		{"profiles.default.unknown", ".", false, nil},
+		{"budget.limits.x.cost", ".", false, nil},
+		{"hotkeys.unknown", ".", false, nil},
+		{"BOGUS", "_", true, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := parsePath(tt.name, tt.sep, tt.env)
+			if tt.want == nil {
+				if ok {
+					t.Fatalf("parsePath(%q) = %v, want 无法识别", tt.name, got)
+				}
+				return
+			}
+			if !ok || !reflect.DeepEqual(got, tt.want) {
+				t.Fatalf("parsePath(%q) = %v, %v, want %v", tt.name, got, ok, tt.want)
+			}
+		})
+	}
+}
+
+func TestSetAndGetField(t *testing.T) {
+	tests := []struct {
+		path  fieldPath
+		value string
+		want  string // 读回的值
+	}{
+		{fieldPath{"ui", "port"}, "9000", "9000"},
+		{fieldPath{"profiles", "default", "model"}, "gpt-4o", "gpt-4o"},
+		{fieldPath{"profiles", "new", "temperature"}, "0.3", "0.3"},
+		{fieldPath{"profiles", "default", "stop"}, "a, b", "a,b"},
+		{fieldPath{"templates", "legal"}, "{{.TargetLanguage}}", "{{.TargetLanguage}}"},
+		{fieldPath{"pricing", "models", "gpt-4.1", "output"}, "8", "8"},
+		{fieldPath{"translation", "rate_limits", "openai"}, "60", "60"},
+		{fieldPath{"budget", "limits", "0", "period"}, "day", "day"},
+		{fieldPath{"hotkeys", "polish"}, "Ctrl+Alt+P", "Ctrl+Alt+P"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.path.String(), func(t *testing.T) {
+			config := defaultConfig()
+			if err := setField(config, tt.path, tt.value); err != nil {
+				t.Fatal(err)
+			}
+			got, err := getField(config, tt.path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != tt.want {
+				t.Errorf("getField = %q, want %q", got, tt.want)
+			}
+		})
+	}
+
+	for _, tt := range []struct {
+		path  fieldPath
+		value string
+	}{
+		{fieldPath{"ui", "port"}, "abc"},
+		{fieldPath{"profiles", "default", "temperature"}, "hot"},
+		{fieldPath{"hotkeys", "translate"}, "Ctrl+Nope"},
+		{fieldPath{"ui", "nope"}, "1"},
+	} {
+		if err := setField(defaultConfig(), tt.path, tt.value); err == nil {
+			t.Errorf("setField(%s, %q) 应返回错误", tt.path, tt.value)
+		}
+	}
+}
+
+func TestSetFieldKeepsHotkeyProfile(t *testing.T) {
+	config := defaultConfig()
+	config.Hotkeys["translate"] = HotkeyConfig{Modifiers: []string{"control"}, Key: "t", Profile: "fast", Template: "legal"}
+	if err := setField(config, fieldPath{"hotkeys", "translate"}, "Alt+Y"); err != nil {
+		t.Fatal(err)
+	}
+	got := config.Hotkeys["translate"]
+	if got.Key != "Y" || !reflect.DeepEqual(got.Modifiers, []string{"alt"}) || got.Profile != "fast" || got.Template != "legal" {
+		t.Errorf("热键为 %#v，应保留AI配置和模板", got)
+	}
+}
+
+func TestWithOverridesMapEntries(t *testing.T) {
+	file := defaultConfig()
+	file.Profiles["Document"] = ProfileConfig{Provider: "claude", Model: "file-model"}
+	file.API.ActiveProfile = "Document"
+	setOverrides(t,
+		override{path: fieldPath{"profiles", "document", "model"}, value: "env-model", source: SourceEnv},
+		override{path: fieldPath{"api", "base_url"}, value: "http://flag", source: SourceFlag},
+		override{path: fieldPath{"templates", "legal"}, value: "legal", source: SourceFlag},
+	)
+
+	effective := withOverrides(file)
+	profile := effective.Profiles["Document"]
+	if profile.Model != "env-model" {
+		t.Errorf("环境变量中的小写键应匹配已有的AI配置，model = %q", profile.Model)
+	}
+	if profile.BaseURL != "http://flag" {
+		t.Errorf("api.base_url 应覆盖当前激活的AI配置，base_url = %q", profile.BaseURL)
+	}
+	if _, ok := effective.Profiles["document"]; ok {
+		t.Error("不应创建小写名称的AI配置")
+	}
+	if file.Profiles["Document"].Model != "file-model" {
+		t.Error("withOverrides 不应修改文件配置")
+	}
+
+	// 保存时还原为文件中的值，由覆盖值创建的条目不写入文件
+	saved := effective.Clone()
+	saved.Profiles["Document"] = ProfileConfig{Provider: "claude", Model: "env-model", BaseURL: "http://flag", Timeout: 5}
+	stripped := withoutOverrides(saved, file)
+	got := stripped.Profiles["Document"]
+	if got.Model != "file-model" || got.BaseURL != "" || got.Timeout != 5 {
+		t.Errorf("还原后的AI配置为 %+v", got)
+	}
+	if _, ok := stripped.Templates["legal"]; ok {
+		t.Error("由覆盖值创建的模板不应写入文件")
+	}
+}
+
+// 在临时目录中写入配置文件并加载，测试结束后恢复全局状态
+func loadTestConfig(t *testing.T, content string) {
+	t.Helper()
+	savedFile, savedInstance, savedFileConfig, savedPaths := configFile, configInstance, fileConfig, filePaths
+	t.Cleanup(func() {
+		configFile, configInstance, fileConfig, filePaths = savedFile, savedInstance, savedFileConfig, savedPaths
+	})
+
+	path := filepath.Join(t.TempDir(), "config.json")
+	if err := os.WriteFile(path, []byte(content), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if err := SetConfigFile(path); err != nil {
+		t.Fatal(err)
+	}
+	if err := LoadConfig(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestOverridePrecedence(t *testing.T) {
+	setOverrides(t)
+	t.Setenv("CT_UI_PORT", "9100")
+	t.Setenv("CT_SYSTEM_LOG_LEVEL", "warning")
+	t.Setenv("CT_PROFILES_DEFAULT_MODEL", "env-model")
+	if err := LoadEnv(); err != nil {
+		t.Fatal(err)
+	}
+	args := []string{"--ui.port=9200", "--profiles.default.model", "flag-model"}
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	RegisterFlags(fs, args)
+	if err := fs.Parse(args); err != nil {
+		t.Fatal(err)
+	}
+	loadTestConfig(t, `{"version": 3, "ui": {"port": 9000, "theme": "dark"}, "system": {"log_level": "debug"},
+		"profiles": {"default": {"provider": "openai", "model": "file-model", "use_env_key": true}}}`)
+
+	tests := []struct {
+		path   string
+		value  string
+		source string
+	}{
+		{"ui.port", "9200", SourceFlag},
+		{"profiles.default.model", "flag-model", SourceFlag},
+		{"system.log_level", "warning", SourceEnv},
+		{"ui.theme", "dark", SourceFile},
+		{"profiles.default.provider", "openai", SourceFile},
+		{"translation.chunk_concurrency", "3", SourceDefault},
+	}
+	fields := make(map[string]EffectiveField)
+	for _, f := range Effective() {
+		fields[f.Path] = f
+	}
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			got, ok := fields[tt.path]
+			if !ok {
+				t.Fatalf("有效配置中缺少 %s", tt.path)
+			}
+			if got.Value != tt.value || got.Source != tt.source {
+				t.Errorf("%s = %q (%s), want %q (%s)", tt.path, got.Value, got.Source, tt.value, tt.source)
+			}
+		})
+	}
+
+	// 保存时被覆盖的字段保留文件中的值
+	config := GetConfig().Clone()
+	config.UI.Theme = "light"
+	if err := SaveConfig(config); err != nil {
+		t.Fatal(err)
+	}
+	file, _, _, err := readConfigFile()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if file.UI.Port != 9000 || file.System.LogLevel != "debug" || file.Profiles["default"].Model != "file-model" || file.UI.Theme != "light" {
+		t.Errorf("写入文件的配置为 ui=%+v system=%+v profile=%+v", file.UI, file.System, file.Profiles["default"])
+	}
+	if GetConfig().UI.Port != 9200 {
+		t.Errorf("保存后有效端口为 %d, want 9200", GetConfig().UI.Port)
+	}
+}
+
+func TestSetActiveProfileOverridden(t *testing.T) {
+	setupVault(t)
+	t.Setenv("AI_API_KEY", "test-key")
+	loadTestConfig(t, `{"version": 3, "api": {"active_profile": "a"}, "profiles": {
+		"a": {"provider": "openai", "use_env_key": true}, "b": {"provider": "openai", "use_env_key": true}}}`)
+
+	tests := []struct {
+		name   string
+		source string
+		want   string // 错误信息中的覆盖来源
+	}{
+		{"未覆盖", "", ""},
+		{"命令行参数", SourceFlag, "--api.active_profile"},
+		{"环境变量", SourceEnv, "CT_API_ACTIVE_PROFILE"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.source == "" {
+				setOverrides(t)
+			} else {
+				setOverrides(t, override{path: fieldPath{"api", "active_profile"}, value: "a", source: tt.source})
+			}
+
+			err := SetActiveProfile("b")
+			if tt.want == "" {
+				if err != nil {
+					t.Fatalf("SetActiveProfile() error = %v", err)
+				}
+				return
+			}
+			var verr *ValidationError
+			if !errors.As(err, &verr) || !hasFieldError(verr, "api.active_profile") || !strings.Contains(verr.Error(), tt.want) {
+				t.Fatalf("SetActiveProfile() error = %v, want 提示 api.active_profile 已由 %s 指定", err, tt.want)
+			}
+		})
+	}
+}
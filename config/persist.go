@@ -0,0 +1,333 @@
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+
+	"clipboard-translate/secrets"
+)
+
+// CurrentVersion 当前配置文件结构版本
+const CurrentVersion = 3
+
+// 保留的配置备份数量
+const maxBackups = 3
+
+// migration 将配置从某个版本升级到下一个版本，直接修改原始JSON对象；
+// 需要移入密钥存储的明文密钥记录到 keys 中，由调用方决定何时写入
+type migration func(raw map[string]any, keys map[string]string) error
+
+// 按版本排列的迁移函数，migrations[i] 将版本 i 升级到 i+1
+var migrations = []migration{
+	migrateV0ToV1,
+	migrateV1ToV2,
+	migrateV2ToV3,
+}
+
+// v0 -> v1: 旧版设置页面使用的字段
+//   - api.gemini_key 改为 api.api_key
+//   - database.max_history 改为 system.max_history_items
+//   - 移除从未生效的 ui.start_minimized
+func migrateV0ToV1(raw map[string]any, _ map[string]string) error {
+	if api, ok := raw["api"].(map[string]any); ok {
+		if key, ok := api["gemini_key"]; ok {
+			if existing, _ := api["api_key"].(string); existing == "" {
+				api["api_key"] = key
+			}
+			delete(api, "gemini_key")
+		}
+	}
+
+	if database, ok := raw["database"].(map[string]any); ok {
+		if maxHistory, ok := database["max_history"]; ok {
+			system, _ := raw["system"].(map[string]any)
+			if system == nil {
+				system = make(map[string]any)
+				raw["system"] = system
+			}
+			if _, exists := system["max_history_items"]; !exists {
+				system["max_history_items"] = maxHistory
+			}
+			delete(database, "max_history")
+		}
+	}
+
+	if ui, ok := raw["ui"].(map[string]any); ok {
+		delete(ui, "start_minimized")
+	}
+	return nil
+}
+
+// v1 -> v2: 明文的 api.api_key 移入密钥存储，按提供商名称保存
+func migrateV1ToV2(raw map[string]any, keys map[string]string) error {
+	api, ok := raw["api"].(map[string]any)
+	if !ok {
+		return nil
+	}
+
+	key, _ := api["api_key"].(string)
+	delete(api, "api_key")
+	if key == "" {
+		return nil
+	}
+
+	provider, _ := api["provider"].(string)
+	name := ProfileConfig{Provider: provider}.KeyName()
+	if name == "" {
+		name = "default"
+		api["api_key_ref"] = name
+	}
+	keys[name] = key
+	return nil
+}
+
+// v2 -> v3: api 中的提供商设置移入名为 default 的AI配置
+func migrateV2ToV3(raw map[string]any, _ map[string]string) error {
+	api, _ := raw["api"].(map[string]any)
+	if api == nil {
+		api = make(map[string]any)
+		raw["api"] = api
+	}
+
+	profile := make(map[string]any)
+	for _, key := range []string{"provider", "api_key_ref", "model", "base_url", "use_env_key"} {
+		if value, ok := api[key]; ok {
+			profile[key] = value
+			delete(api, key)
+		}
+	}
+
+	profiles, _ := raw["profiles"].(map[string]any)
+	if profiles == nil {
+		profiles = make(map[string]any)
+		raw["profiles"] = profiles
+	}
+	if _, exists := profiles[DefaultProfile]; !exists {
+		profiles[DefaultProfile] = profile
+	}
+	if _, ok := api["active_profile"]; !ok {
+		api["active_profile"] = DefaultProfile
+	}
+	return nil
+}
+
+// 执行迁移，返回原始版本及需要写入密钥存储的明文密钥
+func migrate(raw map[string]any) (int, map[string]string, error) {
+	version := 0
+	if v, ok := raw["version"].(float64); ok {
+		version = int(v)
+	}
+
+	if version > CurrentVersion {
+		return version, nil, fmt.Errorf("配置文件版本 %d 高于程序支持的版本 %d", version, CurrentVersion)
+	}
+
+	keys := make(map[string]string)
+	for v := version; v < CurrentVersion; v++ {
+		if err := migrations[v](raw, keys); err != nil {
+			return version, nil, err
+		}
+	}
+	raw["version"] = CurrentVersion
+	return version, keys, nil
+}
+
+// 将迁移出的明文密钥写入密钥存储
+func storeKeys(keys map[string]string) error {
+	if len(keys) == 0 {
+		return nil
+	}
+	store := secrets.Default()
+	if store == nil {
+		return secrets.ErrNoStore
+	}
+	for _, name := range sortedKeys(keys) {
+		if err := store.Set(name, keys[name]); err != nil {
+			return fmt.Errorf("迁移API密钥到密钥存储失败: %w", err)
+		}
+	}
+	return nil
+}
+
+// 配置解析的附加信息
+type decodeInfo struct {
+	fromVersion int               // 迁移前的版本
+	unknown     []string          // 未知字段路径
+	present     map[string]bool   // 文件中出现的字段路径
+	keys        map[string]string // 迁移出的明文密钥，尚未写入密钥存储
+}
+
+// 解析配置JSON：执行版本迁移并找出未知字段
+func decodeConfig(data []byte) (*Config, decodeInfo, error) {
+	var info decodeInfo
+
+	var raw map[string]any
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return nil, info, err
+	}
+	if raw == nil {
+		return nil, info, fmt.Errorf("配置必须是JSON对象")
+	}
+
+	fromVersion, keys, err := migrate(raw)
+	if err != nil {
+		return nil, info, err
+	}
+	info.fromVersion = fromVersion
+	info.keys = keys
+	info.unknown = unknownFields(raw, reflect.TypeOf(Config{}), "")
+	info.present = make(map[string]bool)
+	presentFields(raw, configType, nil, info.present)
+
+	migrated, err := json.Marshal(raw)
+	if err != nil {
+		return nil, info, err
+	}
+
+	var config Config
+	if err := json.Unmarshal(migrated, &config); err != nil {
+		return nil, info, err
+	}
+	return &config, info, nil
+}
+
+// DecodeConfig 解析外部提交的配置，执行版本迁移，存在未知字段时返回 *ValidationError
+//
+// 旧版本中的明文密钥不会立即写入密钥存储，而是在 SaveConfig 保存成功后写入，
+// 校验或保存失败的提交不会留下密钥。
+func DecodeConfig(data []byte) (*Config, error) {
+	config, info, err := decodeConfig(data)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(info.unknown) > 0 {
+		verr := &ValidationError{}
+		for _, field := range info.unknown {
+			verr.add(field, "未知字段")
+		}
+		return nil, verr
+	}
+	config.pendingKeys = info.keys
+	return config, nil
+}
+
+// 按JSON标签比对原始对象与结构体类型，返回未知字段路径
+func unknownFields(value any, t reflect.Type, prefix string) []string {
+	var unknown []string
+
+	switch t.Kind() {
+	case reflect.Struct:
+		obj, ok := value.(map[string]any)
+		if !ok {
+			return nil
+		}
+
+		fields := make(map[string]reflect.Type)
+		for i := 0; i < t.NumField(); i++ {
+			field := t.Field(i)
+			name := strings.Split(field.Tag.Get("json"), ",")[0]
+			if name == "" || name == "-" {
+				continue
+			}
+			fields[strings.ToLower(name)] = field.Type
+		}
+
+		for _, key := range sortedKeys(obj) {
+			path := joinPath(prefix, key)
+			// encoding/json 匹配字段名时不区分大小写
+			fieldType, ok := fields[strings.ToLower(key)]
+			if !ok {
+				unknown = append(unknown, path)
+				continue
+			}
+			unknown = append(unknown, unknownFields(obj[key], fieldType, path)...)
+		}
+
+	case reflect.Map:
+		obj, ok := value.(map[string]any)
+		if !ok {
+			return nil
+		}
+		for _, key := range sortedKeys(obj) {
+			unknown = append(unknown, unknownFields(obj[key], t.Elem(), joinPath(prefix, key))...)
+		}
+	}
+
+	return unknown
+}
+
+// 拼接字段路径
+func joinPath(prefix, key string) string {
+	if prefix == "" {
+		return key
+	}
+	return prefix + "." + key
+}
+
+// 备份文件名，最新的备份为 config.json.bak，更早的依次为 .bak.2、.bak.3
+func backupPath(path string, n int) string {
+	if n == 1 {
+		return path + ".bak"
+	}
+	return fmt.Sprintf("%s.bak.%d", path, n)
+}
+
+// 轮换备份，并将当前文件复制为最新备份
+func rotateBackups(path string) error {
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	for n := maxBackups - 1; n >= 1; n-- {
+		if err := os.Rename(backupPath(path, n), backupPath(path, n+1)); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+	}
+	return os.WriteFile(backupPath(path, 1), data, 0644)
+}
+
+// 先写入同目录的临时文件再重命名，保证目标文件要么是旧内容要么是完整的新内容
+func writeFileAtomic(path string, data []byte, perm os.FileMode) error {
+	tmp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".tmp-*")
+	if err != nil {
+		return err
+	}
+	tmpPath := tmp.Name()
+
+	// 出错时清理临时文件
+	success := false
+	defer func() {
+		if !success {
+			tmp.Close()
+			os.Remove(tmpPath)
+		}
+	}()
+
+	if _, err := tmp.Write(data); err != nil {
+		return err
+	}
+	if err := tmp.Sync(); err != nil {
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	if err := os.Chmod(tmpPath, perm); err != nil {
+		return err
+	}
+	if err := os.Rename(tmpPath, path); err != nil {
+		return err
+	}
+
+	success = true
+	return nil
+}
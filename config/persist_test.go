@@ -0,0 +1,280 @@
+package config
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"clipboard-translate/secrets"
+)
+
+// 使用临时目录中的密钥存储，结束后恢复原来的默认存储
+func setupVault(t *testing.T) secrets.Store {
+	t.Helper()
+	dir := t.TempDir()
+	vault, err := secrets.NewVaultStore(filepath.Join(dir, "secrets.vault"), filepath.Join(dir, "secrets.key"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	saved := secrets.Default()
+	secrets.SetDefault(vault)
+	t.Cleanup(func() { secrets.SetDefault(saved) })
+	return vault
+}
+
+// 解析JSON对象，数字统一为 float64 便于比较
+func parseRaw(t *testing.T, data string) map[string]any {
+	t.Helper()
+	var raw map[string]any
+	if err := json.Unmarshal([]byte(data), &raw); err != nil {
+		t.Fatal(err)
+	}
+	return raw
+}
+
+func TestMigrate(t *testing.T) {
+	tests := []struct {
+		name        string
+		input       string
+		want        string
+		wantVersion int
+		wantKeys    map[string]string
+	}{
+		{
+			name: "v0 旧版设置页面字段",
+			input: `{
+				"api": {"provider": "gemini", "gemini_key": "gk", "model": "gemini-pro"},
+				"database": {"type": "sqlite", "max_history": 50},
+				"ui": {"port": 8080, "start_minimized": true}
+			}`,
+			want: `{
+				"version": 3,
+				"api": {"active_profile": "default"},
+				"profiles": {"default": {"provider": "gemini", "model": "gemini-pro"}},
+				"database": {"type": "sqlite"},
+				"system": {"max_history_items": 50},
+				"ui": {"port": 8080}
+			}`,
+			wantVersion: 0,
+			wantKeys:    map[string]string{"gemini": "gk"},
+		},
+		{
+			name: "v0 已有的新字段优先",
+			input: `{
+				"api": {"provider": "openai", "api_key": "new", "gemini_key": "old"},
+				"database": {"max_history": 50},
+				"system": {"max_history_items": 10}
+			}`,
+			want: `{
+				"version": 3,
+				"api": {"active_profile": "default"},
+				"profiles": {"default": {"provider": "openai"}},
+				"database": {},
+				"system": {"max_history_items": 10}
+			}`,
+			wantVersion: 0,
+			wantKeys:    map[string]string{"openai": "new"},
+		},
+		{
+			name:  "v1 没有提供商时密钥保存为 default",
+			input: `{"version": 1, "api": {"api_key": "k"}}`,
+			want: `{
+				"version": 3,
+				"api": {"active_profile": "default"},
+				"profiles": {"default": {"api_key_ref": "default"}}
+			}`,
+			wantVersion: 1,
+			wantKeys:    map[string]string{"default": "k"},
+		},
+		{
+			name:  "v2 保留已有的 default 配置和激活配置",
+			input: `{"version": 2, "api": {"provider": "claude", "active_profile": "work"}, "profiles": {"default": {"provider": "ollama"}}}`,
+			want: `{
+				"version": 3,
+				"api": {"active_profile": "work"},
+				"profiles": {"default": {"provider": "ollama"}}
+			}`,
+			wantVersion: 2,
+		},
+		{
+			name:        "v2 没有 api",
+			input:       `{"version": 2}`,
+			want:        `{"version": 3, "api": {"active_profile": "default"}, "profiles": {"default": {}}}`,
+			wantVersion: 2,
+		},
+		{
+			name:        "当前版本不变",
+			input:       `{"version": 3, "api": {"active_profile": "work"}, "profiles": {"work": {"provider": "openai"}}}`,
+			want:        `{"version": 3, "api": {"active_profile": "work"}, "profiles": {"work": {"provider": "openai"}}}`,
+			wantVersion: 3,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			raw := parseRaw(t, tt.input)
+
+			version, keys, err := migrate(raw)
+			if err != nil {
+				t.Fatalf("migrate() error = %v", err)
+			}
+			if version != tt.wantVersion {
+				t.Errorf("migrate() version = %d, want %d", version, tt.wantVersion)
+			}
+
+			data, err := json.Marshal(raw)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got, want := parseRaw(t, string(data)), parseRaw(t, tt.want); !reflect.DeepEqual(got, want) {
+				t.Errorf("migrate() = %s, want %s", data, tt.want)
+			}
+
+			if len(keys) != len(tt.wantKeys) || (len(keys) > 0 && !reflect.DeepEqual(keys, tt.wantKeys)) {
+				t.Errorf("migrate() keys = %v, want %v", keys, tt.wantKeys)
+			}
+		})
+	}
+}
+
+func TestMigrateErrors(t *testing.T) {
+	t.Run("版本高于当前版本", func(t *testing.T) {
+		if _, _, err := migrate(parseRaw(t, `{"version": 4}`)); err == nil {
+			t.Error("migrate() error = nil, want error")
+		}
+	})
+
+	t.Run("加载时没有密钥存储", func(t *testing.T) {
+		saved, savedFile := secrets.Default(), configFile
+		secrets.SetDefault(nil)
+		t.Cleanup(func() { secrets.SetDefault(saved); configFile = savedFile })
+
+		path := filepath.Join(t.TempDir(), "config.json")
+		if err := os.WriteFile(path, []byte(`{"version": 1, "api": {"provider": "openai", "api_key": "k"}}`), 0600); err != nil {
+			t.Fatal(err)
+		}
+		configFile = path
+		if _, _, _, err := readConfigFile(); !errors.Is(err, secrets.ErrNoStore) {
+			t.Errorf("readConfigFile() error = %v, want %v", err, secrets.ErrNoStore)
+		}
+	})
+}
+
+func TestMigratedKeys(t *testing.T) {
+	const v1 = `{"version": 1, "api": {"provider": "openai", "api_key": "k"}}`
+
+	t.Run("加载配置文件时写入密钥存储", func(t *testing.T) {
+		vault := setupVault(t)
+		loadTestConfig(t, v1)
+		if got, err := vault.Get("openai"); err != nil || got != "k" {
+			t.Errorf("secret openai = %q, %v, want %q", got, err, "k")
+		}
+	})
+
+	t.Run("提交的配置在保存成功后才写入", func(t *testing.T) {
+		vault := setupVault(t)
+		loadTestConfig(t, `{"version": 3}`)
+
+		config, err := DecodeConfig([]byte(`{"version": 1, "api": {"provider": "openai", "api_key": "k"},
+			"ui": {"port": 8080}, "database": {"type": "sqlite"}}`))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := config.Validate(); err != nil {
+			t.Fatalf("Validate() error = %v, 待写入的密钥应视为已设置", err)
+		}
+		if _, err := vault.Get("openai"); !errors.Is(err, secrets.ErrNotFound) {
+			t.Fatalf("DecodeConfig 后密钥存储中已有 openai, err = %v", err)
+		}
+
+		if err := SaveConfig(config); err != nil {
+			t.Fatal(err)
+		}
+		if got, err := vault.Get("openai"); err != nil || got != "k" {
+			t.Errorf("保存后 secret openai = %q, %v, want %q", got, err, "k")
+		}
+	})
+}
+
+func TestDecodeConfig(t *testing.T) {
+	tests := []struct {
+		name        string
+		input       string
+		wantUnknown []string
+		wantErr     bool
+	}{
+		{
+			name:  "有效配置",
+			input: `{"version": 3, "api": {"active_profile": "default"}, "profiles": {"default": {"provider": "openai", "model": "gpt-4o"}}}`,
+		},
+		{
+			name:  "字段名不区分大小写",
+			input: `{"version": 3, "UI": {"Port": 8080}}`,
+		},
+		{
+			name:  "旧版本字段迁移后不算未知字段",
+			input: `{"api": {"provider": "ollama"}, "ui": {"start_minimized": true}}`,
+		},
+		{
+			name:        "未知字段",
+			input:       `{"version": 3, "colour": "red", "ui": {"port": 8080, "colour": "dark"}}`,
+			wantUnknown: []string{"colour", "ui.colour"},
+		},
+		{
+			name:        "map 中的未知字段",
+			input:       `{"version": 3, "profiles": {"work": {"provider": "openai", "modle": "gpt-4o"}}}`,
+			wantUnknown: []string{"profiles.work.modle"},
+		},
+		{
+			name:    "不是JSON对象",
+			input:   `[]`,
+			wantErr: true,
+		},
+		{
+			name:    "null",
+			input:   `null`,
+			wantErr: true,
+		},
+		{
+			name:    "版本过高",
+			input:   `{"version": 99}`,
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			setupVault(t)
+			cfg, err := DecodeConfig([]byte(tt.input))
+
+			var verr *ValidationError
+			switch {
+			case tt.wantUnknown != nil:
+				if !errors.As(err, &verr) {
+					t.Fatalf("DecodeConfig() error = %v, want *ValidationError", err)
+				}
+				var fields []string
+				for _, fe := range verr.Errors {
+					fields = append(fields, fe.Field)
+				}
+				if !reflect.DeepEqual(fields, tt.wantUnknown) {
+					t.Errorf("unknown fields = %v, want %v", fields, tt.wantUnknown)
+				}
+			case tt.wantErr:
+				if err == nil || errors.As(err, &verr) {
+					t.Errorf("DecodeConfig() error = %v, want decode error", err)
+				}
+			default:
+				if err != nil {
+					t.Fatalf("DecodeConfig() error = %v", err)
+				}
+				if cfg.Version != CurrentVersion {
+					t.Errorf("Version = %d, want %d", cfg.Version, CurrentVersion)
+				}
+			}
+		})
+	}
+}
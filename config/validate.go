@@ -0,0 +1,306 @@
+package config
+
+import (
+	"fmt"
+	"net"
+	"net/url"
+	"os"
+	"sort"
+	"strings"
+
+	"clipboard-translate/ai"
+	"clipboard-translate/constants"
+	"clipboard-translate/secrets"
+	log "clipboard-translate/utils/log"
+)
+
+// 支持的AI提供商，与 ai.NewAIClient 保持一致
+var supportedProviders = []string{"gemini", "openai", "claude", "ollama"}
+
+// 支持的数据库类型，与 database.New 保持一致
+var supportedDatabaseTypes = []string{"sqlite"}
+
+// FieldError 单个字段的校验错误
+type FieldError struct {
+	Field   string `json:"field"`   // 字段路径，如 "api.provider"
+	Message string `json:"message"` // 错误说明
+}
+
+// ValidationError 配置校验错误，包含所有无效字段
+type ValidationError struct {
+	Errors []FieldError `json:"errors"`
+}
+
+func (e *ValidationError) Error() string {
+	parts := make([]string, len(e.Errors))
+	for i, fe := range e.Errors {
+		parts[i] = fmt.Sprintf("%s: %s", fe.Field, fe.Message)
+	}
+	return "配置校验失败: " + strings.Join(parts, "; ")
+}
+
+// 追加字段错误
+func (e *ValidationError) add(field, format string, args ...any) {
+	e.Errors = append(e.Errors, FieldError{Field: field, Message: fmt.Sprintf(format, args...)})
+}
+
+// Validate 校验配置，返回 *ValidationError 列出所有无效字段
+func (c *Config) Validate() error {
+	verr := &ValidationError{}
+
+	c.validateHotkeys(verr)
+	c.validateAPI(verr)
+	c.validateTemplates(verr)
+
+	// 翻译配置
+	if c.Translation.Format != "" && c.Translation.Format != FormatAuto && c.Translation.Format != FormatPlain {
+		verr.add("translation.format", "文本格式必须是 %s 或 %s", FormatAuto, FormatPlain)
+	}
+	if c.Translation.ChunkConcurrency < 0 {
+		verr.add("translation.chunk_concurrency", "分段并发数不能为负数")
+	}
+	if c.Translation.JobWorkers < 0 {
+		verr.add("translation.job_workers", "批量任务并发数不能为负数")
+	}
+	for _, provider := range sortedKeys(c.Translation.RateLimits) {
+		path := "translation.rate_limits." + provider
+		if !contains(supportedProviders, provider) {
+			verr.add(path, "不支持的AI提供商: %s", provider)
+		} else if c.Translation.RateLimits[provider] < 0 {
+			verr.add(path, "每分钟请求数不能为负数")
+		}
+	}
+
+	// 界面配置
+	// 0（自动选择空闲端口）只能通过命令行参数或环境变量指定
+	if (c.UI.Port < 1 || c.UI.Port > 65535) && !(c.UI.Port == 0 && autoPort()) {
+		verr.add("ui.port", "端口必须在 1-65535 之间")
+	}
+	if c.UI.BindAddress != "" && c.UI.BindAddress != "localhost" && net.ParseIP(c.UI.BindAddress) == nil {
+		verr.add("ui.bind_address", "监听地址必须是 IP 地址或 localhost")
+	}
+	for i, host := range c.UI.AllowedHosts {
+		if host == "" || strings.ContainsAny(host, "/ ") {
+			verr.add(fmt.Sprintf("ui.allowed_hosts[%d]", i), "无效的主机名: %q", host)
+		}
+	}
+
+	// 系统配置
+	if c.System.MaxHistoryItems < 0 {
+		verr.add("system.max_history_items", "历史记录数量上限不能为负数")
+	}
+	if c.System.LogLevel != "" {
+		if _, ok := log.LogLevelMapping[strings.ToLower(c.System.LogLevel)]; !ok {
+			verr.add("system.log_level", "未知的日志级别: %s", c.System.LogLevel)
+		}
+	}
+
+	// 数据库配置
+	if !contains(supportedDatabaseTypes, c.Database.Type) {
+		verr.add("database.type", "不支持的数据库类型: %q", c.Database.Type)
+	}
+
+	// 价格配置
+	for _, model := range sortedKeys(c.Pricing.Models) {
+		price := c.Pricing.Models[model]
+		if model == "" {
+			verr.add("pricing.models", "模型名称不能为空")
+		}
+		if price.Input < 0 || price.Output < 0 {
+			verr.add("pricing.models."+model, "价格不能为负数")
+		}
+	}
+
+	c.validateBudget(verr)
+
+	if len(verr.Errors) > 0 {
+		return verr
+	}
+	return nil
+}
+
+// 校验预算：周期、范围、上限和用尽时的处理方式，回退配置必须是本地 Ollama 模型
+func (c *Config) validateBudget(verr *ValidationError) {
+	if name := c.Budget.FallbackProfile; name != "" {
+		if profile, ok := c.Profiles[name]; !ok {
+			verr.add("budget.fallback_profile", "AI配置 %q 不存在", name)
+		} else if strings.ToLower(profile.Provider) != "ollama" {
+			verr.add("budget.fallback_profile", "回退配置必须使用 ollama，%q 使用的是 %s", name, profile.Provider)
+		}
+	}
+
+	for i, limit := range c.Budget.Limits {
+		prefix := fmt.Sprintf("budget.limits[%d]", i)
+		if limit.Period != BudgetDaily && limit.Period != BudgetMonthly {
+			verr.add(prefix+".period", "预算周期必须是 %s 或 %s", BudgetDaily, BudgetMonthly)
+		}
+		switch {
+		case limit.Provider != "" && limit.Profile != "":
+			verr.add(prefix, "provider 和 profile 不能同时指定")
+		case limit.Provider != "" && !contains(supportedProviders, limit.Provider):
+			verr.add(prefix+".provider", "不支持的AI提供商: %s", limit.Provider)
+		case limit.Profile != "":
+			if _, ok := c.Profiles[limit.Profile]; !ok {
+				verr.add(prefix+".profile", "AI配置 %q 不存在", limit.Profile)
+			}
+		}
+		if limit.Tokens < 0 || limit.Cost < 0 {
+			verr.add(prefix, "预算上限不能为负数")
+		} else if limit.Tokens == 0 && limit.Cost == 0 {
+			verr.add(prefix, "至少需要设置 tokens 或 cost 上限")
+		}
+		if limit.WarnAt < 0 || limit.WarnAt > 1 {
+			verr.add(prefix+".warn_at", "提醒比例必须在 0-1 之间")
+		}
+		switch limit.Action {
+		case "", BudgetRefuse:
+		case BudgetFallback:
+			if c.Budget.FallbackProfile == "" {
+				verr.add(prefix+".action", "使用 %s 时需要设置 budget.fallback_profile", BudgetFallback)
+			}
+		default:
+			verr.add(prefix+".action", "用尽时的处理方式必须是 %s 或 %s", BudgetRefuse, BudgetFallback)
+		}
+	}
+}
+
+// 校验热键：动作名称、按键、修饰键及组合键冲突
+func (c *Config) validateHotkeys(verr *ValidationError) {
+	for _, action := range sortedKeys(c.Hotkeys) {
+		if _, ok := constants.HotkeyActionIDs[action]; !ok {
+			verr.add("hotkeys."+action, "未知的热键动作")
+			continue
+		}
+		if err := c.Hotkeys[action].Validate(); err != nil {
+			verr.add("hotkeys."+action, "%v", err)
+		}
+	}
+
+	for i, action := range constants.HotkeyActions {
+		hotkey, ok := c.Hotkeys[action]
+		if !ok || !hotkey.Enabled() {
+			continue
+		}
+		for _, other := range constants.HotkeyActions[i+1:] {
+			if otherHotkey, ok := c.Hotkeys[other]; ok && otherHotkey.Enabled() && hotkey.Equals(otherHotkey) {
+				verr.add("hotkeys."+other, "与 %s 使用了相同的组合键 %s", action, hotkey)
+			}
+		}
+	}
+}
+
+// 校验AI配置：激活的配置及热键引用的配置必须存在，各配置的提供商、密钥和端点有效
+func (c *Config) validateAPI(verr *ValidationError) {
+	if len(c.Profiles) == 0 {
+		verr.add("profiles", "至少需要一个AI配置")
+	} else if _, ok := c.Profiles[c.API.ActiveProfile]; !ok {
+		verr.add("api.active_profile", "AI配置 %q 不存在", c.API.ActiveProfile)
+	}
+
+	for _, action := range sortedKeys(c.Hotkeys) {
+		if profile := c.Hotkeys[action].Profile; profile != "" {
+			if _, ok := c.Profiles[profile]; !ok {
+				verr.add("hotkeys."+action+".profile", "AI配置 %q 不存在", profile)
+			}
+		}
+	}
+
+	for _, name := range sortedKeys(c.Profiles) {
+		c.Profiles[name].validate("profiles."+name, c.pendingKeys, verr)
+	}
+}
+
+// 校验提示词模板：语法正确、能以空变量渲染，热键引用的模板存在
+func (c *Config) validateTemplates(verr *ValidationError) {
+	for _, name := range sortedKeys(c.Templates) {
+		tmpl, err := ai.ParsePromptTemplate(name, c.Templates[name])
+		if err == nil {
+			_, err = tmpl.Render(ai.PromptData{})
+		}
+		if err != nil {
+			verr.add("templates."+name, "%v", err)
+		}
+	}
+
+	for _, action := range sortedKeys(c.Hotkeys) {
+		name := c.Hotkeys[action].Template
+		if name == "" || ai.IsBuiltinTemplate(name) {
+			continue
+		}
+		if _, ok := c.Templates[name]; !ok {
+			verr.add("hotkeys."+action+".template", "提示词模板 %q 不存在", name)
+		}
+	}
+
+	for _, name := range sortedKeys(c.Profiles) {
+		if prompt := c.Profiles[name].Prompt; prompt != "" {
+			if _, err := ai.ParsePromptTemplate("profile:"+name, prompt); err != nil {
+				verr.add("profiles."+name+".prompt", "%v", err)
+			}
+		}
+	}
+}
+
+// 校验单个AI配置，prefix 为字段路径前缀，pending 为尚未写入密钥存储的密钥
+func (p ProfileConfig) validate(prefix string, pending map[string]string, verr *ValidationError) {
+	provider := strings.ToLower(p.Provider)
+	if !contains(supportedProviders, provider) {
+		verr.add(prefix+".provider", "不支持的AI提供商: %q", p.Provider)
+	} else if provider != "ollama" {
+		// Ollama 为本地服务，不需要密钥
+		if p.UseEnvKey {
+			if os.Getenv("AI_API_KEY") == "" {
+				verr.add(prefix+".use_env_key", "环境变量 AI_API_KEY 未设置")
+			}
+		} else if secrets.Reserved(p.KeyName()) {
+			verr.add(prefix+".api_key_ref", "不能使用内部保留的密钥 %q", p.KeyName())
+		} else if _, err := secrets.Lookup(p.KeyName()); err != nil && pending[p.KeyName()] == "" {
+			verr.add(prefix+".api_key_ref", "%s 需要API密钥，密钥 %q 未设置", p.Provider, p.KeyName())
+		}
+	}
+
+	if p.BaseURL != "" {
+		u, err := url.Parse(p.BaseURL)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			verr.add(prefix+".base_url", "无效的URL: %q", p.BaseURL)
+		}
+	}
+
+	if p.Temperature != nil && (*p.Temperature < 0 || *p.Temperature > 2) {
+		verr.add(prefix+".temperature", "采样温度必须在 0-2 之间")
+	}
+	if p.TopP != nil && (*p.TopP <= 0 || *p.TopP > 1) {
+		verr.add(prefix+".top_p", "top_p 必须大于 0 且不超过 1")
+	}
+	if p.MaxTokens < 0 {
+		verr.add(prefix+".max_tokens", "最大输出token数不能为负数")
+	}
+	if p.Timeout < 0 {
+		verr.add(prefix+".timeout", "请求超时不能为负数")
+	}
+	for i, stop := range p.Stop {
+		if stop == "" {
+			verr.add(fmt.Sprintf("%s.stop[%d]", prefix, i), "停止序列不能为空")
+		}
+	}
+}
+
+// 判断列表是否包含指定值
+func contains(list []string, value string) bool {
+	for _, v := range list {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+// 返回排序后的键，保证错误顺序稳定
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
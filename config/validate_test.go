@@ -0,0 +1,198 @@
+package config
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+
+	"clipboard-translate/constants"
+)
+
+func TestValidate(t *testing.T) {
+	tests := []struct {
+		name       string
+		mutate     func(c *Config)
+		wantFields []string // 按出现顺序排列的错误字段，空表示校验通过
+	}{
+		{
+			name:   "有效配置",
+			mutate: func(c *Config) {},
+		},
+		{
+			name:       "没有AI配置",
+			mutate:     func(c *Config) { c.Profiles = nil },
+			wantFields: []string{"profiles"},
+		},
+		{
+			name:       "激活的配置不存在",
+			mutate:     func(c *Config) { c.API.ActiveProfile = "missing" },
+			wantFields: []string{"api.active_profile"},
+		},
+		{
+			name: "AI配置的参数",
+			mutate: func(c *Config) {
+				temperature, topP := 2.5, 0.0
+				c.Profiles[DefaultProfile] = ProfileConfig{
+					Provider:    "ollama",
+					BaseURL:     "localhost:11434",
+					Temperature: &temperature,
+					TopP:        &topP,
+					MaxTokens:   -1,
+					Timeout:     -1,
+					Stop:        []string{"END", ""},
+				}
+			},
+			wantFields: []string{
+				"profiles.default.base_url",
+				"profiles.default.temperature",
+				"profiles.default.top_p",
+				"profiles.default.max_tokens",
+				"profiles.default.timeout",
+				"profiles.default.stop[1]",
+			},
+		},
+		{
+			name:       "不支持的提供商",
+			mutate:     func(c *Config) { c.Profiles[DefaultProfile] = ProfileConfig{Provider: "bard"} },
+			wantFields: []string{"profiles.default.provider"},
+		},
+		{
+			name:       "环境变量中没有密钥",
+			mutate:     func(c *Config) { c.Profiles[DefaultProfile] = ProfileConfig{Provider: "openai", UseEnvKey: true} },
+			wantFields: []string{"profiles.default.use_env_key"},
+		},
+		{
+			name: "使用内部保留的密钥",
+			mutate: func(c *Config) {
+				c.Profiles[DefaultProfile] = ProfileConfig{Provider: "openai", APIKeyRef: "Internal.token"}
+			},
+			wantFields: []string{"profiles.default.api_key_ref"},
+		},
+		{
+			name: "热键",
+			mutate: func(c *Config) {
+				c.Hotkeys["bogus"] = HotkeyConfig{Modifiers: []string{"control"}, Key: "B"}
+				c.Hotkeys[constants.ACTION_TRANSLATE_ALT] = c.Hotkeys[constants.ACTION_TRANSLATE]
+				hotkey := c.Hotkeys[constants.ACTION_EXPLAIN]
+				hotkey.Profile, hotkey.Template = "missing", "missing"
+				c.Hotkeys[constants.ACTION_EXPLAIN] = hotkey
+			},
+			wantFields: []string{
+				"hotkeys.bogus",
+				"hotkeys." + constants.ACTION_TRANSLATE_ALT,
+				"hotkeys." + constants.ACTION_EXPLAIN + ".profile",
+				"hotkeys." + constants.ACTION_EXPLAIN + ".template",
+			},
+		},
+		{
+			name: "提示词模板",
+			mutate: func(c *Config) {
+				c.Templates = map[string]string{"bad": "{{.Missing", "ok": "翻译为 {{.TargetLanguage}}"}
+				c.Profiles[DefaultProfile] = ProfileConfig{Provider: "ollama", Prompt: "{{end}}"}
+			},
+			wantFields: []string{"templates.bad", "profiles.default.prompt"},
+		},
+		{
+			name: "翻译、界面和系统配置",
+			mutate: func(c *Config) {
+				c.Translation.Format = "html"
+				c.Translation.ChunkConcurrency = -1
+				c.Translation.JobWorkers = -1
+				c.Translation.RateLimits = map[string]int{"bard": 10, "openai": -1}
+				c.UI.BindAddress = "example.com"
+				c.UI.AllowedHosts = []string{"ok.local", "http://bad"}
+				c.System.MaxHistoryItems = -1
+				c.System.LogLevel = "verbose"
+				c.Database.Type = "mysql"
+				c.Pricing.Models = map[string]ModelPrice{"gpt": {Input: -1}}
+			},
+			wantFields: []string{
+				"translation.format",
+				"translation.chunk_concurrency",
+				"translation.job_workers",
+				"translation.rate_limits.bard",
+				"translation.rate_limits.openai",
+				"ui.bind_address",
+				"ui.allowed_hosts[1]",
+				"system.max_history_items",
+				"system.log_level",
+				"database.type",
+				"pricing.models.gpt",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			setOverrides(t)
+			t.Setenv("AI_API_KEY", "")
+
+			config := defaultConfig()
+			config.Profiles[DefaultProfile] = ProfileConfig{Provider: "ollama"}
+			tt.mutate(config)
+
+			err := config.Validate()
+			if tt.wantFields == nil {
+				if err != nil {
+					t.Fatalf("Validate() error = %v", err)
+				}
+				return
+			}
+			var verr *ValidationError
+			if !errors.As(err, &verr) {
+				t.Fatalf("Validate() error = %v, want *ValidationError", err)
+			}
+			var fields []string
+			for _, e := range verr.Errors {
+				fields = append(fields, e.Field)
+			}
+			if !reflect.DeepEqual(fields, tt.wantFields) {
+				t.Errorf("error fields = %q, want %q", fields, tt.wantFields)
+			}
+		})
+	}
+}
+
+func TestValidatePort(t *testing.T) {
+	tests := []struct {
+		name     string
+		port     int
+		override string // ui.port 的覆盖值，空表示没有覆盖
+		wantErr  bool
+	}{
+		{"默认端口", 8080, "", false},
+		{"最大端口", 65535, "", false},
+		{"零", 0, "", true},
+		{"负数", -1, "", true},
+		{"超出范围", 65536, "", true},
+		{"命令行指定自动选择", 0, "0", false},
+		{"覆盖为其他端口", 0, "9000", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var list []override
+			if tt.override != "" {
+				list = append(list, override{path: fieldPath{"ui", "port"}, value: tt.override, source: SourceFlag})
+			}
+			setOverrides(t, list...)
+
+			config := defaultConfig()
+			config.UI.Port = tt.port
+			err := config.Validate()
+			var verr *ValidationError
+			failed := errors.As(err, &verr) && hasFieldError(verr, "ui.port")
+			if failed != tt.wantErr {
+				t.Errorf("Validate() = %v, want ui.port 错误 %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func hasFieldError(verr *ValidationError, field string) bool {
+	for _, e := range verr.Errors {
+		if e.Field == field {
+			return true
+		}
+	}
+	return false
+}
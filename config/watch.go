@@ -0,0 +1,240 @@
+package config
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	log "clipboard-translate/utils/log"
+)
+
+// Section 配置分区，可按位组合
+type Section uint
+
+const (
+	SectionHotkeys Section = 1 << iota
+	SectionAPI
+	SectionTranslation
+	SectionUI
+	SectionSystem
+	SectionDatabase
+	SectionProfiles
+	SectionTemplates
+	SectionPricing
+	SectionBudget
+
+	SectionAll = SectionHotkeys | SectionAPI | SectionTranslation | SectionUI | SectionSystem | SectionDatabase | SectionProfiles | SectionTemplates | SectionPricing | SectionBudget
+)
+
+// 分区对应的配置字段名
+var sectionNames = []struct {
+	section Section
+	name    string
+}{
+	{SectionHotkeys, "hotkeys"},
+	{SectionAPI, "api"},
+	{SectionTranslation, "translation"},
+	{SectionUI, "ui"},
+	{SectionSystem, "system"},
+	{SectionDatabase, "database"},
+	{SectionProfiles, "profiles"},
+	{SectionTemplates, "templates"},
+	{SectionPricing, "pricing"},
+	{SectionBudget, "budget"},
+}
+
+// Names 返回包含的分区名称，与配置文件中的字段名一致
+func (s Section) Names() []string {
+	names := []string{}
+	for _, sn := range sectionNames {
+		if s&sn.section != 0 {
+			names = append(names, sn.name)
+		}
+	}
+	return names
+}
+
+// Change 一次配置变更
+type Change struct {
+	Old      *Config // 变更前的配置，首次加载时为 nil
+	New      *Config // 变更后的配置
+	Sections Section // 发生变化的分区
+}
+
+// Has 判断指定分区是否发生变化
+func (c Change) Has(section Section) bool {
+	return c.Sections&section != 0
+}
+
+// Diff 比较两份配置，返回发生变化的分区
+func Diff(old, new *Config) Section {
+	if old == nil {
+		return SectionAll
+	}
+
+	var sections Section
+	if !reflect.DeepEqual(old.Hotkeys, new.Hotkeys) {
+		sections |= SectionHotkeys
+	}
+	if !reflect.DeepEqual(old.API, new.API) {
+		sections |= SectionAPI
+	}
+	if !reflect.DeepEqual(old.Translation, new.Translation) {
+		sections |= SectionTranslation
+	}
+	if !reflect.DeepEqual(old.UI, new.UI) {
+		sections |= SectionUI
+	}
+	if !reflect.DeepEqual(old.System, new.System) {
+		sections |= SectionSystem
+	}
+	if !reflect.DeepEqual(old.Database, new.Database) {
+		sections |= SectionDatabase
+	}
+	if !reflect.DeepEqual(old.Profiles, new.Profiles) {
+		sections |= SectionProfiles
+	}
+	if !reflect.DeepEqual(old.Templates, new.Templates) {
+		sections |= SectionTemplates
+	}
+	if !reflect.DeepEqual(old.Pricing, new.Pricing) {
+		sections |= SectionPricing
+	}
+	if !reflect.DeepEqual(old.Budget, new.Budget) {
+		sections |= SectionBudget
+	}
+	return sections
+}
+
+// Listener 配置变更回调，返回错误表示应用变更失败
+type Listener func(change Change) error
+
+// 订阅者
+type subscriber struct {
+	name     string
+	sections Section
+	fn       Listener
+}
+
+var (
+	subscribers   []subscriber
+	subscribersMu sync.Mutex
+)
+
+// Subscribe 订阅指定分区的配置变更，回调按订阅顺序同步执行
+func Subscribe(name string, sections Section, fn Listener) {
+	subscribersMu.Lock()
+	defer subscribersMu.Unlock()
+
+	subscribers = append(subscribers, subscriber{name: name, sections: sections, fn: fn})
+}
+
+// ApplyError 配置已保存，但部分订阅者应用变更失败
+type ApplyError struct {
+	Errors map[string]error // 订阅者名称到错误
+}
+
+func (e *ApplyError) Error() string {
+	names := make([]string, 0, len(e.Errors))
+	for name := range e.Errors {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	parts := make([]string, len(names))
+	for i, name := range names {
+		parts[i] = fmt.Sprintf("%s: %v", name, e.Errors[name])
+	}
+	return "应用配置失败: " + strings.Join(parts, "; ")
+}
+
+// 通知订阅者配置已变更
+func publish(old, new *Config) error {
+	change := Change{Old: old, New: new, Sections: Diff(old, new)}
+	if change.Sections == 0 {
+		return nil
+	}
+
+	subscribersMu.Lock()
+	subs := make([]subscriber, len(subscribers))
+	copy(subs, subscribers)
+	subscribersMu.Unlock()
+
+	errs := make(map[string]error)
+	for _, sub := range subs {
+		if !change.Has(sub.sections) {
+			continue
+		}
+		if err := sub.fn(change); err != nil {
+			log.Error("应用配置变更失败 [%s]: %v", sub.name, err)
+			errs[sub.name] = err
+		}
+	}
+
+	if len(errs) > 0 {
+		return &ApplyError{Errors: errs}
+	}
+	return nil
+}
+
+// Watch 定期检查配置文件，文件被外部修改时重新加载并通知订阅者，直到 ctx 取消
+func Watch(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	var lastModTime time.Time
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		info, err := os.Stat(configFile)
+		if err != nil || info.ModTime().Equal(lastModTime) {
+			continue
+		}
+		lastModTime = info.ModTime()
+
+		if err := reload(); err != nil {
+			log.Error("重新加载配置文件失败: %v", err)
+		}
+	}
+}
+
+// 重新读取配置文件，内容与当前配置不同时替换并通知订阅者
+func reload() error {
+	configMutex.Lock()
+	config, sum, info, err := readConfigFile()
+	if sum == configHash {
+		// 内容未变化（包括自己写入的情况）
+		configMutex.Unlock()
+		return nil
+	}
+	configHash = sum
+	var effective *Config
+	if err == nil {
+		effective = withOverrides(config)
+		err = effective.Validate()
+	}
+	if err != nil {
+		// 保留当前配置，等待文件被修正
+		configMutex.Unlock()
+		return err
+	}
+	fileConfig = config
+	filePaths = info.present
+	config = effective
+
+	old := configInstance
+	configInstance = config
+	configMutex.Unlock()
+
+	log.Info("检测到配置文件变更，已重新加载")
+	return publish(old, config)
+}
@@ -7,8 +7,14 @@ const (
 	MOD_SHIFT   = 0x0004
 	MOD_WIN     = 0x0008
 
-	// 热键ID
-	HOTKEY_ID = 1
+	// 热键ID，每个热键动作对应一个
+	HOTKEY_ID               = 1 // 翻译
+	HOTKEY_ID_TRANSLATE_ALT = 2 // 翻译为备用语言
+	HOTKEY_ID_EXPLAIN       = 3 // 解释
+	HOTKEY_ID_POLISH        = 4 // 润色/语法修正
+	HOTKEY_ID_SUMMARIZE     = 5 // 摘要
+	HOTKEY_ID_SHOW_HIDE     = 6 // 显示/隐藏窗口
+	HOTKEY_ID_REPEAT_LAST   = 7 // 重复上一次操作
 
 	// 字母键 (A-Z)
 	VK_A = 0x41
@@ -51,8 +57,125 @@ const (
 	VK_9 = 0x39
 
 	// 功能键
-	VK_SPACE  = 0x20
-	VK_DELETE = 0x2E
-	VK_INSERT = 0x2D
-	// ... 更多功能键
+	VK_SPACE    = 0x20
+	VK_DELETE   = 0x2E
+	VK_INSERT   = 0x2D
+	VK_BACK     = 0x08
+	VK_TAB      = 0x09
+	VK_RETURN   = 0x0D
+	VK_PAUSE    = 0x13
+	VK_CAPITAL  = 0x14
+	VK_ESCAPE   = 0x1B
+	VK_SNAPSHOT = 0x2C
+	VK_NUMLOCK  = 0x90
+	VK_SCROLL   = 0x91
+
+	// 导航键
+	VK_PRIOR = 0x21 // Page Up
+	VK_NEXT  = 0x22 // Page Down
+	VK_END   = 0x23
+	VK_HOME  = 0x24
+	VK_LEFT  = 0x25
+	VK_UP    = 0x26
+	VK_RIGHT = 0x27
+	VK_DOWN  = 0x28
+
+	// F1-F24
+	VK_F1  = 0x70
+	VK_F2  = 0x71
+	VK_F3  = 0x72
+	VK_F4  = 0x73
+	VK_F5  = 0x74
+	VK_F6  = 0x75
+	VK_F7  = 0x76
+	VK_F8  = 0x77
+	VK_F9  = 0x78
+	VK_F10 = 0x79
+	VK_F11 = 0x7A
+	VK_F12 = 0x7B
+	VK_F13 = 0x7C
+	VK_F14 = 0x7D
+	VK_F15 = 0x7E
+	VK_F16 = 0x7F
+	VK_F17 = 0x80
+	VK_F18 = 0x81
+	VK_F19 = 0x82
+	VK_F20 = 0x83
+	VK_F21 = 0x84
+	VK_F22 = 0x85
+	VK_F23 = 0x86
+	VK_F24 = 0x87
+
+	// 小键盘
+	VK_NUMPAD0   = 0x60
+	VK_NUMPAD1   = 0x61
+	VK_NUMPAD2   = 0x62
+	VK_NUMPAD3   = 0x63
+	VK_NUMPAD4   = 0x64
+	VK_NUMPAD5   = 0x65
+	VK_NUMPAD6   = 0x66
+	VK_NUMPAD7   = 0x67
+	VK_NUMPAD8   = 0x68
+	VK_NUMPAD9   = 0x69
+	VK_MULTIPLY  = 0x6A
+	VK_ADD       = 0x6B
+	VK_SEPARATOR = 0x6C
+	VK_SUBTRACT  = 0x6D
+	VK_DECIMAL   = 0x6E
+	VK_DIVIDE    = 0x6F
+
+	// OEM 标点键（美式键盘布局）
+	VK_OEM_1      = 0xBA // ;:
+	VK_OEM_PLUS   = 0xBB // =+
+	VK_OEM_COMMA  = 0xBC // ,<
+	VK_OEM_MINUS  = 0xBD // -_
+	VK_OEM_PERIOD = 0xBE // .>
+	VK_OEM_2      = 0xBF // /?
+	VK_OEM_3      = 0xC0 // `~
+	VK_OEM_4      = 0xDB // [{
+	VK_OEM_5      = 0xDC // \|
+	VK_OEM_6      = 0xDD // ]}
+	VK_OEM_7      = 0xDE // '"
+
+	// 媒体键
+	VK_VOLUME_MUTE      = 0xAD
+	VK_VOLUME_DOWN      = 0xAE
+	VK_VOLUME_UP        = 0xAF
+	VK_MEDIA_NEXT_TRACK = 0xB0
+	VK_MEDIA_PREV_TRACK = 0xB1
+	VK_MEDIA_STOP       = 0xB2
+	VK_MEDIA_PLAY_PAUSE = 0xB3
 )
+
+// 热键动作名称，对应 config.json 中 hotkeys 的键
+const (
+	ACTION_TRANSLATE     = "translate"
+	ACTION_TRANSLATE_ALT = "translateAlt"
+	ACTION_EXPLAIN       = "explain"
+	ACTION_POLISH        = "polish"
+	ACTION_SUMMARIZE     = "summarize"
+	ACTION_SHOW_HIDE     = "showHide"
+	ACTION_REPEAT_LAST   = "repeatLast"
+)
+
+// 热键动作到热键ID的映射
+var HotkeyActionIDs = map[string]int{
+	ACTION_TRANSLATE:     HOTKEY_ID,
+	ACTION_TRANSLATE_ALT: HOTKEY_ID_TRANSLATE_ALT,
+	ACTION_EXPLAIN:       HOTKEY_ID_EXPLAIN,
+	ACTION_POLISH:        HOTKEY_ID_POLISH,
+	ACTION_SUMMARIZE:     HOTKEY_ID_SUMMARIZE,
+	ACTION_SHOW_HIDE:     HOTKEY_ID_SHOW_HIDE,
+	ACTION_REPEAT_LAST:   HOTKEY_ID_REPEAT_LAST,
+}
+
+// 热键动作列表，按注册顺序排列
+var HotkeyActions = []string{
+	ACTION_TRANSLATE,
+	ACTION_TRANSLATE_ALT,
+	ACTION_EXPLAIN,
+	ACTION_POLISH,
+	ACTION_SUMMARIZE,
+	ACTION_SHOW_HIDE,
+	ACTION_REPEAT_LAST,
+}
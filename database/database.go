@@ -1,10 +1,14 @@
 package database
 
 import (
+	"errors"
 	"fmt"
 	"time"
 )
 
+// ErrNotFound 记录不存在
+var ErrNotFound = errors.New("记录不存在")
+
 // HistoryItem 代表翻译历史中的一项记录
 type HistoryItem struct {
 	ID         string    `json:"id"`
@@ -12,6 +16,69 @@ type HistoryItem struct {
 	Translated string    `json:"translated"`
 	Direction  string    `json:"direction"` // 翻译方向，如 "中 → 英"
 	Timestamp  time.Time `json:"timestamp"`
+
+	PromptVersion string `json:"prompt_version"` // 使用的提示词模板版本，如 "translate@1a2b3c4d"
+
+	// 模型和token用量，提供商没有返回用量时为零
+	Provider     string  `json:"provider"`
+	Model        string  `json:"model"`
+	InputTokens  int     `json:"input_tokens"`
+	OutputTokens int     `json:"output_tokens"`
+	Cost         float64 `json:"cost"` // 按当时的价格表计算，模型不在价格表中时为零
+}
+
+// Usage 某一天某个模型的累计用量，不随历史记录清理
+type Usage struct {
+	Day          string  `json:"day"` // 本地日期，如 2026-01-02
+	Provider     string  `json:"provider"`
+	Model        string  `json:"model"`
+	Calls        int     `json:"calls"`
+	InputTokens  int     `json:"input_tokens"`
+	OutputTokens int     `json:"output_tokens"`
+	Cost         float64 `json:"cost"`
+}
+
+// 批量翻译任务的状态
+const (
+	JobQueued    = "queued"    // 等待执行
+	JobRunning   = "running"   // 正在执行，重启后继续
+	JobCompleted = "completed" // 所有条目已处理，可能有条目失败
+	JobCanceled  = "canceled"  // 已取消，未处理的条目保持 pending
+)
+
+// 批量翻译任务中条目的状态
+const (
+	ItemPending = "pending"
+	ItemDone    = "done"
+	ItemFailed  = "failed"
+)
+
+// Job 批量翻译任务，翻译参数在创建时确定，重启后按相同参数继续
+type Job struct {
+	ID          string     `json:"id"`
+	Status      string     `json:"status"`
+	Source      string     `json:"source,omitempty"`   // 原文语言，为空时自动检测
+	Target      string     `json:"target,omitempty"`   // 目标语言，为空时中英互译
+	Profile     string     `json:"profile"`            // 使用的AI配置
+	Template    string     `json:"template,omitempty"` // 提示词模板名称
+	SaveHistory bool       `json:"save_history"`
+	CreatedAt   time.Time  `json:"created_at"`
+	UpdatedAt   time.Time  `json:"updated_at"`
+	Items       []*JobItem `json:"items"`
+}
+
+// JobItem 批量翻译任务中的一条文本
+type JobItem struct {
+	Index       int    `json:"index"`
+	Text        string `json:"text"`
+	Status      string `json:"status"`
+	Translation string `json:"translation,omitempty"`
+	Error       string `json:"error,omitempty"`
+}
+
+// Finished 任务是否已结束
+func (j *Job) Finished() bool {
+	return j.Status == JobCompleted || j.Status == JobCanceled
 }
 
 // Database 定义数据库操作的接口
@@ -36,6 +103,36 @@ type Database interface {
 
 	// 删除超出保留数量的旧记录
 	PruneHistory(keepCount int) error
+
+	// 获取本地化文件各条目上次翻译时的原文，键为条目键
+	GetLocaleSources(file, language string) (map[string]string, error)
+
+	// 保存本地化文件各条目当前译文对应的原文，替换该文件之前的记录
+	SaveLocaleSources(file, language string, sources map[string]string) error
+
+	// 保存新建的批量翻译任务及其条目
+	CreateJob(job *Job) error
+
+	// 获取批量翻译任务及其条目，不存在时返回 ErrNotFound
+	GetJob(id string) (*Job, error)
+
+	// 更新任务状态
+	UpdateJobStatus(id, status string) error
+
+	// 保存条目的处理结果
+	UpdateJobItem(jobID string, item *JobItem) error
+
+	// 获取未结束的任务，按创建时间排列，用于重启后继续执行
+	GetUnfinishedJobs() ([]*Job, error)
+
+	// 删除指定时间之前结束的任务
+	PruneJobs(before time.Time) error
+
+	// 累加一次模型调用的用量，按日期、提供商和模型合并
+	AddUsage(usage *Usage) error
+
+	// 获取日期范围内（包含两端，格式为 2006-01-02）的用量，按日期排列
+	GetUsage(from, to string) ([]*Usage, error)
 }
 
 // 数据库配置结构
@@ -46,15 +46,100 @@ func (s *SQLiteDB) Initialize() error {
 			timestamp INTEGER NOT NULL  -- 存储 Unix 时间戳（秒）
 		);
 		CREATE INDEX IF NOT EXISTS idx_history_timestamp ON history(timestamp DESC);
+		CREATE TABLE IF NOT EXISTS locale_sources (
+			file TEXT NOT NULL,
+			language TEXT NOT NULL,
+			key TEXT NOT NULL,
+			source TEXT NOT NULL,
+			PRIMARY KEY (file, language, key)
+		);
+		CREATE TABLE IF NOT EXISTS jobs (
+			id TEXT PRIMARY KEY,
+			status TEXT NOT NULL,
+			source TEXT NOT NULL,
+			target TEXT NOT NULL,
+			profile TEXT NOT NULL,
+			template TEXT NOT NULL,
+			save_history INTEGER NOT NULL,
+			created_at INTEGER NOT NULL, -- Unix 时间戳（秒）
+			updated_at INTEGER NOT NULL
+		);
+		CREATE TABLE IF NOT EXISTS job_items (
+			job_id TEXT NOT NULL,
+			idx INTEGER NOT NULL,
+			text TEXT NOT NULL,
+			status TEXT NOT NULL,
+			translation TEXT NOT NULL,
+			error TEXT NOT NULL,
+			PRIMARY KEY (job_id, idx)
+		);
+		CREATE TABLE IF NOT EXISTS usage_daily (
+			day TEXT NOT NULL, -- 本地日期，如 2026-01-02
+			provider TEXT NOT NULL,
+			model TEXT NOT NULL,
+			calls INTEGER NOT NULL,
+			input_tokens INTEGER NOT NULL,
+			output_tokens INTEGER NOT NULL,
+			cost REAL NOT NULL,
+			PRIMARY KEY (day, provider, model)
+		);
 	`)
 
 	if err != nil {
 		return fmt.Errorf("创建表结构失败: %w", err)
 	}
 
+	// 为旧版本创建的表补充新增的列
+	columns := []struct{ name, definition string }{
+		{"prompt_version", "TEXT NOT NULL DEFAULT ''"},
+		{"provider", "TEXT NOT NULL DEFAULT ''"},
+		{"model", "TEXT NOT NULL DEFAULT ''"},
+		{"input_tokens", "INTEGER NOT NULL DEFAULT 0"},
+		{"output_tokens", "INTEGER NOT NULL DEFAULT 0"},
+		{"cost", "REAL NOT NULL DEFAULT 0"},
+	}
+	for _, column := range columns {
+		if err := s.addColumn("history", column.name, column.definition); err != nil {
+			return fmt.Errorf("升级表结构失败: %w", err)
+		}
+	}
+
 	return nil
 }
 
+// 列不存在时添加列
+func (s *SQLiteDB) addColumn(table, column, definition string) error {
+	rows, err := s.db.Query(fmt.Sprintf("PRAGMA table_info(%s)", table))
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var (
+			cid       int
+			name      string
+			colType   string
+			notNull   int
+			dfltValue sql.NullString
+			pk        int
+		)
+		if err := rows.Scan(&cid, &name, &colType, &notNull, &dfltValue, &pk); err != nil {
+			return err
+		}
+		if name == column {
+			return nil
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return err
+	}
+	rows.Close()
+
+	_, err = s.db.Exec(fmt.Sprintf("ALTER TABLE %s ADD COLUMN %s %s", table, column, definition))
+	return err
+}
+
 // Close 关闭数据库连接
 func (s *SQLiteDB) Close() error {
 	if s.db != nil {
@@ -69,12 +154,19 @@ func (s *SQLiteDB) AddHistoryItem(item *HistoryItem) error {
 	unixTimestamp := item.Timestamp.Unix()
 
 	_, err := s.db.Exec(
-		"INSERT INTO history (id, original, translated, direction, timestamp) VALUES (?, ?, ?, ?, ?)",
+		`INSERT INTO history (id, original, translated, direction, timestamp, prompt_version, provider, model, input_tokens, output_tokens, cost)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
 		item.ID,
 		item.Original,
 		item.Translated,
 		item.Direction,
 		unixTimestamp, // 存储为秒
+		item.PromptVersion,
+		item.Provider,
+		item.Model,
+		item.InputTokens,
+		item.OutputTokens,
+		item.Cost,
 	)
 
 	return err
@@ -82,7 +174,7 @@ func (s *SQLiteDB) AddHistoryItem(item *HistoryItem) error {
 
 // GetHistoryItems 获取所有历史记录，按时间倒序排列
 func (s *SQLiteDB) GetHistoryItems() ([]*HistoryItem, error) {
-	rows, err := s.db.Query("SELECT id, original, translated, direction, timestamp FROM history ORDER BY timestamp DESC")
+	rows, err := s.db.Query("SELECT id, original, translated, direction, timestamp, prompt_version, provider, model, input_tokens, output_tokens, cost FROM history ORDER BY timestamp DESC")
 	if err != nil {
 		return nil, err
 	}
@@ -94,7 +186,8 @@ func (s *SQLiteDB) GetHistoryItems() ([]*HistoryItem, error) {
 		item := &HistoryItem{}
 		var timestamp int64 // 使用 int64 类型读取 Unix 时间戳
 
-		err := rows.Scan(&item.ID, &item.Original, &item.Translated, &item.Direction, &timestamp)
+		err := rows.Scan(&item.ID, &item.Original, &item.Translated, &item.Direction, &timestamp, &item.PromptVersion,
+			&item.Provider, &item.Model, &item.InputTokens, &item.OutputTokens, &item.Cost)
 		if err != nil {
 			return nil, err
 		}
@@ -146,7 +239,7 @@ func (s *SQLiteDB) GetHistoryByDateRange(start, end time.Time) ([]*HistoryItem,
 	endTS := end.Unix()
 
 	rows, err := s.db.Query(
-		"SELECT id, original, translated, direction, timestamp FROM history WHERE timestamp >= ? AND timestamp <= ? ORDER BY timestamp DESC",
+		"SELECT id, original, translated, direction, timestamp, prompt_version, provider, model, input_tokens, output_tokens, cost FROM history WHERE timestamp >= ? AND timestamp <= ? ORDER BY timestamp DESC",
 		startTS,
 		endTS,
 	)
@@ -161,7 +254,8 @@ func (s *SQLiteDB) GetHistoryByDateRange(start, end time.Time) ([]*HistoryItem,
 		item := &HistoryItem{}
 		var timestamp int64 // 使用 int64 类型读取 Unix 时间戳
 
-		err := rows.Scan(&item.ID, &item.Original, &item.Translated, &item.Direction, &timestamp)
+		err := rows.Scan(&item.ID, &item.Original, &item.Translated, &item.Direction, &timestamp, &item.PromptVersion,
+			&item.Provider, &item.Model, &item.InputTokens, &item.OutputTokens, &item.Cost)
 		if err != nil {
 			return nil, err
 		}
@@ -174,3 +268,203 @@ func (s *SQLiteDB) GetHistoryByDateRange(start, end time.Time) ([]*HistoryItem,
 
 	return items, nil
 }
+
+// GetLocaleSources 获取本地化文件各条目上次翻译时的原文
+func (s *SQLiteDB) GetLocaleSources(file, language string) (map[string]string, error) {
+	rows, err := s.db.Query("SELECT key, source FROM locale_sources WHERE file = ? AND language = ?", file, language)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	sources := make(map[string]string)
+	for rows.Next() {
+		var key, source string
+		if err := rows.Scan(&key, &source); err != nil {
+			return nil, err
+		}
+		sources[key] = source
+	}
+	return sources, rows.Err()
+}
+
+// SaveLocaleSources 在一个事务中替换本地化文件的原文记录
+func (s *SQLiteDB) SaveLocaleSources(file, language string, sources map[string]string) error {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec("DELETE FROM locale_sources WHERE file = ? AND language = ?", file, language); err != nil {
+		return err
+	}
+	stmt, err := tx.Prepare("INSERT INTO locale_sources (file, language, key, source) VALUES (?, ?, ?, ?)")
+	if err != nil {
+		return err
+	}
+	defer stmt.Close()
+	for key, source := range sources {
+		if _, err := stmt.Exec(file, language, key, source); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+// CreateJob 在一个事务中保存任务及其条目
+func (s *SQLiteDB) CreateJob(job *Job) error {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	_, err = tx.Exec(`INSERT INTO jobs (id, status, source, target, profile, template, save_history, created_at, updated_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		job.ID, job.Status, job.Source, job.Target, job.Profile, job.Template, job.SaveHistory,
+		job.CreatedAt.Unix(), job.UpdatedAt.Unix())
+	if err != nil {
+		return err
+	}
+	stmt, err := tx.Prepare("INSERT INTO job_items (job_id, idx, text, status, translation, error) VALUES (?, ?, ?, ?, ?, ?)")
+	if err != nil {
+		return err
+	}
+	defer stmt.Close()
+	for _, item := range job.Items {
+		if _, err := stmt.Exec(job.ID, item.Index, item.Text, item.Status, item.Translation, item.Error); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+// GetJob 获取任务及其条目
+func (s *SQLiteDB) GetJob(id string) (*Job, error) {
+	job := &Job{}
+	var createdAt, updatedAt int64
+	err := s.db.QueryRow("SELECT id, status, source, target, profile, template, save_history, created_at, updated_at FROM jobs WHERE id = ?", id).
+		Scan(&job.ID, &job.Status, &job.Source, &job.Target, &job.Profile, &job.Template, &job.SaveHistory, &createdAt, &updatedAt)
+	if err == sql.ErrNoRows {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	job.CreatedAt = time.Unix(createdAt, 0)
+	job.UpdatedAt = time.Unix(updatedAt, 0)
+
+	rows, err := s.db.Query("SELECT idx, text, status, translation, error FROM job_items WHERE job_id = ? ORDER BY idx", id)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	job.Items = []*JobItem{}
+	for rows.Next() {
+		item := &JobItem{}
+		if err := rows.Scan(&item.Index, &item.Text, &item.Status, &item.Translation, &item.Error); err != nil {
+			return nil, err
+		}
+		job.Items = append(job.Items, item)
+	}
+	return job, rows.Err()
+}
+
+// UpdateJobStatus 更新任务状态和更新时间
+func (s *SQLiteDB) UpdateJobStatus(id, status string) error {
+	_, err := s.db.Exec("UPDATE jobs SET status = ?, updated_at = ? WHERE id = ?", status, time.Now().Unix(), id)
+	return err
+}
+
+// UpdateJobItem 保存条目的处理结果
+func (s *SQLiteDB) UpdateJobItem(jobID string, item *JobItem) error {
+	_, err := s.db.Exec("UPDATE job_items SET status = ?, translation = ?, error = ? WHERE job_id = ? AND idx = ?",
+		item.Status, item.Translation, item.Error, jobID, item.Index)
+	return err
+}
+
+// GetUnfinishedJobs 获取排队中或执行中的任务
+func (s *SQLiteDB) GetUnfinishedJobs() ([]*Job, error) {
+	rows, err := s.db.Query("SELECT id FROM jobs WHERE status IN (?, ?) ORDER BY created_at, id", JobQueued, JobRunning)
+	if err != nil {
+		return nil, err
+	}
+	var ids []string
+	for rows.Next() {
+		var id string
+		if err := rows.Scan(&id); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	// SQLite 只有一个连接，读取条目前需要先关闭上面的查询
+	jobs := make([]*Job, 0, len(ids))
+	for _, id := range ids {
+		job, err := s.GetJob(id)
+		if err != nil {
+			return nil, err
+		}
+		jobs = append(jobs, job)
+	}
+	return jobs, nil
+}
+
+// PruneJobs 删除指定时间之前结束的任务及其条目
+func (s *SQLiteDB) PruneJobs(before time.Time) error {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	finished := "SELECT id FROM jobs WHERE status IN (?, ?) AND updated_at < ?"
+	args := []any{JobCompleted, JobCanceled, before.Unix()}
+	if _, err := tx.Exec("DELETE FROM job_items WHERE job_id IN ("+finished+")", args...); err != nil {
+		return err
+	}
+	if _, err := tx.Exec("DELETE FROM jobs WHERE id IN ("+finished+")", args...); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// AddUsage 累加一次模型调用的用量
+func (s *SQLiteDB) AddUsage(usage *Usage) error {
+	_, err := s.db.Exec(`INSERT INTO usage_daily (day, provider, model, calls, input_tokens, output_tokens, cost)
+		VALUES (?, ?, ?, ?, ?, ?, ?)
+		ON CONFLICT (day, provider, model) DO UPDATE SET
+			calls = calls + excluded.calls,
+			input_tokens = input_tokens + excluded.input_tokens,
+			output_tokens = output_tokens + excluded.output_tokens,
+			cost = cost + excluded.cost`,
+		usage.Day, usage.Provider, usage.Model, usage.Calls, usage.InputTokens, usage.OutputTokens, usage.Cost)
+	return err
+}
+
+// GetUsage 获取日期范围内的用量
+func (s *SQLiteDB) GetUsage(from, to string) ([]*Usage, error) {
+	rows, err := s.db.Query(`SELECT day, provider, model, calls, input_tokens, output_tokens, cost FROM usage_daily
+		WHERE day >= ? AND day <= ? ORDER BY day, provider, model`, from, to)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var usages []*Usage
+	for rows.Next() {
+		u := &Usage{}
+		if err := rows.Scan(&u.Day, &u.Provider, &u.Model, &u.Calls, &u.InputTokens, &u.OutputTokens, &u.Cost); err != nil {
+			return nil, err
+		}
+		usages = append(usages, u)
+	}
+	return usages, rows.Err()
+}
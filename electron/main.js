@@ -8,15 +8,30 @@ const AutoLaunch = require('auto-launch');
 let mainWindow;
 let tray;
 let goProcess;
+let goServiceStopped = null; // 等待Go服务退出的 Promise
 let isQuitting = false;
 
 // 应用配置
 const config = {
-  port: 8080,
+  port: 0, // Go服务启动后报告的实际端口
+  token: '', // Go服务启动时发送的访问令牌
   isDev: process.env.NODE_ENV === 'development',
   appName: '剪贴板翻译'
 };
 
+// Go服务报告端口后 resolve，访问令牌在端口之前发送
+let resolveService;
+let rejectService;
+const serviceReady = new Promise((resolve, reject) => {
+  resolveService = resolve;
+  rejectService = reject;
+});
+
+// 请求Go服务接口时携带访问令牌
+function authHeaders(headers = {}) {
+  return { ...headers, Authorization: `Bearer ${config.token}` };
+}
+
 // 自启动配置
 const autoLauncher = new AutoLaunch({
   name: config.appName,
@@ -93,11 +108,18 @@ function createMainWindow() {
   loadAppPage();
 }
 
+// 页面地址；页面用 URL 片段中的令牌登录，令牌不会出现在请求和日志中
+function appURL() {
+  return `http://localhost:${config.port}/#token=${config.token}`;
+}
+
 // 加载应用页面
 async function loadAppPage() {
   try {
+    await serviceReady;
     await checkGoService();
-    mainWindow.loadURL(`http://localhost:${config.port}`);
+    mainWindow.loadURL(appURL());
+    subscribeServerEvents();
   } catch (error) {
     console.error('Failed to load app page:', error);
     // 加载错误页面
@@ -148,6 +170,9 @@ function checkGoService(maxAttempts = 30) {
   });
 }
 
+// Go服务发送的事件行的前缀，与 actions.go 中的 shellEventPrefix 一致
+const GO_EVENT_PREFIX = '@ct-event ';
+
 // 启动Go后端服务
 function startGoService() {
   const exePath = getResourcePath('clipboard-translate.exe');
@@ -163,19 +188,40 @@ function startGoService() {
   // 设置环境变量
   const env = { ...process.env };
 
-  // 启动Go进程
-  goProcess = spawn(exePath, [], {
+  // 启动Go进程，由服务自动选择空闲端口并通过标准输出报告
+  goProcess = spawn(exePath, ['--port', '0'], {
     cwd: path.dirname(exePath),
     env: env,
     windowsHide: true
   });
 
-  goProcess.stdout.on('data', (data) => {
-    console.log(`Go服务输出: ${data}`);
+  // Go服务在标准输出中按行发送事件（以 GO_EVENT_PREFIX 开头的 JSON），日志写入标准错误。
+  // 一次 data 可能只包含半行，未结束的部分留到下次拼接。事件可能包含访问令牌，只记录事件名称。
+  let stdoutBuffer = '';
+  goProcess.stdout.setEncoding('utf8');
+  goProcess.stdout.on('data', (chunk) => {
+    stdoutBuffer += chunk;
+    const lines = stdoutBuffer.split(/\r?\n/);
+    stdoutBuffer = lines.pop();
+    lines.forEach((line) => {
+      if (!line.startsWith(GO_EVENT_PREFIX)) {
+        return;
+      }
+      let message;
+      try {
+        message = JSON.parse(line.slice(GO_EVENT_PREFIX.length));
+      } catch (e) {
+        console.error('无法解析Go服务事件:', e.message);
+        return;
+      }
+      console.log(`Go服务事件: ${message.event}`);
+      handleGoEvent(message);
+    });
   });
 
+  goProcess.stderr.setEncoding('utf8');
   goProcess.stderr.on('data', (data) => {
-    console.error(`Go服务错误: ${data}`);
+    process.stderr.write(data);
   });
 
   goProcess.on('error', (err) => {
@@ -185,12 +231,117 @@ function startGoService() {
 
   goProcess.on('close', (code) => {
     console.log(`Go服务进程退出，代码: ${code}`);
+    rejectService(new Error('Go服务未报告端口即退出'));
     if (!isQuitting && code !== 0) {
       showErrorDialog('服务异常', `后端服务异常退出，退出代码: ${code}`);
     }
   });
 }
 
+// 处理Go服务发送的事件
+function handleGoEvent(message) {
+  switch (message.event) {
+    case 'api-token':
+      config.token = message.token;
+      break;
+    case 'server-started': {
+      // 服务启动或切换端口；已有实例在运行时报告该实例的端口（existing）
+      const changed = config.port !== 0 && config.port !== message.port;
+      config.port = message.port;
+      resolveService();
+      // 事件推送在旧服务关闭后会自动重连到新端口，只需重新加载页面
+      if (changed && mainWindow) {
+        mainWindow.loadURL(appURL());
+      }
+      break;
+    }
+    case 'toggle-window':
+      if (!mainWindow) {
+        return;
+      }
+      if (mainWindow.isVisible() && mainWindow.isFocused()) {
+        mainWindow.hide();
+      } else {
+        mainWindow.show();
+        mainWindow.focus();
+      }
+      break;
+    case 'translation-progress':
+      // 长文本分段翻译的进度，完成后清除任务栏进度条
+      if (mainWindow && message.total > 0) {
+        mainWindow.setProgressBar(message.done >= message.total ? -1 : message.done / message.total);
+      }
+      break;
+  }
+}
+
+// 订阅Go服务推送的事件（Server-Sent Events），连接断开后自动重连
+function subscribeServerEvents() {
+  if (isQuitting) {
+    return;
+  }
+
+  const http = require('http');
+  let reconnecting = false;
+  const reconnect = () => {
+    if (reconnecting) {
+      return;
+    }
+    reconnecting = true;
+    setTimeout(subscribeServerEvents, 2000);
+  };
+
+  const req = http.get(`http://localhost:${config.port}/api/events`, { headers: authHeaders() }, (res) => {
+    let buffer = '';
+    res.setEncoding('utf8');
+    res.on('data', (chunk) => {
+      buffer += chunk.replace(/\r\n/g, '\n');
+      let end;
+      while ((end = buffer.indexOf('\n\n')) >= 0) {
+        const block = buffer.slice(0, end);
+        buffer = buffer.slice(end + 2);
+
+        // 每个事件由 event: 和 data: 行组成，: 开头的是心跳注释
+        let event = '';
+        let data = '';
+        block.split('\n').forEach((line) => {
+          if (line.startsWith('event:')) {
+            event = line.slice(6).trim();
+          } else if (line.startsWith('data:')) {
+            data += line.slice(5).trim();
+          }
+        });
+        if (event && data) {
+          try {
+            handleServerEvent(event, JSON.parse(data));
+          } catch (e) {
+            console.error('解析服务端事件失败:', e);
+          }
+        }
+      }
+    });
+    res.on('end', reconnect);
+  });
+  req.on('error', reconnect);
+}
+
+// 处理服务端事件：剪贴板翻译进行中时在任务栏显示进度
+function handleServerEvent(event, data) {
+  if (!mainWindow || data.origin !== 'clipboard') {
+    return;
+  }
+  switch (event) {
+    case 'translation-started':
+      // 大于 1 的值显示为不确定进度
+      mainWindow.setProgressBar(2);
+      break;
+    case 'translation-completed':
+    case 'translation-failed':
+      mainWindow.setProgressBar(-1);
+      break;
+  }
+}
+
 // 创建系统托盘
 function createTray() {
   const iconPath = path.join(__dirname, 'assets', 'icon.png');
@@ -292,10 +443,10 @@ function triggerTranslation() {
       port: config.port,
       path: '/api/refresh',
       method: 'POST',
-      headers: {
+      headers: authHeaders({
         'Content-Type': 'application/json',
         'Content-Length': Buffer.byteLength(postData)
-      }
+      })
     };
 
     const req = http.request(options, (res) => {
@@ -338,23 +489,38 @@ function showAboutDialog() {
   });
 }
 
-// 退出应用
-function quitApp() {
-  isQuitting = true;
-
-  // 终止Go进程
-  if (goProcess && !goProcess.killed) {
-    console.log('正在终止Go服务...');
-    goProcess.kill('SIGTERM');
+// 请求Go服务退出：通过标准输入发送退出命令（Windows 上无法发送 SIGTERM），
+// 服务会等待进行中的翻译完成后再关闭；超时仍未退出时强制终止
+function stopGoService() {
+  if (!goProcess || goProcess.exitCode !== null || goProcess.signalCode !== null) {
+    return Promise.resolve();
+  }
+  if (!goServiceStopped) {
+    goServiceStopped = new Promise((resolve) => {
+      const proc = goProcess;
+      const timer = setTimeout(() => {
+        console.warn('Go服务未能按时退出，强制终止');
+        proc.kill('SIGKILL');
+      }, 15000);
+      proc.once('exit', () => {
+        clearTimeout(timer);
+        resolve();
+      });
 
-    // 如果进程没有正常退出，强制终止
-    setTimeout(() => {
-      if (!goProcess.killed) {
-        goProcess.kill('SIGKILL');
-      }
-    }, 5000);
+      console.log('正在停止Go服务...');
+      proc.stdin.write('shutdown\n', (err) => {
+        if (err) {
+          proc.kill('SIGTERM');
+        }
+      });
+    });
   }
+  return goServiceStopped;
+}
 
+// 退出应用
+function quitApp() {
+  isQuitting = true;
   app.quit();
 }
 
@@ -412,12 +578,9 @@ app.on('before-quit', () => {
 });
 
 app.on('will-quit', (event) => {
-  if (goProcess && !goProcess.killed) {
+  if (goProcess && goProcess.exitCode === null && goProcess.signalCode === null) {
     event.preventDefault();
-    goProcess.kill();
-    setTimeout(() => {
-      app.quit();
-    }, 2000);
+    stopGoService().then(() => app.quit());
   }
 });
 
@@ -0,0 +1,133 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	log "clipboard-translate/utils/log"
+)
+
+// 推送给界面和外壳的事件类型
+const (
+	EventTranslationStarted   = "translation-started"
+	EventTranslationCompleted = "translation-completed"
+	EventTranslationFailed    = "translation-failed"
+	EventHistoryCleared       = "history-cleared"
+	EventConfigChanged        = "config-changed"
+	EventBudgetWarning        = "budget-warning"
+	EventBudgetExceeded       = "budget-exceeded"
+)
+
+// 订阅者的事件缓冲，处理不及时的订阅者会丢弃新事件
+const eventBufferSize = 64
+
+// SSE 连接的心跳间隔，避免空闲连接被代理或浏览器断开
+const eventHeartbeat = 30 * time.Second
+
+// 服务端事件
+type serverEvent struct {
+	ID   uint64
+	Type string
+	Data any
+}
+
+// 翻译事件的数据，同一次翻译的开始和结束事件使用相同的 ID
+type translationEvent struct {
+	ID         string `json:"id"`
+	Origin     string `json:"origin"` // 触发方式：clipboard、api、job、i18n
+	Action     string `json:"action,omitempty"`
+	Direction  string `json:"direction,omitempty"`
+	Original   string `json:"original,omitempty"`
+	Translated string `json:"translated,omitempty"`
+	Error      string `json:"error,omitempty"`
+	HistoryID  string `json:"history_id,omitempty"` // 保存到历史记录时的记录 ID
+}
+
+// 进程内的事件总线，事件按发布顺序分发给所有订阅者
+type eventBus struct {
+	mu          sync.Mutex
+	nextID      uint64
+	subscribers map[chan serverEvent]struct{}
+}
+
+var events = &eventBus{subscribers: make(map[chan serverEvent]struct{})}
+
+// 订阅事件，返回的函数用于取消订阅
+func (b *eventBus) subscribe() (<-chan serverEvent, func()) {
+	ch := make(chan serverEvent, eventBufferSize)
+	b.mu.Lock()
+	b.subscribers[ch] = struct{}{}
+	b.mu.Unlock()
+
+	return ch, func() {
+		b.mu.Lock()
+		delete(b.subscribers, ch)
+		b.mu.Unlock()
+	}
+}
+
+// 发布事件，不会阻塞发布者
+func (b *eventBus) publish(eventType string, data any) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.nextID++
+	event := serverEvent{ID: b.nextID, Type: eventType, Data: data}
+	for ch := range b.subscribers {
+		select {
+		case ch <- event:
+		default:
+			log.Debug("事件订阅者处理不及时，丢弃事件: %s", eventType)
+		}
+	}
+}
+
+// 新的翻译事件 ID
+func newTranslationID() string {
+	return fmt.Sprintf("%d", time.Now().UnixNano())
+}
+
+// 以 Server-Sent Events 推送事件，直到客户端断开、服务关闭或程序退出
+func serveEvents(c *gin.Context) {
+	closing := serverClosing(c.Request.Context())
+	ch, unsubscribe := events.subscribe()
+	defer unsubscribe()
+
+	c.Header("Content-Type", "text/event-stream")
+	c.Header("Cache-Control", "no-cache")
+	c.Header("Connection", "keep-alive")
+	c.Header("X-Accel-Buffering", "no")
+	c.Status(200)
+
+	// 立即发送一个注释，使客户端确认连接已建立
+	fmt.Fprint(c.Writer, ": connected\n\n")
+	c.Writer.Flush()
+
+	heartbeat := time.NewTicker(eventHeartbeat)
+	defer heartbeat.Stop()
+	c.Stream(func(w io.Writer) bool {
+		select {
+		case <-c.Request.Context().Done():
+			return false
+		case <-closing:
+			return false
+		case <-app.stopping.Done():
+			return false
+		case <-heartbeat.C:
+			fmt.Fprint(w, ": ping\n\n")
+		case event := <-ch:
+			data, err := json.Marshal(event.Data)
+			if err != nil {
+				log.Error("序列化事件失败: %v", err)
+				return true
+			}
+			fmt.Fprintf(w, "id: %d\nevent: %s\ndata: %s\n\n", event.ID, event.Type, data)
+		}
+		return true
+	})
+}
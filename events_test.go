@@ -0,0 +1,157 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// 从通道中取出当前缓冲的全部事件
+func drainEvents(ch <-chan serverEvent) []serverEvent {
+	var got []serverEvent
+	for {
+		select {
+		case event := <-ch:
+			got = append(got, event)
+		default:
+			return got
+		}
+	}
+}
+
+func TestEventBus(t *testing.T) {
+	t.Run("按发布顺序分发给所有订阅者", func(t *testing.T) {
+		bus := &eventBus{subscribers: make(map[chan serverEvent]struct{})}
+		bus.publish(EventConfigChanged, "订阅前的事件")
+		a, unsubscribeA := bus.subscribe()
+		defer unsubscribeA()
+		b, unsubscribeB := bus.subscribe()
+		defer unsubscribeB()
+
+		bus.publish(EventTranslationStarted, "1")
+		bus.publish(EventTranslationCompleted, "2")
+		want := []serverEvent{
+			{ID: 2, Type: EventTranslationStarted, Data: "1"},
+			{ID: 3, Type: EventTranslationCompleted, Data: "2"},
+		}
+		for name, ch := range map[string]<-chan serverEvent{"a": a, "b": b} {
+			if got := drainEvents(ch); !reflect.DeepEqual(got, want) {
+				t.Errorf("订阅者 %s 收到 %+v, want %+v", name, got, want)
+			}
+		}
+	})
+
+	t.Run("缓冲已满时丢弃新事件而不阻塞", func(t *testing.T) {
+		bus := &eventBus{subscribers: make(map[chan serverEvent]struct{})}
+		slow, unsubscribeSlow := bus.subscribe()
+		defer unsubscribeSlow()
+
+		done := make(chan struct{})
+		go func() {
+			for i := 0; i < eventBufferSize+10; i++ {
+				bus.publish(EventTranslationStarted, i)
+			}
+			close(done)
+		}()
+		select {
+		case <-done:
+		case <-time.After(5 * time.Second):
+			t.Fatal("订阅者处理不及时时发布被阻塞")
+		}
+
+		got := drainEvents(slow)
+		if len(got) != eventBufferSize {
+			t.Fatalf("收到 %d 个事件, want %d", len(got), eventBufferSize)
+		}
+		if last := got[len(got)-1]; last.Data != eventBufferSize-1 {
+			t.Errorf("最后一个事件为 %+v，应保留较早的事件", last)
+		}
+
+		// 缓冲腾出后继续接收
+		bus.publish(EventHistoryCleared, nil)
+		if got := drainEvents(slow); len(got) != 1 || got[0].Type != EventHistoryCleared {
+			t.Errorf("缓冲腾出后收到 %+v", got)
+		}
+	})
+
+	t.Run("取消订阅后不再接收", func(t *testing.T) {
+		bus := &eventBus{subscribers: make(map[chan serverEvent]struct{})}
+		ch, unsubscribe := bus.subscribe()
+		unsubscribe()
+		bus.publish(EventConfigChanged, nil)
+		if got := drainEvents(ch); len(got) != 0 {
+			t.Errorf("取消订阅后收到 %+v", got)
+		}
+		if len(bus.subscribers) != 0 {
+			t.Errorf("取消订阅后仍有 %d 个订阅者", len(bus.subscribers))
+		}
+	})
+}
+
+func TestServeEvents(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.GET("/events", serveEvents)
+	srv := httptest.NewServer(r)
+	defer srv.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, srv.URL+"/events", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	if got := resp.Header.Get("Content-Type"); got != "text/event-stream" {
+		t.Errorf("Content-Type = %q", got)
+	}
+
+	// 读取一个以空行结束的帧
+	reader := bufio.NewReader(resp.Body)
+	readFrame := func() []string {
+		t.Helper()
+		var lines []string
+		for {
+			line, err := reader.ReadString('\n')
+			if err != nil {
+				t.Fatalf("读取事件失败: %v", err)
+			}
+			line = strings.TrimSuffix(line, "\n")
+			if line == "" {
+				return lines
+			}
+			lines = append(lines, line)
+		}
+	}
+
+	if got := readFrame(); !reflect.DeepEqual(got, []string{": connected"}) {
+		t.Fatalf("首个帧为 %q", got)
+	}
+
+	// 发送连接注释前已完成订阅，此后发布的事件都会推送
+	events.publish(EventTranslationCompleted, translationEvent{ID: "t1", Origin: "api", Translated: "你好"})
+	events.mu.Lock()
+	id := events.nextID
+	events.mu.Unlock()
+
+	want := []string{
+		fmt.Sprintf("id: %d", id),
+		"event: " + EventTranslationCompleted,
+		`data: {"id":"t1","origin":"api","translated":"你好"}`,
+	}
+	if got := readFrame(); !reflect.DeepEqual(got, want) {
+		t.Errorf("事件帧为 %q, want %q", got, want)
+	}
+}
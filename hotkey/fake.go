@@ -0,0 +1,102 @@
+package hotkey
+
+import (
+	"sync"
+)
+
+// FakeManager 用于测试的热键管理器，不与操作系统交互
+type FakeManager struct {
+	mu       sync.Mutex
+	bindings map[int]binding
+	events   chan Event
+	closed   bool
+}
+
+// NewFakeManager 创建测试用热键管理器
+func NewFakeManager() *FakeManager {
+	return &FakeManager{
+		bindings: make(map[int]binding),
+		events:   make(chan Event, 16),
+	}
+}
+
+// Register 注册热键，相同组合键被其他ID占用时返回 ErrAlreadyRegistered
+func (f *FakeManager) Register(id int, modifiers uint16, key uint16) error {
+	if err := validate(modifiers, key); err != nil {
+		return err
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.closed {
+		return ErrClosed
+	}
+
+	for otherID, b := range f.bindings {
+		if otherID != id && b.modifiers == modifiers && b.key == key {
+			return ErrAlreadyRegistered
+		}
+	}
+
+	f.bindings[id] = binding{modifiers: modifiers, key: key}
+	return nil
+}
+
+// Unregister 取消注册热键
+func (f *FakeManager) Unregister(id int) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if _, ok := f.bindings[id]; !ok {
+		return ErrNotRegistered
+	}
+	delete(f.bindings, id)
+	return nil
+}
+
+// Events 返回热键触发事件通道
+func (f *FakeManager) Events() <-chan Event {
+	return f.events
+}
+
+// Close 关闭管理器
+func (f *FakeManager) Close() error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.closed {
+		return nil
+	}
+	f.closed = true
+	f.bindings = make(map[int]binding)
+	close(f.events)
+	return nil
+}
+
+// Trigger 模拟按下已注册的热键，热键未注册、管理器已关闭或事件通道已满时返回 false
+//
+// 不阻塞等待读取事件，避免持有锁时与 Register、Close 等调用互相等待。
+func (f *FakeManager) Trigger(id int) bool {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if _, ok := f.bindings[id]; !ok || f.closed {
+		return false
+	}
+	select {
+	case f.events <- Event{ID: id}:
+		return true
+	default:
+		return false
+	}
+}
+
+// Registered 判断热键是否已注册
+func (f *FakeManager) Registered(id int) bool {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	_, ok := f.bindings[id]
+	return ok
+}
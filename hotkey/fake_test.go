@@ -0,0 +1,70 @@
+package hotkey
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestFakeManagerRegister(t *testing.T) {
+	tests := []struct {
+		name      string
+		id        int
+		modifiers uint16
+		key       uint16
+		wantErr   error
+	}{
+		{"新热键", 1, 0x2, 'T', nil},
+		{"同一ID重新注册", 1, 0x2, 'T', nil},
+		{"组合键被占用", 2, 0x2, 'T', ErrAlreadyRegistered},
+		{"修饰键不同", 2, 0x1, 'T', nil},
+		{"缺少按键", 3, 0x2, 0, ErrInvalidKey},
+	}
+	f := NewFakeManager()
+	defer f.Close()
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := f.Register(tt.id, tt.modifiers, tt.key)
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("Register() = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+
+	if err := f.Unregister(3); !errors.Is(err, ErrNotRegistered) {
+		t.Errorf("Unregister 未注册的热键 = %v, want ErrNotRegistered", err)
+	}
+}
+
+func TestFakeManagerTrigger(t *testing.T) {
+	f := NewFakeManager()
+	if err := f.Register(1, 0x2, 'T'); err != nil {
+		t.Fatal(err)
+	}
+
+	if f.Trigger(2) {
+		t.Error("未注册的热键不应触发")
+	}
+	if !f.Trigger(1) {
+		t.Fatal("已注册的热键应触发")
+	}
+	if ev := <-f.Events(); ev.ID != 1 {
+		t.Errorf("事件ID = %d, want 1", ev.ID)
+	}
+
+	// 事件通道已满时不阻塞
+	for f.Trigger(1) {
+	}
+	if err := f.Unregister(1); err != nil {
+		t.Errorf("通道已满时 Unregister 失败: %v", err)
+	}
+
+	if err := f.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if f.Trigger(1) {
+		t.Error("关闭后不应触发")
+	}
+	if err := f.Register(1, 0x2, 'T'); !errors.Is(err, ErrClosed) {
+		t.Errorf("关闭后 Register = %v, want ErrClosed", err)
+	}
+}
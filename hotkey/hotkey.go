@@ -0,0 +1,51 @@
+package hotkey
+
+import (
+	"errors"
+	"fmt"
+)
+
+// Event 热键触发事件
+type Event struct {
+	ID int // 触发的热键ID
+}
+
+// Manager 定义全局热键管理器接口
+type Manager interface {
+	// Register 注册热键，modifiers 为 constants.MOD_* 组合，key 为虚拟键码
+	Register(id int, modifiers uint16, key uint16) error
+	// Unregister 取消注册热键
+	Unregister(id int) error
+	// Events 返回热键触发事件通道
+	Events() <-chan Event
+	// Close 取消所有热键并释放资源
+	Close() error
+}
+
+// 通用错误定义
+var (
+	ErrAlreadyRegistered = errors.New("热键已被占用")
+	ErrNotRegistered     = errors.New("热键未注册")
+	ErrInvalidKey        = errors.New("无效的热键")
+	ErrUnsupported       = errors.New("当前平台不支持全局热键")
+	ErrClosed            = errors.New("热键管理器已关闭")
+)
+
+// New 创建当前平台的热键管理器
+func New() (Manager, error) {
+	return newPlatformManager()
+}
+
+// binding 已注册热键的组合键
+type binding struct {
+	modifiers uint16
+	key       uint16
+}
+
+// 检查热键参数
+func validate(modifiers uint16, key uint16) error {
+	if key == 0 {
+		return fmt.Errorf("%w: 缺少按键", ErrInvalidKey)
+	}
+	return nil
+}
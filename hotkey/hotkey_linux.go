@@ -0,0 +1,241 @@
+//go:build linux
+
+package hotkey
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/jezek/xgb"
+	"github.com/jezek/xgb/xproto"
+
+	"clipboard-translate/constants"
+)
+
+// 锁定类修饰符（CapsLock、NumLock）不应影响热键匹配
+var ignoredMasks = []uint16{
+	0,
+	xproto.ModMaskLock,
+	xproto.ModMask2,
+	xproto.ModMaskLock | xproto.ModMask2,
+}
+
+// x11Grab 已抓取的按键
+type x11Grab struct {
+	mods    uint16
+	keycode xproto.Keycode
+}
+
+// x11Manager 基于 XGrabKey 的热键管理器
+type x11Manager struct {
+	conn    *xgb.Conn
+	root    xproto.Window
+	keymap  map[xproto.Keysym]xproto.Keycode
+	mu      sync.Mutex
+	grabs   map[int]x11Grab
+	events  chan Event
+	closed  bool
+	stopped chan struct{}
+}
+
+func newPlatformManager() (Manager, error) {
+	// 没有 DISPLAY 时（如无头服务器）连接会失败
+	conn, err := xgb.NewConn()
+	if err != nil {
+		return nil, fmt.Errorf("%w: 无法连接X11: %v", ErrUnsupported, err)
+	}
+
+	setup := xproto.Setup(conn)
+	m := &x11Manager{
+		conn:    conn,
+		root:    setup.DefaultScreen(conn).Root,
+		keymap:  make(map[xproto.Keysym]xproto.Keycode),
+		grabs:   make(map[int]x11Grab),
+		events:  make(chan Event, 16),
+		stopped: make(chan struct{}),
+	}
+
+	if err := m.loadKeymap(setup); err != nil {
+		conn.Close()
+		return nil, err
+	}
+
+	go m.loop()
+	return m, nil
+}
+
+// 读取键盘映射，建立 keysym 到 keycode 的对照表
+func (m *x11Manager) loadKeymap(setup *xproto.SetupInfo) error {
+	minCode, maxCode := setup.MinKeycode, setup.MaxKeycode
+	reply, err := xproto.GetKeyboardMapping(m.conn, minCode, byte(maxCode-minCode+1)).Reply()
+	if err != nil {
+		return fmt.Errorf("读取键盘映射失败: %w", err)
+	}
+
+	perCode := int(reply.KeysymsPerKeycode)
+	for i, sym := range reply.Keysyms {
+		if sym == 0 {
+			continue
+		}
+		code := xproto.Keycode(int(minCode) + i/perCode)
+		if _, ok := m.keymap[sym]; !ok {
+			m.keymap[sym] = code
+		}
+	}
+	return nil
+}
+
+// 事件循环
+func (m *x11Manager) loop() {
+	defer close(m.stopped)
+	defer close(m.events)
+
+	for {
+		ev, err := m.conn.WaitForEvent()
+		if ev == nil && err == nil {
+			// 连接已关闭
+			return
+		}
+		if err != nil {
+			continue
+		}
+
+		press, ok := ev.(xproto.KeyPressEvent)
+		if !ok {
+			continue
+		}
+
+		state := press.State &^ (xproto.ModMaskLock | xproto.ModMask2)
+		m.mu.Lock()
+		for id, g := range m.grabs {
+			if g.keycode == press.Detail && g.mods == state {
+				select {
+				case m.events <- Event{ID: id}:
+				default:
+					// 消费者处理不过来时丢弃事件
+				}
+				break
+			}
+		}
+		m.mu.Unlock()
+	}
+}
+
+// 将 constants.MOD_* 转换为 X11 修饰符掩码
+func x11Modifiers(modifiers uint16) uint16 {
+	var mods uint16
+	if modifiers&constants.MOD_ALT != 0 {
+		mods |= xproto.ModMask1
+	}
+	if modifiers&constants.MOD_CONTROL != 0 {
+		mods |= xproto.ModMaskControl
+	}
+	if modifiers&constants.MOD_SHIFT != 0 {
+		mods |= xproto.ModMaskShift
+	}
+	if modifiers&constants.MOD_WIN != 0 {
+		mods |= xproto.ModMask4
+	}
+	return mods
+}
+
+// Register 注册热键
+func (m *x11Manager) Register(id int, modifiers uint16, key uint16) error {
+	if err := validate(modifiers, key); err != nil {
+		return err
+	}
+
+	sym, ok := keysymFromVirtualKey(key)
+	if !ok {
+		return fmt.Errorf("%w: 无法映射虚拟键码 0x%02X", ErrInvalidKey, key)
+	}
+	code, ok := m.keymap[sym]
+	if !ok {
+		return fmt.Errorf("%w: 当前键盘布局中没有键码 0x%02X", ErrInvalidKey, key)
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.closed {
+		return ErrClosed
+	}
+
+	grab := x11Grab{mods: x11Modifiers(modifiers), keycode: code}
+	for otherID, g := range m.grabs {
+		if otherID != id && g == grab {
+			return ErrAlreadyRegistered
+		}
+	}
+
+	// 同一ID重复注册时先取消旧的组合键
+	if old, ok := m.grabs[id]; ok {
+		m.ungrab(old)
+		delete(m.grabs, id)
+	}
+
+	for i, mask := range ignoredMasks {
+		err := xproto.GrabKeyChecked(m.conn, true, m.root, grab.mods|mask, grab.keycode,
+			xproto.GrabModeAsync, xproto.GrabModeAsync).Check()
+		if err != nil {
+			// 回滚已抓取的组合
+			for _, done := range ignoredMasks[:i] {
+				xproto.UngrabKeyChecked(m.conn, grab.keycode, m.root, grab.mods|done).Check()
+			}
+			var accessErr xproto.AccessError
+			if errors.As(err, &accessErr) {
+				return ErrAlreadyRegistered
+			}
+			return fmt.Errorf("注册热键失败: %w", err)
+		}
+	}
+
+	m.grabs[id] = grab
+	return nil
+}
+
+// 释放按键抓取，调用方需持有锁
+func (m *x11Manager) ungrab(g x11Grab) {
+	for _, mask := range ignoredMasks {
+		xproto.UngrabKeyChecked(m.conn, g.keycode, m.root, g.mods|mask).Check()
+	}
+}
+
+// Unregister 取消注册热键
+func (m *x11Manager) Unregister(id int) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	g, ok := m.grabs[id]
+	if !ok {
+		return ErrNotRegistered
+	}
+	m.ungrab(g)
+	delete(m.grabs, id)
+	return nil
+}
+
+// Events 返回热键触发事件通道
+func (m *x11Manager) Events() <-chan Event {
+	return m.events
+}
+
+// Close 取消所有热键并断开X11连接
+func (m *x11Manager) Close() error {
+	m.mu.Lock()
+	if m.closed {
+		m.mu.Unlock()
+		return nil
+	}
+	m.closed = true
+	for id, g := range m.grabs {
+		m.ungrab(g)
+		delete(m.grabs, id)
+	}
+	m.mu.Unlock()
+
+	m.conn.Close()
+	<-m.stopped
+	return nil
+}
@@ -0,0 +1,8 @@
+//go:build !windows && !linux
+
+package hotkey
+
+// 其他平台暂不支持全局热键
+func newPlatformManager() (Manager, error) {
+	return nil, ErrUnsupported
+}
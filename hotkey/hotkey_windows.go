@@ -0,0 +1,203 @@
+//go:build windows
+
+package hotkey
+
+import (
+	"fmt"
+	"runtime"
+	"sync"
+	"syscall"
+	"unsafe"
+)
+
+var (
+	user32                 = syscall.NewLazyDLL("user32.dll")
+	kernel32               = syscall.NewLazyDLL("kernel32.dll")
+	procRegisterHotKey     = user32.NewProc("RegisterHotKey")
+	procUnregisterHotKey   = user32.NewProc("UnregisterHotKey")
+	procGetMessage         = user32.NewProc("GetMessageW")
+	procPeekMessage        = user32.NewProc("PeekMessageW")
+	procPostThreadMessage  = user32.NewProc("PostThreadMessageW")
+	procGetCurrentThreadId = kernel32.NewProc("GetCurrentThreadId")
+)
+
+const (
+	wmQuit   = 0x0012
+	wmHotkey = 0x0312
+	wmApp    = 0x8000 // 唤醒消息循环处理注册请求
+
+	modNoRepeat = 0x4000
+
+	errorHotkeyAlreadyRegistered = 1409
+)
+
+// Windows 消息结构体
+type winMsg struct {
+	HWND    uintptr
+	Message uint32
+	WParam  uintptr
+	LParam  uintptr
+	Time    uint32
+	Pt      struct {
+		X int32
+		Y int32
+	}
+}
+
+// 注册/取消注册请求，由消息循环线程执行
+type request struct {
+	register bool
+	id       int
+	binding  binding
+	result   chan error
+}
+
+// windowsManager 基于 RegisterHotKey 的热键管理器
+//
+// RegisterHotKey 注册的线程热键只会投递到注册线程的消息队列，
+// 所以所有注册操作都转交给锁定了系统线程的消息循环执行。
+type windowsManager struct {
+	threadID  uintptr
+	requests  chan request
+	events    chan Event
+	done      chan struct{}
+	closeOnce sync.Once
+}
+
+func newPlatformManager() (Manager, error) {
+	m := &windowsManager{
+		requests: make(chan request),
+		events:   make(chan Event, 16),
+		done:     make(chan struct{}),
+	}
+
+	ready := make(chan struct{})
+	go m.loop(ready)
+	<-ready
+
+	return m, nil
+}
+
+// 消息循环
+func (m *windowsManager) loop(ready chan struct{}) {
+	runtime.LockOSThread()
+	defer runtime.UnlockOSThread()
+	defer close(m.done)
+	defer close(m.events)
+
+	m.threadID, _, _ = procGetCurrentThreadId.Call()
+
+	// 调用 PeekMessage 确保线程消息队列已创建
+	var msg winMsg
+	procPeekMessage.Call(uintptr(unsafe.Pointer(&msg)), 0, 0, 0, 0)
+	close(ready)
+
+	registered := make(map[int]binding)
+	defer func() {
+		for id := range registered {
+			procUnregisterHotKey.Call(0, uintptr(id))
+		}
+	}()
+
+	for {
+		ret, _, _ := procGetMessage.Call(uintptr(unsafe.Pointer(&msg)), 0, 0, 0)
+		if int32(ret) <= 0 {
+			// WM_QUIT 或出错，退出循环
+			return
+		}
+
+		switch msg.Message {
+		case wmHotkey:
+			select {
+			case m.events <- Event{ID: int(msg.WParam)}:
+			default:
+				// 消费者处理不过来时丢弃事件
+			}
+		case wmApp:
+			req := <-m.requests
+			req.result <- m.handle(req, registered)
+		}
+	}
+}
+
+// 在消息循环线程中执行注册请求
+func (m *windowsManager) handle(req request, registered map[int]binding) error {
+	if !req.register {
+		if _, ok := registered[req.id]; !ok {
+			return ErrNotRegistered
+		}
+		procUnregisterHotKey.Call(0, uintptr(req.id))
+		delete(registered, req.id)
+		return nil
+	}
+
+	// 同一ID重复注册时先取消旧的组合键
+	if _, ok := registered[req.id]; ok {
+		procUnregisterHotKey.Call(0, uintptr(req.id))
+		delete(registered, req.id)
+	}
+
+	ret, _, errno := procRegisterHotKey.Call(
+		0,
+		uintptr(req.id),
+		uintptr(req.binding.modifiers|modNoRepeat),
+		uintptr(req.binding.key))
+	if ret == 0 {
+		if e, ok := errno.(syscall.Errno); ok && e == errorHotkeyAlreadyRegistered {
+			return ErrAlreadyRegistered
+		}
+		return fmt.Errorf("注册热键失败: %v", errno)
+	}
+
+	registered[req.id] = req.binding
+	return nil
+}
+
+// 将请求投递到消息循环并等待结果
+func (m *windowsManager) do(req request) error {
+	select {
+	case <-m.done:
+		return ErrClosed
+	default:
+	}
+
+	req.result = make(chan error, 1)
+	ret, _, errno := procPostThreadMessage.Call(m.threadID, wmApp, 0, 0)
+	if ret == 0 {
+		return fmt.Errorf("投递热键消息失败: %v", errno)
+	}
+
+	select {
+	case m.requests <- req:
+	case <-m.done:
+		return ErrClosed
+	}
+	return <-req.result
+}
+
+// Register 注册热键
+func (m *windowsManager) Register(id int, modifiers uint16, key uint16) error {
+	if err := validate(modifiers, key); err != nil {
+		return err
+	}
+	return m.do(request{register: true, id: id, binding: binding{modifiers: modifiers, key: key}})
+}
+
+// Unregister 取消注册热键
+func (m *windowsManager) Unregister(id int) error {
+	return m.do(request{id: id})
+}
+
+// Events 返回热键触发事件通道
+func (m *windowsManager) Events() <-chan Event {
+	return m.events
+}
+
+// Close 退出消息循环并取消所有热键
+func (m *windowsManager) Close() error {
+	m.closeOnce.Do(func() {
+		procPostThreadMessage.Call(m.threadID, wmQuit, 0, 0)
+		<-m.done
+	})
+	return nil
+}
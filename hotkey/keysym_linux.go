@@ -0,0 +1,83 @@
+//go:build linux
+
+package hotkey
+
+import (
+	"github.com/jezek/xgb/xproto"
+
+	"clipboard-translate/constants"
+)
+
+// 非字母数字按键的虚拟键码到 X11 keysym 映射
+var virtualKeyToKeysym = map[uint16]xproto.Keysym{
+	constants.VK_SPACE:    0x0020, // XK_space
+	constants.VK_INSERT:   0xff63, // XK_Insert
+	constants.VK_DELETE:   0xffff, // XK_Delete
+	constants.VK_BACK:     0xff08, // XK_BackSpace
+	constants.VK_TAB:      0xff09, // XK_Tab
+	constants.VK_RETURN:   0xff0d, // XK_Return
+	constants.VK_PAUSE:    0xff13, // XK_Pause
+	constants.VK_CAPITAL:  0xffe5, // XK_Caps_Lock
+	constants.VK_ESCAPE:   0xff1b, // XK_Escape
+	constants.VK_SNAPSHOT: 0xff61, // XK_Print
+	constants.VK_NUMLOCK:  0xff7f, // XK_Num_Lock
+	constants.VK_SCROLL:   0xff14, // XK_Scroll_Lock
+
+	// 导航键
+	constants.VK_HOME:  0xff50, // XK_Home
+	constants.VK_LEFT:  0xff51, // XK_Left
+	constants.VK_UP:    0xff52, // XK_Up
+	constants.VK_RIGHT: 0xff53, // XK_Right
+	constants.VK_DOWN:  0xff54, // XK_Down
+	constants.VK_PRIOR: 0xff55, // XK_Prior
+	constants.VK_NEXT:  0xff56, // XK_Next
+	constants.VK_END:   0xff57, // XK_End
+
+	// 小键盘运算符
+	constants.VK_MULTIPLY:  0xffaa, // XK_KP_Multiply
+	constants.VK_ADD:       0xffab, // XK_KP_Add
+	constants.VK_SEPARATOR: 0xffac, // XK_KP_Separator
+	constants.VK_SUBTRACT:  0xffad, // XK_KP_Subtract
+	constants.VK_DECIMAL:   0xffae, // XK_KP_Decimal
+	constants.VK_DIVIDE:    0xffaf, // XK_KP_Divide
+
+	// OEM 标点键
+	constants.VK_OEM_1:      0x003b, // XK_semicolon
+	constants.VK_OEM_PLUS:   0x003d, // XK_equal
+	constants.VK_OEM_COMMA:  0x002c, // XK_comma
+	constants.VK_OEM_MINUS:  0x002d, // XK_minus
+	constants.VK_OEM_PERIOD: 0x002e, // XK_period
+	constants.VK_OEM_2:      0x002f, // XK_slash
+	constants.VK_OEM_3:      0x0060, // XK_grave
+	constants.VK_OEM_4:      0x005b, // XK_bracketleft
+	constants.VK_OEM_5:      0x005c, // XK_backslash
+	constants.VK_OEM_6:      0x005d, // XK_bracketright
+	constants.VK_OEM_7:      0x0027, // XK_apostrophe
+
+	// 媒体键
+	constants.VK_VOLUME_MUTE:      0x1008ff12, // XF86XK_AudioMute
+	constants.VK_VOLUME_DOWN:      0x1008ff11, // XF86XK_AudioLowerVolume
+	constants.VK_VOLUME_UP:        0x1008ff13, // XF86XK_AudioRaiseVolume
+	constants.VK_MEDIA_PLAY_PAUSE: 0x1008ff14, // XF86XK_AudioPlay
+	constants.VK_MEDIA_STOP:       0x1008ff15, // XF86XK_AudioStop
+	constants.VK_MEDIA_PREV_TRACK: 0x1008ff16, // XF86XK_AudioPrev
+	constants.VK_MEDIA_NEXT_TRACK: 0x1008ff17, // XF86XK_AudioNext
+}
+
+// 将 Windows 虚拟键码转换为 X11 keysym
+func keysymFromVirtualKey(key uint16) (xproto.Keysym, bool) {
+	switch {
+	case key >= constants.VK_A && key <= constants.VK_Z:
+		// 键盘映射第一列为小写字母
+		return xproto.Keysym(key - constants.VK_A + 'a'), true
+	case key >= constants.VK_0 && key <= constants.VK_9:
+		return xproto.Keysym(key), true
+	case key >= constants.VK_F1 && key <= constants.VK_F24:
+		return xproto.Keysym(0xffbe + uint32(key-constants.VK_F1)), true // XK_F1 起
+	case key >= constants.VK_NUMPAD0 && key <= constants.VK_NUMPAD9:
+		return xproto.Keysym(0xffb0 + uint32(key-constants.VK_NUMPAD0)), true // XK_KP_0 起
+	}
+
+	sym, ok := virtualKeyToKeysym[key]
+	return sym, ok
+}
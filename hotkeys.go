@@ -0,0 +1,134 @@
+package main
+
+import (
+	"context"
+
+	"clipboard-translate/config"
+	"clipboard-translate/constants"
+	"clipboard-translate/hotkey"
+	log "clipboard-translate/utils/log"
+)
+
+// 注册单个热键动作，未设置按键的动作会被跳过
+func registerHotKey(action string, hk config.HotkeyConfig) error {
+	if !hk.Enabled() {
+		return nil
+	}
+	if hotkeyManager == nil {
+		return hotkey.ErrUnsupported
+	}
+
+	modifiers := config.ModifierFromString(hk.Modifiers)
+	key := config.VirtualKeyFromString(hk.Key)
+	return hotkeyManager.Register(constants.HotkeyActionIDs[action], modifiers, key)
+}
+
+// 取消注册单个热键动作
+func unregisterHotKey(action string) {
+	if hotkeyManager == nil {
+		return
+	}
+	hotkeyManager.Unregister(constants.HotkeyActionIDs[action])
+}
+
+// 注册所有已启用的热键，返回注册失败的动作及原因
+func registerHotKeys() map[string]error {
+	hotkeys := config.GetConfig().Hotkeys
+	errs := make(map[string]error)
+
+	for _, action := range constants.HotkeyActions {
+		hk := hotkeys[action]
+		if err := registerHotKey(action, hk); err != nil {
+			log.Error("注册热键 %s (%s) 失败: %v", action, hk, err)
+			errs[action] = err
+		} else if hk.Enabled() {
+			log.Info("已注册热键 %s: %s", action, hk)
+		}
+	}
+
+	return errs
+}
+
+// 重新注册配置发生变化的热键，返回注册失败的动作及原因
+func reregisterHotKeys(old map[string]config.HotkeyConfig) map[string]error {
+	hotkeys := config.GetConfig().Hotkeys
+	errs := make(map[string]error)
+
+	// 先取消所有变化的热键，避免动作之间互换组合键时误报冲突
+	var changed []string
+	for _, action := range constants.HotkeyActions {
+		if !hotkeys[action].Equals(old[action]) {
+			unregisterHotKey(action)
+			changed = append(changed, action)
+		}
+	}
+
+	for _, action := range changed {
+		if err := registerHotKey(action, hotkeys[action]); err != nil {
+			log.Error("重新注册热键 %s 失败: %v", action, err)
+			errs[action] = err
+		}
+	}
+
+	return errs
+}
+
+// 取消注册所有热键
+func unregisterHotKeys() {
+	for _, action := range constants.HotkeyActions {
+		unregisterHotKey(action)
+	}
+}
+
+// 热键ID对应的动作名称
+func hotkeyAction(id int) string {
+	for action, actionID := range constants.HotkeyActionIDs {
+		if actionID == id {
+			return action
+		}
+	}
+	return "unknown"
+}
+
+// 根据热键ID执行动作
+func handleHotkey(ctx context.Context, id int) {
+	switch id {
+	case constants.HOTKEY_ID:
+		triggerTranslation(ctx)
+	case constants.HOTKEY_ID_TRANSLATE_ALT:
+		triggerAction(ctx, constants.ACTION_TRANSLATE_ALT)
+	case constants.HOTKEY_ID_EXPLAIN:
+		triggerAction(ctx, constants.ACTION_EXPLAIN)
+	case constants.HOTKEY_ID_POLISH:
+		triggerAction(ctx, constants.ACTION_POLISH)
+	case constants.HOTKEY_ID_SUMMARIZE:
+		triggerAction(ctx, constants.ACTION_SUMMARIZE)
+	case constants.HOTKEY_ID_SHOW_HIDE:
+		sendShellEvent("toggle-window", nil)
+	case constants.HOTKEY_ID_REPEAT_LAST:
+		repeatLastAction(ctx)
+	default:
+		log.Warn("未知的热键ID: %d", id)
+	}
+}
+
+// 监听热键，直到 ctx 结束；热键触发的动作作为后台任务执行，退出时等待其完成
+func listenHotkey(ctx context.Context) {
+	registerHotKeys()
+	defer unregisterHotKeys()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case ev, ok := <-hotkeyManager.Events():
+			if !ok {
+				return
+			}
+			log.Info("检测到热键 %d，开始处理...", ev.ID)
+			hotkeyTriggersTotal.WithLabelValues(hotkeyAction(ev.ID)).Inc()
+			id := ev.ID
+			app.Go(func(ctx context.Context) { handleHotkey(ctx, id) })
+		}
+	}
+}
@@ -0,0 +1,142 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"clipboard-translate/config"
+	"clipboard-translate/constants"
+	"clipboard-translate/hotkey"
+)
+
+// 使用测试用热键管理器并加载给定的热键配置
+func setupHotkeys(t *testing.T, hotkeys map[string]any) *hotkey.FakeManager {
+	t.Helper()
+	if err := config.SetConfigFile(setupCommandDir(t, "http://127.0.0.1:1", map[string]any{"hotkeys": hotkeys})); err != nil {
+		t.Fatal(err)
+	}
+	if err := config.LoadConfig(); err != nil {
+		t.Fatal(err)
+	}
+
+	fake := hotkey.NewFakeManager()
+	saved := hotkeyManager
+	hotkeyManager = fake
+	t.Cleanup(func() {
+		fake.Close()
+		hotkeyManager = saved
+	})
+	return fake
+}
+
+func TestRegisterHotKeys(t *testing.T) {
+	fake := setupHotkeys(t, map[string]any{
+		"translate": map[string]any{"modifiers": []string{"control", "alt"}, "key": "t"},
+		"explain":   map[string]any{"modifiers": []string{"control", "alt"}, "key": "t"},
+		"polish":    map[string]any{"modifiers": []string{"control", "alt"}, "key": "p"},
+		"showHide":  map[string]any{"modifiers": []string{"control", "shift"}, "key": ""},
+	})
+
+	errs := registerHotKeys()
+	tests := []struct {
+		action     string
+		registered bool
+		failed     bool
+	}{
+		{constants.ACTION_TRANSLATE, true, false},
+		{constants.ACTION_EXPLAIN, false, true}, // 与翻译的组合键冲突
+		{constants.ACTION_POLISH, true, false},
+		{constants.ACTION_SHOW_HIDE, false, false}, // 未设置按键
+	}
+	for _, tt := range tests {
+		t.Run(tt.action, func(t *testing.T) {
+			if got := fake.Registered(constants.HotkeyActionIDs[tt.action]); got != tt.registered {
+				t.Errorf("已注册 = %v, want %v", got, tt.registered)
+			}
+			if _, got := errs[tt.action]; got != tt.failed {
+				t.Errorf("注册失败 = %v (%v), want %v", got, errs[tt.action], tt.failed)
+			}
+		})
+	}
+
+	// 两个动作互换组合键不应报告冲突
+	old := config.GetConfig().Hotkeys
+	cfg := config.GetConfig().Clone()
+	cfg.Hotkeys[constants.ACTION_TRANSLATE] = old[constants.ACTION_POLISH]
+	cfg.Hotkeys[constants.ACTION_POLISH] = old[constants.ACTION_TRANSLATE]
+	cfg.Hotkeys[constants.ACTION_EXPLAIN] = config.HotkeyConfig{Modifiers: []string{"control", "alt"}}
+	if err := config.SaveConfig(cfg); err != nil {
+		t.Fatal(err)
+	}
+	if errs := reregisterHotKeys(old); len(errs) != 0 {
+		t.Errorf("互换组合键后重新注册失败: %v", errs)
+	}
+
+	unregisterHotKeys()
+	for _, action := range constants.HotkeyActions {
+		if fake.Registered(constants.HotkeyActionIDs[action]) {
+			t.Errorf("%s 未取消注册", action)
+		}
+	}
+}
+
+func TestListenHotkeyDispatch(t *testing.T) {
+	fake := setupHotkeys(t, map[string]any{
+		"showHide": map[string]any{"modifiers": []string{"control", "shift"}, "key": "h"},
+	})
+
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	stdout := os.Stdout
+	os.Stdout = w
+	t.Cleanup(func() { os.Stdout = stdout })
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		listenHotkey(ctx)
+		close(done)
+	}()
+
+	// 等待监听循环注册热键
+	id := constants.HotkeyActionIDs[constants.ACTION_SHOW_HIDE]
+	deadline := time.Now().Add(time.Second)
+	for !fake.Registered(id) && time.Now().Before(deadline) {
+		time.Sleep(time.Millisecond)
+	}
+	if !fake.Trigger(id) {
+		t.Fatal("触发热键失败")
+	}
+
+	lines := make(chan string)
+	go func() {
+		if line, err := bufio.NewReader(r).ReadString('\n'); err == nil {
+			lines <- line
+		}
+	}()
+	select {
+	case line := <-lines:
+		if !strings.HasPrefix(line, shellEventPrefix) || !strings.Contains(line, `"toggle-window"`) {
+			t.Errorf("外壳事件为 %q", line)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("未收到显示/隐藏窗口事件")
+	}
+
+	cancel()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("监听循环未退出")
+	}
+	if fake.Registered(id) {
+		t.Error("退出监听后应取消注册热键")
+	}
+	w.Close()
+}
@@ -0,0 +1,283 @@
+package i18n
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"clipboard-translate/ai"
+)
+
+// 支持的本地化文件格式
+const (
+	FormatJSON    = "json"
+	FormatARB     = "arb"
+	FormatYAML    = "yaml"
+	FormatPO      = "po"
+	FormatStrings = "strings"
+)
+
+// ErrUnsupportedFormat 无法识别的文件格式
+var ErrUnsupportedFormat = errors.New("不支持的本地化文件格式，支持 JSON、YAML、.po、.strings 和 ARB")
+
+// 需要原样保留的占位符：i18next 的 {{name}}、ICU 的 {name} 和 {n, number}、printf 的 %s %1$d %@，以及换行
+var placeholderPattern = regexp.MustCompile(`\{\{\s*[\w.]+\s*\}\}` +
+	`|\{[\w.]+(?:,\s*\w+(?:,\s*[^{}]*)?)?\}` +
+	`|%(?:\d+\$)?[-+#0]*\d*(?:\.\d+)?(?:hh|h|ll|l|L|q|j|z|t)?[diouxXeEfFgGaAcspn@%]` +
+	`|\n`)
+
+// Instruction 追加到系统提示词中，说明本地化字符串的翻译要求，与 ai.NumberedInstruction 一起使用
+const Instruction = `输入的每一条都是软件界面中的一个字符串。
+* 译文简洁，符合目标语言软件界面的常用说法。
+* 形如 ⟦0⟧ 的标记是变量或换行的占位符，必须原样保留，不要翻译、删除或重复。
+* ICU 消息格式（如 {count, plural, one {...} other {...}}）只翻译花括号内的文字，保留其结构和关键字。`
+
+// Entry 本地化文件中的一个字符串
+type Entry struct {
+	Key   string
+	Text  string
+	Fuzzy bool // 译文被标记为需要复查（.po 的 fuzzy 标志）
+}
+
+// Document 解析后的本地化文件
+type Document interface {
+	// Sources 作为原文文件时需要翻译的条目，按文件顺序排列
+	Sources() []Entry
+	// Translations 作为译文文件时已有的译文，键为条目键
+	Translations() map[string]Entry
+	// Render 以本文件的结构输出译文文件；values 中没有的条目视为未翻译，target 为已有的译文文件，可能为 nil
+	Render(values map[string]string, language string, target Document) ([]byte, error)
+}
+
+// DetectFormat 根据文件扩展名识别格式，.json 文件包含 @@locale 时按 ARB 处理
+func DetectFormat(name string, data []byte) (string, error) {
+	switch strings.ToLower(filepath.Ext(name)) {
+	case ".json":
+		if strings.Contains(string(data), `"@@locale"`) {
+			return FormatARB, nil
+		}
+		return FormatJSON, nil
+	case ".arb":
+		return FormatARB, nil
+	case ".yaml", ".yml":
+		return FormatYAML, nil
+	case ".po", ".pot":
+		return FormatPO, nil
+	case ".strings":
+		return FormatStrings, nil
+	}
+	return "", ErrUnsupportedFormat
+}
+
+// Parse 按格式解析本地化文件
+func Parse(format string, data []byte) (Document, error) {
+	var (
+		doc Document
+		err error
+	)
+	switch format {
+	case FormatJSON, FormatARB, FormatYAML:
+		doc, err = parseTree(format, data)
+	case FormatPO:
+		doc, err = parsePO(data)
+	case FormatStrings:
+		doc, err = parseStrings(data)
+	default:
+		return nil, ErrUnsupportedFormat
+	}
+	if err != nil {
+		return nil, err
+	}
+	return doc, nil
+}
+
+// Status 条目的比较结果
+type Status string
+
+const (
+	StatusMissing   Status = "missing"   // 译文文件中没有该条目或译文为空
+	StatusChanged   Status = "changed"   // 原文在上次翻译后有修改，或译文被标记为需要复查
+	StatusUnchanged Status = "unchanged" // 已有译文且原文未修改
+	StatusObsolete  Status = "obsolete"  // 原文文件中已没有该条目，输出时删除
+)
+
+// Change 差异报告中的一个条目
+type Change struct {
+	Key            string `json:"key"`
+	Status         Status `json:"status"`
+	Source         string `json:"source,omitempty"`
+	PreviousSource string `json:"previous_source,omitempty"` // 上次翻译时的原文
+	PreviousTarget string `json:"previous_target,omitempty"` // 原有译文
+	Target         string `json:"target,omitempty"`          // 新译文
+	Error          string `json:"error,omitempty"`           // 翻译失败的原因，失败时保留原有译文
+}
+
+// Report 差异报告，只列出有变化的条目
+type Report struct {
+	Format    string   `json:"format"`
+	Language  string   `json:"language"`
+	Changes   []Change `json:"changes"`
+	Unchanged int      `json:"unchanged"`
+}
+
+// Count 返回指定状态的条目数
+func (r *Report) Count(status Status) int {
+	n := 0
+	for _, change := range r.Changes {
+		if change.Status == status {
+			n++
+		}
+	}
+	return n
+}
+
+// Failed 返回翻译失败的条目数
+func (r *Report) Failed() int {
+	n := 0
+	for _, change := range r.Changes {
+		if change.Error != "" {
+			n++
+		}
+	}
+	return n
+}
+
+// Plan 比较原文文件与已有译文，previous 为上次翻译时各条目的原文
+//
+// 没有上次记录的已有译文视为人工翻译，保持不变。
+func Plan(format, language string, source, target Document, previous map[string]string) *Report {
+	report := &Report{Format: format, Language: language, Changes: []Change{}}
+
+	translations := map[string]Entry{}
+	if target != nil {
+		translations = target.Translations()
+	}
+
+	keys := make(map[string]bool)
+	for _, entry := range source.Sources() {
+		keys[entry.Key] = true
+		change := Change{Key: entry.Key, Source: entry.Text}
+		existing, ok := translations[entry.Key]
+		prev, recorded := previous[entry.Key]
+		switch {
+		case entry.Text == "":
+			report.Unchanged++
+			continue
+		case !ok || existing.Text == "":
+			change.Status = StatusMissing
+		case existing.Fuzzy || (recorded && prev != entry.Text):
+			change.Status = StatusChanged
+			change.PreviousTarget = existing.Text
+			if recorded && prev != entry.Text {
+				change.PreviousSource = prev
+			}
+		default:
+			report.Unchanged++
+			continue
+		}
+		report.Changes = append(report.Changes, change)
+	}
+
+	if target != nil {
+		for _, entry := range target.Sources() {
+			if !keys[entry.Key] {
+				report.Changes = append(report.Changes, Change{Key: entry.Key, Status: StatusObsolete, PreviousTarget: translations[entry.Key].Text})
+			}
+		}
+	}
+	return report
+}
+
+// Options 本地化文件翻译参数
+type Options struct {
+	Language string // 目标语言
+	Batch    ai.BatchOptions
+	DryRun   bool // 只生成差异报告，不调用模型
+}
+
+// Result 翻译结果
+type Result struct {
+	Content []byte            // 译文文件，试运行时为空
+	Report  *Report           // 差异报告
+	Sources map[string]string // 当前译文对应的原文，供下次比较是否修改
+	Usage   ai.Usage          // 本次翻译的token用量
+}
+
+// Translate 翻译原文文件中缺失或修改过的条目，生成完整的译文文件
+//
+// 占位符在发送前替换为 ⟦n⟧，返回后校验并还原；校验失败的条目保留原有译文并在报告中注明。
+func Translate(ctx context.Context, client ai.AIClient, format string, source, target Document, previous map[string]string, prompt ai.ChunkPrompt, opts Options, progress func(done, total int)) (*Result, error) {
+	report := Plan(format, opts.Language, source, target, previous)
+	result := &Result{Report: report, Sources: make(map[string]string)}
+	if opts.DryRun {
+		return result, nil
+	}
+
+	values := make(map[string]string)
+	if target != nil {
+		for key, entry := range target.Translations() {
+			values[key] = entry.Text
+		}
+	}
+
+	// 需要翻译的条目
+	var pending []int
+	var masks []*ai.MaskedText
+	var texts []string
+	for i, change := range report.Changes {
+		if change.Status != StatusMissing && change.Status != StatusChanged {
+			continue
+		}
+		masked := ai.MaskPattern(change.Source, placeholderPattern)
+		pending = append(pending, i)
+		masks = append(masks, masked)
+		texts = append(texts, masked.Text)
+	}
+
+	if len(texts) > 0 {
+		translated, usage, err := ai.CompleteNumbered(ctx, client, texts, opts.Batch, prompt, progress)
+		result.Usage = usage
+		if err != nil {
+			return nil, err
+		}
+		for j, i := range pending {
+			change := &report.Changes[i]
+			text, err := masks[j].Restore(translated[j])
+			if err != nil {
+				change.Error = err.Error()
+				continue
+			}
+			change.Target = text
+			values[change.Key] = text
+		}
+	}
+
+	// 记录本次有效译文对应的原文；翻译失败的条目保留之前的记录，下次仍会重新翻译
+	for key, text := range previous {
+		result.Sources[key] = text
+	}
+	failed := make(map[string]bool)
+	for _, change := range report.Changes {
+		if change.Error != "" {
+			failed[change.Key] = true
+		}
+		if change.Status == StatusObsolete {
+			delete(result.Sources, change.Key)
+		}
+	}
+	for _, entry := range source.Sources() {
+		if !failed[entry.Key] && values[entry.Key] != "" {
+			result.Sources[entry.Key] = entry.Text
+		}
+	}
+
+	content, err := source.Render(values, opts.Language, target)
+	if err != nil {
+		return nil, fmt.Errorf("生成译文文件失败: %w", err)
+	}
+	result.Content = content
+	return result, nil
+}
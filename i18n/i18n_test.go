@@ -0,0 +1,402 @@
+package i18n
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"regexp"
+	"strings"
+	"testing"
+
+	"clipboard-translate/ai"
+)
+
+func TestDetectFormat(t *testing.T) {
+	tests := []struct {
+		name string
+		data string
+		want string
+	}{
+		{"en.json", `{"a": "b"}`, FormatJSON},
+		{"app_en.json", `{"@@locale": "en", "a": "b"}`, FormatARB},
+		{"intl_en.ARB", `{}`, FormatARB},
+		{"en.yml", ``, FormatYAML},
+		{"en.yaml", ``, FormatYAML},
+		{"messages.pot", ``, FormatPO},
+		{"zh_CN.po", ``, FormatPO},
+		{"Localizable.strings", ``, FormatStrings},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := DetectFormat(tt.name, []byte(tt.data))
+			if err != nil || got != tt.want {
+				t.Errorf("DetectFormat(%q) = %q, %v, want %q", tt.name, got, err, tt.want)
+			}
+		})
+	}
+
+	if _, err := DetectFormat("strings.xml", nil); !errors.Is(err, ErrUnsupportedFormat) {
+		t.Errorf("DetectFormat(strings.xml) error = %v, want %v", err, ErrUnsupportedFormat)
+	}
+}
+
+func TestParseSources(t *testing.T) {
+	tests := []struct {
+		name   string
+		format string
+		data   string
+		want   []Entry // 按文件顺序
+	}{
+		{
+			name:   "JSON 嵌套对象和数组，保持键顺序",
+			format: FormatJSON,
+			data:   `{"zeta": "Z", "menu": {"open": "Open {{name}}", "count": 3, "items": ["One", "Two"]}, "alpha": "A"}`,
+			want: []Entry{
+				{Key: "zeta", Text: "Z"},
+				{Key: "menu.open", Text: "Open {{name}}"},
+				{Key: "menu.items.0", Text: "One"},
+				{Key: "menu.items.1", Text: "Two"},
+				{Key: "alpha", Text: "A"},
+			},
+		},
+		{
+			name:   "ARB 跳过元数据",
+			format: FormatARB,
+			data:   `{"@@locale": "en", "hello": "Hello {name}", "@hello": {"description": "greeting"}}`,
+			want:   []Entry{{Key: "hello", Text: "Hello {name}"}},
+		},
+		{
+			name:   "YAML 根节点为语言代码",
+			format: FormatYAML,
+			data:   "en:\n  title: Title\n  nav:\n    home: Home\n",
+			want:   []Entry{{Key: "title", Text: "Title"}, {Key: "nav.home", Text: "Home"}},
+		},
+		{
+			name:   "YAML 没有语言代码",
+			format: FormatYAML,
+			data:   "title: Title\nenabled: true\n",
+			want:   []Entry{{Key: "title", Text: "Title"}},
+		},
+		{
+			name:   "po 上下文、复数和多行字符串，忽略头部和废弃条目",
+			format: FormatPO,
+			data: `msgid ""
+msgstr "Language: en\n"
+
+#: main.c:1
+msgctxt "menu"
+msgid "Open"
+msgstr ""
+
+msgid "%d file"
+msgid_plural "%d files"
+msgstr[0] ""
+msgstr[1] ""
+
+msgid ""
+"Line one\n"
+"Line two"
+msgstr ""
+
+#~ msgid "Old"
+#~ msgstr "旧"
+`,
+			want: []Entry{
+				{Key: "[menu] Open", Text: "Open"},
+				{Key: "%d file", Text: "%d file"},
+				{Key: "%d file" + pluralSuffix, Text: "%d files"},
+				{Key: "Line one\nLine two", Text: "Line one\nLine two"},
+			},
+		},
+		{
+			name:   ".strings 注释、转义和未加引号的键",
+			format: FormatStrings,
+			data:   "/* Title */\n\"title\" = \"Hello \\\"%@\\\"\";\n// comment\nsave_button = \"Save\\nnow\";\n",
+			want:   []Entry{{Key: "title", Text: `Hello "%@"`}, {Key: "save_button", Text: "Save\nnow"}},
+		},
+		{
+			name:   ".strings UTF-16 编码",
+			format: FormatStrings,
+			data:   "\xff\xfe\"\x00a\x00\"\x00=\x00\"\x00b\x00\"\x00;\x00",
+			want:   []Entry{{Key: "a", Text: "b"}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			doc, err := Parse(tt.format, []byte(tt.data))
+			if err != nil {
+				t.Fatalf("Parse() error = %v", err)
+			}
+			if got := doc.Sources(); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Sources() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	tests := []struct {
+		name   string
+		format string
+		data   string
+	}{
+		{"JSON 根节点不是对象", FormatJSON, `["a"]`},
+		{"无效的 YAML", FormatYAML, "a: [b"},
+		{"po 多余的字符串", FormatPO, `"orphan"`},
+		{"po 未知的关键字", FormatPO, `msgfoo "x"`},
+		{".strings 缺少分号", FormatStrings, `"a" = "b"`},
+		{".strings 不是 UTF-8", FormatStrings, "\"a\" = \"\xff\";"},
+		{"不支持的格式", "xml", `<resources/>`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := Parse(tt.format, []byte(tt.data)); err == nil {
+				t.Error("Parse() error = nil, want error")
+			}
+		})
+	}
+}
+
+func TestTranslations(t *testing.T) {
+	doc, err := Parse(FormatPO, []byte(`#, fuzzy, c-format
+msgid "Open"
+msgstr "打开"
+
+msgid "%d file"
+msgid_plural "%d files"
+msgstr[0] "%d 个文件"
+msgstr[1] "%d 个文件们"
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]Entry{
+		"Open":                   {Key: "Open", Text: "打开", Fuzzy: true},
+		"%d file":                {Key: "%d file", Text: "%d 个文件"},
+		"%d file" + pluralSuffix: {Key: "%d file" + pluralSuffix, Text: "%d 个文件们"},
+	}
+	if got := doc.Translations(); !reflect.DeepEqual(got, want) {
+		t.Errorf("Translations() = %+v, want %+v", got, want)
+	}
+}
+
+func TestRender(t *testing.T) {
+	tests := []struct {
+		name     string
+		format   string
+		data     string
+		target   string // 已有的译文文件，为空表示没有
+		values   map[string]string
+		language string
+		want     string
+	}{
+		{
+			name:     "JSON 保持键顺序，删除未翻译的条目和空对象",
+			format:   FormatJSON,
+			data:     `{"zeta": "Z", "menu": {"open": "Open <b>{{name}}</b>", "close": "Close"}, "empty": {"x": "X"}, "n": 1}`,
+			values:   map[string]string{"zeta": "泽", "menu.open": "打开 <b>{{name}}</b>"},
+			language: "zh-CN",
+			want:     "{\n  \"zeta\": \"泽\",\n  \"menu\": {\n    \"open\": \"打开 <b>{{name}}</b>\"\n  },\n  \"n\": 1\n}\n",
+		},
+		{
+			name:     "ARB 更新 @@locale 并保留元数据",
+			format:   FormatARB,
+			data:     `{"@@locale": "en", "hello": "Hello {name}", "@hello": {"description": "greeting"}}`,
+			values:   map[string]string{"hello": "你好 {name}"},
+			language: "zh",
+			want:     "{\n  \"@@locale\": \"zh\",\n  \"hello\": \"你好 {name}\",\n  \"@hello\": {\n    \"description\": \"greeting\"\n  }\n}\n",
+		},
+		{
+			name:     "YAML 替换语言代码",
+			format:   FormatYAML,
+			data:     "en:\n  title: Title\n  nav:\n    home: Home\n",
+			values:   map[string]string{"title": "标题", "nav.home": "首页"},
+			language: "zh-CN",
+			want:     "zh-CN:\n  title: 标题\n  nav:\n    home: 首页\n",
+		},
+		{
+			name:   "po 使用已有译文的头部，填入复数并去掉 fuzzy",
+			format: FormatPO,
+			data: `msgid ""
+msgstr "Language: en\n"
+
+#, fuzzy, c-format
+msgid "%d file"
+msgid_plural "%d files"
+msgstr[0] ""
+msgstr[1] ""
+
+msgid "Untranslated"
+msgstr ""
+`,
+			target: `msgid ""
+msgstr ""
+"Language: zh\n"
+"Plural-Forms: nplurals=1; plural=0;\n"
+`,
+			values:   map[string]string{"%d file": "%d 个文件", "%d file" + pluralSuffix: "%d 个文件"},
+			language: "zh_CN",
+			want: `msgid ""
+msgstr ""
+"Language: zh_CN\n"
+"Plural-Forms: nplurals=1; plural=0;\n"
+
+#, c-format
+msgid "%d file"
+msgid_plural "%d files"
+msgstr[0] "%d 个文件"
+
+msgid "Untranslated"
+msgstr ""
+`,
+		},
+		{
+			name:     ".strings 保留注释和排版，删除未翻译的语句",
+			format:   FormatStrings,
+			data:     "/* Title */\n\"title\" = \"Hello %@\";\r\n\"skip\" = \"Skip\";\r\n\"empty\" = \"\";\n",
+			values:   map[string]string{"title": "你好 \"%@\""},
+			language: "zh-Hans",
+			want:     "/* Title */\n\"title\" = \"你好 \\\"%@\\\"\";\r\n\"empty\" = \"\";\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			doc, err := Parse(tt.format, []byte(tt.data))
+			if err != nil {
+				t.Fatal(err)
+			}
+			var target Document
+			if tt.target != "" {
+				if target, err = Parse(tt.format, []byte(tt.target)); err != nil {
+					t.Fatal(err)
+				}
+			}
+			got, err := doc.Render(tt.values, tt.language, target)
+			if err != nil {
+				t.Fatalf("Render() error = %v", err)
+			}
+			if string(got) != tt.want {
+				t.Errorf("Render() = %q, want %q", got, tt.want)
+			}
+
+			// 输出的文件能再次解析，译文与填入的值一致
+			out, err := Parse(tt.format, got)
+			if err != nil {
+				t.Fatalf("解析输出失败: %v", err)
+			}
+			for key, entry := range out.Translations() {
+				if value, ok := tt.values[key]; ok && entry.Text != value {
+					t.Errorf("输出中 %q = %q, want %q", key, entry.Text, value)
+				}
+			}
+		})
+	}
+}
+
+func TestPlan(t *testing.T) {
+	source, err := Parse(FormatJSON, []byte(`{"new": "New", "same": "Same", "edited": "Edited now", "manual": "Manual", "blank": ""}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	target, err := Parse(FormatJSON, []byte(`{"same": "相同", "edited": "已编辑", "manual": "手动", "gone": "已删除"}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	previous := map[string]string{"same": "Same", "edited": "Edited"}
+
+	report := Plan(FormatJSON, "zh", source, target, previous)
+	want := []Change{
+		{Key: "new", Status: StatusMissing, Source: "New"},
+		{Key: "edited", Status: StatusChanged, Source: "Edited now", PreviousSource: "Edited", PreviousTarget: "已编辑"},
+		{Key: "gone", Status: StatusObsolete, PreviousTarget: "已删除"},
+	}
+	if !reflect.DeepEqual(report.Changes, want) {
+		t.Errorf("Changes = %+v, want %+v", report.Changes, want)
+	}
+	// same 未修改，manual 没有上次记录视为人工翻译，blank 为空
+	if report.Unchanged != 3 {
+		t.Errorf("Unchanged = %d, want 3", report.Unchanged)
+	}
+}
+
+// 把编号行翻译为「译:原文」，原文包含 drop 时丢掉其中的占位符标记
+type fakeClient struct{}
+
+var (
+	numberedLine = regexp.MustCompile(`^\[(\d+)\] (.*)$`)
+	marker       = regexp.MustCompile(`⟦\d+⟧`)
+)
+
+func (fakeClient) Translate(ctx context.Context, text string) (ai.Result, error) {
+	return fakeClient{}.Complete(ctx, "", text)
+}
+
+func (fakeClient) Complete(ctx context.Context, system, text string) (ai.Result, error) {
+	var lines []string
+	for _, line := range strings.Split(text, "\n") {
+		m := numberedLine.FindStringSubmatch(line)
+		if m == nil {
+			continue
+		}
+		translated := m[2]
+		if strings.Contains(translated, "drop") {
+			translated = marker.ReplaceAllString(translated, "")
+		}
+		lines = append(lines, "["+m[1]+"] 译:"+translated)
+	}
+	return ai.Result{Text: strings.Join(lines, "\n"), Usage: ai.Usage{InputTokens: 10, OutputTokens: 5}}, nil
+}
+
+func (fakeClient) GetName() string  { return "fake" }
+func (fakeClient) GetModel() string { return "fake-model" }
+func (fakeClient) Close() error     { return nil }
+
+func TestTranslatePlaceholders(t *testing.T) {
+	source, err := Parse(FormatJSON, []byte(`{"greet": "Hi {{name}}, you have %d new\nmessages", "count": "{count, plural, one {# item} other {# items}}", "drop": "drop {user}"}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	target, err := Parse(FormatJSON, []byte(`{"drop": "旧译文 {user}"}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	// drop 在上次翻译后被修改，需要重新翻译
+	previous := map[string]string{"drop": "drop {user} old"}
+
+	prompt := func(string) (string, error) { return "", nil }
+	result, err := Translate(context.Background(), fakeClient{}, FormatJSON, source, target, previous, prompt, Options{Language: "zh"}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	got := make(map[string]Change)
+	for _, change := range result.Report.Changes {
+		got[change.Key] = change
+	}
+	// 占位符原样还原
+	if want := "译:Hi {{name}}, you have %d new\nmessages"; got["greet"].Target != want {
+		t.Errorf("greet = %q, want %q", got["greet"].Target, want)
+	}
+	if want := "译:{count, plural, one {# item} other {# items}}"; got["count"].Target != want {
+		t.Errorf("count = %q, want %q", got["count"].Target, want)
+	}
+	// 占位符丢失时报告错误，保留原有译文，不记录新原文
+	if got["drop"].Error == "" || got["drop"].Target != "" {
+		t.Errorf("drop = %+v，应报告占位符错误", got["drop"])
+	}
+	if result.Sources["drop"] != "drop {user} old" || result.Sources["greet"] == "" {
+		t.Errorf("Sources = %q", result.Sources)
+	}
+
+	want := "{\n  \"greet\": \"译:Hi {{name}}, you have %d new\\nmessages\",\n" +
+		"  \"count\": \"译:{count, plural, one {# item} other {# items}}\",\n" +
+		"  \"drop\": \"旧译文 {user}\"\n}\n"
+	if string(result.Content) != want {
+		t.Errorf("Content = %q, want %q", result.Content, want)
+	}
+	if result.Usage != (ai.Usage{InputTokens: 10, OutputTokens: 5}) {
+		t.Errorf("Usage = %+v", result.Usage)
+	}
+}
@@ -0,0 +1,323 @@
+package i18n
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// 复数条目的 msgid_plural 作为单独的条目翻译，键加上该后缀
+const pluralSuffix = " [plural]"
+
+var nPluralsPattern = regexp.MustCompile(`nplurals\s*=\s*(\d+)`)
+
+// gettext .po 文件中的一条消息
+type poEntry struct {
+	comments []string // # 开头的注释行，包括引用位置和标志
+	context  *string  // msgctxt
+	id       string
+	plural   *string // msgid_plural
+	strs     []string
+}
+
+// 条目键：有上下文时为 [msgctxt] msgid
+func (e *poEntry) key() string {
+	if e.context != nil {
+		return "[" + *e.context + "] " + e.id
+	}
+	return e.id
+}
+
+func (e *poEntry) fuzzy() bool {
+	for _, comment := range e.comments {
+		if strings.HasPrefix(comment, "#,") && strings.Contains(comment, "fuzzy") {
+			return true
+		}
+	}
+	return false
+}
+
+type poDocument struct {
+	header  *poEntry // msgid 为空的头部条目
+	entries []*poEntry
+}
+
+// 解析 .po/.pot 文件，忽略 #~ 开头的废弃条目
+func parsePO(data []byte) (*poDocument, error) {
+	d := &poDocument{}
+	var current *poEntry
+	var target *string // 后续引号行追加到的字段
+	sawStr := false
+
+	finish := func() {
+		if current == nil {
+			return
+		}
+		if current.id == "" && current.context == nil {
+			d.header = current
+		} else {
+			d.entries = append(d.entries, current)
+		}
+		current, target, sawStr = nil, nil, false
+	}
+	entry := func() *poEntry {
+		if current == nil {
+			current = &poEntry{}
+		}
+		return current
+	}
+
+	scanner := bufio.NewScanner(bytes.NewReader(bytes.TrimPrefix(data, []byte("\uFEFF"))))
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	for n := 1; scanner.Scan(); n++ {
+		line := strings.TrimSpace(scanner.Text())
+		switch {
+		case line == "":
+			finish()
+		case strings.HasPrefix(line, "#~"):
+			// 废弃条目
+		case strings.HasPrefix(line, "#"):
+			if sawStr {
+				finish()
+			}
+			e := entry()
+			e.comments = append(e.comments, line)
+			target = nil
+		case strings.HasPrefix(line, `"`):
+			if target == nil {
+				return nil, fmt.Errorf("第 %d 行: 多余的字符串", n)
+			}
+			s, err := unquotePO(line)
+			if err != nil {
+				return nil, fmt.Errorf("第 %d 行: %w", n, err)
+			}
+			*target += s
+		default:
+			keyword, rest, _ := strings.Cut(line, " ")
+			s, err := unquotePO(strings.TrimSpace(rest))
+			if err != nil {
+				return nil, fmt.Errorf("第 %d 行: %w", n, err)
+			}
+			if sawStr && (keyword == "msgctxt" || keyword == "msgid") {
+				finish()
+			}
+			e := entry()
+			switch {
+			case keyword == "msgctxt":
+				e.context = &s
+				target = e.context
+			case keyword == "msgid":
+				e.id = s
+				target = &e.id
+			case keyword == "msgid_plural":
+				e.plural = &s
+				target = e.plural
+			case keyword == "msgstr" || strings.HasPrefix(keyword, "msgstr["):
+				e.strs = append(e.strs, s)
+				target = &e.strs[len(e.strs)-1]
+				sawStr = true
+			default:
+				return nil, fmt.Errorf("第 %d 行: 未知的关键字 %s", n, keyword)
+			}
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	finish()
+	return d, nil
+}
+
+func (d *poDocument) Sources() []Entry {
+	var entries []Entry
+	for _, e := range d.entries {
+		entries = append(entries, Entry{Key: e.key(), Text: e.id})
+		if e.plural != nil {
+			entries = append(entries, Entry{Key: e.key() + pluralSuffix, Text: *e.plural})
+		}
+	}
+	return entries
+}
+
+func (d *poDocument) Translations() map[string]Entry {
+	translations := make(map[string]Entry)
+	for _, e := range d.entries {
+		fuzzy := e.fuzzy()
+		if len(e.strs) > 0 {
+			translations[e.key()] = Entry{Key: e.key(), Text: e.strs[0], Fuzzy: fuzzy}
+		}
+		if e.plural != nil && len(e.strs) > 1 {
+			translations[e.key()+pluralSuffix] = Entry{Key: e.key() + pluralSuffix, Text: e.strs[1], Fuzzy: fuzzy}
+		}
+	}
+	return translations
+}
+
+func (d *poDocument) Render(values map[string]string, language string, target Document) ([]byte, error) {
+	var buf bytes.Buffer
+
+	// 头部优先使用已有译文文件的，并更新 Language 字段
+	header := d.header
+	if t, ok := target.(*poDocument); ok && t.header != nil {
+		header = t.header
+	}
+	nplurals := 2
+	if header != nil {
+		h := *header
+		h.strs = []string{setPOHeader(firstOrEmpty(header.strs), "Language", language)}
+		if m := nPluralsPattern.FindStringSubmatch(h.strs[0]); m != nil {
+			if n, err := strconv.Atoi(m[1]); err == nil && n > 0 {
+				nplurals = n
+			}
+		}
+		writePOEntry(&buf, &h, h.comments)
+	}
+
+	for _, e := range d.entries {
+		out := *e
+		single, plural := values[e.key()], values[e.key()+pluralSuffix]
+		if e.plural != nil {
+			out.strs = make([]string, nplurals)
+			for i := range out.strs {
+				if i == 0 {
+					out.strs[i] = single
+				} else {
+					out.strs[i] = plural
+				}
+			}
+		} else {
+			out.strs = []string{single}
+		}
+
+		// 有译文的条目去掉 fuzzy 标志
+		comments := e.comments
+		if single != "" {
+			comments = removeFuzzy(comments)
+		}
+		if buf.Len() > 0 {
+			buf.WriteString("\n")
+		}
+		writePOEntry(&buf, &out, comments)
+	}
+	return buf.Bytes(), nil
+}
+
+func firstOrEmpty(strs []string) string {
+	if len(strs) == 0 {
+		return ""
+	}
+	return strs[0]
+}
+
+// 设置头部中的字段，不存在时追加
+func setPOHeader(header, field, value string) string {
+	lines := strings.SplitAfter(header, "\n")
+	for i, line := range lines {
+		if strings.HasPrefix(line, field+":") {
+			lines[i] = field + ": " + value + "\n"
+			return strings.Join(lines, "")
+		}
+	}
+	if header != "" && !strings.HasSuffix(header, "\n") {
+		header += "\n"
+	}
+	return header + field + ": " + value + "\n"
+}
+
+// 从 #, 标志行中去掉 fuzzy，没有其他标志时删除该行
+func removeFuzzy(comments []string) []string {
+	var kept []string
+	for _, comment := range comments {
+		if strings.HasPrefix(comment, "#,") {
+			var flags []string
+			for _, flag := range strings.Split(strings.TrimPrefix(comment, "#,"), ",") {
+				if flag = strings.TrimSpace(flag); flag != "" && flag != "fuzzy" {
+					flags = append(flags, flag)
+				}
+			}
+			if len(flags) == 0 {
+				continue
+			}
+			comment = "#, " + strings.Join(flags, ", ")
+		}
+		kept = append(kept, comment)
+	}
+	return kept
+}
+
+func writePOEntry(buf *bytes.Buffer, e *poEntry, comments []string) {
+	for _, comment := range comments {
+		buf.WriteString(comment + "\n")
+	}
+	if e.context != nil {
+		writePOString(buf, "msgctxt", *e.context)
+	}
+	writePOString(buf, "msgid", e.id)
+	if e.plural != nil {
+		writePOString(buf, "msgid_plural", *e.plural)
+		for i, s := range e.strs {
+			writePOString(buf, fmt.Sprintf("msgstr[%d]", i), s)
+		}
+		return
+	}
+	writePOString(buf, "msgstr", firstOrEmpty(e.strs))
+}
+
+// 输出字符串字段，包含换行时按 gettext 的习惯分多行
+func writePOString(buf *bytes.Buffer, keyword, s string) {
+	lines := strings.SplitAfter(s, "\n")
+	if len(lines) > 1 && lines[len(lines)-1] == "" {
+		lines = lines[:len(lines)-1]
+	}
+	if len(lines) <= 1 {
+		buf.WriteString(keyword + " " + quotePO(s) + "\n")
+		return
+	}
+	buf.WriteString(keyword + " \"\"\n")
+	for _, line := range lines {
+		buf.WriteString(quotePO(line) + "\n")
+	}
+}
+
+var poEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`, "\t", `\t`, "\r", `\r`)
+
+func quotePO(s string) string {
+	return `"` + poEscaper.Replace(s) + `"`
+}
+
+// 解析带引号的字符串，支持 C 风格的转义
+func unquotePO(s string) (string, error) {
+	if len(s) < 2 || s[0] != '"' || s[len(s)-1] != '"' {
+		return "", fmt.Errorf("无效的字符串 %s", s)
+	}
+	var sb strings.Builder
+	body := s[1 : len(s)-1]
+	for i := 0; i < len(body); i++ {
+		c := body[i]
+		if c != '\\' {
+			sb.WriteByte(c)
+			continue
+		}
+		i++
+		if i >= len(body) {
+			return "", fmt.Errorf("无效的转义 %s", s)
+		}
+		switch body[i] {
+		case 'n':
+			sb.WriteByte('\n')
+		case 't':
+			sb.WriteByte('\t')
+		case 'r':
+			sb.WriteByte('\r')
+		case '"', '\\':
+			sb.WriteByte(body[i])
+		default:
+			sb.WriteByte('\\')
+			sb.WriteByte(body[i])
+		}
+	}
+	return sb.String(), nil
+}
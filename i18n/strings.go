@@ -0,0 +1,244 @@
+package i18n
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode/utf16"
+	"unicode/utf8"
+)
+
+// Apple .strings 文件中的一个 "key" = "value"; 语句
+type stringsEntry struct {
+	key        string
+	value      string
+	start, end int // 整条语句在文本中的位置，不含其后的换行
+	valueStart int // 值（含引号）的起止位置
+	valueEnd   int
+}
+
+// 输出时保留原文件的注释和排版，只替换值
+type stringsDocument struct {
+	text    string
+	entries []stringsEntry
+}
+
+func parseStrings(data []byte) (*stringsDocument, error) {
+	text, err := decodeStringsFile(data)
+	if err != nil {
+		return nil, err
+	}
+
+	d := &stringsDocument{text: text}
+	p := &stringsParser{text: text}
+	for {
+		if err := p.skipSpace(); err != nil {
+			return nil, err
+		}
+		if p.pos >= len(text) {
+			return d, nil
+		}
+
+		var e stringsEntry
+		e.start = p.pos
+		if e.key, err = p.token(); err != nil {
+			return nil, err
+		}
+		if err := p.expect('='); err != nil {
+			return nil, err
+		}
+		if err := p.skipSpace(); err != nil {
+			return nil, err
+		}
+		e.valueStart = p.pos
+		if e.value, err = p.token(); err != nil {
+			return nil, err
+		}
+		e.valueEnd = p.pos
+		if err := p.expect(';'); err != nil {
+			return nil, err
+		}
+		e.end = p.pos
+		d.entries = append(d.entries, e)
+	}
+}
+
+// .strings 文件常见 UTF-16 编码，统一转为 UTF-8
+func decodeStringsFile(data []byte) (string, error) {
+	var order binary.ByteOrder
+	switch {
+	case bytes.HasPrefix(data, []byte{0xFF, 0xFE}):
+		order = binary.LittleEndian
+	case bytes.HasPrefix(data, []byte{0xFE, 0xFF}):
+		order = binary.BigEndian
+	default:
+		data = bytes.TrimPrefix(data, []byte("\uFEFF"))
+		if !utf8.Valid(data) {
+			return "", fmt.Errorf(".strings 文件必须使用 UTF-8 或 UTF-16 编码")
+		}
+		return string(data), nil
+	}
+
+	data = data[2:]
+	if len(data)%2 != 0 {
+		return "", fmt.Errorf("无效的 UTF-16 数据")
+	}
+	units := make([]uint16, len(data)/2)
+	for i := range units {
+		units[i] = order.Uint16(data[i*2:])
+	}
+	return string(utf16.Decode(units)), nil
+}
+
+type stringsParser struct {
+	text string
+	pos  int
+}
+
+func (p *stringsParser) errorf(format string, args ...any) error {
+	line := strings.Count(p.text[:p.pos], "\n") + 1
+	return fmt.Errorf("第 %d 行: %s", line, fmt.Sprintf(format, args...))
+}
+
+// 跳过空白和注释
+func (p *stringsParser) skipSpace() error {
+	for p.pos < len(p.text) {
+		switch {
+		case strings.ContainsRune(" \t\r\n", rune(p.text[p.pos])):
+			p.pos++
+		case strings.HasPrefix(p.text[p.pos:], "//"):
+			end := strings.IndexByte(p.text[p.pos:], '\n')
+			if end < 0 {
+				p.pos = len(p.text)
+			} else {
+				p.pos += end + 1
+			}
+		case strings.HasPrefix(p.text[p.pos:], "/*"):
+			end := strings.Index(p.text[p.pos+2:], "*/")
+			if end < 0 {
+				return p.errorf("注释没有结束")
+			}
+			p.pos += end + 4
+		default:
+			return nil
+		}
+	}
+	return nil
+}
+
+func (p *stringsParser) expect(c byte) error {
+	if err := p.skipSpace(); err != nil {
+		return err
+	}
+	if p.pos >= len(p.text) || p.text[p.pos] != c {
+		return p.errorf("缺少 %q", c)
+	}
+	p.pos++
+	return nil
+}
+
+// 读取带引号的字符串，或不带引号的标识符
+func (p *stringsParser) token() (string, error) {
+	if p.pos >= len(p.text) {
+		return "", p.errorf("意外的文件结尾")
+	}
+	if p.text[p.pos] != '"' {
+		start := p.pos
+		for p.pos < len(p.text) && isIdentifierByte(p.text[p.pos]) {
+			p.pos++
+		}
+		if start == p.pos {
+			return "", p.errorf("无效的字符 %q", p.text[p.pos])
+		}
+		return p.text[start:p.pos], nil
+	}
+
+	var sb strings.Builder
+	for p.pos++; p.pos < len(p.text); p.pos++ {
+		c := p.text[p.pos]
+		switch c {
+		case '"':
+			p.pos++
+			return sb.String(), nil
+		case '\\':
+			p.pos++
+			if p.pos >= len(p.text) {
+				return "", p.errorf("字符串没有结束")
+			}
+			switch e := p.text[p.pos]; e {
+			case 'n':
+				sb.WriteByte('\n')
+			case 't':
+				sb.WriteByte('\t')
+			case 'r':
+				sb.WriteByte('\r')
+			case 'U', 'u':
+				if p.pos+4 >= len(p.text) {
+					return "", p.errorf("无效的转义")
+				}
+				n, err := strconv.ParseUint(p.text[p.pos+1:p.pos+5], 16, 16)
+				if err != nil {
+					return "", p.errorf("无效的转义")
+				}
+				sb.WriteRune(rune(n))
+				p.pos += 4
+			default:
+				sb.WriteByte(e)
+			}
+		default:
+			sb.WriteByte(c)
+		}
+	}
+	return "", p.errorf("字符串没有结束")
+}
+
+func isIdentifierByte(c byte) bool {
+	return c == '_' || c == '.' || c == '-' || (c >= '0' && c <= '9') || (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z')
+}
+
+func (d *stringsDocument) Sources() []Entry {
+	entries := make([]Entry, 0, len(d.entries))
+	for _, e := range d.entries {
+		entries = append(entries, Entry{Key: e.key, Text: e.value})
+	}
+	return entries
+}
+
+func (d *stringsDocument) Translations() map[string]Entry {
+	translations := make(map[string]Entry, len(d.entries))
+	for _, e := range d.entries {
+		translations[e.key] = Entry{Key: e.key, Text: e.value}
+	}
+	return translations
+}
+
+var stringsEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`, "\t", `\t`, "\r", `\r`)
+
+// 以原文文件为模板输出，未翻译的语句连同其后的换行一起删除
+func (d *stringsDocument) Render(values map[string]string, language string, target Document) ([]byte, error) {
+	var sb strings.Builder
+	pos := 0
+	for _, e := range d.entries {
+		value, ok := values[e.key]
+		if e.value == "" {
+			value, ok = "", true
+		}
+		if !ok || (value == "" && e.value != "") {
+			sb.WriteString(d.text[pos:e.start])
+			pos = e.end
+			if strings.HasPrefix(d.text[pos:], "\r\n") {
+				pos += 2
+			} else if strings.HasPrefix(d.text[pos:], "\n") {
+				pos++
+			}
+			continue
+		}
+		sb.WriteString(d.text[pos:e.valueStart])
+		sb.WriteString(`"` + stringsEscaper.Replace(value) + `"`)
+		pos = e.valueEnd
+	}
+	sb.WriteString(d.text[pos:])
+	return []byte(sb.String()), nil
+}
@@ -0,0 +1,245 @@
+package i18n
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// YAML 根节点的语言代码键，如 Rails 的 en:、zh-CN:
+var localeKeyPattern = regexp.MustCompile(`^[a-z]{2,3}(?:[-_][A-Za-z0-9]{2,8})*$`)
+
+// JSON、ARB 和 YAML 文件，统一解析为 YAML 节点树以保留键的顺序
+type treeDocument struct {
+	format string
+	root   *yaml.Node // 根映射节点
+	locale bool       // 根节点只有一个语言代码键，条目键不包含该层
+}
+
+func parseTree(format string, data []byte) (*treeDocument, error) {
+	var doc yaml.Node
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return nil, fmt.Errorf("解析 %s 文件失败: %w", format, err)
+	}
+	if doc.Kind != yaml.DocumentNode || len(doc.Content) == 0 || doc.Content[0].Kind != yaml.MappingNode {
+		return nil, fmt.Errorf("%s 文件的根节点必须是对象", format)
+	}
+
+	d := &treeDocument{format: format, root: doc.Content[0]}
+	if format == FormatYAML && len(d.root.Content) == 2 &&
+		localeKeyPattern.MatchString(d.root.Content[0].Value) && d.root.Content[1].Kind == yaml.MappingNode {
+		d.locale = true
+	}
+	return d, nil
+}
+
+// 条目所在的映射节点
+func (d *treeDocument) body() *yaml.Node {
+	if d.locale {
+		return d.root.Content[1]
+	}
+	return d.root
+}
+
+// 按顺序遍历字符串叶子节点，键以 . 连接，数组元素使用下标
+func (d *treeDocument) walk(fn func(key string, node *yaml.Node)) {
+	var visit func(node *yaml.Node, prefix string)
+	visit = func(node *yaml.Node, prefix string) {
+		switch node.Kind {
+		case yaml.MappingNode:
+			for i := 0; i+1 < len(node.Content); i += 2 {
+				key := node.Content[i].Value
+				// ARB 中 @ 开头的是元数据，不翻译
+				if d.format == FormatARB && strings.HasPrefix(key, "@") {
+					continue
+				}
+				visit(node.Content[i+1], joinKey(prefix, key))
+			}
+		case yaml.SequenceNode:
+			for i, child := range node.Content {
+				visit(child, joinKey(prefix, strconv.Itoa(i)))
+			}
+		case yaml.ScalarNode:
+			if node.Tag == "!!str" {
+				fn(prefix, node)
+			}
+		}
+	}
+	visit(d.body(), "")
+}
+
+func joinKey(prefix, key string) string {
+	if prefix == "" {
+		return key
+	}
+	return prefix + "." + key
+}
+
+func (d *treeDocument) Sources() []Entry {
+	var entries []Entry
+	d.walk(func(key string, node *yaml.Node) {
+		entries = append(entries, Entry{Key: key, Text: node.Value})
+	})
+	return entries
+}
+
+func (d *treeDocument) Translations() map[string]Entry {
+	translations := make(map[string]Entry)
+	d.walk(func(key string, node *yaml.Node) {
+		translations[key] = Entry{Key: key, Text: node.Value}
+	})
+	return translations
+}
+
+func (d *treeDocument) Render(values map[string]string, language string, target Document) ([]byte, error) {
+	clone := &treeDocument{format: d.format, root: cloneNode(d.root), locale: d.locale}
+
+	// 填入译文，没有译文的条目稍后删除
+	untranslated := make(map[*yaml.Node]bool)
+	clone.walk(func(key string, node *yaml.Node) {
+		if value, ok := values[key]; ok && value != "" {
+			node.Value = value
+		} else if node.Value != "" {
+			untranslated[node] = true
+		}
+	})
+	pruneNodes(clone.body(), untranslated)
+
+	switch {
+	case clone.locale:
+		clone.root.Content[0].Value = language
+	case clone.format == FormatARB:
+		setMappingValue(clone.root, "@@locale", language)
+	}
+
+	if clone.format == FormatYAML {
+		var buf bytes.Buffer
+		enc := yaml.NewEncoder(&buf)
+		enc.SetIndent(2)
+		if err := enc.Encode(clone.root); err != nil {
+			return nil, err
+		}
+		enc.Close()
+		return buf.Bytes(), nil
+	}
+
+	var buf bytes.Buffer
+	if err := writeJSON(&buf, clone.root, ""); err != nil {
+		return nil, err
+	}
+	buf.WriteString("\n")
+	return buf.Bytes(), nil
+}
+
+// 深拷贝节点树
+func cloneNode(node *yaml.Node) *yaml.Node {
+	clone := *node
+	clone.Content = make([]*yaml.Node, len(node.Content))
+	for i, child := range node.Content {
+		clone.Content[i] = cloneNode(child)
+	}
+	return &clone
+}
+
+// 删除未翻译的叶子节点，以及因此变空的映射和数组
+func pruneNodes(node *yaml.Node, remove map[*yaml.Node]bool) bool {
+	switch node.Kind {
+	case yaml.MappingNode:
+		var kept []*yaml.Node
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			key, value := node.Content[i], node.Content[i+1]
+			hadChildren := len(value.Content) > 0
+			if remove[value] || (!pruneNodes(value, remove) && hadChildren) {
+				continue
+			}
+			kept = append(kept, key, value)
+		}
+		node.Content = kept
+	case yaml.SequenceNode:
+		var kept []*yaml.Node
+		for _, child := range node.Content {
+			hadChildren := len(child.Content) > 0
+			if remove[child] || (!pruneNodes(child, remove) && hadChildren) {
+				continue
+			}
+			kept = append(kept, child)
+		}
+		node.Content = kept
+	default:
+		return true
+	}
+	return len(node.Content) > 0
+}
+
+// 设置映射中的字符串值，键不存在时插入到最前面
+func setMappingValue(mapping *yaml.Node, key, value string) {
+	for i := 0; i+1 < len(mapping.Content); i += 2 {
+		if mapping.Content[i].Value == key {
+			mapping.Content[i+1].Value = value
+			return
+		}
+	}
+	mapping.Content = append([]*yaml.Node{
+		{Kind: yaml.ScalarNode, Tag: "!!str", Value: key},
+		{Kind: yaml.ScalarNode, Tag: "!!str", Value: value},
+	}, mapping.Content...)
+}
+
+// 将节点树输出为缩进两个空格的 JSON
+func writeJSON(buf *bytes.Buffer, node *yaml.Node, indent string) error {
+	switch node.Kind {
+	case yaml.MappingNode, yaml.SequenceNode:
+		open, close, step := "{", "}", 2
+		if node.Kind == yaml.SequenceNode {
+			open, close, step = "[", "]", 1
+		}
+		if len(node.Content) == 0 {
+			buf.WriteString(open + close)
+			return nil
+		}
+		buf.WriteString(open + "\n")
+		for i := 0; i < len(node.Content); i += step {
+			buf.WriteString(indent + "  ")
+			if step == 2 {
+				writeJSONString(buf, node.Content[i].Value)
+				buf.WriteString(": ")
+			}
+			if err := writeJSON(buf, node.Content[i+step-1], indent+"  "); err != nil {
+				return err
+			}
+			if i+step < len(node.Content) {
+				buf.WriteString(",")
+			}
+			buf.WriteString("\n")
+		}
+		buf.WriteString(indent + close)
+	case yaml.ScalarNode:
+		switch node.Tag {
+		case "!!str":
+			writeJSONString(buf, node.Value)
+		case "!!int", "!!float", "!!bool", "!!null":
+			buf.WriteString(node.Value)
+		default:
+			return fmt.Errorf("不支持的 JSON 值: %s", node.Value)
+		}
+	case yaml.AliasNode:
+		return writeJSON(buf, node.Alias, indent)
+	default:
+		return fmt.Errorf("不支持的 JSON 节点")
+	}
+	return nil
+}
+
+// 输出 JSON 字符串，不转义 HTML 字符
+func writeJSONString(buf *bytes.Buffer, s string) {
+	var b bytes.Buffer
+	enc := json.NewEncoder(&b)
+	enc.SetEscapeHTML(false)
+	enc.Encode(s)
+	buf.Write(bytes.TrimRight(b.Bytes(), "\n"))
+}
@@ -0,0 +1,175 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"time"
+
+	"clipboard-translate/config"
+	log "clipboard-translate/utils/log"
+)
+
+// 锁文件放在配置文件所在目录，使用同一配置文件只能运行一个实例
+const lockFileName = "clipboard-translate.lock"
+
+// 锁文件中还没有端口时视为实例正在启动，超过这个时间仍没有端口则视为失效
+const lockStartupGrace = 10 * time.Second
+
+// 运行中实例的信息，写入锁文件供第二个实例和命令行工具读取
+type instanceInfo struct {
+	PID   int    `json:"pid"`
+	Host  string `json:"host,omitempty"` // 可连接的地址
+	Port  int    `json:"port,omitempty"` // 实际监听的端口，启动完成前为 0
+	Token string `json:"token,omitempty"`
+}
+
+// 服务地址
+func (i *instanceInfo) URL() string {
+	return "http://" + net.JoinHostPort(i.Host, strconv.Itoa(i.Port))
+}
+
+func lockFilePath() string {
+	return filepath.Join(filepath.Dir(config.ConfigFile()), lockFileName)
+}
+
+// 获取单实例锁：已有实例在运行时返回其信息，否则创建锁文件并返回 nil
+//
+// 上次未正常退出留下的锁文件（服务已不再响应）会被替换。
+func acquireInstanceLock() (*instanceInfo, error) {
+	path := lockFilePath()
+	for attempt := 0; attempt < 2; attempt++ {
+		f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0600)
+		if err == nil {
+			err = json.NewEncoder(f).Encode(instanceInfo{PID: os.Getpid()})
+			if closeErr := f.Close(); err == nil {
+				err = closeErr
+			}
+			if err != nil {
+				return nil, fmt.Errorf("写入锁文件失败: %w", err)
+			}
+			return nil, nil
+		}
+		if !errors.Is(err, fs.ErrExist) {
+			return nil, fmt.Errorf("创建锁文件失败: %w", err)
+		}
+
+		if running := runningInstance(path); running != nil {
+			return running, nil
+		}
+		log.Warn("锁文件已失效，上次可能未正常退出: %s", path)
+		if err := os.Remove(path); err != nil && !errors.Is(err, fs.ErrNotExist) {
+			return nil, fmt.Errorf("删除失效的锁文件失败: %w", err)
+		}
+	}
+	return nil, fmt.Errorf("创建锁文件失败: %s", path)
+}
+
+// 读取锁文件并确认对应的服务仍在响应，另一个实例正在启动时等待它写入端口
+func runningInstance(path string) *instanceInfo {
+	for {
+		info, modTime, err := readInstanceInfo(path)
+		if err != nil {
+			return nil
+		}
+		if info.Port > 0 {
+			if !instanceHealthy(info) {
+				return nil
+			}
+			return info
+		}
+		if time.Since(modTime) > lockStartupGrace {
+			return nil
+		}
+		time.Sleep(200 * time.Millisecond)
+	}
+}
+
+func readInstanceInfo(path string) (*instanceInfo, time.Time, error) {
+	stat, err := os.Stat(path)
+	if err != nil {
+		return nil, time.Time{}, err
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, time.Time{}, err
+	}
+	var info instanceInfo
+	if err := json.Unmarshal(data, &info); err != nil {
+		return nil, time.Time{}, fmt.Errorf("解析锁文件失败: %w", err)
+	}
+	return &info, stat.ModTime(), nil
+}
+
+func instanceHealthy(info *instanceInfo) bool {
+	client := &http.Client{Timeout: 2 * time.Second}
+	resp, err := client.Get(info.URL() + "/api/health")
+	if err != nil {
+		return false
+	}
+	resp.Body.Close()
+	return resp.StatusCode == http.StatusOK
+}
+
+// 服务启动或切换端口后更新锁文件，并通知 Electron 外壳实际的端口
+func announceServer(host string, port int) {
+	info := instanceInfo{PID: os.Getpid(), Host: dialHost(host), Port: port, Token: tokens.Admin}
+	if err := writeInstanceInfo(info); err != nil {
+		log.Error("更新锁文件失败: %v", err)
+	}
+	sendShellEvent("server-started", map[string]any{"port": port, "pid": info.PID})
+}
+
+// 先写入临时文件再替换，读取方不会读到写了一半的内容
+func writeInstanceInfo(info instanceInfo) error {
+	data, err := json.Marshal(info)
+	if err != nil {
+		return err
+	}
+	path := lockFilePath()
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0600); err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
+
+// 删除锁文件，锁文件已属于其他实例时保留
+func releaseInstanceLock() error {
+	path := lockFilePath()
+	info, _, err := readInstanceInfo(path)
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return nil
+		}
+		return err
+	}
+	if info.PID != os.Getpid() {
+		return nil
+	}
+	return os.Remove(path)
+}
+
+// 把已运行的实例交给调用方：输出地址和令牌（Electron 外壳从标准输出读取）
+func handOffToInstance(info *instanceInfo) {
+	log.Info("已有实例在运行 (进程 %d)，请访问 %s", info.PID, info.URL())
+	if info.Token != "" {
+		sendShellEvent("api-token", map[string]any{"token": info.Token})
+	}
+	sendShellEvent("server-started", map[string]any{"port": info.Port, "pid": info.PID, "existing": true})
+}
+
+// 监听地址对应的可连接地址：监听所有地址或本机时使用 127.0.0.1
+func dialHost(bind string) string {
+	ip := net.ParseIP(bind)
+	if ip == nil || ip.IsUnspecified() || ip.IsLoopback() {
+		return "127.0.0.1"
+	}
+	return ip.String()
+}
@@ -0,0 +1,168 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"net/http"
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"clipboard-translate/config"
+)
+
+// 在临时目录中使用配置文件，锁文件随之放在该目录
+func setupInstanceDir(t *testing.T) {
+	t.Helper()
+	if err := config.SetConfigFile(setupCommandDir(t, "http://127.0.0.1:1/v1", nil)); err != nil {
+		t.Fatal(err)
+	}
+	saved := tokens
+	tokens = apiTokens{Admin: "admin-token", Read: "read-token"}
+	t.Cleanup(func() { tokens = saved })
+}
+
+// 在 port 端口上启动只响应健康检查的服务，测试结束时关闭
+func startHealthServer(t *testing.T, port int) int {
+	t.Helper()
+	mux := http.NewServeMux()
+	mux.HandleFunc("/api/health", func(w http.ResponseWriter, r *http.Request) {})
+	server, port, err := startHTTPServer(mux, "127.0.0.1", port)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { server.Shutdown(context.Background()) })
+	return port
+}
+
+// 捕获 fn 发送给外壳的事件
+func captureShellEvents(t *testing.T, fn func()) []map[string]any {
+	t.Helper()
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	saved := os.Stdout
+	os.Stdout = w
+	fn()
+	os.Stdout = saved
+	w.Close()
+
+	var events []map[string]any
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line, ok := strings.CutPrefix(scanner.Text(), shellEventPrefix)
+		if !ok {
+			continue
+		}
+		var event map[string]any
+		if err := json.Unmarshal([]byte(line), &event); err != nil {
+			t.Fatalf("解析外壳事件 %q 失败: %v", line, err)
+		}
+		events = append(events, event)
+	}
+	r.Close()
+	return events
+}
+
+func TestInstanceLockHandOff(t *testing.T) {
+	setupInstanceDir(t)
+
+	running, err := acquireInstanceLock()
+	if err != nil || running != nil {
+		t.Fatalf("首个实例获取锁 = %+v, %v", running, err)
+	}
+	info, _, err := readInstanceInfo(lockFilePath())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := (instanceInfo{PID: os.Getpid()}); *info != want {
+		t.Errorf("启动中的锁文件为 %+v, want %+v", *info, want)
+	}
+
+	// 配置的端口为 0 时自动选择端口，锁文件和外壳拿到的都是实际端口
+	var port int
+	events := captureShellEvents(t, func() {
+		port = startHealthServer(t, 0)
+		announceServer("0.0.0.0", port)
+	})
+	if port == 0 {
+		t.Fatal("端口 0 未替换为实际监听的端口")
+	}
+	want := instanceInfo{PID: os.Getpid(), Host: "127.0.0.1", Port: port, Token: "admin-token"}
+	if info, _, err := readInstanceInfo(lockFilePath()); err != nil || *info != want {
+		t.Fatalf("启动后的锁文件为 %+v, %v, want %+v", info, err, want)
+	}
+	if len(events) != 1 || events[0]["event"] != "server-started" || events[0]["port"] != float64(port) {
+		t.Errorf("启动后发送的外壳事件为 %v", events)
+	}
+
+	// 第二个实例拿到运行中实例的地址和令牌，交给外壳后退出
+	running, err = acquireInstanceLock()
+	if err != nil || running == nil || *running != want {
+		t.Fatalf("第二个实例获取锁 = %+v, %v, want %+v", running, err, want)
+	}
+	events = captureShellEvents(t, func() { handOffToInstance(running) })
+	wantEvents := []map[string]any{
+		{"event": "api-token", "token": "admin-token"},
+		{"event": "server-started", "port": float64(port), "pid": float64(os.Getpid()), "existing": true},
+	}
+	if !reflect.DeepEqual(events, wantEvents) {
+		t.Errorf("交接时发送的外壳事件为 %v, want %v", events, wantEvents)
+	}
+}
+
+func TestInstanceLockStarting(t *testing.T) {
+	setupInstanceDir(t)
+
+	// 另一个实例已创建锁文件但还没有写入端口，等待它启动完成
+	if err := writeInstanceInfo(instanceInfo{PID: os.Getpid() + 1}); err != nil {
+		t.Fatal(err)
+	}
+	port := startHealthServer(t, 0)
+	go func() {
+		time.Sleep(300 * time.Millisecond)
+		writeInstanceInfo(instanceInfo{PID: os.Getpid() + 1, Host: "127.0.0.1", Port: port})
+	}()
+
+	running, err := acquireInstanceLock()
+	if err != nil || running == nil || running.Port != port {
+		t.Fatalf("获取锁 = %+v, %v, want 端口 %d 的实例", running, err, port)
+	}
+
+	// 锁文件属于其他实例时不删除
+	if err := releaseInstanceLock(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(lockFilePath()); err != nil {
+		t.Errorf("删除了其他实例的锁文件: %v", err)
+	}
+}
+
+func TestInstanceLockStale(t *testing.T) {
+	setupInstanceDir(t)
+
+	// 上次未正常退出：锁文件中的服务已不再响应
+	if err := writeInstanceInfo(instanceInfo{PID: os.Getpid() + 1, Host: "127.0.0.1", Port: 1}); err != nil {
+		t.Fatal(err)
+	}
+
+	running, err := acquireInstanceLock()
+	if err != nil || running != nil {
+		t.Fatalf("获取锁 = %+v, %v, want 替换失效的锁文件", running, err)
+	}
+	if info, _, err := readInstanceInfo(lockFilePath()); err != nil || info.PID != os.Getpid() {
+		t.Fatalf("替换后的锁文件为 %+v, %v", info, err)
+	}
+
+	// 退出或启动失败时关闭已登记的子系统，删除锁文件
+	l := newLifecycle()
+	l.OnClose("锁文件", releaseInstanceLock)
+	l.Shutdown()
+	if _, err := os.Stat(lockFilePath()); !os.IsNotExist(err) {
+		t.Errorf("退出后锁文件仍存在: %v", err)
+	}
+}
@@ -0,0 +1,365 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/sync/errgroup"
+	"golang.org/x/time/rate"
+
+	"clipboard-translate/ai"
+	"clipboard-translate/config"
+	"clipboard-translate/database"
+	log "clipboard-translate/utils/log"
+)
+
+// 单个批量任务的文本数上限
+const maxJobItems = 1000
+
+// 已结束的任务保留的时间，启动时清理更早的任务
+const jobRetention = 30 * 24 * time.Hour
+
+// errJobFinished 任务已结束，不能取消
+var errJobFinished = errors.New("任务已结束")
+
+// 创建批量翻译任务的参数，除 Texts 外与 textRequest 相同
+type jobRequest struct {
+	Texts       []string `json:"texts"`
+	Source      string   `json:"source"`
+	Target      string   `json:"target"`
+	Profile     string   `json:"profile"`
+	Template    string   `json:"template"`
+	SaveHistory bool     `json:"save_history"`
+}
+
+// 任务及其进度统计
+type jobStatus struct {
+	*database.Job
+	Total  int `json:"total"`
+	Done   int `json:"done"`
+	Failed int `json:"failed"`
+}
+
+func newJobStatus(job *database.Job) *jobStatus {
+	status := &jobStatus{Job: job, Total: len(job.Items)}
+	for _, item := range job.Items {
+		switch item.Status {
+		case database.ItemDone:
+			status.Done++
+		case database.ItemFailed:
+			status.Failed++
+		}
+	}
+	return status
+}
+
+// 批量翻译任务执行器
+//
+// 任务按创建顺序逐个执行，同一任务的文本由 translation.job_workers 个 worker 并发翻译。
+// 任务状态和每条结果都写入数据库，程序退出时未完成的任务在下次启动时继续。
+type jobRunner struct {
+	mu      sync.Mutex
+	queue   []string           // 等待执行的任务
+	running string             // 正在执行的任务
+	cancel  context.CancelFunc // 取消正在执行的任务
+	wake    chan struct{}
+	work    context.Context // 翻译使用的上下文，退出时等待进行中的翻译完成后才取消
+}
+
+var jobs = &jobRunner{wake: make(chan struct{}, 1), work: app.work}
+
+// 创建任务并加入队列，空白文本直接视为已完成
+func (r *jobRunner) create(req jobRequest) (*database.Job, error) {
+	if req.Profile == "" {
+		req.Profile = config.GetConfig().API.ActiveProfile
+	}
+
+	now := time.Now()
+	job := &database.Job{
+		ID:          fmt.Sprintf("%d", now.UnixNano()),
+		Status:      database.JobQueued,
+		Source:      req.Source,
+		Target:      req.Target,
+		Profile:     req.Profile,
+		Template:    req.Template,
+		SaveHistory: req.SaveHistory,
+		CreatedAt:   now,
+		UpdatedAt:   now,
+		Items:       make([]*database.JobItem, len(req.Texts)),
+	}
+	for i, text := range req.Texts {
+		item := &database.JobItem{Index: i, Text: text, Status: database.ItemPending}
+		if strings.TrimSpace(text) == "" {
+			item.Status, item.Translation = database.ItemDone, text
+		}
+		job.Items[i] = item
+	}
+
+	dbMutex.Lock()
+	err := db.CreateJob(job)
+	dbMutex.Unlock()
+	if err != nil {
+		return nil, fmt.Errorf("保存批量翻译任务失败: %w", err)
+	}
+
+	log.Info("创建批量翻译任务: %s, 共 %d 条", job.ID, len(job.Items))
+	r.enqueue(job.ID)
+	return job, nil
+}
+
+// 取消排队中或正在执行的任务，未处理的条目保持 pending
+func (r *jobRunner) cancelJob(id string) (*database.Job, error) {
+	// 持有锁时读取状态，避免覆盖刚刚写入的完成状态
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	job, err := getJob(id)
+	if err != nil {
+		return nil, err
+	}
+	if job.Finished() {
+		return job, errJobFinished
+	}
+
+	for i, queued := range r.queue {
+		if queued == id {
+			r.queue = append(r.queue[:i], r.queue[i+1:]...)
+			break
+		}
+	}
+	if r.running == id && r.cancel != nil {
+		r.cancel()
+	}
+	if err := setJobStatus(id, database.JobCanceled); err != nil {
+		return nil, err
+	}
+
+	log.Info("批量翻译任务已取消: %s", id)
+	job.Status = database.JobCanceled
+	return job, nil
+}
+
+// 把上次未完成的任务重新加入队列，并清理过期的任务
+func (r *jobRunner) resume() error {
+	dbMutex.Lock()
+	pending, err := db.GetUnfinishedJobs()
+	if err == nil {
+		err = db.PruneJobs(time.Now().Add(-jobRetention))
+	}
+	dbMutex.Unlock()
+	if err != nil {
+		return fmt.Errorf("读取批量翻译任务失败: %w", err)
+	}
+
+	for _, job := range pending {
+		r.enqueue(job.ID)
+	}
+	if len(pending) > 0 {
+		log.Info("继续执行 %d 个未完成的批量翻译任务", len(pending))
+	}
+	return nil
+}
+
+func (r *jobRunner) enqueue(id string) {
+	r.mu.Lock()
+	r.queue = append(r.queue, id)
+	r.mu.Unlock()
+
+	select {
+	case r.wake <- struct{}{}:
+	default:
+	}
+}
+
+// 依次执行队列中的任务，直到 ctx 结束
+//
+// ctx 结束后不再开始新的条目，已开始的翻译在 r.work 下继续，由退出流程等待它们完成。
+func (r *jobRunner) run(ctx context.Context) {
+	for {
+		id, jobCtx, ok := r.next()
+		if !ok {
+			select {
+			case <-ctx.Done():
+				return
+			case <-r.wake:
+				continue
+			}
+		}
+
+		r.execute(ctx, jobCtx, id)
+
+		r.mu.Lock()
+		r.cancel()
+		r.running, r.cancel = "", nil
+		r.mu.Unlock()
+
+		if ctx.Err() != nil {
+			return
+		}
+	}
+}
+
+// 取出下一个任务，同时登记为正在执行，使取消请求总能找到它
+func (r *jobRunner) next() (string, context.Context, bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if len(r.queue) == 0 {
+		return "", nil, false
+	}
+
+	id := r.queue[0]
+	r.queue = r.queue[1:]
+	jobCtx, cancel := context.WithCancel(r.work)
+	r.running, r.cancel = id, cancel
+	return id, jobCtx, true
+}
+
+func (r *jobRunner) execute(ctx, jobCtx context.Context, id string) {
+	job, err := getJob(id)
+	if err != nil {
+		log.Error("读取批量翻译任务失败: %s: %v", id, err)
+		return
+	}
+	if job.Finished() {
+		return
+	}
+	if err := setJobStatus(id, database.JobRunning); err != nil {
+		log.Error("更新批量翻译任务状态失败: %s: %v", id, err)
+		return
+	}
+
+	workers := config.GetConfig().Translation.JobWorkers
+	var g errgroup.Group
+	g.SetLimit(max(workers, 1))
+	for _, item := range job.Items {
+		if item.Status != database.ItemPending {
+			continue
+		}
+		if ctx.Err() != nil || jobCtx.Err() != nil {
+			break
+		}
+
+		g.Go(func() error {
+			// 等待空闲 worker 期间可能已开始退出
+			if ctx.Err() != nil {
+				return nil
+			}
+			// 每条文本都重新获取客户端，任务执行中预算用尽时立即生效
+			client, profile, err := jobClient(job.Profile)
+			var result *textResult
+			if err == nil {
+				result, err = translateTextWith(jobCtx, client, textRequest{
+					Text:        item.Text,
+					Source:      job.Source,
+					Target:      job.Target,
+					Profile:     profile,
+					Template:    job.Template,
+					SaveHistory: job.SaveHistory,
+					origin:      "job",
+				})
+			}
+			// 取消或程序退出时保持 pending，重启后继续
+			if jobCtx.Err() != nil {
+				return nil
+			}
+
+			if err != nil {
+				item.Status, item.Error = database.ItemFailed, err.Error()
+			} else {
+				item.Status, item.Translation = database.ItemDone, result.Translation
+			}
+			dbMutex.Lock()
+			err = db.UpdateJobItem(job.ID, item)
+			dbMutex.Unlock()
+			if err != nil {
+				log.Error("保存批量翻译结果失败: %s#%d: %v", job.ID, item.Index, err)
+			}
+			return nil
+		})
+	}
+	g.Wait()
+
+	// 程序退出时保持 running，下次启动时继续未处理的条目；取消时状态已由 cancelJob 写入
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if jobCtx.Err() != nil {
+		return
+	}
+	for _, item := range job.Items {
+		if item.Status == database.ItemPending {
+			return
+		}
+	}
+	if err := setJobStatus(id, database.JobCompleted); err != nil {
+		log.Error("更新批量翻译任务状态失败: %s: %v", id, err)
+		return
+	}
+	status := newJobStatus(job)
+	log.Info("批量翻译任务完成: %s, 成功 %d, 失败 %d", id, status.Done, status.Failed)
+}
+
+func getJob(id string) (*database.Job, error) {
+	dbMutex.Lock()
+	defer dbMutex.Unlock()
+	return db.GetJob(id)
+}
+
+func setJobStatus(id, status string) error {
+	dbMutex.Lock()
+	defer dbMutex.Unlock()
+	return db.UpdateJobStatus(id, status)
+}
+
+// 获取任务使用的客户端及实际使用的AI配置名称，按客户端所属的提供商限制请求频率
+//
+// 预算用尽回退到本地模型时，频率限制按回退客户端的提供商计算。
+func jobClient(profile string) (ai.AIClient, string, error) {
+	client, name, err := aiClientFor(profile)
+	if err != nil {
+		return nil, "", err
+	}
+	return rateLimitedClient{AIClient: client, provider: strings.ToLower(client.GetName())}, name, nil
+}
+
+var (
+	rateLimiters   = make(map[string]*rate.Limiter) // 各提供商的请求频率限制
+	rateLimitersMu sync.Mutex
+)
+
+// 获取提供商的请求频率限制器，没有配置限制时返回 nil；修改配置后立即按新的频率限制
+func rateLimiterFor(provider string) *rate.Limiter {
+	perMinute := config.GetConfig().Translation.RateLimits[provider]
+	if perMinute <= 0 {
+		return nil
+	}
+	limit := rate.Every(time.Minute / time.Duration(perMinute))
+
+	rateLimitersMu.Lock()
+	defer rateLimitersMu.Unlock()
+	limiter, ok := rateLimiters[provider]
+	if !ok {
+		limiter = rate.NewLimiter(limit, 1)
+		rateLimiters[provider] = limiter
+	} else if limiter.Limit() != limit {
+		limiter.SetLimit(limit)
+	}
+	return limiter
+}
+
+// 每次请求前等待所属提供商的频率限制，分段翻译的每一段都计为一次请求
+type rateLimitedClient struct {
+	ai.AIClient
+	provider string
+}
+
+func (c rateLimitedClient) Complete(ctx context.Context, systemPrompt, text string) (ai.Result, error) {
+	if limiter := rateLimiterFor(c.provider); limiter != nil {
+		if err := limiter.Wait(ctx); err != nil {
+			return ai.Result{}, err
+		}
+	}
+	return c.AIClient.Complete(ctx, systemPrompt, text)
+}
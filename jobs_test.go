@@ -0,0 +1,159 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"clipboard-translate/config"
+	"clipboard-translate/database"
+)
+
+func TestJobClientProvider(t *testing.T) {
+	today := time.Now().Format(usageDayLayout)
+	spent := &database.Usage{Day: today, Provider: "OpenAI", Model: "m", Calls: 1, InputTokens: 80, OutputTokens: 20}
+
+	tests := []struct {
+		name         string
+		tokens       int
+		wantProfile  string
+		wantProvider string
+	}{
+		{"未超出预算", 1000, "fake", "openai"},
+		{"回退到本地模型", 100, "local", "ollama"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			setupBudget(t, map[string]any{
+				"fallback_profile": "local",
+				"limits":           []any{map[string]any{"provider": "openai", "period": "day", "tokens": tt.tokens, "action": config.BudgetFallback}},
+			}, spent)
+
+			client, profile, err := jobClient("fake")
+			if err != nil {
+				t.Fatal(err)
+			}
+			limited, ok := client.(rateLimitedClient)
+			if !ok {
+				t.Fatalf("客户端类型为 %T", client)
+			}
+			if profile != tt.wantProfile || limited.provider != tt.wantProvider {
+				t.Errorf("AI配置 %s, 频率限制按 %s, want %s, %s", profile, limited.provider, tt.wantProfile, tt.wantProvider)
+			}
+		})
+	}
+
+	if _, _, err := jobClient("missing"); err == nil {
+		t.Error("AI配置不存在时应返回错误")
+	}
+}
+
+// 模拟 OpenAI 接口，每次请求先通知 started，等 release 关闭后再返回
+func blockingOpenAI(t *testing.T, started chan<- struct{}, release <-chan struct{}) *httptest.Server {
+	t.Helper()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		started <- struct{}{}
+		<-release
+		json.NewEncoder(w).Encode(map[string]any{
+			"usage":   map[string]any{"prompt_tokens": 10, "completion_tokens": 5},
+			"choices": []any{map[string]any{"message": map[string]any{"role": "assistant", "content": "译文"}, "finish_reason": "stop"}},
+		})
+	}))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+// 准备配置和数据库，返回使用独立上下文的任务执行器
+func setupJobRunner(t *testing.T, baseURL string, work context.Context) *jobRunner {
+	t.Helper()
+	setupAPI(t, map[string]any{
+		"profiles":    map[string]any{"fake": map[string]any{"provider": "openai", "model": "m", "base_url": baseURL + "/v1", "use_env_key": true}},
+		"translation": map[string]any{"job_workers": 1},
+	})
+	if err := loadPromptTemplates(config.GetConfig().Templates); err != nil {
+		t.Fatal(err)
+	}
+	var err error
+	if db, err = openDatabase(config.GetConfig()); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		db.Close()
+		db = nil
+	})
+	return &jobRunner{wake: make(chan struct{}, 1), work: work}
+}
+
+func TestJobRunnerShutdown(t *testing.T) {
+	started := make(chan struct{}, 2)
+	release := make(chan struct{})
+	srv := blockingOpenAI(t, started, release)
+	work, cancelWork := context.WithCancel(context.Background())
+	defer cancelWork()
+	r := setupJobRunner(t, srv.URL, work)
+
+	job, err := r.create(jobRequest{Texts: []string{"a", "b"}, Target: "中文"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	stopping, stop := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		r.run(stopping)
+		close(done)
+	}()
+
+	// 第一条翻译进行中时开始退出：不再开始新的条目，进行中的翻译完成后才返回
+	<-started
+	stop()
+	close(release)
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("退出后任务执行器没有返回")
+	}
+
+	got, err := getJob(job.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.Status != database.JobRunning {
+		t.Errorf("任务状态为 %s, want %s（下次启动时继续）", got.Status, database.JobRunning)
+	}
+	if item := got.Items[0]; item.Status != database.ItemDone || item.Translation != "译文" {
+		t.Errorf("进行中的条目为 %+v，应完成翻译", item)
+	}
+	if item := got.Items[1]; item.Status != database.ItemPending {
+		t.Errorf("未开始的条目状态为 %s, want %s", item.Status, database.ItemPending)
+	}
+}
+
+func TestCancelFinishedJob(t *testing.T) {
+	started := make(chan struct{}, 1)
+	release := make(chan struct{})
+	close(release)
+	srv := blockingOpenAI(t, started, release)
+	r := setupJobRunner(t, srv.URL, context.Background())
+
+	job, err := r.create(jobRequest{Texts: []string{"a"}, Target: "中文"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	id, jobCtx, ok := r.next()
+	if !ok || id != job.ID {
+		t.Fatalf("next() = %q, %v", id, ok)
+	}
+	r.execute(context.Background(), jobCtx, id)
+
+	// 完成后取消请求不能覆盖完成状态
+	if _, err := r.cancelJob(id); !errors.Is(err, errJobFinished) {
+		t.Errorf("cancelJob() error = %v, want %v", err, errJobFinished)
+	}
+	if got, err := getJob(id); err != nil || got.Status != database.JobCompleted {
+		t.Errorf("任务状态为 %v, %v, want %s", got.Status, err, database.JobCompleted)
+	}
+}
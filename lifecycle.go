@@ -0,0 +1,179 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"os"
+	"os/signal"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	log "clipboard-translate/utils/log"
+)
+
+// 退出时等待进行中的请求和翻译完成的最长时间，超时后取消它们
+const shutdownTimeout = 10 * time.Second
+
+// 取消后等待翻译结束并写入历史记录的最长时间
+const drainTimeout = 3 * time.Second
+
+// 应用生命周期：跟踪后台任务，退出时按顺序停止接收新工作、等待进行中的翻译、关闭各子系统
+type lifecycle struct {
+	stopping   context.Context // 开始退出时取消：热键监听、配置文件监视、批量任务和事件推送随之结束
+	stop       context.CancelFunc
+	work       context.Context // 退出超时后取消：中断进行中的翻译
+	cancelWork context.CancelFunc
+
+	mu      sync.Mutex
+	tasks   sync.WaitGroup
+	closers []closer
+}
+
+// 退出时关闭的子系统
+type closer struct {
+	name  string
+	close func() error
+}
+
+var app = newLifecycle()
+
+func newLifecycle() *lifecycle {
+	l := &lifecycle{}
+	l.stopping, l.stop = context.WithCancel(context.Background())
+	l.work, l.cancelWork = context.WithCancel(context.Background())
+	return l
+}
+
+// 在后台执行翻译等任务，ctx 在退出超时后取消；开始退出后不再接受新任务
+func (l *lifecycle) Go(fn func(ctx context.Context)) bool {
+	return l.spawn(l.work, fn)
+}
+
+// 在后台运行监听循环，ctx 在开始退出时取消
+func (l *lifecycle) Loop(fn func(ctx context.Context)) bool {
+	return l.spawn(l.stopping, fn)
+}
+
+func (l *lifecycle) spawn(ctx context.Context, fn func(ctx context.Context)) bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.stopping.Err() != nil {
+		log.Debug("程序正在退出，忽略新的后台任务")
+		return false
+	}
+	l.tasks.Add(1)
+	go func() {
+		defer l.tasks.Done()
+		fn(ctx)
+	}()
+	return true
+}
+
+// 登记退出时关闭的子系统，按登记的相反顺序关闭
+func (l *lifecycle) OnClose(name string, fn func() error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.closers = append(l.closers, closer{name: name, close: fn})
+}
+
+// 退出：停止接收新的请求和热键，等待进行中的翻译完成（超时后取消），再依次关闭各子系统
+func (l *lifecycle) Shutdown() {
+	l.mu.Lock()
+	l.stop()
+	l.mu.Unlock()
+
+	ctx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+	defer cancel()
+
+	if err := shutdownHTTPServer(ctx); err != nil {
+		log.Warn("等待Web请求完成超时: %v", err)
+	}
+	if !l.wait(ctx) {
+		log.Warn("等待进行中的翻译超时，取消剩余的翻译")
+	}
+
+	// 被取消的翻译仍会写入历史记录，等待写入完成后再关闭数据库
+	l.cancelWork()
+	drainCtx, cancelDrain := context.WithTimeout(context.Background(), drainTimeout)
+	defer cancelDrain()
+	if !l.wait(drainCtx) {
+		log.Error("仍有后台任务未结束，强制退出")
+	}
+
+	l.mu.Lock()
+	closers := l.closers
+	l.closers = nil
+	l.mu.Unlock()
+	for i := len(closers) - 1; i >= 0; i-- {
+		if err := closers[i].close(); err != nil {
+			log.Error("关闭%s失败: %v", closers[i].name, err)
+		}
+	}
+	log.Info("程序已退出")
+}
+
+// 启动失败时退出：先关闭已登记的子系统，其中包括删除锁文件，避免下次启动时遇到失效的锁
+func (l *lifecycle) Fatal(format string, v ...any) {
+	log.Error(format, v...)
+	l.Shutdown()
+	os.Exit(1)
+}
+
+// 等待所有后台任务结束，ctx 先结束时返回 false
+func (l *lifecycle) wait(ctx context.Context) bool {
+	done := make(chan struct{})
+	go func() {
+		l.tasks.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+		return true
+	case <-ctx.Done():
+		return false
+	}
+}
+
+// 等待退出信号、外壳的退出命令或Web服务异常，返回进程退出码
+func waitForExit() int {
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, os.Interrupt, syscall.SIGTERM)
+	defer signal.Stop(signals)
+
+	select {
+	case sig := <-signals:
+		log.Info("收到信号 %v，正在退出...", sig)
+		return 0
+	case <-shellCommand("shutdown"):
+		log.Info("收到外壳的退出命令，正在退出...")
+		return 0
+	case err := <-serverErrors:
+		log.Error("Web服务器异常退出: %v", err)
+		return 1
+	}
+}
+
+// 从标准输入按行读取外壳发送的命令，收到指定命令时关闭返回的通道
+//
+// Windows 上外壳无法向子进程发送 SIGTERM，因此通过标准输入请求退出。
+// 外壳可能使用管道或套接字（如 Node 的 stdio: 'pipe'），只在标准输入是终端等字符设备时不读取。
+func shellCommand(command string) <-chan struct{} {
+	received := make(chan struct{})
+	info, err := os.Stdin.Stat()
+	if err != nil || info.Mode()&os.ModeCharDevice != 0 {
+		return received
+	}
+
+	go func() {
+		scanner := bufio.NewScanner(os.Stdin)
+		for scanner.Scan() {
+			if strings.TrimSpace(scanner.Text()) == command {
+				close(received)
+				return
+			}
+		}
+	}()
+	return received
+}
@@ -0,0 +1,132 @@
+package main
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"os"
+	"reflect"
+	"runtime"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestLifecycleShutdownOrder(t *testing.T) {
+	var mu sync.Mutex
+	var steps []string
+	record := func(step string) {
+		mu.Lock()
+		defer mu.Unlock()
+		steps = append(steps, step)
+	}
+
+	l := newLifecycle()
+	l.OnClose("数据库", func() error { record("关闭数据库"); return nil })
+	l.OnClose("AI客户端", func() error { record("关闭AI客户端"); return nil })
+
+	// 进行中的请求在HTTP服务开始关闭后才结束
+	requestStarted := make(chan struct{})
+	shuttingDown := make(chan struct{})
+	requestDone := make(chan struct{})
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	server := &http.Server{Handler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		close(requestStarted)
+		<-shuttingDown
+		record("请求完成")
+		close(requestDone)
+	})}
+	server.RegisterOnShutdown(func() {
+		record("关闭HTTP服务")
+		close(shuttingDown)
+	})
+	go server.Serve(ln)
+	httpServerMu.Lock()
+	saved := httpServer
+	httpServer = server
+	httpServerMu.Unlock()
+	t.Cleanup(func() {
+		httpServerMu.Lock()
+		httpServer = saved
+		httpServerMu.Unlock()
+	})
+	go http.Get("http://" + ln.Addr().String())
+	<-requestStarted
+
+	// 进行中的翻译由该请求发起，请求完成后还需要一段时间才结束；监听循环在开始退出时结束
+	l.Go(func(ctx context.Context) {
+		<-requestDone
+		time.Sleep(100 * time.Millisecond)
+		record("翻译完成")
+	})
+	l.Loop(func(ctx context.Context) {
+		<-ctx.Done()
+	})
+
+	done := make(chan struct{})
+	go func() {
+		l.Shutdown()
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("Shutdown 未返回")
+	}
+
+	want := []string{"关闭HTTP服务", "请求完成", "翻译完成", "关闭AI客户端", "关闭数据库"}
+	if !reflect.DeepEqual(steps, want) {
+		t.Errorf("退出顺序为 %q, want %q", steps, want)
+	}
+	if l.work.Err() == nil {
+		t.Error("退出后未取消翻译的 context")
+	}
+	if l.Go(func(context.Context) { record("退出后的任务") }) {
+		t.Error("退出后仍接受新的后台任务")
+	}
+}
+
+func TestShellCommandSocket(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("Windows 上无法把套接字转为文件")
+	}
+
+	// 外壳以套接字作为子进程的标准输入（如 Node 的 stdio: 'pipe'）
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ln.Close()
+	client, err := net.Dial("tcp", ln.Addr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer client.Close()
+	conn, err := ln.Accept()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	stdin, err := conn.(*net.TCPConn).File()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer stdin.Close()
+
+	saved := os.Stdin
+	os.Stdin = stdin
+	t.Cleanup(func() { os.Stdin = saved })
+
+	received := shellCommand("shutdown")
+	if _, err := client.Write([]byte("status\nshutdown\n")); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case <-received:
+	case <-time.After(5 * time.Second):
+		t.Fatal("从套接字读取到 shutdown 后未关闭通道")
+	}
+}
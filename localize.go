@@ -0,0 +1,147 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"clipboard-translate/ai"
+	"clipboard-translate/config"
+	"clipboard-translate/constants"
+	"clipboard-translate/i18n"
+	log "clipboard-translate/utils/log"
+)
+
+// 上传本地化文件的大小上限
+const maxLocaleFileSize = 10 << 20
+
+// 本地化文件翻译参数，留空的字段取自配置
+type localeRequest struct {
+	TargetLanguage string // 目标语言，默认为 translation.target_language
+	Profile        string // 使用的AI配置，默认为当前激活的配置
+	DryRun         bool   // 只输出差异报告
+}
+
+// 解析原文文件和已有的译文文件，target 为 nil 表示还没有译文文件
+func parseLocaleFiles(sourceName string, source []byte, targetName string, target []byte) (string, i18n.Document, i18n.Document, error) {
+	format, err := i18n.DetectFormat(sourceName, source)
+	if err != nil {
+		return "", nil, nil, err
+	}
+	src, err := i18n.Parse(format, source)
+	if err != nil {
+		return "", nil, nil, fmt.Errorf("解析原文文件失败: %w", err)
+	}
+	if target == nil {
+		return format, src, nil, nil
+	}
+
+	// ARB 与 JSON 只是扩展名或 @@locale 的区别，按原文文件的格式解析
+	targetFormat, err := i18n.DetectFormat(targetName, target)
+	if err != nil {
+		return "", nil, nil, err
+	}
+	if targetFormat != format && !(isJSONFormat(format) && isJSONFormat(targetFormat)) {
+		return "", nil, nil, fmt.Errorf("译文文件格式 %s 与原文文件格式 %s 不一致", targetFormat, format)
+	}
+	tgt, err := i18n.Parse(format, target)
+	if err != nil {
+		return "", nil, nil, fmt.Errorf("解析译文文件失败: %w", err)
+	}
+	return format, src, tgt, nil
+}
+
+func isJSONFormat(format string) bool {
+	return format == i18n.FormatJSON || format == i18n.FormatARB
+}
+
+// 翻译本地化文件中缺失或修改过的条目
+//
+// targetName 标识译文文件，数据库按文件和语言记录各条目翻译时的原文，用于下次判断原文是否修改；
+// 每次翻译在历史记录中保存一条汇总，便于审计。
+func translateLocaleFile(ctx context.Context, format string, source, target i18n.Document, targetName string, req localeRequest, progress func(done, total int)) (*i18n.Result, error) {
+	cfg := config.GetConfig()
+	if req.TargetLanguage == "" {
+		req.TargetLanguage = cfg.Translation.TargetLanguage
+	}
+	if req.Profile == "" {
+		req.Profile = cfg.API.ActiveProfile
+	}
+
+	dbMutex.Lock()
+	previous, err := db.GetLocaleSources(targetName, req.TargetLanguage)
+	dbMutex.Unlock()
+	if err != nil {
+		return nil, fmt.Errorf("读取翻译记录失败: %w", err)
+	}
+
+	// 试运行不调用模型
+	var client ai.AIClient
+	if !req.DryRun {
+		if client, _, err = aiClientFor(req.Profile); err != nil {
+			return nil, err
+		}
+	}
+
+	tmpl, _ := currentPromptTemplates().Get(string(ai.TaskTranslateTo))
+	promptVars := promptData(cfg, constants.ACTION_TRANSLATE_ALT, "")
+	promptVars.TargetLanguage = req.TargetLanguage
+	render := func(previous string) (string, error) {
+		d := promptVars
+		d.Context = previous
+		prompt, err := tmpl.Render(d)
+		if err != nil {
+			return "", err
+		}
+		return prompt + "\n\n" + ai.NumberedInstruction + "\n\n" + i18n.Instruction, nil
+	}
+
+	opts := i18n.Options{
+		Language: req.TargetLanguage,
+		Batch: ai.BatchOptions{
+			Tokens:      cfg.Translation.ChunkTokens,
+			Concurrency: cfg.Translation.ChunkConcurrency,
+		},
+		DryRun: req.DryRun,
+	}
+	if req.DryRun {
+		return i18n.Translate(ctx, client, format, source, target, previous, render, opts, progress)
+	}
+
+	direction := fmt.Sprintf("%s → %s", filepath.Base(targetName), req.TargetLanguage)
+	event := translationEvent{ID: newTranslationID(), Origin: "i18n", Direction: direction}
+	events.publish(EventTranslationStarted, event)
+	result, err := i18n.Translate(ctx, client, format, source, target, previous, render, opts, progress)
+	if err != nil {
+		event.Error = err.Error()
+		events.publish(EventTranslationFailed, event)
+		return nil, err
+	}
+
+	dbMutex.Lock()
+	err = db.SaveLocaleSources(targetName, req.TargetLanguage, result.Sources)
+	dbMutex.Unlock()
+	if err != nil {
+		return nil, fmt.Errorf("保存翻译记录失败: %w", err)
+	}
+
+	// 历史记录中保存本次翻译的条目
+	var original, translated strings.Builder
+	for _, change := range result.Report.Changes {
+		if change.Target == "" {
+			continue
+		}
+		fmt.Fprintf(&original, "%s: %s\n", change.Key, change.Source)
+		fmt.Fprintf(&translated, "%s: %s\n", change.Key, change.Target)
+	}
+	if original.Len() > 0 {
+		event.HistoryID = addHistoryItem(strings.TrimSpace(original.String()), strings.TrimSpace(translated.String()), direction, tmpl.Stamp(), usageOf(client, result.Usage))
+	}
+	events.publish(EventTranslationCompleted, event)
+
+	report := result.Report
+	log.Info("本地化文件翻译完成: %s, 新增 %d, 修改 %d, 删除 %d, 失败 %d",
+		targetName, report.Count(i18n.StatusMissing), report.Count(i18n.StatusChanged), report.Count(i18n.StatusObsolete), report.Failed())
+	return result, nil
+}
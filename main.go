@@ -1,50 +1,45 @@
 package main
 
 import (
-	"context"
+	"errors"
+	"flag"
 	"fmt"
+	"io"
+	"mime"
 	"net/http"
 	"os"
 	"path"
 	"path/filepath"
+	"strconv"
+	"strings"
 	"sync"
-	"syscall"
 	"time"
-	"unsafe"
 
-	"github.com/atotto/clipboard"
 	"github.com/gin-gonic/gin"
-	"github.com/go-toast/toast"
 
 	"clipboard-translate/ai"
 	"clipboard-translate/config"
 	"clipboard-translate/constants"
 	"clipboard-translate/database"
+	"clipboard-translate/hotkey"
+	"clipboard-translate/openapi"
+	"clipboard-translate/secrets"
+	"clipboard-translate/subtitle"
 	log "clipboard-translate/utils/log"
 )
 
 var (
-	aiClient      ai.AIClient       // 替换 geminiClient
-	staticDirPath string            // 全局变量存储静态文件目录路径
-	db            database.Database // 数据库实例
-	dbMutex       sync.Mutex        // 数据库操作互斥锁
+	aiClients     = make(map[string]ai.AIClient) // 按AI配置名称缓存的客户端
+	aiClientMu    sync.RWMutex                   // 保护 aiClients，配置变更时替换
+	aiClientGen   uint64                         // 缓存客户端被丢弃的次数，创建客户端期间变化时不缓存
+	staticDirPath string                         // 全局变量存储静态文件目录路径
+	db            database.Database              // 数据库实例
+	dbMutex       sync.Mutex                     // 数据库操作互斥锁
+	hotkeyManager hotkey.Manager                 // 全局热键管理器
 )
 
-// 翻译函数
-func translateWithAI(ctx context.Context, text string) (string, error) {
-
-	// 使用AI客户端进行翻译
-	return aiClient.Translate(ctx, text)
-}
-
-// 添加历史项
-func addHistoryItem(original, translated string) {
-	// 确定翻译方向
-	direction := "中 → 英"
-	if !isChineseText(original) {
-		direction = "英 → 中"
-	}
-
+// 添加历史项，返回记录ID，保存失败时返回空字符串
+func addHistoryItem(original, translated, direction, promptVersion string, usage translationUsage) string {
 	// 创建历史记录项
 	newItem := &database.HistoryItem{
 		Original:   original,
@@ -52,6 +47,14 @@ func addHistoryItem(original, translated string) {
 		Timestamp:  time.Now(),
 		ID:         fmt.Sprintf("%d", time.Now().UnixNano()),
 		Direction:  direction,
+
+		PromptVersion: promptVersion,
+
+		Provider:     usage.Provider,
+		Model:        usage.Model,
+		InputTokens:  usage.InputTokens,
+		OutputTokens: usage.OutputTokens,
+		Cost:         usage.Cost,
 	}
 
 	// 使用互斥锁保护数据库操作
@@ -61,7 +64,7 @@ func addHistoryItem(original, translated string) {
 	// 添加到数据库
 	if err := db.AddHistoryItem(newItem); err != nil {
 		log.Error("添加历史记录失败: %v", err)
-		return
+		return ""
 	}
 
 	// 检查是否需要清理旧记录
@@ -70,7 +73,7 @@ func addHistoryItem(original, translated string) {
 		count, err := db.GetHistoryCount()
 		if err != nil {
 			log.Error("获取历史记录数量失败: %v", err)
-			return
+			return newItem.ID
 		}
 
 		if count > maxHistory {
@@ -79,132 +82,45 @@ func addHistoryItem(original, translated string) {
 			}
 		}
 	}
+	return newItem.ID
 }
 
-// 注册热键
-func registerHotKey() bool {
-	// 从配置读取热键设置
-	translateHotkey := config.GetConfig().Hotkeys["translate"]
-	modifiers := config.ModifierFromString(translateHotkey.Modifiers)
-	key := config.VirtualKeyFromString(translateHotkey.Key)
-
-	user32 := syscall.NewLazyDLL("user32.dll")
-	procRegisterHotKey := user32.NewProc("RegisterHotKey")
-	ret, _, _ := procRegisterHotKey.Call(
-		0,
-		uintptr(constants.HOTKEY_ID),
-		uintptr(modifiers),
-		uintptr(key))
-
-	return ret != 0
-}
-
-// 取消注册热键
-func unregisterHotKey() {
-	user32 := syscall.NewLazyDLL("user32.dll")
-	procUnregisterHotKey := user32.NewProc("UnregisterHotKey")
-	procUnregisterHotKey.Call(0, uintptr(constants.HOTKEY_ID))
-}
-
-// 触发翻译
-func triggerTranslation(ctx context.Context) {
-	// 获取当前剪贴板内容
-	content, err := clipboard.ReadAll()
-	if err != nil {
-		log.Error("读取剪贴板失败: %v", err)
+// 根据保存配置的结果写入响应：订阅者应用失败时列出失败的子系统和热键
+func respondSaveResult(c *gin.Context, err error) {
+	if err == nil {
+		c.Status(http.StatusOK)
 		return
 	}
 
-	if content == "" {
-		log.Warn("剪贴板内容为空")
+	var applyErr *config.ApplyError
+	if !errors.As(err, &applyErr) {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "保存配置失败"})
 		return
 	}
 
-	// 检测语言并记录
-	isChinese := isChineseText(content)
-	translationDirection := "中 → 英"
-	if !isChinese {
-		translationDirection = "英 → 中"
-	}
-
-	log.Info("开始翻译剪贴板内容... 方向: %s, 使用: %s", translationDirection, aiClient.GetName())
-	translated, err := translateWithAI(ctx, content)
-	if err != nil {
-		log.Error("翻译失败: %v", err)
-		translated = "翻译失败: " + err.Error()
-	}
-
-	notification := toast.Notification{
-		AppID:   "剪贴板翻译",
-		Title:   fmt.Sprintf("翻译结果 (%s - %s)", aiClient.GetName(), translationDirection),
-		Message: translated,
-	}
-
-	if err := notification.Push(); err != nil {
-		log.Error("发送通知失败: %v", err)
-	} else {
-		log.Info("已发送通知，内容: %s", translated)
-	}
-
-	// 添加到历史记录，包含翻译方向信息
-	addHistoryItem(content, translated)
-}
-
-// 监听热键
-func listenHotkey(ctx context.Context) {
-	// 注册热键
-	if !registerHotKey() {
-		log.Error("注册热键失败，按 Ctrl+Alt+T 触发翻译可能不可用")
-	} else {
-		log.Info("已注册热键: Ctrl+Alt+T 触发翻译")
-		defer unregisterHotKey()
-	}
-
-	// 加载所需的 Windows API
-	user32 := syscall.NewLazyDLL("user32.dll")
-	procGetMessage := user32.NewProc("GetMessageW")
-	procTranslateMessage := user32.NewProc("TranslateMessage")
-	procDispatchMessage := user32.NewProc("DispatchMessageW")
-
-	// 定义消息结构体
-	type MSG struct {
-		HWND   uintptr
-		UINT   uint32
-		WPARAM uintptr
-		LPARAM uintptr
-		Time   uint32
-		Pt     struct {
-			X int32
-			Y int32
+	// 配置已保存，但部分设置未能生效
+	response := gin.H{}
+	details := make(map[string]string)
+	for name, err := range applyErr.Errors {
+		var hkErrs hotkeyErrors
+		if errors.As(err, &hkErrs) {
+			hotkeyErrors := make(map[string]string, len(hkErrs))
+			for action, err := range hkErrs {
+				hotkeyErrors[action] = err.Error()
+			}
+			response["hotkey_errors"] = hotkeyErrors
+		} else {
+			details[name] = err.Error()
 		}
 	}
 
-	// 消息循环
-	var msg MSG
-	for {
-		// 获取Windows消息
-		ret, _, _ := procGetMessage.Call(
-			uintptr(unsafe.Pointer(&msg)),
-			0,
-			0,
-			0,
-		)
-
-		if ret == 0 {
-			// WM_QUIT 消息，退出循环
-			break
-		}
-
-		// 检查是否是热键消息
-		if msg.UINT == 0x0312 /* WM_HOTKEY */ && msg.WPARAM == uintptr(constants.HOTKEY_ID) {
-			log.Info("检测到热键 Ctrl+Alt+T，开始翻译...")
-			go triggerTranslation(ctx)
-		}
-
-		// 处理消息
-		procTranslateMessage.Call(uintptr(unsafe.Pointer(&msg)))
-		procDispatchMessage.Call(uintptr(unsafe.Pointer(&msg)))
+	if len(details) > 0 {
+		response["error"] = "配置已保存，但部分设置应用失败"
+		response["details"] = details
+		c.JSON(http.StatusInternalServerError, response)
+		return
 	}
+	c.JSON(http.StatusOK, response)
 }
 
 // 设置Gin路由
@@ -212,45 +128,21 @@ func setupRouter() *gin.Engine {
 	// 设置为发布模式
 	gin.SetMode(gin.ReleaseMode)
 
-	// 创建带有默认中间件的路由
-	r := gin.Default()
-
-	// 日志中间件
-	r.Use(func(c *gin.Context) {
-		// 开始时间
-		start := time.Now()
-
-		// 处理请求
-		c.Next()
-
-		// 结束时间
-		end := time.Now()
-
-		// 执行时间
-		latency := end.Sub(start)
-
-		// 请求方式
-		reqMethod := c.Request.Method
-
-		// 请求路由
-		reqURI := c.Request.RequestURI
+	// 创建路由，请求日志和耗时由指标中间件记录
+	r := gin.New()
+	r.Use(gin.Recovery())
+	r.Use(httpMetrics())
 
-		// 状态码
-		statusCode := c.Writer.Status()
-
-		// 请求IP
-		clientIP := c.ClientIP()
+	// 按 OpenAPI 文档校验请求，文档是接口的唯一来源
+	spec, err := openapi.Load()
+	if err != nil {
+		app.Fatal("加载 OpenAPI 文档失败: %v", err)
+	}
 
-		// 日志格式
-		log.Info("[GIN] %v | %3d | %13v | %15s | %s %s",
-			end.Format("2006/01/02 - 15:04:05"),
-			statusCode,
-			latency,
-			clientIP,
-			reqMethod,
-			reqURI,
-		)
-	})
+	// 先拒绝来自其他网站的请求，再校验访问令牌和请求内容
+	r.Use(checkOrigin())
+	r.Use(requireToken(spec))
+	r.Use(validateRequest(spec))
 
 	// API 路由组
 	api := r.Group("/api")
@@ -280,13 +172,14 @@ func setupRouter() *gin.Engine {
 				c.JSON(http.StatusInternalServerError, gin.H{"error": "清空历史记录失败"})
 				return
 			}
+			events.publish(EventHistoryCleared, gin.H{})
 
 			c.Status(http.StatusOK)
 		})
 
 		// 手动刷新剪贴板
 		api.POST("/refresh", func(c *gin.Context) {
-			go triggerTranslation(context.Background())
+			app.Go(triggerTranslation)
 			c.Status(http.StatusOK)
 		})
 
@@ -297,81 +190,416 @@ func setupRouter() *gin.Engine {
 
 		// 更新配置
 		api.POST("/config", func(c *gin.Context) {
-			var newConfig config.Config
-			if err := c.BindJSON(&newConfig); err != nil {
+			data, err := io.ReadAll(c.Request.Body)
+			if err != nil {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "读取请求失败"})
+				return
+			}
+
+			// 解析配置，旧版本字段会被迁移，未知字段会被拒绝
+			newConfig, err := config.DecodeConfig(data)
+			if err != nil {
+				var verr *config.ValidationError
+				if errors.As(err, &verr) {
+					c.JSON(http.StatusUnprocessableEntity, gin.H{"error": "配置校验失败", "errors": verr.Errors})
+					return
+				}
 				c.JSON(http.StatusBadRequest, gin.H{"error": "无效的配置数据"})
 				return
 			}
 
-			// 保存旧热键设置以检查是否需要重新注册
-			oldTranslateHotkey := config.GetConfig().Hotkeys["translate"]
-			oldDBType := config.GetConfig().Database.Type
-			oldDBConn := config.GetConfig().Database.Connection
+			// 校验配置，返回每个无效字段的错误信息
+			if err := newConfig.Validate(); err != nil {
+				var verr *config.ValidationError
+				if errors.As(err, &verr) {
+					c.JSON(http.StatusUnprocessableEntity, gin.H{"error": "配置校验失败", "errors": verr.Errors})
+					return
+				}
+				c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+				return
+			}
 
-			// 保存到文件
-			if err := config.SaveConfig(&newConfig); err != nil {
-				c.JSON(http.StatusInternalServerError, gin.H{"error": "保存配置失败"})
+			// 保存到文件，各子系统通过订阅自动应用变更
+			respondSaveResult(c, config.SaveConfig(newConfig))
+		})
+
+		// 列出AI配置及当前激活的配置
+		api.GET("/profiles", func(c *gin.Context) {
+			cfg := config.GetConfig()
+			c.JSON(http.StatusOK, gin.H{"active": cfg.API.ActiveProfile, "profiles": cfg.Profiles})
+		})
+
+		// 切换激活的AI配置，AI客户端随之重建
+		api.PUT("/profiles/active", func(c *gin.Context) {
+			var req struct {
+				Name string `json:"name"`
+			}
+			if err := c.BindJSON(&req); err != nil || req.Name == "" {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "配置名称不能为空"})
 				return
 			}
 
-			// 检查热键是否已更改
-			newTranslateHotkey := config.GetConfig().Hotkeys["translate"]
-			if !newTranslateHotkey.Equals(oldTranslateHotkey) {
-				// 取消注册旧热键
-				unregisterHotKey()
+			err := config.SetActiveProfile(req.Name)
+			var verr *config.ValidationError
+			if errors.As(err, &verr) {
+				c.JSON(http.StatusUnprocessableEntity, gin.H{"error": "配置校验失败", "errors": verr.Errors})
+				return
+			}
+			respondSaveResult(c, err)
+		})
+
+		// 列出已保存的密钥，只返回遮盖后的值
+		api.GET("/secrets", func(c *gin.Context) {
+			store := secrets.Default()
+			names, err := store.List()
+			if err != nil {
+				c.JSON(http.StatusInternalServerError, gin.H{"error": "读取密钥列表失败"})
+				return
+			}
+
+			items := make([]gin.H, 0, len(names))
+			for _, name := range names {
+				// 访问令牌等内部密钥不列出
+				if secrets.Reserved(name) {
+					continue
+				}
+				value, err := store.Get(name)
+				if err != nil {
+					continue
+				}
+				items = append(items, gin.H{"name": name, "masked": secrets.Mask(value)})
+			}
+			c.JSON(http.StatusOK, items)
+		})
+
+		// 保存密钥
+		api.PUT("/secrets/:name", func(c *gin.Context) {
+			var req struct {
+				Value string `json:"value"`
+			}
+			if err := c.BindJSON(&req); err != nil || req.Value == "" {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "密钥不能为空"})
+				return
+			}
 
-				// 注册新热键
-				if !registerHotKey() {
-					log.Error("重新注册热键失败")
+			name := c.Param("name")
+			if secrets.Reserved(name) {
+				c.JSON(http.StatusForbidden, gin.H{"error": "不能修改内部保留的密钥"})
+				return
+			}
+			if err := secrets.Default().Set(name, req.Value); err != nil {
+				if errors.Is(err, secrets.ErrInvalidName) {
+					c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+					return
 				}
+				log.Error("保存密钥失败: %v", err)
+				c.JSON(http.StatusInternalServerError, gin.H{"error": "保存密钥失败"})
+				return
 			}
 
-			// 检查数据库配置是否已更改
-			newDBType := config.GetConfig().Database.Type
-			newDBConn := config.GetConfig().Database.Connection
-			if newDBType != oldDBType || newDBConn != oldDBConn {
-				log.Info("数据库配置已更改，重新初始化数据库连接")
+			// 重建使用该密钥的AI客户端
+			if err := reloadAIClientsForKey(name); err != nil {
+				c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+				return
+			}
 
-				dbMutex.Lock()
-				defer dbMutex.Unlock()
+			c.JSON(http.StatusOK, gin.H{"name": name, "masked": secrets.Mask(req.Value)})
+		})
 
-				// 关闭旧连接
-				if db != nil {
-					if err := db.Close(); err != nil {
-						log.Error("关闭数据库连接失败: %v", err)
-					}
+		// 删除密钥
+		api.DELETE("/secrets/:name", func(c *gin.Context) {
+			name := c.Param("name")
+			if secrets.Reserved(name) {
+				c.JSON(http.StatusForbidden, gin.H{"error": "不能删除内部保留的密钥"})
+				return
+			}
+			if err := secrets.Default().Delete(name); err != nil {
+				if errors.Is(err, secrets.ErrNotFound) {
+					c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+					return
 				}
+				log.Error("删除密钥失败: %v", err)
+				c.JSON(http.StatusInternalServerError, gin.H{"error": "删除密钥失败"})
+				return
+			}
 
-				// 创建新连接
-				var err error
-				db, err = database.New(database.DBConfig{
-					Type:       newDBType,
-					Connection: newDBConn,
-					MaxHistory: newConfig.System.MaxHistoryItems,
-				})
+			// 丢弃使用该密钥的AI客户端，不再用已删除的密钥发起请求；
+			// 当前激活的配置使用该密钥时无法重建，调用时报告缺少密钥
+			if err := reloadAIClientsForKey(name); err != nil {
+				log.Warn("删除密钥 %s 后AI客户端不可用: %v", name, err)
+			}
+			c.Status(http.StatusOK)
+		})
+
+		// 列出提示词模板，包括内置模板
+		api.GET("/templates", func(c *gin.Context) {
+			c.JSON(http.StatusOK, currentPromptTemplates().List())
+		})
+
+		// 预览提示词：渲染已保存的模板或请求中提供的模板源码，变量默认取自翻译配置
+		api.POST("/templates/preview", func(c *gin.Context) {
+			var req struct {
+				Name   string         `json:"name"`
+				Source *string        `json:"source"`
+				Data   *ai.PromptData `json:"data"`
+			}
+			if err := c.BindJSON(&req); err != nil {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "无效的请求数据"})
+				return
+			}
 
+			var tmpl *ai.PromptTemplate
+			if req.Source != nil {
+				name := req.Name
+				if name == "" {
+					name = "preview"
+				}
+				parsed, err := ai.ParsePromptTemplate(name, *req.Source)
 				if err != nil {
-					log.Error("创建数据库连接失败: %v", err)
-					c.JSON(http.StatusInternalServerError, gin.H{"error": "重新初始化数据库失败"})
+					c.JSON(http.StatusUnprocessableEntity, gin.H{"error": err.Error()})
 					return
 				}
+				tmpl = parsed
+			} else {
+				found, ok := currentPromptTemplates().Get(req.Name)
+				if !ok {
+					c.JSON(http.StatusNotFound, gin.H{"error": fmt.Sprintf("提示词模板 %q 不存在", req.Name)})
+					return
+				}
+				tmpl = found
+			}
 
-				// 初始化数据库
-				if err := db.Initialize(); err != nil {
-					log.Error("初始化数据库失败: %v", err)
-					c.JSON(http.StatusInternalServerError, gin.H{"error": "初始化数据库失败"})
+			data := promptData(config.GetConfig(), "", "")
+			if req.Data != nil {
+				data = *req.Data
+			}
+			prompt, err := tmpl.Render(data)
+			if err != nil {
+				c.JSON(http.StatusUnprocessableEntity, gin.H{"error": err.Error()})
+				return
+			}
+			c.JSON(http.StatusOK, gin.H{"name": tmpl.Name, "version": tmpl.Stamp(), "prompt": prompt, "data": data})
+		})
+
+		// 同步翻译一段文本，供脚本、编辑器和浏览器扩展调用
+		api.POST("/translate", func(c *gin.Context) {
+			var req textRequest
+			if err := c.BindJSON(&req); err != nil {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "无效的请求数据"})
+				return
+			}
+			if strings.TrimSpace(req.Text) == "" {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "缺少要翻译的文本"})
+				return
+			}
+			cfg := config.GetConfig()
+			if _, ok := cfg.Profiles[req.Profile]; req.Profile != "" && !ok {
+				c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("AI配置 %q 不存在", req.Profile)})
+				return
+			}
+			if _, ok := currentPromptTemplates().Get(req.Template); req.Template != "" && !ok {
+				c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("提示词模板 %q 不存在", req.Template)})
+				return
+			}
+
+			result, err := translateText(c.Request.Context(), req)
+			if err != nil {
+				log.Error("%v", err)
+				c.JSON(modelErrorStatus(err), gin.H{"error": err.Error()})
+				return
+			}
+			c.JSON(http.StatusOK, result)
+		})
+
+		// 创建批量翻译任务，任务在后台执行，通过 GET /api/jobs/:id 查询进度
+		api.POST("/jobs", func(c *gin.Context) {
+			var req jobRequest
+			if err := c.BindJSON(&req); err != nil {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "无效的请求数据"})
+				return
+			}
+			if len(req.Texts) == 0 {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "缺少要翻译的文本"})
+				return
+			}
+			if len(req.Texts) > maxJobItems {
+				c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("单个任务最多 %d 条文本", maxJobItems)})
+				return
+			}
+			cfg := config.GetConfig()
+			if _, ok := cfg.Profiles[req.Profile]; req.Profile != "" && !ok {
+				c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("AI配置 %q 不存在", req.Profile)})
+				return
+			}
+			if _, ok := currentPromptTemplates().Get(req.Template); req.Template != "" && !ok {
+				c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("提示词模板 %q 不存在", req.Template)})
+				return
+			}
+
+			job, err := jobs.create(req)
+			if err != nil {
+				log.Error("%v", err)
+				c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+				return
+			}
+			c.JSON(http.StatusAccepted, newJobStatus(job))
+		})
+
+		// 查询批量翻译任务的进度和结果
+		api.GET("/jobs/:id", func(c *gin.Context) {
+			job, err := getJob(c.Param("id"))
+			if errors.Is(err, database.ErrNotFound) {
+				c.JSON(http.StatusNotFound, gin.H{"error": "任务不存在"})
+				return
+			}
+			if err != nil {
+				log.Error("读取批量翻译任务失败: %v", err)
+				c.JSON(http.StatusInternalServerError, gin.H{"error": "读取任务失败"})
+				return
+			}
+			c.JSON(http.StatusOK, newJobStatus(job))
+		})
+
+		// 取消批量翻译任务，已完成的条目保留
+		api.DELETE("/jobs/:id", func(c *gin.Context) {
+			job, err := jobs.cancelJob(c.Param("id"))
+			switch {
+			case errors.Is(err, database.ErrNotFound):
+				c.JSON(http.StatusNotFound, gin.H{"error": "任务不存在"})
+			case errors.Is(err, errJobFinished):
+				c.JSON(http.StatusConflict, gin.H{"error": err.Error(), "status": job.Status})
+			case err != nil:
+				log.Error("取消批量翻译任务失败: %v", err)
+				c.JSON(http.StatusInternalServerError, gin.H{"error": "取消任务失败"})
+			default:
+				c.JSON(http.StatusOK, newJobStatus(job))
+			}
+		})
+
+		// 翻译上传的字幕文件（SRT 或 WebVTT），返回翻译后的文件
+		api.POST("/subtitles/translate", func(c *gin.Context) {
+			filename, data, err := readUpload(c, "file", maxSubtitleSize)
+			if err != nil {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "读取字幕文件失败: " + err.Error()})
+				return
+			}
+			if data == nil {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "缺少字幕文件"})
+				return
+			}
+
+			cfg := config.GetConfig()
+			req := subtitleRequest{
+				TargetLanguage: c.PostForm("target_language"),
+				Profile:        c.PostForm("profile"),
+			}
+			if req.TargetLanguage == "" {
+				req.TargetLanguage = cfg.Translation.TargetLanguage
+			}
+			if _, ok := cfg.Profiles[req.Profile]; req.Profile != "" && !ok {
+				c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("AI配置 %q 不存在", req.Profile)})
+				return
+			}
+			if value := c.PostForm("max_line_length"); value != "" {
+				if req.MaxLineLength, err = strconv.Atoi(value); err != nil {
+					c.JSON(http.StatusBadRequest, gin.H{"error": "无效的每行最大宽度"})
 					return
 				}
 			}
 
-			c.Status(http.StatusOK)
+			f, err := translateSubtitle(c.Request.Context(), data, req, nil)
+			if err != nil {
+				if errors.Is(err, subtitle.ErrNoCues) || errors.Is(err, subtitle.ErrEncoding) {
+					c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+					return
+				}
+				log.Error("%v", err)
+				c.JSON(modelErrorStatus(err), gin.H{"error": err.Error()})
+				return
+			}
+
+			name := translatedFileName(filename, req.TargetLanguage)
+			c.Header("Content-Disposition", mime.FormatMediaType("attachment", map[string]string{"filename": name}))
+			c.Data(http.StatusOK, f.ContentType(), f.Bytes())
+		})
+
+		// 翻译上传的本地化文件，可同时上传已有的译文文件，只翻译缺失或原文有修改的条目
+		api.POST("/i18n/translate", func(c *gin.Context) {
+			sourceName, source, err := readUpload(c, "source", maxLocaleFileSize)
+			if err != nil {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "读取原文文件失败: " + err.Error()})
+				return
+			}
+			if source == nil {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "缺少原文文件"})
+				return
+			}
+			targetName, target, err := readUpload(c, "target", maxLocaleFileSize)
+			if err != nil {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "读取译文文件失败: " + err.Error()})
+				return
+			}
+
+			cfg := config.GetConfig()
+			req := localeRequest{
+				TargetLanguage: c.PostForm("target_language"),
+				Profile:        c.PostForm("profile"),
+				DryRun:         c.PostForm("dry_run") == "true",
+			}
+			if req.TargetLanguage == "" {
+				req.TargetLanguage = cfg.Translation.TargetLanguage
+			}
+			if _, ok := cfg.Profiles[req.Profile]; req.Profile != "" && !ok {
+				c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("AI配置 %q 不存在", req.Profile)})
+				return
+			}
+			if target == nil {
+				targetName = translatedFileName(sourceName, req.TargetLanguage)
+			}
+
+			format, src, tgt, err := parseLocaleFiles(sourceName, source, targetName, target)
+			if err != nil {
+				c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+				return
+			}
+
+			result, err := translateLocaleFile(c.Request.Context(), format, src, tgt, targetName, req, nil)
+			if err != nil {
+				log.Error("翻译本地化文件失败: %v", err)
+				c.JSON(modelErrorStatus(err), gin.H{"error": err.Error()})
+				return
+			}
+			c.JSON(http.StatusOK, gin.H{"filename": targetName, "content": string(result.Content), "report": result.Report})
+		})
+
+		// 按天或按月汇总token用量和费用
+		api.GET("/usage", getUsage)
+
+		// 以 Server-Sent Events 推送翻译、历史记录和配置变更事件
+		api.GET("/events", serveEvents)
+
+		// 获取支持的热键按键列表
+		api.GET("/hotkeys/keys", func(c *gin.Context) {
+			c.JSON(http.StatusOK, config.SupportedKeys())
 		})
 
 		// 健康检查端点
 		api.GET("/health", func(c *gin.Context) {
 			c.String(http.StatusOK, "OK")
 		})
+
+		// 浏览器登录，令牌写入 Cookie
+		api.POST("/login", login)
+
+		// OpenAPI 文档，apiclient 包由它生成
+		api.GET("/openapi.json", func(c *gin.Context) {
+			c.Data(http.StatusOK, "application/json; charset=utf-8", openapi.Spec())
+		})
 	}
+	checkAPIDocs(r, spec)
+
+	// Prometheus 指标，需要访问令牌（只读令牌即可）
+	r.GET("/metrics", serveMetrics())
 
 	// 使用 StaticFile 处理单个文件
 	r.StaticFile("/", filepath.Join(staticDirPath, "index.html")) // 根路径
@@ -385,6 +613,31 @@ func setupRouter() *gin.Engine {
 	return r
 }
 
+// 读取上传的文件，返回文件名和内容；没有该字段时内容为 nil
+func readUpload(c *gin.Context, field string, maxSize int64) (string, []byte, error) {
+	header, err := c.FormFile(field)
+	if errors.Is(err, http.ErrMissingFile) {
+		return "", nil, nil
+	}
+	if err != nil {
+		return "", nil, err
+	}
+	if header.Size > maxSize {
+		return "", nil, fmt.Errorf("文件超过 %d MB", maxSize>>20)
+	}
+
+	file, err := header.Open()
+	if err != nil {
+		return "", nil, err
+	}
+	defer file.Close()
+	data, err := io.ReadAll(file)
+	if err != nil {
+		return "", nil, err
+	}
+	return filepath.Base(header.Filename), data, nil
+}
+
 // 检测输入文本的主要语言
 func isChineseText(text string) bool {
 	// 统计中文和英文字符的数量
@@ -446,7 +699,18 @@ func getStaticDir() string {
 
 // main函数
 func main() {
-	// 设置工作目录为可执行文件所在目录
+	// 解析命令行参数，相对路径的 --config 以启动时的目录为准
+	args, err := parseCommandLine(os.Args[1:])
+	if err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			os.Exit(0)
+		}
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(2)
+	}
+
+	// 设置工作目录为可执行文件所在目录，子命令的文件参数仍以启动时的目录为准
+	launchDir, _ = os.Getwd()
 	execDir, err := filepath.Abs(filepath.Dir(os.Args[0]))
 	if err != nil {
 		log.Fatal("无法获取可执行文件目录: %v", err)
@@ -455,6 +719,11 @@ func main() {
 	if err != nil {
 		log.Fatal("无法切换工作目录: %v", err)
 	}
+
+	// 子命令执行后直接退出
+	if len(args) > 0 {
+		os.Exit(runCommand(args))
+	}
 	log.Info("当前工作目录: %s", execDir)
 
 	// 找到静态目录
@@ -477,73 +746,113 @@ func main() {
 		Compress:   true,
 	})
 
+	// 打开密钥存储，配置迁移时可能需要写入密钥
+	vault, err := secrets.NewVaultStore("secrets.vault", "secrets.key")
+	if err != nil {
+		log.Fatal("打开密钥存储失败: %v", err)
+	}
+	secrets.SetDefault(vault)
+
 	// 加载配置
 	if err := config.LoadConfig(); err != nil {
 		log.Fatal("加载配置文件失败: %v", err)
 	}
 	log.Info("配置加载成功")
 
-	// 初始化数据库连接
-	dbConfig := database.DBConfig{
-		Type:       config.GetConfig().Database.Type,
-		Connection: config.GetConfig().Database.Connection,
-		MaxHistory: config.GetConfig().System.MaxHistoryItems,
+	// 应用配置中的日志级别
+	if err := applyLogLevel(config.GetConfig().System.LogLevel); err != nil {
+		log.Warn("%v，使用默认日志级别", err)
 	}
 
-	// 创建数据库实例
-	db, err = database.New(dbConfig)
+	// 同一配置文件只运行一个实例，已有实例在运行时把它交给调用方后退出
+	running, err := acquireInstanceLock()
 	if err != nil {
-		log.Fatal("创建数据库连接失败: %v", err)
+		log.Fatal("%v", err)
 	}
-
-	// 初始化数据库
-	if err := db.Initialize(); err != nil {
-		log.Fatal("初始化数据库失败: %v", err)
+	if running != nil {
+		handOffToInstance(running)
+		os.Exit(0)
 	}
-	log.Info("数据库初始化成功: %s", dbConfig.Type)
-	defer db.Close()
-
-	// 初始化AI客户端
-	apiConfig := config.GetConfig().API
+	app.OnClose("锁文件", releaseInstanceLock)
 
-	// 根据配置选择API密钥
-	var apiKey string
-	if apiConfig.UseEnvKey {
-		apiKey = os.Getenv("AI_API_KEY")
-	} else {
-		apiKey = apiConfig.APIKey
+	// 初始化数据库连接
+	db, err = openDatabase(config.GetConfig())
+	if err != nil {
+		app.Fatal("%v", err)
 	}
+	log.Info("数据库初始化成功: %s", config.GetConfig().Database.Type)
+	app.OnClose("数据库", func() error {
+		dbMutex.Lock()
+		defer dbMutex.Unlock()
+		return db.Close()
+	})
 
-	// 创建AI客户端
-	aiClientConfig := ai.AIConfig{
-		Provider: apiConfig.Provider,
-		APIKey:   apiKey,
-		Model:    apiConfig.Model,
-		BaseURL:  apiConfig.BaseURL,
+	// 加载提示词模板
+	if err := loadPromptTemplates(config.GetConfig().Templates); err != nil {
+		app.Fatal("%v", err)
 	}
 
-	aiClient, err = ai.NewAIClient(aiClientConfig)
+	// 初始化AI客户端
+	client, err := currentAIClient()
 	if err != nil {
-		log.Fatal("AI客户端初始化失败: %v", err)
+		app.Fatal("%v", err)
 	}
-	defer aiClient.Close()
+	app.OnClose("AI客户端", func() error {
+		closeAIClients()
+		return nil
+	})
+
+	log.Info("AI客户端初始化成功: %s (%s)", config.GetConfig().API.ActiveProfile, client.GetName())
+
+	// 启动批量翻译任务执行器，继续上次未完成的任务
+	app.Loop(jobs.run)
+	if err := jobs.resume(); err != nil {
+		log.Error("%v", err)
+	}
+
+	// 使用配置中的监听地址和端口
+	host, port := config.GetConfig().UI.BindAddress, config.GetConfig().UI.Port
 
-	log.Info("AI客户端初始化成功: %s", aiClient.GetName())
+	// 创建热键管理器，无头环境下热键不可用但服务照常运行
+	hotkeyManager, err = hotkey.New()
+	if err != nil {
+		log.Warn("全局热键不可用: %v", err)
+	} else {
+		app.OnClose("热键管理器", hotkeyManager.Close)
 
-	// 使用配置中的端口
-	port := config.GetConfig().UI.Port
+		// 启动热键监听，退出时注销热键
+		app.Loop(listenHotkey)
+	}
 
-	// 启动热键监听
-	go listenHotkey(context.Background())
+	// 读取访问令牌，首次启动时生成；管理令牌交给 Electron 外壳
+	tokens, err = loadAPITokens()
+	if err != nil {
+		app.Fatal("%v", err)
+	}
+	sendShellEvent("api-token", map[string]any{"token": tokens.Admin})
 
 	// 设置路由
 	router := setupRouter()
 
+	// 订阅配置变更并监视配置文件
+	subscribeConfigChanges(router)
+	app.Loop(watchConfigFile)
+
 	// 启动Web服务器
 	log.Info("启动Web服务器，端口 %d...", port)
-	log.Info("请访问 http://localhost:%d 查看剪贴板翻译历史", port)
-	log.Info("按 Ctrl+Alt+T 触发翻译，将自动翻译当前剪贴板内容")
-	if err := router.Run(fmt.Sprintf(":%d", port)); err != nil {
-		log.Fatal("启动服务器失败: %v", err)
+	server, port, err := startHTTPServer(router, host, port)
+	if err != nil {
+		app.Fatal("启动服务器失败: %v", err)
 	}
+	httpServerMu.Lock()
+	httpServer = server
+	httpServerMu.Unlock()
+	announceServer(host, port)
+	log.Info("请访问 http://localhost:%d 查看剪贴板翻译历史，首次访问需要登录，运行 clipboard-translate token 获取登录链接", port)
+	log.Info("按 %s 触发翻译，将自动翻译当前剪贴板内容", config.GetConfig().Hotkeys[constants.ACTION_TRANSLATE])
+
+	// 收到退出信号后等待进行中的翻译完成，再依次关闭热键、AI客户端和数据库
+	code := waitForExit()
+	app.Shutdown()
+	os.Exit(code)
 }
@@ -0,0 +1,201 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+
+	"clipboard-translate/config"
+	"clipboard-translate/secrets"
+)
+
+// 在临时目录中加载配置和密钥存储，返回使用管理令牌的路由
+func setupAPI(t *testing.T, extra map[string]any) *gin.Engine {
+	t.Helper()
+	srv := fakeOpenAI(t)
+	if err := config.SetConfigFile(setupCommandDir(t, srv.URL, extra)); err != nil {
+		t.Fatal(err)
+	}
+	vault, err := secrets.NewVaultStore("secrets.vault", "secrets.key")
+	if err != nil {
+		t.Fatal(err)
+	}
+	secrets.SetDefault(vault)
+	if err := config.LoadConfig(); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(closeAIClients)
+
+	saved := tokens
+	tokens = apiTokens{Admin: "admin-token", Read: "read-token"}
+	t.Cleanup(func() { tokens = saved })
+	return setupRouter()
+}
+
+// 以管理令牌发送请求
+func apiRequest(r http.Handler, method, path, body string) *httptest.ResponseRecorder {
+	var reader io.Reader
+	if body != "" {
+		reader = strings.NewReader(body)
+	}
+	req := httptest.NewRequest(method, path, reader)
+	req.Host = "localhost"
+	req.Header.Set("Authorization", "Bearer admin-token")
+	if body != "" {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	return w
+}
+
+func TestDeleteSecretDropsClients(t *testing.T) {
+	r := setupAPI(t, map[string]any{
+		"profiles": map[string]any{
+			"fake":  map[string]any{"provider": "openai", "model": "m", "base_url": "http://127.0.0.1:1/v1", "use_env_key": true},
+			"keyed": map[string]any{"provider": "openai", "model": "m", "base_url": "http://127.0.0.1:1/v1", "api_key_ref": "work"},
+		},
+	})
+
+	if w := apiRequest(r, http.MethodPut, "/api/secrets/work", `{"value":"sk-test"}`); w.Code != http.StatusOK {
+		t.Fatalf("保存密钥返回 %d: %s", w.Code, w.Body)
+	}
+	if _, err := cachedAIClient("keyed"); err != nil {
+		t.Fatal(err)
+	}
+
+	if w := apiRequest(r, http.MethodDelete, "/api/secrets/work", ""); w.Code != http.StatusOK {
+		t.Fatalf("删除密钥返回 %d: %s", w.Code, w.Body)
+	}
+	aiClientMu.RLock()
+	_, cached := aiClients["keyed"]
+	aiClientMu.RUnlock()
+	if cached {
+		t.Error("删除密钥后仍缓存使用该密钥的AI客户端")
+	}
+	if _, err := cachedAIClient("keyed"); err == nil {
+		t.Error("删除密钥后应无法创建使用该密钥的AI客户端")
+	}
+}
+
+func TestProfileGenerationParams(t *testing.T) {
+	// 记录每个请求体中的生成参数
+	var sent map[string]any
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body map[string]any
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		sent = make(map[string]any)
+		for _, key := range []string{"temperature", "top_p", "max_tokens", "seed", "stop"} {
+			if value, ok := body[key]; ok {
+				sent[key] = value
+			}
+		}
+		json.NewEncoder(w).Encode(map[string]any{
+			"choices": []any{map[string]any{"message": map[string]any{"role": "assistant", "content": "译"}, "finish_reason": "stop"}},
+		})
+	}))
+	defer srv.Close()
+
+	setupAPI(t, map[string]any{
+		"profiles": map[string]any{
+			"precise":  map[string]any{"provider": "openai", "model": "m", "base_url": srv.URL, "use_env_key": true, "temperature": 0, "seed": 7, "stop": []string{"END"}},
+			"creative": map[string]any{"provider": "openai", "model": "m", "base_url": srv.URL, "use_env_key": true, "temperature": 1.2, "top_p": 0.8, "max_tokens": 64},
+			"default":  map[string]any{"provider": "openai", "model": "m", "base_url": srv.URL, "use_env_key": true},
+		},
+	})
+
+	tests := []struct {
+		profile string
+		want    map[string]any
+	}{
+		// 显式的 0 也要发送，不能被当作未设置
+		{"precise", map[string]any{"temperature": 0.0, "seed": 7.0, "stop": []any{"END"}}},
+		{"creative", map[string]any{"temperature": 1.2, "top_p": 0.8, "max_tokens": 64.0}},
+		{"default", map[string]any{}},
+	}
+	for _, tt := range tests {
+		client, err := cachedAIClient(tt.profile)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := client.Complete(context.Background(), "system", "text"); err != nil {
+			t.Fatalf("%s: %v", tt.profile, err)
+		}
+		if !reflect.DeepEqual(sent, tt.want) {
+			t.Errorf("配置 %s 发送的生成参数为 %v, want %v", tt.profile, sent, tt.want)
+		}
+	}
+}
+
+func TestReservedSecrets(t *testing.T) {
+	r := setupAPI(t, nil)
+	store := secrets.Default()
+	if err := store.Set("api-token", "user-secret"); err != nil {
+		t.Fatal(err)
+	}
+	if err := store.Set("openai", "sk-test-1234"); err != nil {
+		t.Fatal(err)
+	}
+
+	// 令牌只保存在保留的命名空间，不使用同名的用户密钥
+	loaded, err := loadAPITokens()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if loaded.Admin == "" || loaded.Admin == "user-secret" || loaded.Read == "" {
+		t.Fatalf("读取的令牌为 %+v", loaded)
+	}
+	if value, _ := store.Get("api-token"); value != "user-secret" {
+		t.Errorf("用户密钥 api-token 被修改为 %q", value)
+	}
+
+	w := apiRequest(r, http.MethodGet, "/api/secrets", "")
+	if w.Code != http.StatusOK {
+		t.Fatalf("列出密钥返回 %d: %s", w.Code, w.Body)
+	}
+	if body := w.Body.String(); strings.Contains(body, secrets.ReservedPrefix) || !strings.Contains(body, `"openai"`) || !strings.Contains(body, `"api-token"`) {
+		t.Errorf("密钥列表为 %s", body)
+	}
+
+	tests := []struct {
+		method string
+		path   string
+		body   string
+	}{
+		{http.MethodPut, "/api/secrets/" + adminTokenName, `{"value":"x"}`},
+		{http.MethodPut, "/api/secrets/Internal.other", `{"value":"x"}`},
+		{http.MethodDelete, "/api/secrets/" + readTokenName, ""},
+	}
+	for _, tt := range tests {
+		if w := apiRequest(r, tt.method, tt.path, tt.body); w.Code != http.StatusForbidden {
+			t.Errorf("%s %s 返回 %d, want 403", tt.method, tt.path, w.Code)
+		}
+	}
+	if value, _ := store.Get(adminTokenName); value != loaded.Admin {
+		t.Error("保留的密钥不应被接口修改")
+	}
+}
+
+func TestPostConfigKeepsKeyOnRejection(t *testing.T) {
+	r := setupAPI(t, nil)
+
+	// 旧版本配置中的明文密钥，校验失败时不应写入密钥存储
+	body := `{"version": 1, "api": {"provider": "openai", "api_key": "sk-rejected"}, "ui": {"port": 0}, "database": {"type": "sqlite"}}`
+	if w := apiRequest(r, http.MethodPost, "/api/config", body); w.Code != http.StatusUnprocessableEntity {
+		t.Fatalf("提交无效配置返回 %d: %s", w.Code, w.Body)
+	}
+	if _, err := secrets.Default().Get("openai"); !errors.Is(err, secrets.ErrNotFound) {
+		t.Errorf("被拒绝的配置留下了密钥, err = %v", err)
+	}
+}
@@ -0,0 +1,305 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+
+	"clipboard-translate/ai"
+	"clipboard-translate/database"
+	log "clipboard-translate/utils/log"
+)
+
+// 指标名称前缀
+const metricsNamespace = "clipboard_translate"
+
+// 模型调用的结果
+const (
+	outcomeSuccess  = "success"
+	outcomeError    = "error"
+	outcomeCanceled = "canceled" // 调用方取消或超时
+)
+
+var (
+	metricsRegistry = prometheus.NewRegistry()
+
+	// 模型调用次数，长文本分段和批量翻译时每次调用计一次
+	translationsTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: metricsNamespace,
+		Name:      "translations_total",
+		Help:      "模型调用次数，按提供商、模型和结果分类",
+	}, []string{"provider", "model", "outcome"})
+
+	translationDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: metricsNamespace,
+		Name:      "translation_duration_seconds",
+		Help:      "模型调用耗时",
+		Buckets:   []float64{0.25, 0.5, 1, 2, 5, 10, 20, 30, 60, 120},
+	}, []string{"provider", "model", "outcome"})
+
+	tokensTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: metricsNamespace,
+		Name:      "tokens_total",
+		Help:      "模型返回的token用量，type 为 input 或 output",
+	}, []string{"provider", "model", "type"})
+
+	// 按配置缓存的AI客户端，未命中时需要新建客户端
+	clientCacheTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: metricsNamespace,
+		Name:      "ai_client_cache_requests_total",
+		Help:      "获取AI客户端时缓存命中 (hit) 和未命中 (miss) 的次数",
+	}, []string{"result"})
+
+	hotkeyTriggersTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: metricsNamespace,
+		Name:      "hotkey_triggers_total",
+		Help:      "热键触发次数",
+	}, []string{"action"})
+
+	dbDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: metricsNamespace,
+		Name:      "db_operation_duration_seconds",
+		Help:      "数据库操作耗时",
+		Buckets:   []float64{0.0005, 0.001, 0.0025, 0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1},
+	}, []string{"operation", "outcome"})
+
+	httpRequestsTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: metricsNamespace,
+		Name:      "http_requests_total",
+		Help:      "HTTP请求次数，route 为路由模板",
+	}, []string{"method", "route", "status"})
+
+	httpRequestDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: metricsNamespace,
+		Name:      "http_request_duration_seconds",
+		Help:      "HTTP请求处理耗时，事件推送等长连接在断开时计入",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"method", "route"})
+)
+
+func init() {
+	metricsRegistry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+		translationsTotal,
+		translationDuration,
+		tokensTotal,
+		clientCacheTotal,
+		hotkeyTriggersTotal,
+		dbDuration,
+		httpRequestsTotal,
+		httpRequestDuration,
+	)
+}
+
+// 以 Prometheus 文本格式输出指标
+func serveMetrics() gin.HandlerFunc {
+	return gin.WrapH(promhttp.HandlerFor(metricsRegistry, promhttp.HandlerOpts{}))
+}
+
+// 记录HTTP请求次数和耗时，请求日志只在 debug 级别输出
+func httpMetrics() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		start := time.Now()
+		c.Next()
+		latency := time.Since(start)
+
+		// 使用路由模板，避免任务 ID 等路径参数产生大量标签值
+		route := c.FullPath()
+		if route == "" {
+			route = "unmatched"
+		}
+		method, status := c.Request.Method, c.Writer.Status()
+		httpRequestsTotal.WithLabelValues(method, route, strconv.Itoa(status)).Inc()
+		httpRequestDuration.WithLabelValues(method, route).Observe(latency.Seconds())
+
+		log.Debug("[HTTP] %3d | %13v | %15s | %s %s", status, latency, c.ClientIP(), method, c.Request.URL.Path)
+	}
+}
+
+// 记录每次模型调用的次数、耗时和token用量，并累加到用量统计
+type instrumentedClient struct {
+	ai.AIClient
+	provider string
+}
+
+func newInstrumentedClient(client ai.AIClient, provider string) ai.AIClient {
+	return instrumentedClient{AIClient: client, provider: strings.ToLower(provider)}
+}
+
+func (c instrumentedClient) Translate(ctx context.Context, text string) (ai.Result, error) {
+	return c.observe(func() (ai.Result, error) {
+		return c.AIClient.Translate(ctx, text)
+	})
+}
+
+func (c instrumentedClient) Complete(ctx context.Context, systemPrompt, text string) (ai.Result, error) {
+	return c.observe(func() (ai.Result, error) {
+		return c.AIClient.Complete(ctx, systemPrompt, text)
+	})
+}
+
+func (c instrumentedClient) observe(call func() (ai.Result, error)) (ai.Result, error) {
+	start := time.Now()
+	result, err := call()
+	elapsed := time.Since(start)
+
+	outcome := outcomeSuccess
+	switch {
+	case errors.Is(err, context.Canceled), errors.Is(err, context.DeadlineExceeded):
+		outcome = outcomeCanceled
+	case err != nil:
+		outcome = outcomeError
+	}
+	model := c.GetModel()
+	translationsTotal.WithLabelValues(c.provider, model, outcome).Inc()
+	translationDuration.WithLabelValues(c.provider, model, outcome).Observe(elapsed.Seconds())
+
+	u := result.Usage
+	if u.InputTokens > 0 {
+		tokensTotal.WithLabelValues(c.provider, model, "input").Add(float64(u.InputTokens))
+	}
+	if u.OutputTokens > 0 {
+		tokensTotal.WithLabelValues(c.provider, model, "output").Add(float64(u.OutputTokens))
+	}
+	if err == nil || u.Total() > 0 {
+		recordUsage(c.GetName(), model, u)
+	}
+	return result, err
+}
+
+// 记录每次数据库操作的耗时
+type instrumentedDB struct {
+	db database.Database
+}
+
+func observeDB(operation string, start time.Time, err error) {
+	outcome := outcomeSuccess
+	if err != nil && !errors.Is(err, database.ErrNotFound) {
+		outcome = outcomeError
+	}
+	dbDuration.WithLabelValues(operation, outcome).Observe(time.Since(start).Seconds())
+}
+
+func (d instrumentedDB) Initialize() error {
+	start := time.Now()
+	err := d.db.Initialize()
+	observeDB("initialize", start, err)
+	return err
+}
+
+func (d instrumentedDB) Close() error {
+	return d.db.Close()
+}
+
+func (d instrumentedDB) AddHistoryItem(item *database.HistoryItem) error {
+	start := time.Now()
+	err := d.db.AddHistoryItem(item)
+	observeDB("add_history_item", start, err)
+	return err
+}
+
+func (d instrumentedDB) GetHistoryItems() ([]*database.HistoryItem, error) {
+	start := time.Now()
+	items, err := d.db.GetHistoryItems()
+	observeDB("get_history_items", start, err)
+	return items, err
+}
+
+func (d instrumentedDB) ClearHistory() error {
+	start := time.Now()
+	err := d.db.ClearHistory()
+	observeDB("clear_history", start, err)
+	return err
+}
+
+func (d instrumentedDB) GetHistoryCount() (int, error) {
+	start := time.Now()
+	count, err := d.db.GetHistoryCount()
+	observeDB("get_history_count", start, err)
+	return count, err
+}
+
+func (d instrumentedDB) PruneHistory(keepCount int) error {
+	start := time.Now()
+	err := d.db.PruneHistory(keepCount)
+	observeDB("prune_history", start, err)
+	return err
+}
+
+func (d instrumentedDB) GetLocaleSources(file, language string) (map[string]string, error) {
+	start := time.Now()
+	sources, err := d.db.GetLocaleSources(file, language)
+	observeDB("get_locale_sources", start, err)
+	return sources, err
+}
+
+func (d instrumentedDB) SaveLocaleSources(file, language string, sources map[string]string) error {
+	start := time.Now()
+	err := d.db.SaveLocaleSources(file, language, sources)
+	observeDB("save_locale_sources", start, err)
+	return err
+}
+
+func (d instrumentedDB) CreateJob(job *database.Job) error {
+	start := time.Now()
+	err := d.db.CreateJob(job)
+	observeDB("create_job", start, err)
+	return err
+}
+
+func (d instrumentedDB) GetJob(id string) (*database.Job, error) {
+	start := time.Now()
+	job, err := d.db.GetJob(id)
+	observeDB("get_job", start, err)
+	return job, err
+}
+
+func (d instrumentedDB) UpdateJobStatus(id, status string) error {
+	start := time.Now()
+	err := d.db.UpdateJobStatus(id, status)
+	observeDB("update_job_status", start, err)
+	return err
+}
+
+func (d instrumentedDB) UpdateJobItem(jobID string, item *database.JobItem) error {
+	start := time.Now()
+	err := d.db.UpdateJobItem(jobID, item)
+	observeDB("update_job_item", start, err)
+	return err
+}
+
+func (d instrumentedDB) GetUnfinishedJobs() ([]*database.Job, error) {
+	start := time.Now()
+	unfinished, err := d.db.GetUnfinishedJobs()
+	observeDB("get_unfinished_jobs", start, err)
+	return unfinished, err
+}
+
+func (d instrumentedDB) PruneJobs(before time.Time) error {
+	start := time.Now()
+	err := d.db.PruneJobs(before)
+	observeDB("prune_jobs", start, err)
+	return err
+}
+
+func (d instrumentedDB) AddUsage(usage *database.Usage) error {
+	start := time.Now()
+	err := d.db.AddUsage(usage)
+	observeDB("add_usage", start, err)
+	return err
+}
+
+func (d instrumentedDB) GetUsage(from, to string) ([]*database.Usage, error) {
+	start := time.Now()
+	usages, err := d.db.GetUsage(from, to)
+	observeDB("get_usage", start, err)
+	return usages, err
+}
@@ -0,0 +1,83 @@
+package main
+
+import (
+	"bufio"
+	"net/http"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+// 抓取指标，返回各序列（名称及标签）的值
+func scrapeMetrics(t *testing.T, r *gin.Engine) map[string]float64 {
+	t.Helper()
+	w := apiRequest(r, http.MethodGet, "/metrics", "")
+	if w.Code != http.StatusOK {
+		t.Fatalf("GET /metrics 返回 %d: %s", w.Code, w.Body)
+	}
+	series := make(map[string]float64)
+	scanner := bufio.NewScanner(w.Body)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		i := strings.LastIndex(line, " ")
+		value, err := strconv.ParseFloat(line[i+1:], 64)
+		if err != nil {
+			t.Fatalf("无法解析指标行 %q", line)
+		}
+		series[line[:i]] = value
+	}
+	return series
+}
+
+func TestHTTPMetrics(t *testing.T) {
+	r := setupAPI(t, nil)
+	before := scrapeMetrics(t, r)
+
+	requests := []struct {
+		method string
+		path   string
+		body   string
+		status int
+	}{
+		{http.MethodGet, "/api/profiles", "", http.StatusOK},
+		{http.MethodGet, "/api/profiles", "", http.StatusOK},
+		{http.MethodDelete, "/api/secrets/missing-secret", "", http.StatusNotFound},
+		{http.MethodPut, "/api/profiles/active", `{}`, http.StatusBadRequest},
+		{http.MethodGet, "/api/nope", "", http.StatusNotFound},
+	}
+	for _, req := range requests {
+		if w := apiRequest(r, req.method, req.path, req.body); w.Code != req.status {
+			t.Fatalf("%s %s 返回 %d, want %d", req.method, req.path, w.Code, req.status)
+		}
+	}
+	after := scrapeMetrics(t, r)
+
+	// 标签使用路由模板，路径参数不会产生新的标签值
+	tests := []struct {
+		series string
+		delta  float64
+	}{
+		{`clipboard_translate_http_requests_total{method="GET",route="/api/profiles",status="200"}`, 2},
+		{`clipboard_translate_http_requests_total{method="DELETE",route="/api/secrets/:name",status="404"}`, 1},
+		{`clipboard_translate_http_requests_total{method="PUT",route="/api/profiles/active",status="400"}`, 1},
+		{`clipboard_translate_http_requests_total{method="GET",route="unmatched",status="404"}`, 1},
+		{`clipboard_translate_http_request_duration_seconds_count{method="GET",route="/api/profiles"}`, 2},
+		{`clipboard_translate_http_request_duration_seconds_bucket{method="GET",route="/api/profiles",le="+Inf"}`, 2},
+		{`clipboard_translate_http_request_duration_seconds_count{method="DELETE",route="/api/secrets/:name"}`, 1},
+	}
+	for _, tt := range tests {
+		if got := after[tt.series] - before[tt.series]; got != tt.delta {
+			t.Errorf("%s 增加了 %v, want %v", tt.series, got, tt.delta)
+		}
+	}
+	for series := range after {
+		if strings.Contains(series, "missing-secret") || strings.Contains(series, "/api/nope") {
+			t.Errorf("指标标签包含原始路径: %s", series)
+		}
+	}
+}
@@ -0,0 +1,14 @@
+package notify
+
+import "errors"
+
+// 应用标识，显示在系统通知中
+const appID = "剪贴板翻译"
+
+// ErrUnsupported 当前平台不支持系统通知
+var ErrUnsupported = errors.New("当前平台不支持系统通知")
+
+// Push 发送系统通知
+func Push(title, message string) error {
+	return push(title, message)
+}
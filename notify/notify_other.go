@@ -0,0 +1,17 @@
+//go:build !windows
+
+package notify
+
+import (
+	"os/exec"
+)
+
+// 使用 notify-send 发送桌面通知，未安装时返回 ErrUnsupported
+func push(title, message string) error {
+	path, err := exec.LookPath("notify-send")
+	if err != nil {
+		return ErrUnsupported
+	}
+	// 译文可能以 - 开头，用 -- 结束选项解析
+	return exec.Command(path, "--app-name="+appID, "--", title, message).Run()
+}
@@ -0,0 +1,17 @@
+//go:build windows
+
+package notify
+
+import (
+	"github.com/go-toast/toast"
+)
+
+// 使用 Windows Toast 通知
+func push(title, message string) error {
+	notification := toast.Notification{
+		AppID:   appID,
+		Title:   title,
+		Message: message,
+	}
+	return notification.Push()
+}
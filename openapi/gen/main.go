@@ -0,0 +1,517 @@
+// 根据 OpenAPI 文档生成 apiclient 包的类型和方法
+//
+// 用法：在 apiclient 目录下运行 go generate，或
+//
+//	go run ./openapi/gen -o apiclient/client.gen.go
+package main
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"go/format"
+	"os"
+	"sort"
+	"strings"
+	"unicode"
+
+	"clipboard-translate/openapi"
+)
+
+func main() {
+	output := flag.String("o", "client.gen.go", "输出文件")
+	pkg := flag.String("package", "apiclient", "生成代码的包名")
+	flag.Parse()
+
+	doc, err := openapi.Load()
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+
+	g := &generator{doc: doc, imports: map[string]bool{"context": true}}
+	src, err := g.generate(*pkg)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+	if err := os.WriteFile(*output, src, 0644); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+}
+
+type generator struct {
+	doc     *openapi.Document
+	buf     bytes.Buffer
+	imports map[string]bool // 生成的代码用到的包
+}
+
+func (g *generator) printf(format string, args ...any) {
+	fmt.Fprintf(&g.buf, format, args...)
+}
+
+func (g *generator) generate(pkg string) ([]byte, error) {
+	names := make([]string, 0, len(g.doc.Components.Schemas))
+	for name := range g.doc.Components.Schemas {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		if err := g.genType(name, g.doc.Components.Schemas[name]); err != nil {
+			return nil, fmt.Errorf("schema %s: %w", name, err)
+		}
+	}
+
+	for _, path := range g.doc.SortedPaths() {
+		ops := g.doc.Paths[path].Operations()
+		for _, method := range []string{"GET", "POST", "PUT", "DELETE"} {
+			if op, ok := ops[method]; ok {
+				if err := g.genOperation(method, path, op); err != nil {
+					return nil, fmt.Errorf("%s %s: %w", method, path, err)
+				}
+			}
+		}
+	}
+
+	var out bytes.Buffer
+	fmt.Fprintf(&out, "// Code generated by clipboard-translate/openapi/gen. DO NOT EDIT.\n\n")
+	fmt.Fprintf(&out, "package %s\n\n", pkg)
+	imports := make([]string, 0, len(g.imports))
+	for path := range g.imports {
+		imports = append(imports, path)
+	}
+	sort.Strings(imports)
+	fmt.Fprintf(&out, "import (\n")
+	for _, path := range imports {
+		fmt.Fprintf(&out, "\t%q\n", path)
+	}
+	fmt.Fprintf(&out, ")\n\n")
+	out.Write(g.buf.Bytes())
+
+	src, err := format.Source(out.Bytes())
+	if err != nil {
+		return nil, fmt.Errorf("格式化生成的代码失败: %w\n%s", err, out.Bytes())
+	}
+	return src, nil
+}
+
+// 为组件生成类型，对象生成结构体，其他类型生成类型定义
+func (g *generator) genType(name string, schema *openapi.Schema) error {
+	g.comment(name, schema.Description)
+	if schema.Type != "object" || len(schema.Properties) == 0 {
+		typ, err := g.goType(schema)
+		if err != nil {
+			return err
+		}
+		g.printf("type %s %s\n\n", name, typ)
+		return nil
+	}
+
+	g.printf("type %s struct {\n", name)
+	for _, p := range schema.Properties {
+		typ, err := g.fieldType(p.Schema)
+		if err != nil {
+			return fmt.Errorf("%s: %w", p.Name, err)
+		}
+		tag := p.Name
+		// 结构体的零值不会被 omitempty 省略，不加该选项
+		if !schema.IsRequired(p.Name) && !g.isStruct(typ) {
+			tag += ",omitempty"
+		}
+		g.printf("\t%s %s `json:%q`", goName(p.Name), typ, tag)
+		if p.Schema.Description != "" {
+			g.printf(" // %s", p.Schema.Description)
+		}
+		g.printf("\n")
+	}
+	g.printf("}\n\n")
+	return nil
+}
+
+// Go 类型是否为结构体值
+func (g *generator) isStruct(typ string) bool {
+	if typ == "time.Time" {
+		return true
+	}
+	schema, ok := g.doc.Components.Schemas[typ]
+	return ok && schema.Type == "object" && len(schema.Properties) > 0
+}
+
+// 结构体字段的类型，可为 null 的基本类型和结构体使用指针
+func (g *generator) fieldType(schema *openapi.Schema) (string, error) {
+	typ, err := g.goType(schema)
+	if err != nil {
+		return "", err
+	}
+	if schema.Nullable && !strings.HasPrefix(typ, "[]") && !strings.HasPrefix(typ, "map[") && !strings.HasPrefix(typ, "*") {
+		typ = "*" + typ
+	}
+	return typ, nil
+}
+
+// schema 对应的 Go 类型，内联的对象必须定义为组件
+func (g *generator) goType(schema *openapi.Schema) (string, error) {
+	if schema.Ref != "" {
+		return openapi.RefName(schema)
+	}
+	if len(schema.AllOf) == 1 {
+		return g.goType(schema.AllOf[0])
+	}
+
+	switch schema.Type {
+	case "string":
+		switch schema.Format {
+		case "date-time":
+			g.imports["time"] = true
+			return "time.Time", nil
+		case "binary":
+			return "*File", nil
+		}
+		return "string", nil
+	case "integer":
+		if schema.Format == "int64" {
+			return "int64", nil
+		}
+		return "int", nil
+	case "number":
+		return "float64", nil
+	case "boolean":
+		return "bool", nil
+	case "array":
+		if schema.Items == nil {
+			return "[]any", nil
+		}
+		item, err := g.goType(schema.Items)
+		if err != nil {
+			return "", err
+		}
+		return "[]" + item, nil
+	case "object":
+		if len(schema.Properties) > 0 {
+			return "", fmt.Errorf("不支持内联的对象，请在 components/schemas 中定义")
+		}
+		if schema.AdditionalProperties == nil {
+			return "map[string]any", nil
+		}
+		value, err := g.goType(schema.AdditionalProperties)
+		if err != nil {
+			return "", err
+		}
+		return "map[string]" + value, nil
+	}
+	return "any", nil
+}
+
+// 操作成功时的响应
+type result struct {
+	kind string // json、bytes、text、stream 或 none
+	typ  string // kind 为 json 时的 Go 类型
+}
+
+func (g *generator) result(op *openapi.Operation) (result, error) {
+	codes := make([]string, 0, len(op.Responses))
+	for code := range op.Responses {
+		if strings.HasPrefix(code, "2") {
+			codes = append(codes, code)
+		}
+	}
+	sort.Strings(codes)
+	if len(codes) == 0 {
+		return result{}, fmt.Errorf("没有成功响应")
+	}
+
+	resp := op.Responses[codes[0]]
+	if len(resp.Content) == 0 {
+		return result{kind: "none"}, nil
+	}
+	if media, ok := resp.Content["application/json"]; ok {
+		typ, err := g.goType(media.Schema)
+		if err != nil {
+			return result{}, err
+		}
+		return result{kind: "json", typ: typ}, nil
+	}
+	if _, ok := resp.Content["text/event-stream"]; ok {
+		return result{kind: "stream"}, nil
+	}
+	if _, ok := resp.Content["text/plain"]; ok {
+		return result{kind: "text"}, nil
+	}
+	return result{kind: "bytes"}, nil
+}
+
+func (g *generator) genOperation(method, path string, op *openapi.Operation) error {
+	name := exportName(op.OperationID)
+	res, err := g.result(op)
+	if err != nil {
+		return err
+	}
+
+	params := []string{"ctx context.Context"}
+	pathExpr := g.pathExpr(path)
+	var query []*openapi.Parameter
+	for _, p := range op.Parameters {
+		switch p.In {
+		case "path":
+			params = append(params, fmt.Sprintf("%s string", lowerName(p.Name)))
+		case "query":
+			query = append(query, p)
+		}
+	}
+	if len(query) > 0 {
+		if err := g.genParams(name+"Params", query); err != nil {
+			return err
+		}
+		params = append(params, "params *"+name+"Params")
+	}
+
+	var bodyExpr string
+	var form *openapi.Schema
+	if op.RequestBody != nil {
+		if media, ok := op.RequestBody.Content["multipart/form-data"]; ok {
+			typ, err := g.goType(media.Schema)
+			if err != nil {
+				return err
+			}
+			if form, err = g.doc.Resolve(media.Schema); err != nil {
+				return err
+			}
+			params = append(params, "form *"+typ)
+			bodyExpr = "multipartBody(fields, files)"
+		} else if media, ok := op.RequestBody.Content["application/json"]; ok {
+			typ, err := g.goType(media.Schema)
+			if err != nil {
+				return err
+			}
+			params = append(params, "body *"+typ)
+			bodyExpr = "jsonBody(body)"
+		}
+	}
+	if bodyExpr == "" {
+		bodyExpr = "nil"
+	}
+
+	// 列表和映射直接返回，其他 JSON 响应返回指针
+	var returns, zero string
+	switch res.kind {
+	case "json":
+		returns, zero = "*"+res.typ, "nil"
+		if strings.HasPrefix(res.typ, "[]") || strings.HasPrefix(res.typ, "map[") {
+			returns = res.typ
+		}
+	case "bytes":
+		returns, zero = "[]byte", "nil"
+	case "text":
+		returns, zero = "string", `""`
+	case "stream":
+		returns, zero = "io.ReadCloser", "nil"
+		g.imports["io"] = true
+	}
+
+	g.comment(name, op.Summary)
+	g.printf("//\n// %s %s\n", method, path)
+	if returns == "" {
+		g.printf("func (c *Client) %s(%s) error {\n", name, strings.Join(params, ", "))
+	} else {
+		g.printf("func (c *Client) %s(%s) (%s, error) {\n", name, strings.Join(params, ", "), returns)
+	}
+	if form != nil {
+		if err := g.genForm(form); err != nil {
+			return err
+		}
+	}
+	if len(query) > 0 {
+		if err := g.genQuery(pathExpr, query); err != nil {
+			return err
+		}
+		pathExpr = "path"
+	}
+
+	if returns == "" {
+		g.printf("\tresp, err := c.do(ctx, %q, %s, %s)\n", method, pathExpr, bodyExpr)
+		g.printf("\tif err != nil {\n\t\treturn err\n\t}\n")
+		g.printf("\treturn discard(resp)\n}\n\n")
+		return nil
+	}
+
+	g.printf("\tresp, err := c.do(ctx, %q, %s, %s)\n", method, pathExpr, bodyExpr)
+	g.printf("\tif err != nil {\n\t\treturn %s, err\n\t}\n", zero)
+	switch res.kind {
+	case "json":
+		g.printf("\tvar out %s\n", res.typ)
+		g.printf("\tif err := decodeJSON(resp, &out); err != nil {\n\t\treturn nil, err\n\t}\n")
+		if strings.HasPrefix(returns, "*") {
+			g.printf("\treturn &out, nil\n")
+		} else {
+			g.printf("\treturn out, nil\n")
+		}
+	case "bytes":
+		g.printf("\treturn readAll(resp)\n")
+	case "text":
+		g.printf("\tdata, err := readAll(resp)\n\treturn string(data), err\n")
+	case "stream":
+		g.printf("\treturn resp.Body, nil\n")
+	}
+	g.printf("}\n\n")
+	return nil
+}
+
+// 请求路径的表达式，路径参数经过转义
+func (g *generator) pathExpr(path string) string {
+	if !strings.Contains(path, "{") {
+		return fmt.Sprintf("%q", path)
+	}
+	var parts []string
+	rest := path
+	for {
+		start := strings.Index(rest, "{")
+		if start < 0 {
+			break
+		}
+		end := strings.Index(rest, "}")
+		parts = append(parts, fmt.Sprintf("%q", rest[:start]))
+		g.imports["net/url"] = true
+		parts = append(parts, fmt.Sprintf("url.PathEscape(%s)", lowerName(rest[start+1:end])))
+		rest = rest[end+1:]
+	}
+	if rest != "" {
+		parts = append(parts, fmt.Sprintf("%q", rest))
+	}
+	return strings.Join(parts, " + ")
+}
+
+// 把表单结构体转换为 multipartBody 的参数，零值字段不发送
+func (g *generator) genForm(form *openapi.Schema) error {
+	g.printf("\tfields := make(map[string]string)\n")
+	g.imports["strconv"] = true
+	g.printf("\tfiles := make(map[string]*File)\n")
+	for _, p := range form.Properties {
+		field := "form." + goName(p.Name)
+		typ, err := g.goType(p.Schema)
+		if err != nil {
+			return fmt.Errorf("%s: %w", p.Name, err)
+		}
+		switch typ {
+		case "*File":
+			g.printf("\tif %s != nil {\n\t\tfiles[%q] = %s\n\t}\n", field, p.Name, field)
+		case "string":
+			g.printf("\tif %s != \"\" {\n\t\tfields[%q] = %s\n\t}\n", field, p.Name, field)
+		case "int":
+			g.printf("\tif %s != 0 {\n\t\tfields[%q] = strconv.Itoa(%s)\n\t}\n", field, p.Name, field)
+		case "int64":
+			g.printf("\tif %s != 0 {\n\t\tfields[%q] = strconv.FormatInt(%s, 10)\n\t}\n", field, p.Name, field)
+		case "float64":
+			g.printf("\tif %s != 0 {\n\t\tfields[%q] = strconv.FormatFloat(%s, 'f', -1, 64)\n\t}\n", field, p.Name, field)
+		case "bool":
+			g.printf("\tif %s {\n\t\tfields[%q] = \"true\"\n\t}\n", field, p.Name)
+		default:
+			return fmt.Errorf("%s: 表单字段不支持类型 %s", p.Name, typ)
+		}
+	}
+	return nil
+}
+
+// 为查询参数生成结构体，零值的参数不发送
+func (g *generator) genParams(name string, query []*openapi.Parameter) error {
+	g.printf("// %s 查询参数，零值的参数不发送\n", name)
+	g.printf("type %s struct {\n", name)
+	for _, p := range query {
+		typ, err := g.goType(p.Schema)
+		if err != nil {
+			return fmt.Errorf("%s: %w", p.Name, err)
+		}
+		g.printf("\t%s %s", goName(p.Name), typ)
+		if p.Description != "" {
+			g.printf(" // %s", p.Description)
+		}
+		g.printf("\n")
+	}
+	g.printf("}\n\n")
+	return nil
+}
+
+// 把查询参数结构体转换为带查询字符串的 path 变量
+func (g *generator) genQuery(pathExpr string, query []*openapi.Parameter) error {
+	g.imports["net/url"] = true
+	g.printf("\tquery := url.Values{}\n")
+	g.printf("\tif params != nil {\n")
+	for _, p := range query {
+		field := "params." + goName(p.Name)
+		typ, err := g.goType(p.Schema)
+		if err != nil {
+			return fmt.Errorf("%s: %w", p.Name, err)
+		}
+		switch typ {
+		case "string":
+			g.printf("\t\tif %s != \"\" {\n\t\t\tquery.Set(%q, %s)\n\t\t}\n", field, p.Name, field)
+		case "int":
+			g.imports["strconv"] = true
+			g.printf("\t\tif %s != 0 {\n\t\t\tquery.Set(%q, strconv.Itoa(%s))\n\t\t}\n", field, p.Name, field)
+		case "bool":
+			g.printf("\t\tif %s {\n\t\t\tquery.Set(%q, \"true\")\n\t\t}\n", field, p.Name)
+		default:
+			return fmt.Errorf("%s: 查询参数不支持类型 %s", p.Name, typ)
+		}
+	}
+	g.printf("\t}\n")
+	g.printf("\tpath := %s\n", pathExpr)
+	g.printf("\tif len(query) > 0 {\n\t\tpath += \"?\" + query.Encode()\n\t}\n")
+	return nil
+}
+
+// 输出注释，首行以名称开头
+func (g *generator) comment(name, text string) {
+	if text == "" {
+		return
+	}
+	for i, line := range strings.Split(text, "\n") {
+		if i == 0 {
+			g.printf("// %s %s\n", name, line)
+		} else {
+			g.printf("// %s\n", line)
+		}
+	}
+}
+
+// 需要全部大写的缩写
+var initialisms = map[string]string{
+	"id":   "ID",
+	"api":  "API",
+	"url":  "URL",
+	"ui":   "UI",
+	"http": "HTTP",
+	"json": "JSON",
+}
+
+// 把 snake_case 转换为导出的 Go 名称，如 history_id -> HistoryID
+func goName(name string) string {
+	var sb strings.Builder
+	for _, part := range strings.Split(name, "_") {
+		if upper, ok := initialisms[part]; ok {
+			sb.WriteString(upper)
+		} else {
+			sb.WriteString(exportName(part))
+		}
+	}
+	return sb.String()
+}
+
+// 首字母大写
+func exportName(name string) string {
+	if name == "" {
+		return name
+	}
+	r := []rune(name)
+	r[0] = unicode.ToUpper(r[0])
+	return string(r)
+}
+
+// 参数名，与 Go 关键字冲突时加后缀
+func lowerName(name string) string {
+	switch name {
+	case "type", "func", "range", "map", "go", "var":
+		return name + "_"
+	}
+	return name
+}
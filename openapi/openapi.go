@@ -0,0 +1,381 @@
+// Package openapi 提供HTTP API的 OpenAPI 3 文档，并按文档校验请求
+//
+// openapi.json 是接口的唯一来源：服务端按它校验请求，apiclient 包由它生成。
+// 修改接口时先修改文档，再运行 go generate ./apiclient。
+package openapi
+
+import (
+	"bytes"
+	_ "embed"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+)
+
+//go:embed openapi.json
+var specData []byte
+
+// Spec 返回 OpenAPI 文档的原始内容
+func Spec() []byte {
+	return specData
+}
+
+var (
+	loadOnce sync.Once
+	loaded   *Document
+	loadErr  error
+)
+
+// Load 解析内嵌的 OpenAPI 文档，结果会被缓存
+func Load() (*Document, error) {
+	loadOnce.Do(func() {
+		loaded, loadErr = Parse(specData)
+	})
+	return loaded, loadErr
+}
+
+// Document OpenAPI 文档，只包含本项目用到的部分
+type Document struct {
+	OpenAPI    string               `json:"openapi"`
+	Info       Info                 `json:"info"`
+	Paths      map[string]*PathItem `json:"paths"`
+	Components Components           `json:"components"`
+}
+
+// Info 文档信息
+type Info struct {
+	Title       string `json:"title"`
+	Description string `json:"description"`
+	Version     string `json:"version"`
+}
+
+// PathItem 一个路径下各请求方式的操作
+type PathItem struct {
+	Get    *Operation `json:"get,omitempty"`
+	Post   *Operation `json:"post,omitempty"`
+	Put    *Operation `json:"put,omitempty"`
+	Delete *Operation `json:"delete,omitempty"`
+}
+
+// Operations 按请求方式返回路径下的操作
+func (p *PathItem) Operations() map[string]*Operation {
+	ops := make(map[string]*Operation)
+	for method, op := range map[string]*Operation{"GET": p.Get, "POST": p.Post, "PUT": p.Put, "DELETE": p.Delete} {
+		if op != nil {
+			ops[method] = op
+		}
+	}
+	return ops
+}
+
+// Operation 接口操作
+type Operation struct {
+	OperationID string               `json:"operationId"`
+	Summary     string               `json:"summary"`
+	Description string               `json:"description"`
+	Tags        []string             `json:"tags"`
+	Parameters  []*Parameter         `json:"parameters"`
+	RequestBody *RequestBody         `json:"requestBody"`
+	Responses   map[string]*Response `json:"responses"`
+
+	// 覆盖文档级别的认证要求，为空列表时不需要认证
+	Security *[]map[string][]string `json:"security"`
+}
+
+// Public 接口是否不需要认证
+func (o *Operation) Public() bool {
+	return o.Security != nil && len(*o.Security) == 0
+}
+
+// Parameter 路径或查询参数
+type Parameter struct {
+	Ref         string  `json:"$ref"`
+	Name        string  `json:"name"`
+	In          string  `json:"in"` // path 或 query
+	Required    bool    `json:"required"`
+	Description string  `json:"description"`
+	Schema      *Schema `json:"schema"`
+}
+
+// RequestBody 请求体
+type RequestBody struct {
+	Required bool                  `json:"required"`
+	Content  map[string]*MediaType `json:"content"`
+}
+
+// Response 响应
+type Response struct {
+	Ref         string                `json:"$ref"`
+	Description string                `json:"description"`
+	Content     map[string]*MediaType `json:"content"`
+}
+
+// MediaType 请求体或响应的内容
+type MediaType struct {
+	Schema *Schema `json:"schema"`
+}
+
+// Components 可复用的定义
+type Components struct {
+	Parameters map[string]*Parameter `json:"parameters"`
+	Responses  map[string]*Response  `json:"responses"`
+	Schemas    map[string]*Schema    `json:"schemas"`
+}
+
+// Schema JSON Schema 的子集：类型、对象属性、数组元素、枚举和长度范围
+type Schema struct {
+	Ref         string
+	Type        string
+	Format      string
+	Description string
+	Nullable    bool
+	Enum        []string
+	AllOf       []*Schema
+
+	Properties []*Property // 按文档中的顺序排列
+	Required   []string
+
+	// 对象中未列出的属性：为 nil 且 NoAdditional 为 false 时允许任意属性
+	AdditionalProperties *Schema
+	NoAdditional         bool
+
+	Items     *Schema
+	MinItems  *int
+	MaxItems  *int
+	MinLength *int
+	Minimum   *float64
+	Maximum   *float64
+}
+
+// Property 对象的属性
+type Property struct {
+	Name   string
+	Schema *Schema
+}
+
+// Property 按名称查找属性
+func (s *Schema) Property(name string) (*Schema, bool) {
+	for _, p := range s.Properties {
+		if p.Name == name {
+			return p.Schema, true
+		}
+	}
+	return nil, false
+}
+
+// IsRequired 属性是否必填
+func (s *Schema) IsRequired(name string) bool {
+	for _, r := range s.Required {
+		if r == name {
+			return true
+		}
+	}
+	return false
+}
+
+// UnmarshalJSON 保留属性的顺序，并支持布尔值形式的 additionalProperties
+func (s *Schema) UnmarshalJSON(data []byte) error {
+	var raw struct {
+		Ref                  string          `json:"$ref"`
+		Type                 string          `json:"type"`
+		Format               string          `json:"format"`
+		Description          string          `json:"description"`
+		Nullable             bool            `json:"nullable"`
+		Enum                 []string        `json:"enum"`
+		AllOf                []*Schema       `json:"allOf"`
+		Properties           orderedSchemas  `json:"properties"`
+		Required             []string        `json:"required"`
+		AdditionalProperties json.RawMessage `json:"additionalProperties"`
+		Items                *Schema         `json:"items"`
+		MinItems             *int            `json:"minItems"`
+		MaxItems             *int            `json:"maxItems"`
+		MinLength            *int            `json:"minLength"`
+		Minimum              *float64        `json:"minimum"`
+		Maximum              *float64        `json:"maximum"`
+	}
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+
+	*s = Schema{
+		Ref:         raw.Ref,
+		Type:        raw.Type,
+		Format:      raw.Format,
+		Description: raw.Description,
+		Nullable:    raw.Nullable,
+		Enum:        raw.Enum,
+		AllOf:       raw.AllOf,
+		Properties:  raw.Properties,
+		Required:    raw.Required,
+		Items:       raw.Items,
+		MinItems:    raw.MinItems,
+		MaxItems:    raw.MaxItems,
+		MinLength:   raw.MinLength,
+		Minimum:     raw.Minimum,
+		Maximum:     raw.Maximum,
+	}
+	switch additional := bytes.TrimSpace(raw.AdditionalProperties); {
+	case len(additional) == 0, string(additional) == "true":
+	case string(additional) == "false":
+		s.NoAdditional = true
+	default:
+		s.AdditionalProperties = new(Schema)
+		if err := json.Unmarshal(additional, s.AdditionalProperties); err != nil {
+			return fmt.Errorf("additionalProperties: %w", err)
+		}
+	}
+	return nil
+}
+
+// 按文档中的顺序解析的属性列表
+type orderedSchemas []*Property
+
+func (o *orderedSchemas) UnmarshalJSON(data []byte) error {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	if tok, err := dec.Token(); err != nil || tok != json.Delim('{') {
+		return fmt.Errorf("properties 必须是对象")
+	}
+	for dec.More() {
+		tok, err := dec.Token()
+		if err != nil {
+			return err
+		}
+		name, _ := tok.(string)
+		schema := new(Schema)
+		if err := dec.Decode(schema); err != nil {
+			return fmt.Errorf("%s: %w", name, err)
+		}
+		*o = append(*o, &Property{Name: name, Schema: schema})
+	}
+	_, err := dec.Token()
+	return err
+}
+
+// Parse 解析 OpenAPI 文档并检查所有引用都能解析
+func Parse(data []byte) (*Document, error) {
+	var doc Document
+	if err := json.Unmarshal(data, &doc); err != nil {
+		return nil, fmt.Errorf("解析 OpenAPI 文档失败: %w", err)
+	}
+
+	for _, path := range doc.SortedPaths() {
+		for method, op := range doc.Paths[path].Operations() {
+			for i, param := range op.Parameters {
+				resolved, err := doc.resolveParameter(param)
+				if err != nil {
+					return nil, fmt.Errorf("%s %s: %w", method, path, err)
+				}
+				op.Parameters[i] = resolved
+			}
+			for status, resp := range op.Responses {
+				resolved, err := doc.resolveResponse(resp)
+				if err != nil {
+					return nil, fmt.Errorf("%s %s: %w", method, path, err)
+				}
+				op.Responses[status] = resolved
+			}
+		}
+	}
+	for name, schema := range doc.Components.Schemas {
+		if err := doc.checkRefs(schema); err != nil {
+			return nil, fmt.Errorf("schema %s: %w", name, err)
+		}
+	}
+	return &doc, nil
+}
+
+// SortedPaths 返回按字母排序的路径
+func (d *Document) SortedPaths() []string {
+	paths := make([]string, 0, len(d.Paths))
+	for path := range d.Paths {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+	return paths
+}
+
+// 解析 #/components/<kind>/<name> 形式的引用，返回名称
+func refName(ref, kind string) (string, error) {
+	prefix := "#/components/" + kind + "/"
+	if !strings.HasPrefix(ref, prefix) {
+		return "", fmt.Errorf("不支持的引用: %s", ref)
+	}
+	return strings.TrimPrefix(ref, prefix), nil
+}
+
+func (d *Document) resolveParameter(p *Parameter) (*Parameter, error) {
+	if p.Ref == "" {
+		return p, nil
+	}
+	name, err := refName(p.Ref, "parameters")
+	if err != nil {
+		return nil, err
+	}
+	resolved, ok := d.Components.Parameters[name]
+	if !ok {
+		return nil, fmt.Errorf("参数 %s 不存在", p.Ref)
+	}
+	return resolved, nil
+}
+
+func (d *Document) resolveResponse(r *Response) (*Response, error) {
+	if r.Ref == "" {
+		return r, nil
+	}
+	name, err := refName(r.Ref, "responses")
+	if err != nil {
+		return nil, err
+	}
+	resolved, ok := d.Components.Responses[name]
+	if !ok {
+		return nil, fmt.Errorf("响应 %s 不存在", r.Ref)
+	}
+	return resolved, nil
+}
+
+// Resolve 返回引用指向的 schema，不是引用时返回自身
+func (d *Document) Resolve(s *Schema) (*Schema, error) {
+	if s.Ref == "" {
+		return s, nil
+	}
+	name, err := RefName(s)
+	if err != nil {
+		return nil, err
+	}
+	resolved, ok := d.Components.Schemas[name]
+	if !ok {
+		return nil, fmt.Errorf("schema %s 不存在", s.Ref)
+	}
+	return resolved, nil
+}
+
+// RefName 返回 schema 引用的组件名称
+func RefName(s *Schema) (string, error) {
+	return refName(s.Ref, "schemas")
+}
+
+// 检查 schema 中的引用都存在
+func (d *Document) checkRefs(s *Schema) error {
+	if s == nil {
+		return nil
+	}
+	if _, err := d.Resolve(s); err != nil {
+		return err
+	}
+	for _, sub := range s.AllOf {
+		if err := d.checkRefs(sub); err != nil {
+			return err
+		}
+	}
+	for _, p := range s.Properties {
+		if err := d.checkRefs(p.Schema); err != nil {
+			return fmt.Errorf("%s: %w", p.Name, err)
+		}
+	}
+	if err := d.checkRefs(s.Items); err != nil {
+		return err
+	}
+	return d.checkRefs(s.AdditionalProperties)
+}
@@ -0,0 +1,387 @@
+package openapi
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// 解析 multipart 表单时保存在内存中的上限，与 gin 的默认值相同，超出部分写入临时文件
+const maxMultipartMemory = 32 << 20
+
+// FieldError 单个字段的校验错误
+type FieldError struct {
+	Field   string `json:"field"`   // 字段路径，如 "texts[0]"，请求体本身无效时为空
+	Message string `json:"message"` // 错误说明
+}
+
+// ValidationError 请求不符合文档，包含所有无效字段
+type ValidationError struct {
+	Errors []FieldError `json:"errors"`
+}
+
+func (e *ValidationError) Error() string {
+	parts := make([]string, len(e.Errors))
+	for i, fe := range e.Errors {
+		if fe.Field == "" {
+			parts[i] = fe.Message
+		} else {
+			parts[i] = fmt.Sprintf("%s: %s", fe.Field, fe.Message)
+		}
+	}
+	return "请求校验失败: " + strings.Join(parts, "; ")
+}
+
+// 追加字段错误
+func (e *ValidationError) add(field, format string, args ...any) {
+	e.Errors = append(e.Errors, FieldError{Field: field, Message: fmt.Sprintf(format, args...)})
+}
+
+// Find 查找与请求方式和路径匹配的操作，返回操作和路径参数
+//
+// 没有参数的路径优先匹配，如 /api/profiles/active 不会匹配到 /api/profiles/{name}。
+func (d *Document) Find(method, path string) (*Operation, map[string]string, bool) {
+	if item, ok := d.Paths[path]; ok {
+		if op, ok := item.Operations()[method]; ok {
+			return op, nil, true
+		}
+	}
+
+	segments := strings.Split(strings.Trim(path, "/"), "/")
+	for _, template := range d.SortedPaths() {
+		if !strings.Contains(template, "{") {
+			continue
+		}
+		op, ok := d.Paths[template].Operations()[method]
+		if !ok {
+			continue
+		}
+		if params, ok := matchPath(template, segments); ok {
+			return op, params, true
+		}
+	}
+	return nil, nil, false
+}
+
+// 按路径模板匹配路径分段，返回路径参数
+func matchPath(template string, segments []string) (map[string]string, bool) {
+	parts := strings.Split(strings.Trim(template, "/"), "/")
+	if len(parts) != len(segments) {
+		return nil, false
+	}
+	params := make(map[string]string)
+	for i, part := range parts {
+		if strings.HasPrefix(part, "{") && strings.HasSuffix(part, "}") {
+			if segments[i] == "" {
+				return nil, false
+			}
+			params[part[1:len(part)-1]] = segments[i]
+		} else if part != segments[i] {
+			return nil, false
+		}
+	}
+	return params, true
+}
+
+// ValidateRequest 按文档校验请求的参数和请求体，返回 *ValidationError 列出所有无效字段
+//
+// 文档中没有的路径不做校验。JSON 请求体读取后会重新放回 r.Body，multipart 表单解析后保存在
+// r.MultipartForm 中，处理函数可以照常读取。
+func (d *Document) ValidateRequest(r *http.Request) error {
+	op, pathParams, ok := d.Find(r.Method, r.URL.Path)
+	if !ok {
+		return nil
+	}
+
+	verr := &ValidationError{}
+	query := r.URL.Query()
+	for _, param := range op.Parameters {
+		var (
+			value string
+			found bool
+		)
+		switch param.In {
+		case "path":
+			value, found = pathParams[param.Name]
+		case "query":
+			found = query.Has(param.Name)
+			value = query.Get(param.Name)
+		default:
+			continue
+		}
+		if !found {
+			if param.Required {
+				verr.add(param.Name, "缺少必填参数")
+			}
+			continue
+		}
+		if param.Schema != nil {
+			d.validateString(param.Schema, value, param.Name, verr)
+		}
+	}
+
+	if op.RequestBody != nil {
+		if err := d.validateBody(op.RequestBody, r, verr); err != nil {
+			return err
+		}
+	}
+
+	if len(verr.Errors) > 0 {
+		return verr
+	}
+	return nil
+}
+
+// 校验请求体，读取请求体失败时返回错误
+func (d *Document) validateBody(body *RequestBody, r *http.Request, verr *ValidationError) error {
+	mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+
+	if form, ok := body.Content["multipart/form-data"]; ok {
+		if mediaType != "multipart/form-data" {
+			verr.add("", "请求体必须是 multipart/form-data")
+			return nil
+		}
+		if err := r.ParseMultipartForm(maxMultipartMemory); err != nil {
+			verr.add("", "无效的表单数据: %v", err)
+			return nil
+		}
+		d.validateForm(form.Schema, r, verr)
+		return nil
+	}
+
+	// 与 gin 的 BindJSON 一致，不要求 Content-Type 为 application/json
+	content, ok := body.Content["application/json"]
+	if !ok {
+		return nil
+	}
+	data, err := io.ReadAll(r.Body)
+	r.Body.Close()
+	if err != nil {
+		return fmt.Errorf("读取请求体失败: %w", err)
+	}
+	r.Body = io.NopCloser(bytes.NewReader(data))
+
+	if len(bytes.TrimSpace(data)) == 0 {
+		if body.Required {
+			verr.add("", "缺少请求体")
+		}
+		return nil
+	}
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	var value any
+	if err := dec.Decode(&value); err != nil {
+		verr.add("", "无效的JSON: %v", err)
+		return nil
+	}
+	d.validateValue(content.Schema, value, "", verr)
+	return nil
+}
+
+// 校验 multipart 表单，format 为 binary 的字段是文件，其他字段按类型校验文本值
+func (d *Document) validateForm(schema *Schema, r *http.Request, verr *ValidationError) {
+	schema, err := d.Resolve(schema)
+	if err != nil {
+		verr.add("", "%v", err)
+		return
+	}
+	form := r.MultipartForm
+	for _, p := range schema.Properties {
+		prop, err := d.Resolve(p.Schema)
+		if err != nil {
+			verr.add(p.Name, "%v", err)
+			continue
+		}
+		if prop.Format == "binary" {
+			if len(form.File[p.Name]) == 0 && schema.IsRequired(p.Name) {
+				verr.add(p.Name, "缺少文件")
+			}
+			continue
+		}
+		values := form.Value[p.Name]
+		if len(values) == 0 || values[0] == "" {
+			if schema.IsRequired(p.Name) {
+				verr.add(p.Name, "缺少必填字段")
+			}
+			continue
+		}
+		d.validateString(prop, values[0], p.Name, verr)
+	}
+}
+
+// 校验文本形式的值（路径参数、查询参数和表单字段），按 schema 的类型解析
+func (d *Document) validateString(schema *Schema, value, field string, verr *ValidationError) {
+	schema, err := d.Resolve(schema)
+	if err != nil {
+		verr.add(field, "%v", err)
+		return
+	}
+	switch schema.Type {
+	case "integer":
+		if _, err := strconv.ParseInt(value, 10, 64); err != nil {
+			verr.add(field, "必须是整数")
+		}
+	case "number":
+		if _, err := strconv.ParseFloat(value, 64); err != nil {
+			verr.add(field, "必须是数字")
+		}
+	case "boolean":
+		if _, err := strconv.ParseBool(value); err != nil {
+			verr.add(field, "必须是 true 或 false")
+		}
+	default:
+		d.validateValue(schema, value, field, verr)
+	}
+}
+
+// 校验 JSON 值，field 为字段路径
+func (d *Document) validateValue(schema *Schema, value any, field string, verr *ValidationError) {
+	schema, err := d.Resolve(schema)
+	if err != nil {
+		verr.add(field, "%v", err)
+		return
+	}
+	if value == nil {
+		if !schema.Nullable {
+			verr.add(field, "不能为 null")
+		}
+		return
+	}
+	for _, sub := range schema.AllOf {
+		d.validateValue(sub, value, field, verr)
+	}
+
+	switch schema.Type {
+	case "object":
+		obj, ok := value.(map[string]any)
+		if !ok {
+			verr.add(field, "必须是对象")
+			return
+		}
+		d.validateObject(schema, obj, field, verr)
+	case "array":
+		arr, ok := value.([]any)
+		if !ok {
+			verr.add(field, "必须是数组")
+			return
+		}
+		if schema.MinItems != nil && len(arr) < *schema.MinItems {
+			verr.add(field, "至少需要 %d 项", *schema.MinItems)
+		}
+		if schema.MaxItems != nil && len(arr) > *schema.MaxItems {
+			verr.add(field, "最多 %d 项", *schema.MaxItems)
+		}
+		if schema.Items != nil {
+			for i, item := range arr {
+				d.validateValue(schema.Items, item, fmt.Sprintf("%s[%d]", field, i), verr)
+			}
+		}
+	case "string":
+		s, ok := value.(string)
+		if !ok {
+			verr.add(field, "必须是字符串")
+			return
+		}
+		if schema.MinLength != nil && utf8.RuneCountInString(s) < *schema.MinLength {
+			if *schema.MinLength == 1 {
+				verr.add(field, "不能为空")
+			} else {
+				verr.add(field, "长度不能少于 %d", *schema.MinLength)
+			}
+		}
+		if len(schema.Enum) > 0 && !contains(schema.Enum, s) {
+			verr.add(field, "必须是 %s 之一", strings.Join(schema.Enum, "、"))
+		}
+		switch schema.Format {
+		case "date-time":
+			if _, err := time.Parse(time.RFC3339, s); err != nil {
+				verr.add(field, "必须是 RFC 3339 格式的时间")
+			}
+		case "date":
+			if _, err := time.Parse(time.DateOnly, s); err != nil {
+				verr.add(field, "必须是 YYYY-MM-DD 格式的日期")
+			}
+		}
+	case "integer", "number":
+		n, ok := value.(json.Number)
+		if !ok {
+			verr.add(field, "必须是数字")
+			return
+		}
+		f, err := n.Float64()
+		if err != nil {
+			verr.add(field, "必须是数字")
+			return
+		}
+		if schema.Type == "integer" {
+			if _, err := n.Int64(); err != nil {
+				verr.add(field, "必须是整数")
+				return
+			}
+		}
+		if schema.Minimum != nil && f < *schema.Minimum {
+			verr.add(field, "不能小于 %v", *schema.Minimum)
+		}
+		if schema.Maximum != nil && f > *schema.Maximum {
+			verr.add(field, "不能大于 %v", *schema.Maximum)
+		}
+	case "boolean":
+		if _, ok := value.(bool); !ok {
+			verr.add(field, "必须是 true 或 false")
+		}
+	}
+}
+
+func (d *Document) validateObject(schema *Schema, obj map[string]any, field string, verr *ValidationError) {
+	for _, name := range schema.Required {
+		if _, ok := obj[name]; !ok {
+			verr.add(joinField(field, name), "缺少必填字段")
+		}
+	}
+	for _, p := range schema.Properties {
+		if value, ok := obj[p.Name]; ok {
+			d.validateValue(p.Schema, value, joinField(field, p.Name), verr)
+		}
+	}
+
+	// 按名称排序后校验其余字段，使错误顺序稳定
+	var extra []string
+	for name := range obj {
+		if _, ok := schema.Property(name); !ok {
+			extra = append(extra, name)
+		}
+	}
+	sort.Strings(extra)
+	for _, name := range extra {
+		switch {
+		case schema.AdditionalProperties != nil:
+			d.validateValue(schema.AdditionalProperties, obj[name], joinField(field, name), verr)
+		case schema.NoAdditional:
+			verr.add(joinField(field, name), "未知字段")
+		}
+	}
+}
+
+func joinField(parent, name string) string {
+	if parent == "" {
+		return name
+	}
+	return parent + "." + name
+}
+
+func contains(list []string, s string) bool {
+	for _, item := range list {
+		if item == s {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,279 @@
+package openapi
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// 测试用的文档，覆盖校验支持的各种约束
+const testSpec = `{
+	"paths": {
+		"/api/items": {
+			"post": {
+				"requestBody": {"required": true, "content": {"application/json": {"schema": {"$ref": "#/components/schemas/Item"}}}}
+			},
+			"get": {
+				"parameters": [{"name": "limit", "in": "query", "schema": {"type": "integer"}}, {"$ref": "#/components/parameters/Day"}]
+			}
+		},
+		"/api/items/{id}": {
+			"delete": {"parameters": [{"name": "id", "in": "path", "required": true, "schema": {"type": "string", "minLength": 1}}]}
+		},
+		"/api/items/active": {"put": {}},
+		"/api/upload": {
+			"post": {
+				"requestBody": {"required": true, "content": {"multipart/form-data": {"schema": {
+					"type": "object",
+					"required": ["file"],
+					"properties": {"file": {"type": "string", "format": "binary"}, "count": {"type": "integer"}}
+				}}}}
+			}
+		}
+	},
+	"components": {
+		"parameters": {"Day": {"name": "day", "in": "query", "schema": {"type": "string", "format": "date"}}},
+		"schemas": {
+			"Item": {
+				"type": "object",
+				"required": ["name", "tags"],
+				"additionalProperties": false,
+				"properties": {
+					"name": {"type": "string", "minLength": 1},
+					"kind": {"type": "string", "enum": ["a", "b"]},
+					"tags": {"type": "array", "minItems": 1, "maxItems": 2, "items": {"type": "string"}},
+					"size": {"type": "integer", "minimum": 1, "maximum": 10},
+					"ratio": {"type": "number"},
+					"note": {"type": "string", "nullable": true},
+					"at": {"type": "string", "format": "date-time"},
+					"meta": {"type": "object", "additionalProperties": {"type": "boolean"}}
+				}
+			}
+		}
+	}
+}`
+
+func loadTestSpec(t *testing.T) *Document {
+	t.Helper()
+	doc, err := Parse([]byte(testSpec))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return doc
+}
+
+func TestValidateRequest(t *testing.T) {
+	doc := loadTestSpec(t)
+
+	tests := []struct {
+		name   string
+		method string
+		target string
+		body   string
+		want   []FieldError // nil 表示通过校验
+	}{
+		{
+			name:   "有效的请求体",
+			method: http.MethodPost, target: "/api/items",
+			body: `{"name": "x", "kind": "a", "tags": ["t"], "size": 10, "ratio": 0.5, "note": null, "at": "2024-01-02T03:04:05Z", "meta": {"ok": true}}`,
+		},
+		{
+			name:   "缺少请求体",
+			method: http.MethodPost, target: "/api/items",
+			want: []FieldError{{"", "缺少请求体"}},
+		},
+		{
+			name:   "无效的JSON",
+			method: http.MethodPost, target: "/api/items",
+			body: `{"name": `,
+			want: []FieldError{{"", "无效的JSON: unexpected EOF"}},
+		},
+		{
+			name:   "请求体不是对象",
+			method: http.MethodPost, target: "/api/items",
+			body: `["x"]`,
+			want: []FieldError{{"", "必须是对象"}},
+		},
+		{
+			name:   "缺少必填字段和未知字段",
+			method: http.MethodPost, target: "/api/items",
+			body: `{"nmae": "x", "extra": 1}`,
+			want: []FieldError{{"name", "缺少必填字段"}, {"tags", "缺少必填字段"}, {"extra", "未知字段"}, {"nmae", "未知字段"}},
+		},
+		{
+			name:   "类型和取值范围",
+			method: http.MethodPost, target: "/api/items",
+			body: `{"name": "", "kind": "c", "tags": [1, "t", "u"], "size": 1.5, "ratio": "x", "note": 1, "at": "yesterday", "meta": {"ok": "yes"}}`,
+			want: []FieldError{
+				{"name", "不能为空"},
+				{"kind", "必须是 a、b 之一"},
+				{"tags", "最多 2 项"},
+				{"tags[0]", "必须是字符串"},
+				{"size", "必须是整数"},
+				{"ratio", "必须是数字"},
+				{"note", "必须是字符串"},
+				{"at", "必须是 RFC 3339 格式的时间"},
+				{"meta.ok", "必须是 true 或 false"},
+			},
+		},
+		{
+			name:   "不能为 null 和超出范围",
+			method: http.MethodPost, target: "/api/items",
+			body: `{"name": null, "tags": [], "size": 11}`,
+			want: []FieldError{{"name", "不能为 null"}, {"tags", "至少需要 1 项"}, {"size", "不能大于 10"}},
+		},
+		{
+			name:   "查询参数",
+			method: http.MethodGet, target: "/api/items?limit=ten&day=2024-13-01",
+			want: []FieldError{{"limit", "必须是整数"}, {"day", "必须是 YYYY-MM-DD 格式的日期"}},
+		},
+		{
+			name:   "有效的查询参数",
+			method: http.MethodGet, target: "/api/items?limit=10&day=2024-01-31",
+		},
+		{
+			name:   "路径参数",
+			method: http.MethodDelete, target: "/api/items/42",
+		},
+		{
+			name:   "文档中没有的路径不校验",
+			method: http.MethodPost, target: "/api/unknown",
+			body: `not json`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var body io.Reader
+			if tt.body != "" {
+				body = strings.NewReader(tt.body)
+			}
+			req := httptest.NewRequest(tt.method, tt.target, body)
+			err := doc.ValidateRequest(req)
+			if tt.want == nil {
+				if err != nil {
+					t.Fatalf("ValidateRequest() error = %v", err)
+				}
+				return
+			}
+			var verr *ValidationError
+			if !errors.As(err, &verr) {
+				t.Fatalf("ValidateRequest() error = %v, want *ValidationError", err)
+			}
+			if !reflect.DeepEqual(verr.Errors, tt.want) {
+				t.Errorf("errors = %q, want %q", verr.Errors, tt.want)
+			}
+		})
+	}
+}
+
+func TestValidateRequestKeepsBody(t *testing.T) {
+	doc := loadTestSpec(t)
+	body := `{"name": "x", "tags": ["t"]}`
+	req := httptest.NewRequest(http.MethodPost, "/api/items", strings.NewReader(body))
+	if err := doc.ValidateRequest(req); err != nil {
+		t.Fatal(err)
+	}
+	// 处理函数仍能读取完整的请求体
+	if data, err := io.ReadAll(req.Body); err != nil || string(data) != body {
+		t.Errorf("校验后的请求体为 %q, %v", data, err)
+	}
+}
+
+func TestValidateMultipart(t *testing.T) {
+	doc := loadTestSpec(t)
+
+	form := func(file bool, count string) *http.Request {
+		var buf bytes.Buffer
+		w := multipart.NewWriter(&buf)
+		if file {
+			part, _ := w.CreateFormFile("file", "a.srt")
+			part.Write([]byte("data"))
+		}
+		if count != "" {
+			w.WriteField("count", count)
+		}
+		w.Close()
+		req := httptest.NewRequest(http.MethodPost, "/api/upload", &buf)
+		req.Header.Set("Content-Type", w.FormDataContentType())
+		return req
+	}
+
+	tests := []struct {
+		name string
+		req  *http.Request
+		want []FieldError
+	}{
+		{"有效的表单", form(true, "2"), nil},
+		{"缺少文件，字段类型错误", form(false, "two"), []FieldError{{"file", "缺少文件"}, {"count", "必须是整数"}}},
+		{"不是表单", httptest.NewRequest(http.MethodPost, "/api/upload", strings.NewReader(`{}`)), []FieldError{{"", "请求体必须是 multipart/form-data"}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := doc.ValidateRequest(tt.req)
+			var verr *ValidationError
+			if tt.want == nil {
+				if err != nil {
+					t.Fatalf("ValidateRequest() error = %v", err)
+				}
+				return
+			}
+			if !errors.As(err, &verr) || !reflect.DeepEqual(verr.Errors, tt.want) {
+				t.Errorf("ValidateRequest() error = %v, want %q", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestFind(t *testing.T) {
+	doc := loadTestSpec(t)
+	tests := []struct {
+		method     string
+		path       string
+		wantOK     bool
+		wantParams map[string]string
+	}{
+		{http.MethodPut, "/api/items/active", true, nil},
+		{http.MethodDelete, "/api/items/42", true, map[string]string{"id": "42"}},
+		{http.MethodPut, "/api/items/42", false, nil},
+		{http.MethodDelete, "/api/items/", false, nil},
+		{http.MethodGet, "/api/items", true, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.method+" "+tt.path, func(t *testing.T) {
+			_, params, ok := doc.Find(tt.method, tt.path)
+			if ok != tt.wantOK || !reflect.DeepEqual(params, tt.wantParams) {
+				t.Errorf("Find() = %v, %v, want %v, %v", params, ok, tt.wantParams, tt.wantOK)
+			}
+		})
+	}
+}
+
+func TestParseRefs(t *testing.T) {
+	if _, err := Load(); err != nil {
+		t.Fatalf("内嵌的 OpenAPI 文档无效: %v", err)
+	}
+
+	tests := []struct {
+		name string
+		spec string
+	}{
+		{"schema 引用不存在", `{"components": {"schemas": {"A": {"properties": {"b": {"$ref": "#/components/schemas/B"}}}}}}`},
+		{"参数引用不存在", `{"paths": {"/a": {"get": {"parameters": [{"$ref": "#/components/parameters/P"}]}}}}`},
+		{"响应引用不存在", `{"paths": {"/a": {"get": {"responses": {"400": {"$ref": "#/components/responses/E"}}}}}}`},
+		{"不支持的引用", `{"components": {"schemas": {"A": {"$ref": "other.json#/A"}}}}`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := Parse([]byte(tt.spec)); err == nil {
+				t.Error("Parse() error = nil, want error")
+			}
+		})
+	}
+}
@@ -0,0 +1,90 @@
+package main
+
+import (
+	"fmt"
+	"sync"
+
+	"clipboard-translate/ai"
+	"clipboard-translate/config"
+	"clipboard-translate/constants"
+)
+
+var (
+	promptTemplates   *ai.PromptTemplates // 内置模板与配置中的用户模板
+	promptTemplatesMu sync.RWMutex        // 保护 promptTemplates，配置变更时替换
+)
+
+// 获取当前的提示词模板集合
+func currentPromptTemplates() *ai.PromptTemplates {
+	promptTemplatesMu.RLock()
+	defer promptTemplatesMu.RUnlock()
+	return promptTemplates
+}
+
+// 按配置中的用户模板重建模板集合
+func loadPromptTemplates(custom map[string]string) error {
+	templates, err := ai.NewPromptTemplates(custom)
+	if err != nil {
+		return err
+	}
+
+	promptTemplatesMu.Lock()
+	promptTemplates = templates
+	promptTemplatesMu.Unlock()
+	return nil
+}
+
+// 选择动作使用的提示词模板
+//
+// 优先级：热键指定的模板 > AI配置的自定义提示词（仅翻译动作）> 动作对应的内置模板。
+func promptFor(cfg *config.Config, action, profile string) (*ai.PromptTemplate, error) {
+	templates := currentPromptTemplates()
+
+	if name := cfg.Hotkeys[action].Template; name != "" {
+		tmpl, ok := templates.Get(name)
+		if !ok {
+			return nil, fmt.Errorf("提示词模板 %q 不存在", name)
+		}
+		return tmpl, nil
+	}
+	return defaultPrompt(cfg, action, profile)
+}
+
+// 选择不经热键触发时动作使用的提示词模板，如翻译接口和批量任务
+//
+// 优先级：AI配置的自定义提示词（仅翻译动作）> 动作对应的内置模板；不使用热键指定的模板。
+func defaultPrompt(cfg *config.Config, action, profile string) (*ai.PromptTemplate, error) {
+	if prompt := cfg.Profiles[profile].Prompt; prompt != "" && action == constants.ACTION_TRANSLATE {
+		return ai.ParsePromptTemplate("profile:"+profile, prompt)
+	}
+
+	task, ok := actionTasks[action]
+	if !ok {
+		return nil, fmt.Errorf("未知的文本处理动作: %s", action)
+	}
+	tmpl, _ := currentPromptTemplates().Get(string(task))
+	return tmpl, nil
+}
+
+// 构造模板变量，默认值来自翻译配置
+func promptData(cfg *config.Config, action, text string) ai.PromptData {
+	data := ai.PromptData{
+		TargetLanguage: cfg.Translation.TargetLanguage,
+		Tone:           cfg.Translation.Tone,
+		Domain:         cfg.Translation.Domain,
+		Glossary:       cfg.Translation.Glossary,
+	}
+
+	switch action {
+	case constants.ACTION_TRANSLATE:
+		// 内置翻译模板在中英文之间互译
+		if isChineseText(text) {
+			data.SourceLanguage, data.TargetLanguage = "zh-CN", "en-US"
+		} else {
+			data.SourceLanguage, data.TargetLanguage = "en-US", "zh-CN"
+		}
+	case constants.ACTION_TRANSLATE_ALT:
+		data.TargetLanguage = cfg.Translation.AlternateLanguage
+	}
+	return data
+}
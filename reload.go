@@ -0,0 +1,286 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"reflect"
+	"strings"
+	"time"
+
+	"clipboard-translate/ai"
+	"clipboard-translate/config"
+	"clipboard-translate/database"
+	"clipboard-translate/secrets"
+	log "clipboard-translate/utils/log"
+)
+
+// 旧AI客户端替换后延迟关闭的时间，留给进行中的请求完成
+const aiClientCloseDelay = time.Minute
+
+// 获取AI配置对应的客户端并检查消费预算，预算用尽时返回回退的本地模型客户端或错误
+//
+// 返回实际使用的AI配置名称，回退时为 budget.fallback_profile。
+// 热键、翻译接口、批量任务、字幕和本地化文件翻译都通过这里获取客户端。
+func aiClientFor(name string) (ai.AIClient, string, error) {
+	client, err := cachedAIClient(name)
+	if err != nil {
+		return nil, "", err
+	}
+	return enforceBudget(name, client)
+}
+
+// 获取AI配置对应的客户端，首次使用时创建；不检查预算
+func cachedAIClient(name string) (ai.AIClient, error) {
+	for {
+		aiClientMu.RLock()
+		client, ok := aiClients[name]
+		gen := aiClientGen
+		aiClientMu.RUnlock()
+		if ok {
+			clientCacheTotal.WithLabelValues("hit").Inc()
+			return client, nil
+		}
+		clientCacheTotal.WithLabelValues("miss").Inc()
+
+		profile, ok := config.GetConfig().Profiles[name]
+		if !ok {
+			return nil, fmt.Errorf("AI配置 %q 不存在", name)
+		}
+		client, err := newAIClient(profile)
+		if err != nil {
+			return nil, fmt.Errorf("AI客户端初始化失败 (%s): %w", name, err)
+		}
+
+		aiClientMu.Lock()
+		if existing, ok := aiClients[name]; ok {
+			// 其他请求已并发创建
+			aiClientMu.Unlock()
+			client.Close()
+			return existing, nil
+		}
+		if aiClientGen != gen {
+			// 创建期间配置或密钥已变化，客户端可能使用了旧的设置，按新配置重新创建
+			aiClientMu.Unlock()
+			client.Close()
+			continue
+		}
+		aiClients[name] = client
+		aiClientMu.Unlock()
+		return client, nil
+	}
+}
+
+// 获取当前激活的AI配置对应的客户端，用于启动和重新加载配置时及早报告错误，不检查预算
+func currentAIClient() (ai.AIClient, error) {
+	return cachedAIClient(config.GetConfig().API.ActiveProfile)
+}
+
+// 根据AI配置创建AI客户端
+func newAIClient(profile config.ProfileConfig) (ai.AIClient, error) {
+	// 根据配置选择API密钥：环境变量或密钥存储
+	var apiKey string
+	if profile.UseEnvKey {
+		apiKey = os.Getenv("AI_API_KEY")
+	} else {
+		key, err := secrets.Lookup(profile.KeyName())
+		if err != nil && !errors.Is(err, secrets.ErrNotFound) {
+			return nil, fmt.Errorf("读取API密钥 %q 失败: %w", profile.KeyName(), err)
+		}
+		apiKey = key
+	}
+
+	client, err := ai.NewAIClient(ai.AIConfig{
+		Provider:     profile.Provider,
+		APIKey:       apiKey,
+		Model:        profile.Model,
+		BaseURL:      profile.BaseURL,
+		SystemPrompt: profile.Prompt,
+		Params: ai.GenerationParams{
+			Temperature: profile.Temperature,
+			TopP:        profile.TopP,
+			MaxTokens:   profile.MaxTokens,
+			Seed:        profile.Seed,
+			Stop:        profile.Stop,
+		},
+		Timeout: time.Duration(profile.Timeout) * time.Second,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return newInstrumentedClient(client, profile.Provider), nil
+}
+
+// 根据配置创建并初始化数据库
+func openDatabase(cfg *config.Config) (database.Database, error) {
+	newDB, err := database.New(database.DBConfig{
+		Type:       cfg.Database.Type,
+		Connection: cfg.Database.Connection,
+		MaxHistory: cfg.System.MaxHistoryItems,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("创建数据库连接失败: %w", err)
+	}
+
+	newDB = instrumentedDB{db: newDB}
+	if err := newDB.Initialize(); err != nil {
+		newDB.Close()
+		return nil, fmt.Errorf("初始化数据库失败: %w", err)
+	}
+	return newDB, nil
+}
+
+// 将配置中的日志级别应用到日志系统
+func applyLogLevel(level string) error {
+	logLevel, ok := log.LogLevelMapping[strings.ToLower(level)]
+	if !ok {
+		return fmt.Errorf("未知的日志级别: %s", level)
+	}
+
+	logConfig := log.GetLogConfig()
+	if logConfig.Level != logLevel {
+		logConfig.Level = logLevel
+		log.SetLogConfig(logConfig)
+	}
+	return nil
+}
+
+// 移除满足条件的AI配置的缓存客户端，下次使用时重新创建
+func dropAIClients(stale func(name string) bool) {
+	aiClientMu.Lock()
+	aiClientGen++
+	var dropped []ai.AIClient
+	for name, client := range aiClients {
+		if stale(name) {
+			dropped = append(dropped, client)
+			delete(aiClients, name)
+		}
+	}
+	aiClientMu.Unlock()
+
+	// 延迟关闭，留给进行中的请求完成
+	for _, client := range dropped {
+		client := client
+		time.AfterFunc(aiClientCloseDelay, func() {
+			client.Close()
+		})
+	}
+}
+
+// 关闭所有AI客户端
+func closeAIClients() {
+	aiClientMu.Lock()
+	defer aiClientMu.Unlock()
+	aiClientGen++
+	for name, client := range aiClients {
+		client.Close()
+		delete(aiClients, name)
+	}
+}
+
+// AI配置变更后丢弃已变化的客户端，并立即创建激活配置的客户端以便及早报告错误
+func reloadAIClients(change config.Change) error {
+	dropAIClients(func(name string) bool {
+		return change.Old == nil || !reflect.DeepEqual(change.Old.Profiles[name], change.New.Profiles[name])
+	})
+
+	active := change.New.API.ActiveProfile
+	client, err := cachedAIClient(active)
+	if err != nil {
+		return err
+	}
+	if change.Old == nil || change.Old.API.ActiveProfile != active {
+		log.Info("AI配置已切换: %s (%s)", active, client.GetName())
+	}
+	return nil
+}
+
+// 密钥变化后重建使用该密钥的AI客户端
+func reloadAIClientsForKey(keyName string) error {
+	profiles := config.GetConfig().Profiles
+	dropAIClients(func(name string) bool {
+		profile := profiles[name]
+		return !profile.UseEnvKey && profile.KeyName() == keyName
+	})
+
+	_, err := currentAIClient()
+	return err
+}
+
+// hotkeyErrors 热键注册失败的动作及原因
+type hotkeyErrors map[string]error
+
+func (e hotkeyErrors) Error() string {
+	parts := make([]string, 0, len(e))
+	for _, action := range sortedKeys(e) {
+		parts = append(parts, fmt.Sprintf("%s: %v", action, e[action]))
+	}
+	return "热键注册失败: " + strings.Join(parts, ", ")
+}
+
+// 订阅配置变更，使各子系统无需重启即可应用新配置
+func subscribeConfigChanges(handler http.Handler) {
+	// AI客户端：切换激活的配置或修改配置后重建
+	config.Subscribe("ai", config.SectionAPI|config.SectionProfiles, reloadAIClients)
+
+	// 提示词模板
+	config.Subscribe("templates", config.SectionTemplates, func(change config.Change) error {
+		return loadPromptTemplates(change.New.Templates)
+	})
+
+	// 热键：只重新注册变化的动作
+	config.Subscribe("hotkeys", config.SectionHotkeys, func(change config.Change) error {
+		if errs := reregisterHotKeys(change.Old.Hotkeys); len(errs) > 0 {
+			return hotkeyErrors(errs)
+		}
+		return nil
+	})
+
+	// 数据库：新连接初始化成功后再关闭旧连接
+	config.Subscribe("database", config.SectionDatabase, func(change config.Change) error {
+		log.Info("数据库配置已更改，重新初始化数据库连接")
+
+		newDB, err := openDatabase(change.New)
+		if err != nil {
+			return err
+		}
+
+		dbMutex.Lock()
+		oldDB := db
+		db = newDB
+		dbMutex.Unlock()
+
+		if oldDB != nil {
+			if err := oldDB.Close(); err != nil {
+				log.Error("关闭数据库连接失败: %v", err)
+			}
+		}
+		return nil
+	})
+
+	// 日志级别
+	config.Subscribe("logging", config.SectionSystem, func(change config.Change) error {
+		return applyLogLevel(change.New.System.LogLevel)
+	})
+
+	// HTTP监听地址和端口：在新地址启动成功后关闭旧服务
+	config.Subscribe("http", config.SectionUI, func(change config.Change) error {
+		if change.Old.UI.Port == change.New.UI.Port && change.Old.UI.BindAddress == change.New.UI.BindAddress {
+			return nil
+		}
+		return restartHTTPServer(handler, change.New.UI.BindAddress, change.New.UI.Port)
+	})
+
+	// 通知界面配置已变更，最后订阅，使事件发出时各子系统已应用新配置
+	config.Subscribe("events", config.SectionAll, func(change config.Change) error {
+		events.publish(EventConfigChanged, map[string]any{"sections": change.Sections.Names()})
+		return nil
+	})
+}
+
+// 持续监视配置文件的外部修改
+func watchConfigFile(ctx context.Context) {
+	config.Watch(ctx, 2*time.Second)
+}
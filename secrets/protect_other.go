@@ -0,0 +1,13 @@
+//go:build !windows
+
+package secrets
+
+// 非 Windows 平台没有统一的用户级加密接口，主密钥以明文保存，只依赖 0600 文件权限保护。
+// 因此这些平台上没有静态加密：能读取 secrets.key 和 secrets.vault 的用户或进程（包括备份）即可解密所有密钥。
+func protectKey(key []byte) ([]byte, error) {
+	return key, nil
+}
+
+func unprotectKey(blob []byte) ([]byte, error) {
+	return blob, nil
+}
@@ -0,0 +1,68 @@
+//go:build windows
+
+package secrets
+
+import (
+	"fmt"
+	"syscall"
+	"unsafe"
+)
+
+var (
+	crypt32                = syscall.NewLazyDLL("crypt32.dll")
+	kernel32               = syscall.NewLazyDLL("kernel32.dll")
+	procCryptProtectData   = crypt32.NewProc("CryptProtectData")
+	procCryptUnprotectData = crypt32.NewProc("CryptUnprotectData")
+	procLocalFree          = kernel32.NewProc("LocalFree")
+)
+
+const cryptprotectUIForbidden = 0x1
+
+// DATA_BLOB 结构体
+type dataBlob struct {
+	cbData uint32
+	pbData *byte
+}
+
+func newBlob(data []byte) *dataBlob {
+	if len(data) == 0 {
+		return &dataBlob{}
+	}
+	return &dataBlob{cbData: uint32(len(data)), pbData: &data[0]}
+}
+
+// 复制 DPAPI 分配的输出并释放
+func (b *dataBlob) bytes() []byte {
+	out := make([]byte, b.cbData)
+	copy(out, unsafe.Slice(b.pbData, b.cbData))
+	procLocalFree.Call(uintptr(unsafe.Pointer(b.pbData)))
+	return out
+}
+
+// 使用 DPAPI 以当前用户身份加密主密钥
+func protectKey(key []byte) ([]byte, error) {
+	var out dataBlob
+	ret, _, errno := procCryptProtectData.Call(
+		uintptr(unsafe.Pointer(newBlob(key))),
+		0, 0, 0, 0,
+		cryptprotectUIForbidden,
+		uintptr(unsafe.Pointer(&out)))
+	if ret == 0 {
+		return nil, fmt.Errorf("CryptProtectData: %v", errno)
+	}
+	return out.bytes(), nil
+}
+
+// 使用 DPAPI 解密主密钥
+func unprotectKey(blob []byte) ([]byte, error) {
+	var out dataBlob
+	ret, _, errno := procCryptUnprotectData.Call(
+		uintptr(unsafe.Pointer(newBlob(blob))),
+		0, 0, 0, 0,
+		cryptprotectUIForbidden,
+		uintptr(unsafe.Pointer(&out)))
+	if ret == 0 {
+		return nil, fmt.Errorf("CryptUnprotectData: %v", errno)
+	}
+	return out.bytes(), nil
+}
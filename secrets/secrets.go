@@ -0,0 +1,87 @@
+package secrets
+
+import (
+	"errors"
+	"strings"
+	"sync"
+)
+
+// Store 定义密钥存储接口
+type Store interface {
+	// Get 读取密钥，不存在时返回 ErrNotFound
+	Get(name string) (string, error)
+	// Set 保存密钥
+	Set(name, value string) error
+	// Delete 删除密钥
+	Delete(name string) error
+	// List 列出所有密钥名称
+	List() ([]string, error)
+}
+
+// ReservedPrefix 程序内部使用的密钥（如访问令牌）的名称前缀，不能通过密钥接口读取、修改或删除
+const ReservedPrefix = "internal."
+
+// Reserved 判断密钥名称是否属于内部保留的命名空间，不区分大小写
+func Reserved(name string) bool {
+	return strings.HasPrefix(strings.ToLower(name), ReservedPrefix)
+}
+
+// 通用错误定义
+var (
+	ErrNotFound    = errors.New("密钥不存在")
+	ErrInvalidName = errors.New("无效的密钥名称")
+	ErrNoStore     = errors.New("密钥存储未初始化")
+)
+
+var (
+	defaultStore Store
+	defaultMu    sync.RWMutex
+)
+
+// SetDefault 设置全局密钥存储
+func SetDefault(store Store) {
+	defaultMu.Lock()
+	defer defaultMu.Unlock()
+	defaultStore = store
+}
+
+// Default 获取全局密钥存储，未设置时返回 nil
+func Default() Store {
+	defaultMu.RLock()
+	defer defaultMu.RUnlock()
+	return defaultStore
+}
+
+// Lookup 从全局密钥存储读取密钥
+func Lookup(name string) (string, error) {
+	store := Default()
+	if store == nil {
+		return "", ErrNoStore
+	}
+	return store.Get(name)
+}
+
+// Mask 遮盖密钥，只保留末尾4个字符用于辨认
+func Mask(value string) string {
+	if value == "" {
+		return ""
+	}
+	runes := []rune(value)
+	if len(runes) <= 8 {
+		return strings.Repeat("*", len(runes))
+	}
+	return strings.Repeat("*", 8) + string(runes[len(runes)-4:])
+}
+
+// 检查密钥名称：字母、数字、点、下划线和连字符
+func validateName(name string) error {
+	if name == "" || len(name) > 64 {
+		return ErrInvalidName
+	}
+	for _, r := range name {
+		if !(r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' || r == '.' || r == '_' || r == '-') {
+			return ErrInvalidName
+		}
+	}
+	return nil
+}
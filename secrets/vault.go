@@ -0,0 +1,242 @@
+package secrets
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+)
+
+// 主密钥长度（AES-256）
+const masterKeySize = 32
+
+// VaultStore 基于本地加密文件的密钥存储
+//
+// 密钥以 AES-256-GCM 加密后保存在保险库文件中。主密钥单独保存，
+// 在 Windows 上经 DPAPI 绑定当前用户加密；其他平台以明文保存主密钥，只依赖文件权限保护，不提供静态加密。
+type VaultStore struct {
+	path    string
+	keyPath string
+	mu      sync.Mutex
+	key     []byte
+	data    map[string]string
+}
+
+// 保险库文件格式
+type vaultFile struct {
+	Version    int    `json:"version"`
+	Nonce      []byte `json:"nonce"`
+	Ciphertext []byte `json:"ciphertext"`
+}
+
+// NewVaultStore 打开或创建保险库
+func NewVaultStore(path, keyPath string) (*VaultStore, error) {
+	key, err := loadMasterKey(keyPath)
+	if err != nil {
+		return nil, err
+	}
+
+	v := &VaultStore{
+		path:    path,
+		keyPath: keyPath,
+		key:     key,
+		data:    make(map[string]string),
+	}
+
+	if err := v.load(); err != nil {
+		return nil, err
+	}
+	return v, nil
+}
+
+// 读取主密钥，不存在时生成新密钥
+func loadMasterKey(keyPath string) ([]byte, error) {
+	blob, err := os.ReadFile(keyPath)
+	if os.IsNotExist(err) {
+		key := make([]byte, masterKeySize)
+		if _, err := rand.Read(key); err != nil {
+			return nil, fmt.Errorf("生成主密钥失败: %w", err)
+		}
+
+		protected, err := protectKey(key)
+		if err != nil {
+			return nil, fmt.Errorf("保护主密钥失败: %w", err)
+		}
+		if err := writeFile(keyPath, protected); err != nil {
+			return nil, fmt.Errorf("保存主密钥失败: %w", err)
+		}
+		return key, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("读取主密钥失败: %w", err)
+	}
+
+	key, err := unprotectKey(blob)
+	if err != nil {
+		return nil, fmt.Errorf("解密主密钥失败: %w", err)
+	}
+	if len(key) != masterKeySize {
+		return nil, fmt.Errorf("主密钥长度无效")
+	}
+	return key, nil
+}
+
+// 读取并解密保险库
+func (v *VaultStore) load() error {
+	raw, err := os.ReadFile(v.path)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("读取保险库失败: %w", err)
+	}
+
+	var file vaultFile
+	if err := json.Unmarshal(raw, &file); err != nil {
+		return fmt.Errorf("解析保险库失败: %w", err)
+	}
+
+	gcm, err := v.cipher()
+	if err != nil {
+		return err
+	}
+	plaintext, err := gcm.Open(nil, file.Nonce, file.Ciphertext, nil)
+	if err != nil {
+		return fmt.Errorf("解密保险库失败，主密钥可能不匹配: %w", err)
+	}
+
+	return json.Unmarshal(plaintext, &v.data)
+}
+
+// 加密并保存保险库，调用方需持有锁
+func (v *VaultStore) save() error {
+	plaintext, err := json.Marshal(v.data)
+	if err != nil {
+		return err
+	}
+
+	gcm, err := v.cipher()
+	if err != nil {
+		return err
+	}
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return err
+	}
+
+	raw, err := json.Marshal(vaultFile{
+		Version:    1,
+		Nonce:      nonce,
+		Ciphertext: gcm.Seal(nil, nonce, plaintext, nil),
+	})
+	if err != nil {
+		return err
+	}
+	return writeFile(v.path, raw)
+}
+
+func (v *VaultStore) cipher() (cipher.AEAD, error) {
+	block, err := aes.NewCipher(v.key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+// Get 读取密钥
+func (v *VaultStore) Get(name string) (string, error) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	value, ok := v.data[name]
+	if !ok {
+		return "", ErrNotFound
+	}
+	return value, nil
+}
+
+// Set 保存密钥
+func (v *VaultStore) Set(name, value string) error {
+	if err := validateName(name); err != nil {
+		return err
+	}
+
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	old, existed := v.data[name]
+	v.data[name] = value
+	if err := v.save(); err != nil {
+		// 保存失败时恢复内存中的数据
+		if existed {
+			v.data[name] = old
+		} else {
+			delete(v.data, name)
+		}
+		return err
+	}
+	return nil
+}
+
+// Delete 删除密钥
+func (v *VaultStore) Delete(name string) error {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	old, ok := v.data[name]
+	if !ok {
+		return ErrNotFound
+	}
+	delete(v.data, name)
+	if err := v.save(); err != nil {
+		v.data[name] = old
+		return err
+	}
+	return nil
+}
+
+// List 列出所有密钥名称
+func (v *VaultStore) List() ([]string, error) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	names := make([]string, 0, len(v.data))
+	for name := range v.data {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names, nil
+}
+
+// 写入临时文件后重命名，文件仅当前用户可读写
+func writeFile(path string, data []byte) error {
+	tmp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".tmp-*")
+	if err != nil {
+		return err
+	}
+	tmpPath := tmp.Name()
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		os.Remove(tmpPath)
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmpPath)
+		return err
+	}
+	if err := os.Chmod(tmpPath, 0600); err != nil {
+		os.Remove(tmpPath)
+		return err
+	}
+	if err := os.Rename(tmpPath, path); err != nil {
+		os.Remove(tmpPath)
+		return err
+	}
+	return nil
+}
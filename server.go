@@ -0,0 +1,110 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	log "clipboard-translate/utils/log"
+)
+
+var (
+	httpServer   *http.Server // 当前HTTP服务
+	httpServerMu sync.Mutex   // 保护 httpServer
+	serverErrors = make(chan error, 1)
+)
+
+// 在指定地址和端口启动HTTP服务，返回实际监听的端口；port 为 0 时自动选择空闲端口。
+// 端口被占用等监听错误会直接返回
+func startHTTPServer(handler http.Handler, host string, port int) (*http.Server, int, error) {
+	addr := net.JoinHostPort(host, strconv.Itoa(port))
+	listener, err := net.Listen("tcp", addr)
+	if err != nil {
+		return nil, 0, fmt.Errorf("监听 %s 失败: %w", addr, err)
+	}
+	port = listener.Addr().(*net.TCPAddr).Port
+
+	// 请求的 context 在程序退出超时后取消；服务关闭时通知事件推送等长连接及时结束
+	closing, cancel := context.WithCancel(context.Background())
+	server := &http.Server{
+		Handler: handler,
+		BaseContext: func(net.Listener) context.Context {
+			return context.WithValue(app.work, serverClosingKey{}, closing.Done())
+		},
+	}
+	server.RegisterOnShutdown(cancel)
+	go func() {
+		if err := server.Serve(listener); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			select {
+			case serverErrors <- err:
+			default:
+			}
+		}
+	}()
+
+	log.Info("Web服务器已启动，监听 %s", listener.Addr())
+	return server, port, nil
+}
+
+type serverClosingKey struct{}
+
+// 请求所在的HTTP服务开始关闭时关闭的通道
+func serverClosing(ctx context.Context) <-chan struct{} {
+	ch, _ := ctx.Value(serverClosingKey{}).(<-chan struct{})
+	return ch
+}
+
+// 切换HTTP服务的监听地址或端口，新服务启动成功后再关闭旧服务
+func restartHTTPServer(handler http.Handler, host string, port int) error {
+	server, port, err := startHTTPServer(handler, host, port)
+	if err != nil {
+		return err
+	}
+	announceServer(host, port)
+
+	httpServerMu.Lock()
+	oldServer := httpServer
+	httpServer = server
+	httpServerMu.Unlock()
+
+	// 旧服务可能正在处理触发本次变更的请求，异步关闭
+	if oldServer != nil {
+		go func() {
+			ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+			defer cancel()
+			if err := oldServer.Shutdown(ctx); err != nil {
+				log.Error("关闭旧Web服务失败: %v", err)
+			}
+		}()
+	}
+	return nil
+}
+
+// 关闭当前HTTP服务，等待进行中的请求完成直到 ctx 结束
+func shutdownHTTPServer(ctx context.Context) error {
+	httpServerMu.Lock()
+	server := httpServer
+	httpServer = nil
+	httpServerMu.Unlock()
+
+	if server == nil {
+		return nil
+	}
+	return server.Shutdown(ctx)
+}
+
+// 返回排序后的键，保证输出稳定
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
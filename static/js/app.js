@@ -3,7 +3,12 @@ let selectedId = null;
 // 加载历史记录
 function loadHistory() {
     fetch('/api/history')
-        .then(response => response.json())
+        .then(response => {
+            if (response.status === 401) {
+                throw new Error('unauthorized');
+            }
+            return response.json();
+        })
         .then(data => {
             const historyList = document.getElementById('historyList');
             historyList.innerHTML = '';
@@ -13,6 +18,7 @@ function loadHistory() {
                 div.className = 'history-item';
                 if (item.id === selectedId) {
                     div.className += ' selected';
+                    displayItem(item);
                 }
                 div.innerHTML = `
                     <div class="timestamp">${item.timestamp}</div>
@@ -38,9 +44,32 @@ function loadHistory() {
                     firstItem.classList.add('selected');
                 }
             }
+        })
+        .catch(err => {
+            if (err.message === 'unauthorized') {
+                document.getElementById('historyList').textContent = '未登录：运行 clipboard-translate token --url 获取登录链接';
+            }
         });
 }
 
+// 使用链接中的访问令牌登录（#token=...），令牌写入 Cookie 后从地址栏移除
+async function loginFromHash() {
+    const match = location.hash.match(/^#token=(.+)$/);
+    if (!match) {
+        return;
+    }
+    history.replaceState(null, '', location.pathname + location.search);
+
+    const response = await fetch('/api/login', {
+        method: 'POST',
+        headers: { 'Content-Type': 'application/json' },
+        body: JSON.stringify({ token: decodeURIComponent(match[1]) })
+    });
+    if (!response.ok) {
+        showToast('登录失败：访问令牌无效');
+    }
+}
+
 // 显示选中的项目内容
 function displayItem(item) {
     document.getElementById('originalText').textContent = item.original;
@@ -50,6 +79,7 @@ function displayItem(item) {
     const directionElem = document.getElementById('translationDirection');
     if (directionElem) {
         directionElem.textContent = item.direction || "自动检测";
+        directionElem.title = item.prompt_version ? `提示词模板: ${item.prompt_version}` : '';
     }
 }
 
@@ -69,22 +99,52 @@ function copyTextToClipboard(text, button) {
     });
 }
 
-// 清空历史记录
+// 清空历史记录，界面在收到 history-cleared 事件后更新
 document.getElementById('clearBtn').addEventListener('click', () => {
-    fetch('/api/clear', { method: 'POST' })
-        .then(() => {
-            loadHistory();
-            document.getElementById('originalText').textContent = '';
-            document.getElementById('translatedText').textContent = '';
-            selectedId = null;
-        });
+    fetch('/api/clear', { method: 'POST' });
 });
 
-// 刷新剪贴板
+// 刷新剪贴板，翻译完成后通过 translation-completed 事件更新历史记录
 document.getElementById('refreshBtn').addEventListener('click', () => {
-    fetch('/api/refresh', { method: 'POST' })
-        .then(() => {
-            setTimeout(loadHistory, 1000);
+    fetch('/api/refresh', { method: 'POST' });
+});
+
+// 加载AI配置列表
+function loadProfiles() {
+    fetch('/api/profiles')
+        .then(response => response.json())
+        .then(data => {
+            const select = document.getElementById('profileSelect');
+            select.innerHTML = '';
+            Object.keys(data.profiles).sort().forEach(name => {
+                const option = document.createElement('option');
+                option.value = name;
+                option.textContent = `${name} (${data.profiles[name].provider})`;
+                select.appendChild(option);
+            });
+            select.value = data.active;
+        });
+}
+
+// 切换AI配置
+document.getElementById('profileSelect').addEventListener('change', (e) => {
+    fetch('/api/profiles/active', {
+        method: 'PUT',
+        headers: { 'Content-Type': 'application/json' },
+        body: JSON.stringify({ name: e.target.value })
+    })
+        .then(async response => {
+            if (!response.ok) {
+                const result = await response.json().catch(() => ({}));
+                const errors = (result.errors || []).map(e => `${e.field}: ${e.message}`);
+                const details = Object.values(result.details || {});
+                throw new Error([result.error, ...errors, ...details].filter(Boolean).join('\n'));
+            }
+            showToast(`已切换到 ${e.target.value}`);
+        })
+        .catch(err => {
+            alert('切换AI配置失败: ' + err.message);
+            loadProfiles();
         });
 });
 
@@ -114,8 +174,61 @@ document.getElementById('copyTranslated').addEventListener('click', () => {
   });
 });
 
-// 初始加载
-loadHistory();
+// 订阅服务端事件，断线后浏览器会自动重连
+function subscribeEvents() {
+    const source = new EventSource('/api/events');
+
+    // 连接或重连后重新加载，补上断线期间错过的事件
+    source.onopen = () => {
+        loadHistory();
+        loadProfiles();
+    };
+
+    source.addEventListener('translation-started', (e) => {
+        const data = JSON.parse(e.data);
+        if (data.origin === 'clipboard') {
+            showToast('正在处理剪贴板内容...');
+        }
+    });
+
+    const onFinished = (e) => {
+        const data = JSON.parse(e.data);
+        if (!data.history_id) {
+            return;
+        }
+        // 剪贴板翻译的结果直接显示
+        if (data.origin === 'clipboard') {
+            selectedId = data.history_id;
+        }
+        loadHistory();
+    };
+    source.addEventListener('translation-completed', onFinished);
+    source.addEventListener('translation-failed', (e) => {
+        const data = JSON.parse(e.data);
+        if (data.origin === 'clipboard') {
+            showToast('处理失败: ' + data.error);
+        }
+        onFinished(e);
+    });
+
+    source.addEventListener('history-cleared', () => {
+        document.getElementById('originalText').textContent = '';
+        document.getElementById('translatedText').textContent = '';
+        selectedId = null;
+        loadHistory();
+    });
+
+    source.addEventListener('config-changed', (e) => {
+        const data = JSON.parse(e.data);
+        if (data.sections.includes('profiles') || data.sections.includes('api')) {
+            loadProfiles();
+        }
+    });
+}
 
-// 定期刷新
-setInterval(loadHistory, 5000);
\ No newline at end of file
+// 初始加载，先完成登录再请求数据
+loginFromHash().finally(() => {
+    loadHistory();
+    loadProfiles();
+    subscribeEvents();
+});
\ No newline at end of file
@@ -1,29 +1,333 @@
 // static/js/config.js
-// 键码映射表
-const KEYS = [
-    { value: 'A', label: 'A' },
-    { value: 'B', label: 'B' },
-    // ...其他字母
-    { value: 'T', label: 'T' },
-    { value: 'U', label: 'U' },
-    // ...其他字母和功能键
-];
-
-// 填充键码选择器
-function populateKeySelectors() {
-    const selectors = ['#translate-key', '#showhide-key'];
-
-    selectors.forEach(selector => {
-        const select = document.querySelector(selector);
-        KEYS.forEach(key => {
+// 热键动作，元素ID前缀为动作名的小写形式
+const HOTKEY_ACTIONS = ['translate', 'translateAlt', 'explain', 'polish', 'summarize', 'showHide', 'repeatLast'];
+
+// 服务端返回的完整配置，保存时保留页面上没有的字段
+let loadedConfig = {};
+
+// 正在编辑的AI配置，保存时整体提交
+let profiles = {};
+let editingProfile = '';
+
+// 待保存的API密钥，键为密钥名称
+const pendingKeys = {};
+
+// 内置模板（由服务端提供）及用户模板
+let builtinTemplates = {};
+let customTemplates = {};
+let editingTemplate = '';
+
+// 填充键码选择器，按键列表由服务端提供
+async function populateKeySelectors() {
+    let keys = [];
+    try {
+        const response = await fetch('/api/hotkeys/keys');
+        if (response.ok) {
+            keys = await response.json();
+        }
+    } catch (error) {
+        console.error('Error loading hotkey keys:', error);
+    }
+
+    HOTKEY_ACTIONS.forEach(action => {
+        const select = document.querySelector(`#${action.toLowerCase()}-key`);
+        [{ name: '' }, ...keys].forEach(key => {
             const option = document.createElement('option');
-            option.value = key.value;
-            option.textContent = key.label;
+            option.value = key.name;
+            option.textContent = key.name || '(未设置)';
             select.appendChild(option);
         });
     });
 }
 
+// 按名称选中按键选项，忽略大小写
+function selectKey(select, key) {
+    const option = Array.from(select.options).find(o => o.value.toLowerCase() === (key || '').toLowerCase());
+    select.value = option ? option.value : '';
+}
+
+// API密钥在密钥存储中的名称，与服务端 ProfileConfig.KeyName 一致
+function apiKeyName(profile) {
+    return profile.api_key_ref || (profile.provider || '').toLowerCase();
+}
+
+// 已保存的密钥只显示遮盖后的值，输入框留空表示不修改
+async function loadAPIKeyPlaceholder(profile) {
+    const input = document.getElementById('api-key');
+    input.value = pendingKeys[apiKeyName(profile)] || '';
+    input.placeholder = '未设置';
+    try {
+        const response = await fetch('/api/secrets');
+        if (!response.ok) {
+            return;
+        }
+        const items = await response.json();
+        const item = items.find(i => i.name === apiKeyName(profile));
+        if (item) {
+            input.placeholder = `${item.masked}（留空保持不变）`;
+        }
+    } catch (error) {
+        console.error('Error loading secrets:', error);
+    }
+}
+
+// 填充AI配置选择器，保留当前选中的值
+function renderProfileOptions() {
+    const names = Object.keys(profiles).sort();
+    const fill = (select, options) => {
+        const value = select.value;
+        select.innerHTML = '';
+        options.forEach(([name, label]) => {
+            const option = document.createElement('option');
+            option.value = name;
+            option.textContent = label;
+            select.appendChild(option);
+        });
+        if (options.some(([name]) => name === value)) {
+            select.value = value;
+        }
+    };
+
+    const options = names.map(name => [name, name]);
+    fill(document.getElementById('active-profile'), options);
+    fill(document.getElementById('profile-select'), options);
+    HOTKEY_ACTIONS.forEach(action => {
+        fill(document.getElementById(`${action.toLowerCase()}-profile`), [['', '(当前配置)'], ...options]);
+    });
+}
+
+// 显示AI配置的各项设置
+function showProfile(name) {
+    editingProfile = name;
+    const profile = profiles[name] || {};
+    document.getElementById('profile-select').value = name;
+    document.getElementById('provider').value = profile.provider || 'gemini';
+    document.getElementById('model').value = profile.model || '';
+    document.getElementById('base-url').value = profile.base_url || '';
+    document.getElementById('temperature').value = profile.temperature ?? '';
+    document.getElementById('top-p').value = profile.top_p ?? '';
+    document.getElementById('max-tokens').value = profile.max_tokens || '';
+    document.getElementById('timeout').value = profile.timeout || '';
+    document.getElementById('seed').value = profile.seed ?? '';
+    document.getElementById('stop').value = (profile.stop || []).join('\n');
+    document.getElementById('prompt').value = profile.prompt || '';
+    document.getElementById('use-env-key').checked = !!profile.use_env_key;
+    document.getElementById('api-key-ref').value = profile.api_key_ref || '';
+    document.getElementById('api-key-group').style.display = profile.use_env_key ? 'none' : 'block';
+    loadAPIKeyPlaceholder(profile);
+}
+
+// 将页面上的设置写回正在编辑的AI配置
+function storeProfile() {
+    if (!editingProfile) {
+        return;
+    }
+    // 数字输入框留空表示使用默认值
+    const number = (id, parse) => {
+        const value = document.getElementById(id).value;
+        return value === '' ? undefined : parse(value);
+    };
+    const stop = document.getElementById('stop').value.split('\n').filter(s => s !== '');
+    const profile = {
+        ...profiles[editingProfile],
+        provider: document.getElementById('provider').value,
+        model: document.getElementById('model').value.trim(),
+        base_url: document.getElementById('base-url').value.trim(),
+        temperature: number('temperature', parseFloat),
+        top_p: number('top-p', parseFloat),
+        max_tokens: number('max-tokens', v => parseInt(v, 10)),
+        timeout: number('timeout', v => parseInt(v, 10)),
+        seed: number('seed', v => parseInt(v, 10)),
+        stop: stop.length > 0 ? stop : undefined,
+        prompt: document.getElementById('prompt').value,
+        use_env_key: document.getElementById('use-env-key').checked,
+        api_key_ref: document.getElementById('api-key-ref').value.trim()
+    };
+    profiles[editingProfile] = profile;
+
+    const apiKey = document.getElementById('api-key').value;
+    if (apiKey && !profile.use_env_key) {
+        pendingKeys[apiKeyName(profile)] = apiKey;
+    }
+}
+
+// 新建AI配置，复制正在编辑的配置作为初始值
+function addProfile() {
+    const name = (prompt('新配置名称') || '').trim();
+    if (!name) {
+        return;
+    }
+    if (profiles[name]) {
+        alert(`配置 ${name} 已存在`);
+        return;
+    }
+
+    storeProfile();
+    profiles[name] = { ...profiles[editingProfile] };
+    renderProfileOptions();
+    showProfile(name);
+}
+
+// 删除正在编辑的AI配置，引用它的热键改为使用当前配置
+function deleteProfile() {
+    const names = Object.keys(profiles);
+    if (names.length <= 1) {
+        alert('至少需要保留一个配置');
+        return;
+    }
+    if (!confirm(`确定删除配置 ${editingProfile}？`)) {
+        return;
+    }
+
+    delete profiles[editingProfile];
+    HOTKEY_ACTIONS.forEach(action => {
+        const select = document.getElementById(`${action.toLowerCase()}-profile`);
+        if (select.value === editingProfile) {
+            select.value = '';
+        }
+    });
+    editingProfile = '';
+    renderProfileOptions();
+    showProfile(document.getElementById('active-profile').value);
+}
+
+// 加载内置提示词模板
+async function loadBuiltinTemplates() {
+    try {
+        const response = await fetch('/api/templates');
+        if (response.ok) {
+            const templates = await response.json();
+            templates.filter(t => t.builtin).forEach(t => { builtinTemplates[t.name] = t.source; });
+        }
+    } catch (error) {
+        console.error('Error loading templates:', error);
+    }
+}
+
+// 填充模板选择器，内置模板被修改时标注
+function renderTemplateOptions() {
+    const names = [...new Set([...Object.keys(builtinTemplates), ...Object.keys(customTemplates)])].sort();
+    const label = name => {
+        if (!(name in builtinTemplates)) return `${name}（自定义）`;
+        return name in customTemplates ? `${name}（已修改）` : `${name}（内置）`;
+    };
+
+    const fill = (select, options) => {
+        const value = select.value;
+        select.innerHTML = '';
+        options.forEach(([name, text]) => {
+            const option = document.createElement('option');
+            option.value = name;
+            option.textContent = text;
+            select.appendChild(option);
+        });
+        if (options.some(([name]) => name === value)) {
+            select.value = value;
+        }
+    };
+
+    fill(document.getElementById('template-select'), names.map(name => [name, label(name)]));
+    HOTKEY_ACTIONS.forEach(action => {
+        fill(document.getElementById(`${action.toLowerCase()}-template`), [['', '(默认模板)'], ...names.map(name => [name, name])]);
+    });
+}
+
+// 显示模板内容
+function showTemplate(name) {
+    editingTemplate = name;
+    document.getElementById('template-select').value = name;
+    document.getElementById('template-source').value = customTemplates[name] ?? builtinTemplates[name] ?? '';
+    document.getElementById('template-preview').textContent = '';
+}
+
+// 将编辑的内容写回模板，与内置模板相同时不保存覆盖
+function storeTemplate() {
+    if (!editingTemplate) {
+        return;
+    }
+    const source = document.getElementById('template-source').value;
+    if (builtinTemplates[editingTemplate] === source) {
+        delete customTemplates[editingTemplate];
+    } else {
+        customTemplates[editingTemplate] = source;
+    }
+}
+
+// 新建模板，以正在编辑的模板为初始内容
+function addTemplate() {
+    const name = (prompt('新模板名称') || '').trim();
+    if (!name) {
+        return;
+    }
+    if (name in customTemplates || name in builtinTemplates) {
+        alert(`模板 ${name} 已存在`);
+        return;
+    }
+
+    storeTemplate();
+    customTemplates[name] = document.getElementById('template-source').value;
+    renderTemplateOptions();
+    showTemplate(name);
+}
+
+// 删除自定义模板；内置模板恢复默认内容
+function deleteTemplate() {
+    const name = editingTemplate;
+    if (name in builtinTemplates) {
+        delete customTemplates[name];
+        renderTemplateOptions();
+        showTemplate(name);
+        return;
+    }
+    if (!confirm(`确定删除模板 ${name}？`)) {
+        return;
+    }
+
+    delete customTemplates[name];
+    HOTKEY_ACTIONS.forEach(action => {
+        const select = document.getElementById(`${action.toLowerCase()}-template`);
+        if (select.value === name) {
+            select.value = '';
+        }
+    });
+    editingTemplate = '';
+    renderTemplateOptions();
+    showTemplate(document.getElementById('template-select').value);
+}
+
+// 预览正在编辑的模板，变量取自已保存的翻译配置
+async function previewTemplate() {
+    const output = document.getElementById('template-preview');
+    try {
+        const response = await fetch('/api/templates/preview', {
+            method: 'POST',
+            headers: {
+                'Content-Type': 'application/json'
+            },
+            body: JSON.stringify({
+                name: editingTemplate,
+                source: document.getElementById('template-source').value
+            })
+        });
+        const result = await response.json();
+        output.textContent = response.ok ? `[${result.version}]\n${result.prompt}` : `错误: ${result.error}`;
+    } catch (error) {
+        output.textContent = `错误: ${error.message}`;
+    }
+}
+
+// 术语表在页面上每行一条，格式为 原文=译文
+function parseGlossary(text) {
+    const glossary = {};
+    text.split('\n').forEach(line => {
+        const index = line.indexOf('=');
+        if (index > 0) {
+            glossary[line.slice(0, index).trim()] = line.slice(index + 1).trim();
+        }
+    });
+    return Object.keys(glossary).length > 0 ? glossary : undefined;
+}
+
 // 加载配置
 async function loadConfig() {
     try {
@@ -33,35 +337,45 @@ async function loadConfig() {
         }
 
         const config = await response.json();
+        loadedConfig = config;
+
+        // AI配置
+        profiles = JSON.parse(JSON.stringify(config.profiles || {}));
+        renderProfileOptions();
+        document.getElementById('active-profile').value = config.api.active_profile;
+        showProfile(config.api.active_profile);
+
+        // 提示词模板
+        customTemplates = { ...(config.templates || {}) };
+        renderTemplateOptions();
+        showTemplate(document.getElementById('template-select').value);
 
         // 填充热键设置
-        const translateHotkey = config.hotkeys.translate;
-        document.getElementById('translate-ctrl').checked = translateHotkey.modifiers.includes('control');
-        document.getElementById('translate-alt').checked = translateHotkey.modifiers.includes('alt');
-        document.getElementById('translate-shift').checked = translateHotkey.modifiers.includes('shift');
-        document.getElementById('translate-win').checked = translateHotkey.modifiers.includes('win');
-        document.getElementById('translate-key').value = translateHotkey.key.toUpperCase();
-
-        const showHideHotkey = config.hotkeys.showHide;
-        document.getElementById('showhide-ctrl').checked = showHideHotkey.modifiers.includes('control');
-        document.getElementById('showhide-alt').checked = showHideHotkey.modifiers.includes('alt');
-        document.getElementById('showhide-shift').checked = showHideHotkey.modifiers.includes('shift');
-        document.getElementById('showhide-win').checked = showHideHotkey.modifiers.includes('win');
-        document.getElementById('showhide-key').value = showHideHotkey.key.toUpperCase();
-
-        // API设置
-        document.getElementById('use-env-key').checked = config.api.use_env_key;
-        document.getElementById('gemini-key').value = config.api.gemini_key;
-        document.getElementById('api-key-group').style.display = config.api.use_env_key ? 'none' : 'block';
+        HOTKEY_ACTIONS.forEach(action => {
+            const prefix = action.toLowerCase();
+            const hotkey = config.hotkeys[action] || { modifiers: [], key: '' };
+            document.getElementById(`${prefix}-ctrl`).checked = hotkey.modifiers.includes('control');
+            document.getElementById(`${prefix}-alt`).checked = hotkey.modifiers.includes('alt');
+            document.getElementById(`${prefix}-shift`).checked = hotkey.modifiers.includes('shift');
+            document.getElementById(`${prefix}-win`).checked = hotkey.modifiers.includes('win');
+            selectKey(document.getElementById(`${prefix}-key`), hotkey.key);
+            document.getElementById(`${prefix}-profile`).value = hotkey.profile || '';
+            document.getElementById(`${prefix}-template`).value = hotkey.template || '';
+        });
 
         // 翻译设置
         document.getElementById('target-language').value = config.translation.target_language;
+        document.getElementById('alternate-language').value = config.translation.alternate_language;
         document.getElementById('auto-translate').checked = config.translation.auto_translate;
         document.getElementById('show-notification').checked = config.translation.show_notification;
+        document.getElementById('text-format').value = config.translation.format || 'auto';
+        document.getElementById('tone').value = config.translation.tone || '';
+        document.getElementById('domain').value = config.translation.domain || '';
+        document.getElementById('glossary').value = Object.entries(config.translation.glossary || {})
+            .map(([source, target]) => `${source}=${target}`).join('\n');
 
         // UI设置
         document.getElementById('port').value = config.ui.port;
-        document.getElementById('start-minimized').checked = config.ui.start_minimized;
         document.getElementById('theme').value = config.ui.theme;
 
         // 系统设置
@@ -71,7 +385,6 @@ async function loadConfig() {
         // 数据库设置
         document.getElementById('dbType').value = config.database.type || 'sqlite';
         document.getElementById('dbConnection').value = config.database.connection || 'clipboard-translate.db';
-        document.getElementById('maxHistory').value = config.database.max_history || 100;
 
     } catch (error) {
         console.error('Error loading config:', error);
@@ -81,53 +394,78 @@ async function loadConfig() {
 // 保存配置
 async function saveConfig() {
     try {
+        storeProfile();
+        storeTemplate();
+
         // 构建配置对象
         const config = {
-            hotkeys: {
-                translate: {
-                    modifiers: [],
-                    key: document.getElementById('translate-key').value.toLowerCase()
-                },
-                showHide: {
-                    modifiers: [],
-                    key: document.getElementById('showhide-key').value.toLowerCase()
-                }
-            },
+            ...loadedConfig,
+            hotkeys: {},
             api: {
-                gemini_key: document.getElementById('gemini-key').value,
-                use_env_key: document.getElementById('use-env-key').checked
+                ...loadedConfig.api,
+                active_profile: document.getElementById('active-profile').value
             },
+            profiles: profiles,
+            templates: customTemplates,
             translation: {
+                ...loadedConfig.translation,
                 target_language: document.getElementById('target-language').value,
+                alternate_language: document.getElementById('alternate-language').value,
                 auto_translate: document.getElementById('auto-translate').checked,
-                show_notification: document.getElementById('show-notification').checked
+                show_notification: document.getElementById('show-notification').checked,
+                format: document.getElementById('text-format').value,
+                tone: document.getElementById('tone').value.trim() || undefined,
+                domain: document.getElementById('domain').value.trim() || undefined,
+                glossary: parseGlossary(document.getElementById('glossary').value)
             },
             ui: {
+                ...loadedConfig.ui,
                 port: parseInt(document.getElementById('port').value),
-                start_minimized: document.getElementById('start-minimized').checked,
                 theme: document.getElementById('theme').value
             },
             system: {
+                ...loadedConfig.system,
                 auto_start: document.getElementById('auto-start').checked,
                 max_history_items: parseInt(document.getElementById('max-history').value)
             },
             database: {
+                ...loadedConfig.database,
                 type: document.getElementById('dbType').value,
-                connection: document.getElementById('dbConnection').value,
-                max_history: parseInt(document.getElementById('maxHistory').value)
+                connection: document.getElementById('dbConnection').value
             }
         };
 
-        // 添加修饰符
-        if (document.getElementById('translate-ctrl').checked) config.hotkeys.translate.modifiers.push('control');
-        if (document.getElementById('translate-alt').checked) config.hotkeys.translate.modifiers.push('alt');
-        if (document.getElementById('translate-shift').checked) config.hotkeys.translate.modifiers.push('shift');
-        if (document.getElementById('translate-win').checked) config.hotkeys.translate.modifiers.push('win');
+        // 添加热键及修饰符
+        HOTKEY_ACTIONS.forEach(action => {
+            const prefix = action.toLowerCase();
+            const hotkey = {
+                modifiers: [],
+                key: document.getElementById(`${prefix}-key`).value,
+                profile: document.getElementById(`${prefix}-profile`).value || undefined,
+                template: document.getElementById(`${prefix}-template`).value || undefined
+            };
+            if (document.getElementById(`${prefix}-ctrl`).checked) hotkey.modifiers.push('control');
+            if (document.getElementById(`${prefix}-alt`).checked) hotkey.modifiers.push('alt');
+            if (document.getElementById(`${prefix}-shift`).checked) hotkey.modifiers.push('shift');
+            if (document.getElementById(`${prefix}-win`).checked) hotkey.modifiers.push('win');
+            config.hotkeys[action] = hotkey;
+        });
 
-        if (document.getElementById('showhide-ctrl').checked) config.hotkeys.showHide.modifiers.push('control');
-        if (document.getElementById('showhide-alt').checked) config.hotkeys.showHide.modifiers.push('alt');
-        if (document.getElementById('showhide-shift').checked) config.hotkeys.showHide.modifiers.push('shift');
-        if (document.getElementById('showhide-win').checked) config.hotkeys.showHide.modifiers.push('win');
+        // API密钥单独保存到密钥存储，不写入配置文件
+        for (const [name, apiKey] of Object.entries(pendingKeys)) {
+            const secretResponse = await fetch(`/api/secrets/${encodeURIComponent(name)}`, {
+                method: 'PUT',
+                headers: {
+                    'Content-Type': 'application/json'
+                },
+                body: JSON.stringify({ value: apiKey })
+            });
+            if (!secretResponse.ok) {
+                const result = await secretResponse.json().catch(() => ({}));
+                throw new Error(result.error || 'Failed to save API key');
+            }
+            delete pendingKeys[name];
+        }
 
         // 发送到服务器
         const response = await fetch('/api/config', {
@@ -139,10 +477,24 @@ async function saveConfig() {
         });
 
         if (!response.ok) {
-            throw new Error('Failed to save config');
+            const result = await response.json().catch(() => ({}));
+            let message = result.error || 'Failed to save config';
+            const errors = { ...result.details, ...result.hotkey_errors };
+            (result.errors || []).forEach(e => { errors[e.field] = e.message; });
+            if (Object.keys(errors).length > 0) {
+                message += '\n' + Object.entries(errors).map(([name, error]) => `${name}: ${error}`).join('\n');
+            }
+            throw new Error(message);
+        }
+
+        // 热键注册失败（如被其他程序占用）时提示用户
+        const result = await response.json().catch(() => ({}));
+        if (result.hotkey_errors) {
+            const messages = Object.entries(result.hotkey_errors).map(([action, error]) => `${action}: ${error}`);
+            alert('以下热键注册失败:\n' + messages.join('\n'));
         }
 
-        alert('设置已保存！部分设置可能需要重启应用才能生效。');
+        alert('设置已保存并已生效！');
         window.location.href = '/';
 
     } catch (error) {
@@ -152,8 +504,8 @@ async function saveConfig() {
 }
 
 // 初始化
-document.addEventListener('DOMContentLoaded', () => {
-    populateKeySelectors();
+document.addEventListener('DOMContentLoaded', async () => {
+    await Promise.all([populateKeySelectors(), loadBuiltinTemplates()]);
     loadConfig();
 
     // 事件监听
@@ -162,6 +514,23 @@ document.addEventListener('DOMContentLoaded', () => {
         window.location.href = '/';
     });
 
+    // AI配置的切换、新建和删除
+    document.getElementById('profile-select').addEventListener('change', (e) => {
+        storeProfile();
+        showProfile(e.target.value);
+    });
+    document.getElementById('add-profile-btn').addEventListener('click', addProfile);
+    document.getElementById('delete-profile-btn').addEventListener('click', deleteProfile);
+
+    // 提示词模板的切换、新建、删除和预览
+    document.getElementById('template-select').addEventListener('change', (e) => {
+        storeTemplate();
+        showTemplate(e.target.value);
+    });
+    document.getElementById('add-template-btn').addEventListener('click', addTemplate);
+    document.getElementById('delete-template-btn').addEventListener('click', deleteTemplate);
+    document.getElementById('preview-template-btn').addEventListener('click', previewTemplate);
+
     // API密钥显示/隐藏逻辑
     document.getElementById('use-env-key').addEventListener('change', (e) => {
         document.getElementById('api-key-group').style.display = e.target.checked ? 'none' : 'block';
@@ -0,0 +1,238 @@
+package subtitle
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// 字幕格式
+const (
+	FormatSRT = "srt"
+	FormatVTT = "vtt"
+)
+
+// 字幕文件解析错误
+var (
+	ErrNoCues   = errors.New("未找到字幕条目，文件不是有效的 SRT 或 WebVTT 字幕")
+	ErrEncoding = errors.New("字幕文件必须使用 UTF-8 编码")
+)
+
+// Cue 一条字幕，序号和时间轴原样保留
+type Cue struct {
+	Header []string // 时间轴行及其之前的行（SRT 序号、WebVTT 标识）
+	Lines  []string // 字幕文本，每个元素为一行
+}
+
+// Text 返回合并为一行的字幕文本
+func (c *Cue) Text() string {
+	return strings.Join(c.Lines, " ")
+}
+
+// 文件中的一个块：字幕条目，或原样保留的内容（WebVTT 头部、NOTE、STYLE 等）
+type block struct {
+	cue *Cue
+	raw []string
+}
+
+// File 解析后的字幕文件
+type File struct {
+	Format string
+	Cues   []*Cue
+
+	blocks  []block
+	newline string // 原文件的换行符，写回时保持一致
+	bom     bool
+}
+
+// Parse 解析 SRT 或 WebVTT 字幕，以 WEBVTT 开头的按 WebVTT 处理
+func Parse(data []byte) (*File, error) {
+	f := &File{Format: FormatSRT, newline: "\n"}
+	if bytes.HasPrefix(data, []byte("\uFEFF")) {
+		f.bom = true
+		data = data[3:]
+	}
+	if !utf8.Valid(data) {
+		return nil, ErrEncoding
+	}
+	text := string(data)
+	if strings.Contains(text, "\r\n") {
+		f.newline = "\r\n"
+		text = strings.ReplaceAll(text, "\r\n", "\n")
+	}
+	if strings.HasPrefix(text, "WEBVTT") {
+		f.Format = FormatVTT
+	}
+
+	// 条目之间以空行分隔
+	for _, chunk := range splitBlocks(text) {
+		lines := strings.Split(chunk, "\n")
+		timing := -1
+		for i, line := range lines {
+			if strings.Contains(line, "-->") {
+				timing = i
+				break
+			}
+		}
+		// WebVTT 的 NOTE、STYLE、REGION 块不是字幕条目
+		if timing < 0 || (f.Format == FormatVTT && isVTTMetaBlock(lines[0])) {
+			f.blocks = append(f.blocks, block{raw: lines})
+			continue
+		}
+
+		cue := &Cue{Header: lines[:timing+1], Lines: lines[timing+1:]}
+		f.Cues = append(f.Cues, cue)
+		f.blocks = append(f.blocks, block{cue: cue})
+	}
+
+	if len(f.Cues) == 0 {
+		return nil, ErrNoCues
+	}
+	return f, nil
+}
+
+// 按空行切分，去掉首尾空行
+func splitBlocks(text string) []string {
+	var blocks []string
+	var current []string
+	for _, line := range strings.Split(text, "\n") {
+		if strings.TrimSpace(line) == "" {
+			if len(current) > 0 {
+				blocks = append(blocks, strings.Join(current, "\n"))
+				current = nil
+			}
+			continue
+		}
+		current = append(current, line)
+	}
+	if len(current) > 0 {
+		blocks = append(blocks, strings.Join(current, "\n"))
+	}
+	return blocks
+}
+
+func isVTTMetaBlock(first string) bool {
+	for _, prefix := range []string{"WEBVTT", "NOTE", "STYLE", "REGION"} {
+		if first == prefix || strings.HasPrefix(first, prefix+" ") || strings.HasPrefix(first, prefix+"\t") {
+			return true
+		}
+	}
+	return false
+}
+
+// ContentType 返回字幕格式对应的 MIME 类型
+func (f *File) ContentType() string {
+	if f.Format == FormatVTT {
+		return "text/vtt; charset=utf-8"
+	}
+	return "application/x-subrip; charset=utf-8"
+}
+
+// Bytes 按原格式输出字幕文件
+func (f *File) Bytes() []byte {
+	var sb strings.Builder
+	if f.bom {
+		sb.WriteString("\uFEFF")
+	}
+	for i, b := range f.blocks {
+		if i > 0 {
+			sb.WriteString(f.newline)
+		}
+		lines := b.raw
+		if b.cue != nil {
+			lines = append(append([]string{}, b.cue.Header...), b.cue.Lines...)
+		}
+		for _, line := range lines {
+			sb.WriteString(line)
+			sb.WriteString(f.newline)
+		}
+	}
+	return []byte(sb.String())
+}
+
+// Wrap 将文本按显示宽度折行，中日韩字符宽度计为 2；maxWidth 不大于 0 时不折行
+//
+// 优先在空白处断行，没有空白的长串（如中文）在标点后或按字符断行。
+func Wrap(text string, maxWidth int) []string {
+	text = strings.Join(strings.Fields(text), " ")
+	if maxWidth <= 0 || width(text) <= maxWidth {
+		return []string{text}
+	}
+
+	var lines []string
+	var line strings.Builder
+	lineWidth := 0
+	for _, word := range strings.Fields(text) {
+		w := width(word)
+		if lineWidth > 0 && lineWidth+1+w <= maxWidth {
+			line.WriteString(" ")
+			line.WriteString(word)
+			lineWidth += 1 + w
+			continue
+		}
+		if lineWidth > 0 {
+			lines = append(lines, line.String())
+			line.Reset()
+			lineWidth = 0
+		}
+		if w <= maxWidth {
+			line.WriteString(word)
+			lineWidth = w
+			continue
+		}
+
+		// 单个词超过宽度，按字符断开
+		pieces := breakWord(word, maxWidth)
+		lines = append(lines, pieces[:len(pieces)-1]...)
+		line.WriteString(pieces[len(pieces)-1])
+		lineWidth = width(pieces[len(pieces)-1])
+	}
+	if lineWidth > 0 {
+		lines = append(lines, line.String())
+	}
+	return lines
+}
+
+// 将没有空白的长串按宽度断开，尽量在标点之后断行
+func breakWord(word string, maxWidth int) []string {
+	var pieces []string
+	for width(word) > maxWidth {
+		cut, lastPunct, w := 0, 0, 0
+		for i, r := range word {
+			rw := runeWidth(r)
+			if w+rw > maxWidth && i > 0 {
+				break
+			}
+			w += rw
+			cut = i + utf8.RuneLen(r)
+			if unicode.IsPunct(r) {
+				lastPunct = cut
+			}
+		}
+		// 标点位置过于靠前时不采用，避免出现很短的行
+		if lastPunct > cut/2 {
+			cut = lastPunct
+		}
+		pieces = append(pieces, word[:cut])
+		word = word[cut:]
+	}
+	return append(pieces, word)
+}
+
+// 文本的显示宽度
+func width(text string) int {
+	w := 0
+	for _, r := range text {
+		w += runeWidth(r)
+	}
+	return w
+}
+
+func runeWidth(r rune) int {
+	if r >= 0x2E80 {
+		return 2
+	}
+	return 1
+}
@@ -0,0 +1,156 @@
+package subtitle
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		name       string
+		data       string
+		wantFormat string
+		wantCues   []string // 各条目合并后的文本
+	}{
+		{
+			name:       "SRT",
+			data:       "1\n00:00:01,000 --> 00:00:02,000\nHello\nworld\n\n2\n00:00:03,000 --> 00:00:04,000\n<i>Bye</i>\n",
+			wantFormat: FormatSRT,
+			wantCues:   []string{"Hello world", "<i>Bye</i>"},
+		},
+		{
+			name:       "SRT 使用 CRLF 和 BOM，多余空行",
+			data:       "\uFEFF\r\n1\r\n00:00:01,000 --> 00:00:02,000\r\nHello\r\n\r\n\r\n2\r\n00:00:03,000 --> 00:00:04,000\r\nBye\r\n",
+			wantFormat: FormatSRT,
+			wantCues:   []string{"Hello", "Bye"},
+		},
+		{
+			name:       "WebVTT 跳过 NOTE 和 STYLE 块",
+			data:       "WEBVTT - title\n\nNOTE a --> b\n\nSTYLE\n::cue { color: red }\n\nintro\n00:00.000 --> 00:01.000 align:start\n{\\an8}Hello\n",
+			wantFormat: FormatVTT,
+			wantCues:   []string{"{\\an8}Hello"},
+		},
+		{
+			name:       "没有文本的条目",
+			data:       "1\n00:00:01,000 --> 00:00:02,000\n",
+			wantFormat: FormatSRT,
+			wantCues:   []string{""},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f, err := Parse([]byte(tt.data))
+			if err != nil {
+				t.Fatalf("Parse() error = %v", err)
+			}
+			if f.Format != tt.wantFormat {
+				t.Errorf("Format = %q, want %q", f.Format, tt.wantFormat)
+			}
+			var cues []string
+			for _, cue := range f.Cues {
+				cues = append(cues, cue.Text())
+			}
+			if !reflect.DeepEqual(cues, tt.wantCues) {
+				t.Errorf("cues = %q, want %q", cues, tt.wantCues)
+			}
+		})
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		data string
+		want error
+	}{
+		{"空文件", "", ErrNoCues},
+		{"没有时间轴", "hello\n\nworld\n", ErrNoCues},
+		{"只有 WebVTT 头部", "WEBVTT\n\nNOTE nothing here\n", ErrNoCues},
+		{"不是 UTF-8", "1\n00:00:01,000 --> 00:00:02,000\n\xff\xfe\n", ErrEncoding},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := Parse([]byte(tt.data)); !errors.Is(err, tt.want) {
+				t.Errorf("Parse() error = %v, want %v", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestBytes(t *testing.T) {
+	tests := []struct {
+		name  string
+		data  string
+		lines [][]string // 替换各条目的文本，nil 表示保持不变
+		want  string
+	}{
+		{
+			name: "保持原格式",
+			data: "WEBVTT\n\nNOTE keep\n\n00:00.000 --> 00:01.000\nHello\n",
+			want: "WEBVTT\n\nNOTE keep\n\n00:00.000 --> 00:01.000\nHello\n",
+		},
+		{
+			name:  "替换文本，保留序号、时间轴、CRLF 和 BOM",
+			data:  "\uFEFF1\r\n00:00:01,000 --> 00:00:02,000\r\nHello\r\nworld\r\n\r\n2\r\n00:00:03,000 --> 00:00:04,000\r\nBye\r\n",
+			lines: [][]string{{"你好世界"}, {"再见", "朋友"}},
+			want:  "\uFEFF1\r\n00:00:01,000 --> 00:00:02,000\r\n你好世界\r\n\r\n2\r\n00:00:03,000 --> 00:00:04,000\r\n再见\r\n朋友\r\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f, err := Parse([]byte(tt.data))
+			if err != nil {
+				t.Fatal(err)
+			}
+			for i, lines := range tt.lines {
+				f.Cues[i].Lines = lines
+			}
+			if got := string(f.Bytes()); got != tt.want {
+				t.Errorf("Bytes() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestContentType(t *testing.T) {
+	if got := (&File{Format: FormatVTT}).ContentType(); got != "text/vtt; charset=utf-8" {
+		t.Errorf("VTT ContentType() = %q", got)
+	}
+	if got := (&File{Format: FormatSRT}).ContentType(); got != "application/x-subrip; charset=utf-8" {
+		t.Errorf("SRT ContentType() = %q", got)
+	}
+}
+
+func TestWrap(t *testing.T) {
+	tests := []struct {
+		name     string
+		text     string
+		maxWidth int
+		want     []string
+	}{
+		{"不折行", "a  b\nc", 0, []string{"a b c"}},
+		{"不超过宽度", "short line", 20, []string{"short line"}},
+		{"在空白处断行", "the quick brown fox jumps", 10, []string{"the quick", "brown fox", "jumps"}},
+		{"中文按宽度断行", "一二三四五六七八", 6, []string{"一二三", "四五六", "七八"}},
+		{"中文在标点后断行", "你好，世界和平", 10, []string{"你好，", "世界和平"}},
+		{"标点过于靠前时按字符断行", "一，二三四五六", 10, []string{"一，二三四", "五六"}},
+		{"超长单词", "abcdefghij xy", 4, []string{"abcd", "efgh", "ij", "xy"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := Wrap(tt.text, tt.maxWidth)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Wrap(%q, %d) = %q, want %q", tt.text, tt.maxWidth, got, tt.want)
+			}
+			for _, line := range got {
+				if tt.maxWidth > 0 && width(line) > tt.maxWidth {
+					t.Errorf("line %q is wider than %d", line, tt.maxWidth)
+				}
+			}
+		})
+	}
+}
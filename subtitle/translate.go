@@ -0,0 +1,42 @@
+package subtitle
+
+import (
+	"context"
+	"fmt"
+
+	"clipboard-translate/ai"
+)
+
+// Instruction 追加到系统提示词中，说明字幕翻译的要求，与 ai.NumberedInstruction 一起使用
+const Instruction = `输入的每一条都是一条字幕。
+* 译文简洁口语化，适合作为字幕阅读。
+* 保留字幕中的 HTML 标签（如 <i>）和 {\an8} 等样式标记。`
+
+// Options 字幕翻译参数
+type Options struct {
+	Batch         ai.BatchOptions
+	MaxLineLength int // 每行的最大显示宽度，中日韩字符计为 2；0 表示不折行
+}
+
+// Translate 批量翻译字幕文本，时间轴和序号保持不变
+//
+// 条目按编号分批发送，每批以上一批的原文作为上下文；模型漏译的条目会单独重试一次。
+func Translate(ctx context.Context, client ai.AIClient, f *File, prompt ai.ChunkPrompt, opts Options, progress func(done, total int)) error {
+	texts := make([]string, len(f.Cues))
+	for i, cue := range f.Cues {
+		texts[i] = cue.Text()
+	}
+
+	results, _, err := ai.CompleteNumbered(ctx, client, texts, opts.Batch, prompt, progress)
+	if err != nil {
+		return fmt.Errorf("翻译字幕失败: %w", err)
+	}
+
+	for i, text := range results {
+		// 空字幕保持原样
+		if text != "" {
+			f.Cues[i].Lines = Wrap(text, opts.MaxLineLength)
+		}
+	}
+	return nil
+}
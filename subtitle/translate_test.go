@@ -0,0 +1,79 @@
+package subtitle
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"clipboard-translate/ai"
+)
+
+// 测试用的AI客户端，逐条返回 reply 生成的译文
+type fakeClient struct {
+	reply func(n, text string) string
+	err   error
+}
+
+func (c *fakeClient) Translate(ctx context.Context, text string) (ai.Result, error) {
+	return c.Complete(ctx, "", text)
+}
+
+func (c *fakeClient) Complete(ctx context.Context, system, text string) (ai.Result, error) {
+	if c.err != nil {
+		return ai.Result{}, c.err
+	}
+	var lines []string
+	for _, line := range strings.Split(text, "\n") {
+		n, text, _ := strings.Cut(strings.TrimPrefix(line, "["), "] ")
+		lines = append(lines, "["+n+"] "+c.reply(n, text))
+	}
+	return ai.Result{Text: strings.Join(lines, "\n")}, nil
+}
+
+func (c *fakeClient) GetName() string  { return "fake" }
+func (c *fakeClient) GetModel() string { return "fake-model" }
+func (c *fakeClient) Close() error     { return nil }
+
+const testSRT = "1\n00:00:01,000 --> 00:00:02,000\nHello\nworld\n\n2\n00:00:03,000 --> 00:00:04,000\n\n3\n00:00:05,000 --> 00:00:06,000\nSee you later\n"
+
+func TestTranslate(t *testing.T) {
+	prompt := func(string) (string, error) { return "", nil }
+
+	t.Run("替换文本并折行", func(t *testing.T) {
+		f, err := Parse([]byte(testSRT))
+		if err != nil {
+			t.Fatal(err)
+		}
+		translations := map[string]string{"1": "你好世界", "3": "回头见，我的朋友们"}
+		client := &fakeClient{reply: func(n, text string) string { return translations[n] }}
+
+		var last int
+		err = Translate(context.Background(), client, f, prompt, Options{MaxLineLength: 10}, func(done, total int) { last = done })
+		if err != nil {
+			t.Fatalf("Translate() error = %v", err)
+		}
+		want := "1\n00:00:01,000 --> 00:00:02,000\n你好世界\n\n2\n00:00:03,000 --> 00:00:04,000\n\n3\n00:00:05,000 --> 00:00:06,000\n回头见，\n我的朋友们\n"
+		if got := string(f.Bytes()); got != want {
+			t.Errorf("Bytes() = %q, want %q", got, want)
+		}
+		if last != 1 {
+			t.Errorf("progress done = %d, want 1", last)
+		}
+	})
+
+	t.Run("失败时不修改字幕", func(t *testing.T) {
+		f, err := Parse([]byte(testSRT))
+		if err != nil {
+			t.Fatal(err)
+		}
+		errFailed := errors.New("failed")
+		err = Translate(context.Background(), &fakeClient{err: errFailed}, f, prompt, Options{}, nil)
+		if !errors.Is(err, errFailed) {
+			t.Errorf("Translate() error = %v, want %v", err, errFailed)
+		}
+		if got := string(f.Bytes()); got != testSRT {
+			t.Errorf("Bytes() = %q, want unchanged", got)
+		}
+	})
+}
@@ -0,0 +1,80 @@
+package main
+
+import (
+	"context"
+	"path/filepath"
+	"strings"
+
+	"clipboard-translate/ai"
+	"clipboard-translate/config"
+	"clipboard-translate/constants"
+	"clipboard-translate/subtitle"
+	log "clipboard-translate/utils/log"
+)
+
+// 上传字幕文件的大小上限
+const maxSubtitleSize = 10 << 20
+
+// 字幕翻译参数，留空的字段取自配置
+type subtitleRequest struct {
+	TargetLanguage string // 目标语言，默认为 translation.target_language
+	Profile        string // 使用的AI配置，默认为当前激活的配置
+	MaxLineLength  int    // 每行最大显示宽度，默认为 translation.subtitle_line_length
+}
+
+// 翻译字幕文件，返回翻译后的文件内容
+func translateSubtitle(ctx context.Context, data []byte, req subtitleRequest, progress func(done, total int)) (*subtitle.File, error) {
+	f, err := subtitle.Parse(data)
+	if err != nil {
+		return nil, err
+	}
+
+	cfg := config.GetConfig()
+	if req.TargetLanguage == "" {
+		req.TargetLanguage = cfg.Translation.TargetLanguage
+	}
+	if req.Profile == "" {
+		req.Profile = cfg.API.ActiveProfile
+	}
+	if req.MaxLineLength == 0 {
+		req.MaxLineLength = cfg.Translation.SubtitleLineLength
+	}
+
+	client, _, err := aiClientFor(req.Profile)
+	if err != nil {
+		return nil, err
+	}
+
+	// 使用翻译为指定语言的模板，附加字幕格式说明
+	tmpl, _ := currentPromptTemplates().Get(string(ai.TaskTranslateTo))
+	promptVars := promptData(cfg, constants.ACTION_TRANSLATE_ALT, "")
+	promptVars.TargetLanguage = req.TargetLanguage
+	render := func(previous string) (string, error) {
+		d := promptVars
+		d.Context = previous
+		prompt, err := tmpl.Render(d)
+		if err != nil {
+			return "", err
+		}
+		return prompt + "\n\n" + ai.NumberedInstruction + "\n\n" + subtitle.Instruction, nil
+	}
+
+	log.Info("开始翻译字幕: %d 条, 格式: %s, 目标语言: %s, 使用: %s", len(f.Cues), f.Format, req.TargetLanguage, client.GetName())
+	opts := subtitle.Options{
+		Batch: ai.BatchOptions{
+			Tokens:      cfg.Translation.ChunkTokens,
+			Concurrency: cfg.Translation.ChunkConcurrency,
+		},
+		MaxLineLength: req.MaxLineLength,
+	}
+	if err := subtitle.Translate(ctx, client, f, render, opts, progress); err != nil {
+		return nil, err
+	}
+	return f, nil
+}
+
+// 翻译后的文件名，如 movie.srt -> movie.ja-JP.srt
+func translatedFileName(name, language string) string {
+	ext := filepath.Ext(name)
+	return strings.TrimSuffix(name, ext) + "." + language + ext
+}
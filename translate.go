@@ -0,0 +1,187 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"time"
+	"unicode"
+
+	"clipboard-translate/ai"
+	"clipboard-translate/config"
+	"clipboard-translate/constants"
+	log "clipboard-translate/utils/log"
+)
+
+// 文本翻译参数，留空的字段取自配置
+type textRequest struct {
+	Text        string `json:"text"`
+	Source      string `json:"source"`       // 原文语言，为空时自动检测
+	Target      string `json:"target"`       // 目标语言，为空时与划词翻译一样在中英文之间互译
+	Profile     string `json:"profile"`      // 使用的AI配置，默认为当前激活的配置
+	Template    string `json:"template"`     // 提示词模板名称，默认为翻译模板
+	SaveHistory bool   `json:"save_history"` // 是否保存到历史记录
+
+	origin string // 事件中的触发方式，默认为 api
+}
+
+// 文本翻译结果
+type textResult struct {
+	Translation      string    `json:"translation"`
+	DetectedLanguage string    `json:"detected_language"`
+	Source           string    `json:"source"`
+	Target           string    `json:"target"`
+	Profile          string    `json:"profile"`
+	Provider         string    `json:"provider"`
+	Model            string    `json:"model"`
+	Template         string    `json:"template"` // 模板版本，格式为 名称@哈希
+	LatencyMs        int64     `json:"latency_ms"`
+	Usage            textUsage `json:"usage"`
+}
+
+// token用量，提供商没有返回用量时为零
+type textUsage struct {
+	InputTokens  int     `json:"input_tokens"`
+	OutputTokens int     `json:"output_tokens"`
+	TotalTokens  int     `json:"total_tokens"`
+	Cost         float64 `json:"cost"` // 按价格表计算，模型不在价格表中时为零
+}
+
+// 翻译一段文本，调用方需要先校验AI配置和模板名称
+func translateText(ctx context.Context, req textRequest) (*textResult, error) {
+	if req.Profile == "" {
+		req.Profile = config.GetConfig().API.ActiveProfile
+	}
+	client, profile, err := aiClientFor(req.Profile)
+	if err != nil {
+		return nil, err
+	}
+	// 预算用尽回退时报告实际使用的AI配置
+	req.Profile = profile
+	return translateTextWith(ctx, client, req)
+}
+
+// 使用指定的客户端翻译文本，req.Profile 必须是该客户端对应的AI配置
+//
+// 与划词翻译使用相同的流程：Markdown 中的代码和链接替换为占位符，长文本分段处理。
+func translateTextWith(ctx context.Context, client ai.AIClient, req textRequest) (*textResult, error) {
+	cfg := config.GetConfig()
+
+	// 指定目标语言时使用翻译为指定语言的模板，否则与翻译热键一样中英互译
+	action := constants.ACTION_TRANSLATE
+	if req.Target != "" {
+		action = constants.ACTION_TRANSLATE_ALT
+	}
+	var (
+		tmpl *ai.PromptTemplate
+		err  error
+	)
+	if req.Template != "" {
+		found, ok := currentPromptTemplates().Get(req.Template)
+		if !ok {
+			return nil, fmt.Errorf("提示词模板 %q 不存在", req.Template)
+		}
+		tmpl = found
+	} else if tmpl, err = defaultPrompt(cfg, action, req.Profile); err != nil {
+		return nil, err
+	}
+
+	detected := detectLanguage(req.Text)
+	data := promptData(cfg, action, req.Text)
+	if req.Target != "" {
+		data.TargetLanguage = req.Target
+	}
+	if req.Source != "" {
+		data.SourceLanguage = req.Source
+	} else if data.SourceLanguage == "" {
+		data.SourceLanguage = detected
+	}
+
+	event := translationEvent{ID: newTranslationID(), Origin: req.origin, Action: action, Original: req.Text}
+	if event.Origin == "" {
+		event.Origin = "api"
+	}
+	event.Direction = data.SourceLanguage + " → " + data.TargetLanguage
+	events.publish(EventTranslationStarted, event)
+
+	start := time.Now()
+	output, err := completeChunked(ctx, cfg, client, action, tmpl, data, req.Text)
+	latency := time.Since(start)
+	if err != nil {
+		event.Error = err.Error()
+		events.publish(EventTranslationFailed, event)
+		return nil, fmt.Errorf("翻译失败: %w", err)
+	}
+
+	translation := output.Text
+	u := usageOf(client, output.Usage)
+	result := &textResult{
+		Translation:      translation,
+		DetectedLanguage: detected,
+		Source:           data.SourceLanguage,
+		Target:           data.TargetLanguage,
+		Profile:          req.Profile,
+		Provider:         client.GetName(),
+		Model:            client.GetModel(),
+		Template:         tmpl.Stamp(),
+		LatencyMs:        latency.Milliseconds(),
+		Usage:            textUsage{InputTokens: u.InputTokens, OutputTokens: u.OutputTokens, TotalTokens: u.Total(), Cost: u.Cost},
+	}
+	log.Info("文本翻译完成: %s → %s, 使用: %s/%s, 耗时 %v, token %d", result.Source, result.Target, result.Provider, result.Model, latency, u.Total())
+
+	if req.SaveHistory {
+		event.HistoryID = addHistoryItem(req.Text, translation, event.Direction, result.Template, u)
+	}
+	event.Translated = translation
+	events.publish(EventTranslationCompleted, event)
+	return result, nil
+}
+
+// 按文字系统粗略判断文本的语言，拉丁字母的文本视为英文
+//
+// 日文通常混有汉字，出现假名即判断为日文；中文的判断规则与 isChineseText 一致。
+func detectLanguage(text string) string {
+	scripts := []struct {
+		table    *unicode.RangeTable
+		language string
+	}{
+		{unicode.Hangul, "ko-KR"},
+		{unicode.Cyrillic, "ru-RU"},
+		{unicode.Arabic, "ar"},
+		{unicode.Thai, "th-TH"},
+		{unicode.Greek, "el-GR"},
+		{unicode.Hebrew, "he-IL"},
+		{unicode.Devanagari, "hi-IN"},
+	}
+
+	counts := make(map[string]int)
+	var kana, latin int
+	for _, r := range text {
+		switch {
+		case unicode.In(r, unicode.Hiragana, unicode.Katakana):
+			kana++
+		case r < unicode.MaxASCII && unicode.IsLetter(r):
+			latin++
+		default:
+			for _, s := range scripts {
+				if unicode.Is(s.table, r) {
+					counts[s.language]++
+					break
+				}
+			}
+		}
+	}
+
+	if kana > 0 {
+		return "ja-JP"
+	}
+	if isChineseText(text) {
+		return "zh-CN"
+	}
+	language, best := "en-US", latin
+	for _, s := range scripts {
+		if counts[s.language] > best {
+			language, best = s.language, counts[s.language]
+		}
+	}
+	return language
+}
@@ -0,0 +1,61 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+	"testing"
+
+	"clipboard-translate/config"
+)
+
+func TestTranslateAPI(t *testing.T) {
+	// 热键指定的模板只用于热键，接口请求不指定模板时使用默认模板
+	r := setupAPI(t, map[string]any{
+		"hotkeys": map[string]any{
+			"translate":    map[string]any{"modifiers": []string{"control", "alt"}, "key": "t", "template": "legal"},
+			"translateAlt": map[string]any{"modifiers": []string{"control", "alt"}, "key": "y", "template": "legal"},
+		},
+		"templates": map[string]any{"legal": "你是法律翻译，译为{{.TargetLanguage}}。"},
+	})
+	if err := loadPromptTemplates(config.GetConfig().Templates); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name         string
+		body         string
+		wantStatus   int
+		wantTemplate string // 响应中模板版本的名称部分
+	}{
+		{"无效的JSON", `{"text":`, http.StatusBadRequest, ""},
+		{"空白文本", `{"text": "  \n"}`, http.StatusBadRequest, ""},
+		{"AI配置不存在", `{"text": "hi", "profile": "missing"}`, http.StatusBadRequest, ""},
+		{"模板不存在", `{"text": "hi", "template": "missing"}`, http.StatusBadRequest, ""},
+		{"默认使用翻译模板", `{"text": "hi"}`, http.StatusOK, "translate"},
+		{"指定目标语言时使用翻译为指定语言的模板", `{"text": "hi", "target": "日语"}`, http.StatusOK, "translate_to"},
+		{"使用请求指定的模板", `{"text": "hi", "template": "legal"}`, http.StatusOK, "legal"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := apiRequest(r, http.MethodPost, "/api/translate", tt.body)
+			if w.Code != tt.wantStatus {
+				t.Fatalf("返回 %d, want %d: %s", w.Code, tt.wantStatus, w.Body)
+			}
+			if tt.wantStatus != http.StatusOK {
+				return
+			}
+
+			var result textResult
+			if err := json.Unmarshal(w.Body.Bytes(), &result); err != nil {
+				t.Fatal(err)
+			}
+			if name, _, _ := strings.Cut(result.Template, "@"); name != tt.wantTemplate {
+				t.Errorf("template = %q, want %s@...", result.Template, tt.wantTemplate)
+			}
+			if result.Translation != "译:hi" || result.Profile != "fake" {
+				t.Errorf("翻译结果为 %+v", result)
+			}
+		})
+	}
+}
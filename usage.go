@@ -0,0 +1,209 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"clipboard-translate/ai"
+	"clipboard-translate/config"
+	"clipboard-translate/database"
+	log "clipboard-translate/utils/log"
+)
+
+// 用量统计的日期格式
+const usageDayLayout = "2006-01-02"
+
+// 统计周期
+const (
+	periodDay   = "day"
+	periodMonth = "month"
+)
+
+// 一次翻译使用的模型和token用量，保存到历史记录
+type translationUsage struct {
+	Provider string
+	Model    string
+	ai.Usage
+	Cost float64
+}
+
+// 用量统计和价格表中使用的模型名称
+//
+// Gemini 客户端的模型带有 API 要求的 models/ 前缀，价格表按配置中的模型名称填写，因此去掉前缀。
+func usageModel(model string) string {
+	return strings.TrimPrefix(model, "models/")
+}
+
+// 按当前价格表计算一次翻译的费用
+func usageOf(client ai.AIClient, u ai.Usage) translationUsage {
+	model := usageModel(client.GetModel())
+	cost, _ := config.GetConfig().Pricing.Cost(model, u.InputTokens, u.OutputTokens)
+	return translationUsage{Provider: client.GetName(), Model: model, Usage: u, Cost: cost}
+}
+
+// 累加一次模型调用的用量，按调用时的价格表计算费用；统计不随历史记录清理，数据库未打开时忽略
+func recordUsage(provider, model string, u ai.Usage) {
+	model = usageModel(model)
+	cost, _ := config.GetConfig().Pricing.Cost(model, u.InputTokens, u.OutputTokens)
+	usage := &database.Usage{
+		Day:          time.Now().Format(usageDayLayout),
+		Provider:     provider,
+		Model:        model,
+		Calls:        1,
+		InputTokens:  u.InputTokens,
+		OutputTokens: u.OutputTokens,
+		Cost:         cost,
+	}
+
+	dbMutex.Lock()
+	defer dbMutex.Unlock()
+	if db == nil {
+		// 没有打开数据库的命令不记录用量
+		return
+	}
+	if err := db.AddUsage(usage); err != nil {
+		log.Error("保存用量统计失败: %v", err)
+	}
+}
+
+// 读取日期范围内（包含两端）的用量统计，数据库未打开时返回空
+func usagesBetween(from, to string) ([]*database.Usage, error) {
+	dbMutex.Lock()
+	defer dbMutex.Unlock()
+	if db == nil {
+		return nil, nil
+	}
+	usages, err := db.GetUsage(from, to)
+	if err != nil {
+		return nil, fmt.Errorf("读取用量统计失败: %w", err)
+	}
+	return usages, nil
+}
+
+// 用量合计
+type usageTotals struct {
+	Calls        int     `json:"calls"`
+	InputTokens  int     `json:"input_tokens"`
+	OutputTokens int     `json:"output_tokens"`
+	Cost         float64 `json:"cost"`
+}
+
+func (t *usageTotals) add(u *database.Usage) {
+	t.Calls += u.Calls
+	t.InputTokens += u.InputTokens
+	t.OutputTokens += u.OutputTokens
+	t.Cost += u.Cost
+}
+
+// 某个模型在一个周期内的用量
+type modelUsage struct {
+	Provider string `json:"provider"`
+	Model    string `json:"model"`
+	usageTotals
+}
+
+// 一个周期（一天或一个月）的用量
+type periodUsage struct {
+	Period string `json:"period"` // 2006-01-02 或 2006-01
+	usageTotals
+	Models []*modelUsage `json:"models"`
+}
+
+// 用量汇总
+type usageSummary struct {
+	Currency string         `json:"currency"`
+	Period   string         `json:"period"`
+	From     string         `json:"from"`
+	To       string         `json:"to"`
+	Total    usageTotals    `json:"total"`
+	Periods  []*periodUsage `json:"periods"`
+}
+
+// 按天或按月汇总日期范围内（包含两端）的用量
+func summarizeUsage(period string, from, to time.Time) (*usageSummary, error) {
+	fromDay, toDay := from.Format(usageDayLayout), to.Format(usageDayLayout)
+	usages, err := usagesBetween(fromDay, toDay)
+	if err != nil {
+		return nil, err
+	}
+
+	summary := &usageSummary{
+		Currency: config.GetConfig().Pricing.Currency,
+		Period:   period,
+		From:     fromDay,
+		To:       toDay,
+		Periods:  []*periodUsage{},
+	}
+	periods := make(map[string]*periodUsage)
+	models := make(map[string]*modelUsage)
+	for _, u := range usages {
+		key := u.Day
+		if period == periodMonth {
+			key = u.Day[:len("2006-01")]
+		}
+		p, ok := periods[key]
+		if !ok {
+			p = &periodUsage{Period: key, Models: []*modelUsage{}}
+			periods[key] = p
+			summary.Periods = append(summary.Periods, p)
+		}
+		m, ok := models[key+"\x00"+u.Provider+"\x00"+u.Model]
+		if !ok {
+			m = &modelUsage{Provider: u.Provider, Model: u.Model}
+			models[key+"\x00"+u.Provider+"\x00"+u.Model] = m
+			p.Models = append(p.Models, m)
+		}
+		m.add(u)
+		p.add(u)
+		summary.Total.add(u)
+	}
+
+	// 每个周期内按费用从高到低列出模型
+	for _, p := range summary.Periods {
+		sort.SliceStable(p.Models, func(i, j int) bool { return p.Models[i].Cost > p.Models[j].Cost })
+	}
+	return summary, nil
+}
+
+// GET /api/usage?period=day|month&from=2006-01-02&to=2006-01-02
+//
+// 默认按天统计最近 30 天，按月统计最近 12 个月。
+func getUsage(c *gin.Context) {
+	period := c.DefaultQuery("period", periodDay)
+	now := time.Now()
+	to := now
+	from := now.AddDate(0, 0, -29)
+	if period == periodMonth {
+		from = time.Date(now.Year(), now.Month()-11, 1, 0, 0, 0, 0, now.Location())
+	}
+
+	for name, value := range map[string]*time.Time{"from": &from, "to": &to} {
+		s := c.Query(name)
+		if s == "" {
+			continue
+		}
+		t, err := time.ParseInLocation(usageDayLayout, s, now.Location())
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("%s 必须是 YYYY-MM-DD 格式的日期", name)})
+			return
+		}
+		*value = t
+	}
+	if from.After(to) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "from 不能晚于 to"})
+		return
+	}
+
+	summary, err := summarizeUsage(period, from, to)
+	if err != nil {
+		log.Error("%v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "读取用量统计失败"})
+		return
+	}
+	c.JSON(http.StatusOK, summary)
+}
@@ -70,7 +70,8 @@ func init() {
 	opts := &slog.HandlerOptions{
 		Level: slog.LevelDebug,
 	}
-	handler := slog.NewTextHandler(os.Stdout, opts)
+	// 日志输出到标准错误，标准输出留给发送给外壳的事件
+	handler := slog.NewTextHandler(os.Stderr, opts)
 	logger = slog.New(handler)
 	slog.SetDefault(logger)
 }
@@ -82,13 +83,13 @@ func GetLogConfig() LogConfig {
 func SetLogConfig(config LogConfig) {
 	handlers := []slog.Handler{}
 
-	// 添加标准输出处理器
-	stdoutOpts := &slog.HandlerOptions{
+	// 添加标准错误处理器
+	stderrOpts := &slog.HandlerOptions{
 		Level:     convertLevel(config.Level),
 		AddSource: false,
 	}
-	stdoutHandler := slog.NewTextHandler(os.Stdout, stdoutOpts)
-	handlers = append(handlers, stdoutHandler)
+	stderrHandler := slog.NewTextHandler(os.Stderr, stderrOpts)
+	handlers = append(handlers, stderrHandler)
 
 	// 如果配置了文件输出，添加文件处理器
 	if config.Filename != "" {